@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/temmyjay001/ledger-service/internal/config"
+	"github.com/temmyjay001/ledger-service/internal/partitions"
+	"github.com/temmyjay001/ledger-service/internal/storage"
+)
+
+// partition-archive exports monthly partitions older than the retention
+// window to gzip-compressed JSON lines, then detaches and drops them, e.g.
+//
+//	go run ./cmd/partition-archive -keep-months 12 -dest https://archive.example.com/ledger
+func main() {
+	// Load Configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	keepMonths := flag.Int("keep-months", cfg.ArchiveKeepMonths, "whole months to keep in the database besides the current one")
+	dest := flag.String("dest", cfg.ArchiveStore, "local directory or http(s) base URL to write archives to")
+	dryRun := flag.Bool("dry-run", false, "list the partitions that would be archived without touching them")
+	flag.Parse()
+
+	if *keepMonths < 0 {
+		log.Fatal("-keep-months must not be negative")
+	}
+
+	// Initialize Database Connection
+	db, err := storage.NewPostgresDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	service := partitions.NewService(db, partitions.NewStore(*dest))
+
+	now := time.Now().UTC()
+	cutoff := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -*keepMonths, 0)
+	log.Printf("Archiving partitions ending on or before %s to %s", cutoff.Format("2006-01-02"), *dest)
+
+	if *dryRun {
+		all, err := service.ListPartitions(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list partitions: %v", err)
+		}
+		for _, p := range all {
+			if !p.RangeEnd.After(cutoff) {
+				log.Printf("Would archive %s.%s", p.Schema, p.Name)
+			}
+		}
+		return
+	}
+
+	results, err := service.ArchivePartitions(ctx, cutoff)
+	if err != nil {
+		log.Fatalf("Failed to archive partitions (%d archived before the failure): %v", len(results), err)
+	}
+	log.Printf("Archived %d partitions", len(results))
+}
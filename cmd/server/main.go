@@ -30,6 +30,12 @@ func main() {
 	}
 	defer db.Close()
 
+	// Tenant isolation in rls mode rests entirely on the row-level security
+	// policies, so refuse to run as a role they don't apply to
+	if err := db.CheckRowLevelSecurity(context.Background(), cfg.TenancyMode == config.TenancyModeRLS); err != nil {
+		log.Fatalf("Refusing to serve rls-mode tenants: %v", err)
+	}
+
 	// initialize server
 	srv := server.New(cfg, db)
 
@@ -41,12 +47,44 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Start background webhook worker
 	ctx, cancel := context.WithCancel(context.Background())
+
+	// Encrypt legacy webhook secrets and retire old master keys before any
+	// delivery needs them
+	if err := srv.ReencryptWebhookSecrets(ctx); err != nil {
+		log.Fatalf("Failed to re-encrypt webhook secrets: %v", err)
+	}
+
+	// Start background webhook worker
 	go func() {
 		srv.StartWebhookWorker(ctx)
 	}()
 
+	// Start event dispatcher feeding the webhook worker
+	go func() {
+		srv.StartEventDispatcher(ctx)
+	}()
+
+	// Start pushing new events to live event streams
+	go func() {
+		srv.StartEventListener(ctx)
+	}()
+
+	// Start pruning of the webhook delivery attempt log
+	go func() {
+		srv.StartWebhookAttemptPruner(ctx)
+	}()
+
+	// Start queuing the deliveries of webhook replays
+	go func() {
+		srv.StartWebhookReplayWorker(ctx)
+	}()
+
+	// Start background partition maintenance
+	go func() {
+		srv.StartPartitionMaintenance(ctx)
+	}()
+
 	// Start Http server
 	go func() {
 		log.Printf("Server starting on %s:%s", cfg.Host, cfg.Port)
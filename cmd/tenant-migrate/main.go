@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/temmyjay001/ledger-service/internal/config"
+	"github.com/temmyjay001/ledger-service/internal/storage"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// tenant-migrate moves a tenant between schema-per-tenant and shared
+// row-level-security storage, e.g.
+//
+//	go run ./cmd/tenant-migrate -tenant acme -to rls
+func main() {
+	tenantSlug := flag.String("tenant", "", "slug of the tenant to move")
+	to := flag.String("to", "", "target tenancy mode (schema or rls)")
+	flag.Parse()
+
+	if *tenantSlug == "" {
+		log.Fatal("-tenant is required")
+	}
+
+	mode := queries.TenancyModeEnum(*to)
+	if !mode.Valid() {
+		log.Fatalf("-to must be %q or %q", config.TenancyModeSchema, config.TenancyModeRLS)
+	}
+
+	// Load Configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Initialize Database Connection
+	db, err := storage.NewPostgresDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	tenant, err := db.Queries.GetTenantBySlug(ctx, *tenantSlug)
+	if err != nil {
+		log.Fatalf("Failed to find tenant %s: %v", *tenantSlug, err)
+	}
+
+	if tenant.TenancyMode == mode {
+		log.Printf("Tenant %s is already in %s mode", *tenantSlug, mode)
+		return
+	}
+
+	// Once moved, the tenant is kept apart from the others only by row-level
+	// security, which must apply to the role the server connects as
+	if mode == queries.TenancyModeEnumRls {
+		if err := db.CheckRowLevelSecurity(ctx, true); err != nil {
+			log.Fatalf("Refusing to move tenant to %s mode: %v", mode, err)
+		}
+	}
+
+	log.Printf("Moving tenant %s from %s to %s mode...", *tenantSlug, tenant.TenancyMode, mode)
+	if err := db.MoveTenant(ctx, *tenantSlug, mode); err != nil {
+		log.Fatalf("Failed to move tenant: %v", err)
+	}
+	log.Printf("Tenant %s moved to %s mode", *tenantSlug, mode)
+}
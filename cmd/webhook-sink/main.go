@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/temmyjay001/ledger-service/pkg/webhook"
+)
+
+// webhook-sink receives webhook deliveries locally, verifies their
+// signatures and shows them at http://<addr>/ while logging each one to
+// stdout as a JSON line, e.g.
+//
+//	go run ./cmd/webhook-sink -addr :9090 -secret "$ENDPOINT_SECRET"
+//
+// Register http://localhost:9090/ as an endpoint (allowed in development or
+// through WEBHOOK_ALLOWED_HOSTS), then send it synthetic events with
+// POST /webhooks/endpoints/{endpointId}/synthetic-events.
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	secrets := flag.String("secret", os.Getenv("WEBHOOK_SINK_SECRET"), "comma-separated signing secrets to verify deliveries with; none accepts everything unverified")
+	keep := flag.Int("keep", 200, "how many recent deliveries to keep")
+	status := flag.Int("status", 0, "status to answer verified deliveries with instead of 200, e.g. 503 to exercise retries")
+	quiet := flag.Bool("quiet", false, "don't log deliveries to stdout")
+	flag.Parse()
+
+	var verifyWith []string
+	for _, secret := range strings.Split(*secrets, ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			verifyWith = append(verifyWith, secret)
+		}
+	}
+	if len(verifyWith) == 0 {
+		log.Println("No -secret given, deliveries will not be verified")
+	}
+
+	sink := webhook.NewSink(*keep, verifyWith...)
+	sink.Status = *status
+	if !*quiet {
+		encoder := json.NewEncoder(os.Stdout)
+		sink.OnDelivery = func(delivery webhook.Delivery) {
+			if err := encoder.Encode(delivery); err != nil {
+				log.Printf("Failed to log delivery: %v", err)
+			}
+		}
+	}
+
+	log.Printf("Webhook sink listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, sink); err != nil {
+		log.Fatalf("Webhook sink failed: %v", err)
+	}
+}
@@ -340,6 +340,112 @@ func (h *Handlers) DeleteAccountHandler(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// PUT /api/v1/tenants/{slug}/accounts/{accountId}/high-volume
+func (h *Handlers) EnableHighVolumeHandler(w http.ResponseWriter, r *http.Request) {
+	// Get tenant slug from URL
+	tenantSlug := chi.URLParam(r, "tenantSlug")
+	if tenantSlug == "" {
+		api.WriteBadRequestResponse(w, "tenant slug is required")
+		return
+	}
+
+	// Validate API key claims
+	claims, ok := auth.GetAPIKeyClaims(r.Context())
+	if !ok {
+		api.WriteUnauthorizedResponse(w, "API key authentication required")
+		return
+	}
+
+	// Verify tenant slug matches API key
+	if claims.TenantSlug != tenantSlug {
+		api.WriteForbiddenResponse(w, "API key not authorized for this tenant")
+		return
+	}
+
+	// Parse account ID
+	accountIDStr := chi.URLParam(r, "accountId")
+	accountID, err := uuid.Parse(accountIDStr)
+	if err != nil {
+		api.WriteBadRequestResponse(w, "invalid account ID")
+		return
+	}
+
+	var req HighVolumeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteBadRequestResponse(w, "invalid JSON payload")
+		return
+	}
+
+	// Validate request
+	if err := h.validator.Struct(req); err != nil {
+		api.WriteValidationErrorResponse(w, err)
+		return
+	}
+
+	// Enable high-volume mode
+	highVolume, err := h.accountService.EnableHighVolume(r.Context(), tenantSlug, accountID, req)
+	if err != nil {
+		switch err {
+		case ErrAccountNotFound:
+			api.WriteNotFoundResponse(w, "account not found")
+		default:
+			api.WriteInternalErrorResponse(w, "failed to enable high-volume mode")
+		}
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"high_volume": highVolume,
+	})
+}
+
+// DELETE /api/v1/tenants/{slug}/accounts/{accountId}/high-volume
+func (h *Handlers) DisableHighVolumeHandler(w http.ResponseWriter, r *http.Request) {
+	// Get tenant slug from URL
+	tenantSlug := chi.URLParam(r, "tenantSlug")
+	if tenantSlug == "" {
+		api.WriteBadRequestResponse(w, "tenant slug is required")
+		return
+	}
+
+	// Validate API key claims
+	claims, ok := auth.GetAPIKeyClaims(r.Context())
+	if !ok {
+		api.WriteUnauthorizedResponse(w, "API key authentication required")
+		return
+	}
+
+	// Verify tenant slug matches API key
+	if claims.TenantSlug != tenantSlug {
+		api.WriteForbiddenResponse(w, "API key not authorized for this tenant")
+		return
+	}
+
+	// Parse account ID
+	accountIDStr := chi.URLParam(r, "accountId")
+	accountID, err := uuid.Parse(accountIDStr)
+	if err != nil {
+		api.WriteBadRequestResponse(w, "invalid account ID")
+		return
+	}
+
+	// Disable high-volume mode
+	err = h.accountService.DisableHighVolume(r.Context(), tenantSlug, accountID)
+	if err != nil {
+		switch err {
+		case ErrAccountNotHighVolume:
+			api.WriteNotFoundResponse(w, "account is not in high-volume mode")
+		default:
+			api.WriteInternalErrorResponse(w, "failed to disable high-volume mode")
+		}
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "High-volume mode disabled successfully",
+	})
+}
+
 // GET /api/v1/tenants/{slug}/accounts/{accountId}/balance
 func (h *Handlers) GetAccountBalanceHandler(w http.ResponseWriter, r *http.Request) {
 	tenantSlug := chi.URLParam(r, "tenantSlug")
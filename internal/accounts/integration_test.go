@@ -0,0 +1,85 @@
+// internal/accounts/integration_test.go
+// +build integration
+
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/events"
+	"github.com/temmyjay001/ledger-service/internal/testUtil"
+)
+
+func TestIntegration_AccountLifecyclePublishesEvents(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	tenantSlug := testutil.RandomSlug()
+	tenant := testutil.CreateTestTenant(t, db, tenantSlug)
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, tenantSlug)
+	})
+
+	eventService := events.NewService(db)
+	service := NewService(db, eventService)
+
+	created, err := service.CreateAccount(ctx, tenantSlug, CreateAccountRequest{
+		Code:        "1000",
+		Name:        "Cash",
+		AccountType: "asset",
+		Metadata:    map[string]interface{}{"branch": "lagos"},
+	})
+	require.NoError(t, err)
+	accountID := created.ID
+
+	// an update that changes nothing publishes nothing
+	_, err = service.UpdateAccount(ctx, tenantSlug, accountID, UpdateAccountRequest{})
+	require.NoError(t, err)
+
+	updated, err := service.UpdateAccount(ctx, tenantSlug, accountID, UpdateAccountRequest{
+		Metadata: map[string]interface{}{"branch": "abuja"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Cash", updated.Name, "an omitted name is kept")
+
+	require.NoError(t, service.DeactivateAccount(ctx, tenantSlug, accountID))
+	// deactivating again is not another lifecycle change
+	require.NoError(t, service.DeactivateAccount(ctx, tenantSlug, accountID))
+
+	history, err := eventService.ListEvents(ctx, tenant.ID, events.ListEventsRequest{
+		AggregateType: events.AggregateTypeAccount,
+		AggregateID:   &accountID,
+		Limit:         10,
+	})
+	require.NoError(t, err)
+	require.Len(t, history.Events, 3)
+	assert.Equal(t, events.EventTypeAccountCreated, history.Events[0].EventType)
+	assert.Equal(t, events.EventTypeAccountUpdated, history.Events[1].EventType)
+	assert.Equal(t, events.EventTypeAccountDeactivated, history.Events[2].EventType)
+
+	var createdEvent events.AccountCreatedEvent
+	require.NoError(t, json.Unmarshal(history.Events[0].Data, &createdEvent))
+	assert.Equal(t, "1000", createdEvent.Account.Code)
+	assert.True(t, createdEvent.Account.IsActive)
+
+	var updatedEvent events.AccountUpdatedEvent
+	require.NoError(t, json.Unmarshal(history.Events[1].Data, &updatedEvent))
+	assert.Equal(t, []string{"metadata"}, updatedEvent.ChangedFields)
+	assert.JSONEq(t, `{"branch":"lagos"}`, string(updatedEvent.Before.Metadata))
+	assert.JSONEq(t, `{"branch":"abuja"}`, string(updatedEvent.After.Metadata))
+
+	var deactivatedEvent events.AccountDeactivatedEvent
+	require.NoError(t, json.Unmarshal(history.Events[2].Data, &deactivatedEvent))
+	assert.True(t, deactivatedEvent.Before.IsActive)
+	assert.False(t, deactivatedEvent.After.IsActive)
+
+	// a deactivated account cannot be updated
+	_, err = service.UpdateAccount(ctx, tenantSlug, accountID, UpdateAccountRequest{Name: "Petty cash"})
+	assert.Equal(t, ErrAccountNotFound, err)
+}
@@ -4,24 +4,30 @@ package accounts
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/shopspring/decimal"
+	"github.com/temmyjay001/ledger-service/internal/events"
+	"github.com/temmyjay001/ledger-service/internal/requestctx"
 	"github.com/temmyjay001/ledger-service/internal/storage"
 	"github.com/temmyjay001/ledger-service/internal/storage/queries"
 )
 
 type Service struct {
-	db *storage.DB
+	db           *storage.DB
+	eventService *events.Service
 }
 
-func NewService(db *storage.DB) *Service {
+func NewService(db *storage.DB, eventService *events.Service) *Service {
 	return &Service{
-		db: db,
+		db:           db,
+		eventService: eventService,
 	}
 }
 
@@ -46,103 +52,108 @@ func (s *Service) CreateAccount(ctx context.Context, tenantSlug string, req Crea
 		return nil, ErrInvalidCurrency
 	}
 
-	// Switch to tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
+	requestctx.Logf(ctx, "Creating account in tenant schema: tenant_%s", tenantSlug)
 
-	log.Printf("Creating account in tenant schema: tenant_%s", tenantSlug)
+	var account queries.Account
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		// Check if account code already exists
+		exists, err := q.ValidateAccountCode(ctx, req.Code)
+		if err != nil {
+			return fmt.Errorf("failed to validate account code: %w", err)
+		}
+		if exists {
+			return ErrAccountCodeExists
+		}
 
-	// Check if account code already exists
-	exists, err := s.db.Queries.ValidateAccountCode(ctx, req.Code)
-	if err != nil {
-		return nil, fmt.Errorf("failed to validate account code: %w", err)
-	}
-	if exists {
-		return nil, ErrAccountCodeExists
-	}
+		// Validate parent account if specified
+		var parentID *uuid.UUID
+		if req.ParentCode != "" {
+			parent, err := q.GetAccountByCode(ctx, req.ParentCode)
+			if err != nil {
+				return ErrInvalidParentAccount
+			}
+			parentID = &parent.ID
+		}
+
+		// Prepare metadata
+		var metadata json.RawMessage
+		if req.Metadata != nil {
+			metadataBytes, err := json.Marshal(req.Metadata)
+			if err != nil {
+				return fmt.Errorf("failed to marshal metadata: %w", err)
+			}
+			metadata = json.RawMessage(metadataBytes)
+		}
 
-	// Validate parent account if specified
-	var parentID *uuid.UUID
-	if req.ParentCode != "" {
-		parent, err := s.db.Queries.GetAccountByCode(ctx, req.ParentCode)
+		// Create account
+		account, err = q.CreateAccount(ctx, queries.CreateAccountParams{
+			Code:        req.Code,
+			Name:        req.Name,
+			AccountType: queries.AccountTypeEnum(req.AccountType),
+			ParentID:    parentID,
+			Currency:    currency,
+			Metadata:    metadata,
+		})
 		if err != nil {
-			return nil, ErrInvalidParentAccount
+			return fmt.Errorf("failed to create account: %w", err)
 		}
-		parentID = &parent.ID
-	}
 
-	// Prepare metadata
-	var metadata json.RawMessage
-	if req.Metadata != nil {
-		metadataBytes, err := json.Marshal(req.Metadata)
+		// Initialize balance for the account's default currency in the same
+		// transaction so an account never exists without its balance row
+		_, err = q.CreateAccountBalance(ctx, queries.CreateAccountBalanceParams{
+			AccountID: account.ID,
+			Currency:  currency,
+			Balance:   decimal.Zero,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+			return fmt.Errorf("failed to create initial balance: %w", err)
 		}
-		metadata = json.RawMessage(metadataBytes)
-	}
 
-	// Create account
-	account, err := s.db.Queries.CreateAccount(ctx, queries.CreateAccountParams{
-		Code:        req.Code,
-		Name:        req.Name,
-		AccountType: queries.AccountTypeEnum(req.AccountType),
-		ParentID:    parentID,
-		Currency:    currency,
-		Metadata:    metadata,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create account: %w", err)
-	}
+		tenant, err := q.GetTenantBySlug(ctx, tenantSlug)
+		if err != nil {
+			return fmt.Errorf("failed to get tenant: %w", err)
+		}
 
-	// Initialize balance for the account's default currency
-	_, err = s.db.Queries.CreateAccountBalance(ctx, queries.CreateAccountBalanceParams{
-		AccountID: account.ID,
-		Currency:  currency,
-		Balance:   decimal.Zero,
+		return s.eventService.PublishAccountCreated(ctx, q, tenant.ID, account)
 	})
 	if err != nil {
-		log.Printf("Failed to create initial balance for account %s: %v", account.ID, err)
-		// Don't fail account creation if balance creation fails
+		return nil, err
 	}
 
-	log.Printf("Account created successfully: %s (%s)", account.Code, account.Name)
+	requestctx.Logf(ctx, "Account created successfully: %s (%s)", account.Code, account.Name)
 	return s.accountToResponse(account, req.ParentCode)
 }
 
 // ListAccounts returns accounts based on filters
 func (s *Service) ListAccounts(ctx context.Context, tenantSlug string, req ListAccountsRequest) ([]*AccountResponse, error) {
-	// Switch to tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
+	if req.AccountType != "" && !IsValidAccountType(req.AccountType) {
+		return nil, ErrInvalidAccountType
 	}
-	defer s.db.SetSearchPath(ctx, "public")
 
 	var accounts []queries.Account
-	var err error
-
-	// Apply filters
-	if req.AccountType != "" {
-		if !IsValidAccountType(req.AccountType) {
-			return nil, ErrInvalidAccountType
-		}
-		accounts, err = s.db.Queries.ListAccountsByType(ctx, queries.AccountTypeEnum(req.AccountType))
-	} else if req.ParentCode != "" {
-		accounts, err = s.db.Queries.ListAccountsByParentCode(ctx, req.ParentCode)
-	} else if req.Search != "" {
-		limit := req.Limit
-		if limit == 0 {
-			limit = 100
-		}
-		accounts, err = s.db.Queries.SearchAccounts(ctx, queries.SearchAccountsParams{
-			Column1: pgtype.Text{String: req.Search, Valid: true},
-			Limit:   int32(limit),
-		})
-	} else {
-		accounts, err = s.db.Queries.ListAccounts(ctx)
-	}
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+
+		// Apply filters
+		if req.AccountType != "" {
+			accounts, err = q.ListAccountsByType(ctx, queries.AccountTypeEnum(req.AccountType))
+		} else if req.ParentCode != "" {
+			accounts, err = q.ListAccountsByParentCode(ctx, req.ParentCode)
+		} else if req.Search != "" {
+			limit := req.Limit
+			if limit == 0 {
+				limit = 100
+			}
+			accounts, err = q.SearchAccounts(ctx, queries.SearchAccountsParams{
+				Column1: pgtype.Text{String: req.Search, Valid: true},
+				Limit:   int32(limit),
+			})
+		} else {
+			accounts, err = q.ListAccounts(ctx)
+		}
 
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list accounts: %w", err)
 	}
@@ -152,7 +163,7 @@ func (s *Service) ListAccounts(ctx context.Context, tenantSlug string, req ListA
 	for _, account := range accounts {
 		resp, err := s.accountToResponse(account, "")
 		if err != nil {
-			log.Printf("Failed to convert account to response: %v", err)
+			requestctx.Logf(ctx, "Failed to convert account to response: %v", err)
 			continue
 		}
 		response = append(response, resp)
@@ -163,13 +174,12 @@ func (s *Service) ListAccounts(ctx context.Context, tenantSlug string, req ListA
 
 // GetAccountByID retrieves a specific account by ID
 func (s *Service) GetAccountByID(ctx context.Context, tenantSlug string, accountID uuid.UUID) (*AccountResponse, error) {
-	// Switch to tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
-
-	account, err := s.db.Queries.GetAccountByID(ctx, accountID)
+	var account queries.Account
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		account, err = q.GetAccountByID(ctx, accountID)
+		return err
+	})
 	if err != nil {
 		return nil, ErrAccountNotFound
 	}
@@ -179,13 +189,12 @@ func (s *Service) GetAccountByID(ctx context.Context, tenantSlug string, account
 
 // GetAccountByCode retrieves a specific account by code
 func (s *Service) GetAccountByCode(ctx context.Context, tenantSlug string, code string) (*AccountResponse, error) {
-	// Switch to tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
-
-	account, err := s.db.Queries.GetAccountByCode(ctx, code)
+	var account queries.Account
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		account, err = q.GetAccountByCode(ctx, code)
+		return err
+	})
 	if err != nil {
 		return nil, ErrAccountNotFound
 	}
@@ -195,18 +204,6 @@ func (s *Service) GetAccountByCode(ctx context.Context, tenantSlug string, code
 
 // UpdateAccount updates an existing account
 func (s *Service) UpdateAccount(ctx context.Context, tenantSlug string, accountID uuid.UUID, req UpdateAccountRequest) (*AccountResponse, error) {
-	// Switch to tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
-
-	// Prepare optional fields
-	var name string
-	if req.Name != "" {
-		name = pgtype.Text{String: req.Name, Valid: true}.String
-	}
-
 	var metadata json.RawMessage
 	if req.Metadata != nil {
 		metadataBytes, err := json.Marshal(req.Metadata)
@@ -217,13 +214,43 @@ func (s *Service) UpdateAccount(ctx context.Context, tenantSlug string, accountI
 	}
 
 	// Update account
-	account, err := s.db.Queries.UpdateAccount(ctx, queries.UpdateAccountParams{
-		ID:       accountID,
-		Name:     name,
-		Metadata: metadata,
+	var account queries.Account
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		before, err := q.GetAccountForUpdate(ctx, accountID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrAccountNotFound
+			}
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+		if !before.IsActive {
+			return ErrAccountNotFound
+		}
+
+		// an omitted name keeps the current one
+		name := req.Name
+		if name == "" {
+			name = before.Name
+		}
+
+		account, err = q.UpdateAccount(ctx, queries.UpdateAccountParams{
+			ID:       accountID,
+			Name:     name,
+			Metadata: metadata,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update account: %w", err)
+		}
+
+		tenant, err := q.GetTenantBySlug(ctx, tenantSlug)
+		if err != nil {
+			return fmt.Errorf("failed to get tenant: %w", err)
+		}
+
+		return s.eventService.PublishAccountUpdated(ctx, q, tenant.ID, before, account)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update account: %w", err)
+		return nil, err
 	}
 
 	return s.accountToResponse(account, "")
@@ -231,71 +258,88 @@ func (s *Service) UpdateAccount(ctx context.Context, tenantSlug string, accountI
 
 // DeactivateAccount soft deletes an account
 func (s *Service) DeactivateAccount(ctx context.Context, tenantSlug string, accountID uuid.UUID) error {
-	// Switch to tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
+	return s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		before, err := q.GetAccountForUpdate(ctx, accountID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrAccountNotFound
+			}
+			return fmt.Errorf("failed to get account: %w", err)
+		}
 
-	// Check if account has children
-	children, err := s.db.Queries.ListAccountsByParent(ctx, &accountID)
-	if err != nil {
-		return fmt.Errorf("failed to check for child accounts: %w", err)
-	}
-	if len(children) > 0 {
-		return ErrAccountHasChildren
-	}
+		// Check if account has children
+		children, err := q.ListAccountsByParent(ctx, &accountID)
+		if err != nil {
+			return fmt.Errorf("failed to check for child accounts: %w", err)
+		}
+		if len(children) > 0 {
+			return ErrAccountHasChildren
+		}
 
-	// Check if account has non-zero balances
-	balances, err := s.db.Queries.GetAccountBalances(ctx, accountID)
-	if err != nil {
-		return fmt.Errorf("failed to check account balances: %w", err)
-	}
+		// Check if account has non-zero balances
+		balances, err := q.GetAccountBalanceTotals(ctx, accountID)
+		if err != nil {
+			return fmt.Errorf("failed to check account balances: %w", err)
+		}
 
-	for _, balance := range balances {
-		if !balance.Balance.IsZero() {
-			return ErrAccountHasBalances
+		for _, balance := range balances {
+			if !balance.Balance.IsZero() {
+				return ErrAccountHasBalances
+			}
 		}
-	}
 
-	// Deactivate account
-	_, err = s.db.Queries.DeactivateAccount(ctx, accountID)
-	if err != nil {
-		return fmt.Errorf("failed to deactivate account: %w", err)
-	}
+		// Deactivate account
+		account, err := q.DeactivateAccount(ctx, accountID)
+		if err != nil {
+			return fmt.Errorf("failed to deactivate account: %w", err)
+		}
 
-	return nil
+		// deactivating twice is not a second lifecycle change
+		if !before.IsActive {
+			return nil
+		}
+
+		tenant, err := q.GetTenantBySlug(ctx, tenantSlug)
+		if err != nil {
+			return fmt.Errorf("failed to get tenant: %w", err)
+		}
+
+		return s.eventService.PublishAccountDeactivated(ctx, q, tenant.ID, before, account)
+	})
 }
 
 // GetAccountBalance retrieves the balance for a specific account and currency
 func (s *Service) GetAccountBalance(ctx context.Context, tenantSlug string, accountID uuid.UUID, currency string) (*AccountBalanceResponse, error) {
-	// Switch to tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
-
-	// Get account to ensure it exists
-	_, err := s.db.Queries.GetAccountByID(ctx, accountID)
-	if err != nil {
-		return nil, ErrAccountNotFound
-	}
+	var balance queries.AccountBalanceTotal
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		// Get account to ensure it exists
+		_, err := q.GetAccountByID(ctx, accountID)
+		if err != nil {
+			return ErrAccountNotFound
+		}
 
-	// Get balance
-	balance, err := s.db.Queries.GetAccountBalance(ctx, queries.GetAccountBalanceParams{
-		AccountID: accountID,
-		Currency:  currency,
-	})
-	if err != nil {
-		// If balance doesn't exist, create it with zero balance
-		balance, err = s.db.Queries.CreateAccountBalance(ctx, queries.CreateAccountBalanceParams{
+		// Get balance, including any high-volume slots
+		balance, err = q.GetAccountBalanceTotal(ctx, queries.GetAccountBalanceTotalParams{
 			AccountID: accountID,
 			Currency:  currency,
-			Balance:   decimal.Zero,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to create or get balance: %w", err)
+			// If balance doesn't exist, create it with zero balance
+			created, err := q.CreateAccountBalance(ctx, queries.CreateAccountBalanceParams{
+				AccountID: accountID,
+				Currency:  currency,
+				Balance:   decimal.Zero,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create or get balance: %w", err)
+			}
+			balance = queries.AccountBalanceTotal(created)
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &AccountBalanceResponse{
@@ -309,17 +353,17 @@ func (s *Service) GetAccountBalance(ctx context.Context, tenantSlug string, acco
 
 // GetAccountBalanceHistory method
 func (s *Service) GetAccountBalanceHistory(ctx context.Context, tenantSlug string, accountID uuid.UUID, currency string, days int) (*BalanceHistoryResponse, error) {
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
-
 	startDate := time.Now().AddDate(0, 0, -days)
 
-	history, err := s.db.Queries.GetAccountBalanceHistory(ctx, queries.GetAccountBalanceHistoryParams{
-		AccountID: accountID,
-		Currency:  currency,
-		UpdatedAt: startDate,
+	var history []queries.AccountBalance
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		history, err = q.GetAccountBalanceHistory(ctx, queries.GetAccountBalanceHistoryParams{
+			AccountID: accountID,
+			Currency:  currency,
+			UpdatedAt: startDate,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get balance history: %w", err)
@@ -344,61 +388,64 @@ func (s *Service) GetAccountBalanceHistory(ctx context.Context, tenantSlug strin
 
 // GetBalanceSummary retrieves all accounts summary
 func (s *Service) GetBalanceSummary(ctx context.Context, tenantSlug string, currency string) (*BalanceSummaryResponse, error) {
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
-
 	// Create response variables that we'll populate from either query
 	var responseCurrency string
 	var totalAccounts int64
 	var totalAssets, totalLiabilities, totalEquity, totalRevenue, totalExpenses decimal.Decimal
 	var generatedAt time.Time
-	var err error
-
-	if currency != "" {
-		// Get summary for specific currency
-		summary, err := s.db.Queries.GetBalanceSummaryByCurrency(ctx, currency)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get balance summary: %w", err)
-		}
-
-		responseCurrency = summary.Currency
-		totalAccounts = summary.TotalAccounts
-		totalAssets = convertNumeric(summary.TotalAssets)
-		totalLiabilities = convertNumeric(summary.TotalLiabilities)
-		totalEquity = convertNumeric(summary.TotalEquity)
-		totalRevenue = convertNumeric(summary.TotalRevenue)
-		totalExpenses = convertNumeric(summary.TotalExpenses)
-		generatedAt = summary.GeneratedAt.(time.Time)
-	} else {
-		// Get summary for all currencies combined
-		summary, err := s.db.Queries.GetAllBalanceSummary(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get balance summary: %w", err)
+	var breakdown []queries.GetBalanceSummaryByAccountTypeRow
+
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		if currency != "" {
+			// Get summary for specific currency
+			summary, err := q.GetBalanceSummaryByCurrency(ctx, currency)
+			if err != nil {
+				return fmt.Errorf("failed to get balance summary: %w", err)
+			}
+
+			responseCurrency = summary.Currency
+			totalAccounts = summary.TotalAccounts
+			totalAssets = convertNumeric(summary.TotalAssets)
+			totalLiabilities = convertNumeric(summary.TotalLiabilities)
+			totalEquity = convertNumeric(summary.TotalEquity)
+			totalRevenue = convertNumeric(summary.TotalRevenue)
+			totalExpenses = convertNumeric(summary.TotalExpenses)
+			generatedAt = summary.GeneratedAt.(time.Time)
+		} else {
+			// Get summary for all currencies combined
+			summary, err := q.GetAllBalanceSummary(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get balance summary: %w", err)
+			}
+
+			responseCurrency = summary.Currency
+			totalAccounts = summary.TotalAccounts
+			totalAssets = convertNumeric(summary.TotalAssets)
+			totalLiabilities = convertNumeric(summary.TotalLiabilities)
+			totalEquity = convertNumeric(summary.TotalEquity)
+			totalRevenue = convertNumeric(summary.TotalRevenue)
+			totalExpenses = convertNumeric(summary.TotalExpenses)
+			generatedAt = summary.GeneratedAt.(time.Time)
 		}
 
-		responseCurrency = summary.Currency
-		totalAccounts = summary.TotalAccounts
-		totalAssets = convertNumeric(summary.TotalAssets)
-		totalLiabilities = convertNumeric(summary.TotalLiabilities)
-		totalEquity = convertNumeric(summary.TotalEquity)
-		totalRevenue = convertNumeric(summary.TotalRevenue)
-		totalExpenses = convertNumeric(summary.TotalExpenses)
-		generatedAt = summary.GeneratedAt.(time.Time)
-	}
+		// Get breakdown by account type (with optional currency filter)
+		var currencyFilter pgtype.Text
+		if currency != "" {
+			currencyFilter = pgtype.Text{String: currency, Valid: true}
+		} else {
+			currencyFilter = pgtype.Text{Valid: false} // NULL = all currencies
+		}
 
-	// Get breakdown by account type (with optional currency filter)
-	var currencyFilter pgtype.Text
-	if currency != "" {
-		currencyFilter = pgtype.Text{String: currency, Valid: true}
-	} else {
-		currencyFilter = pgtype.Text{Valid: false} // NULL = all currencies
-	}
+		var err error
+		breakdown, err = q.GetBalanceSummaryByAccountType(ctx, currencyFilter.String)
+		if err != nil {
+			return fmt.Errorf("failed to get balance breakdown: %w", err)
+		}
 
-	breakdown, err := s.db.Queries.GetBalanceSummaryByAccountType(ctx, currencyFilter.String)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get balance breakdown: %w", err)
+		return nil, err
 	}
 
 	var breakdownEntries []AccountTypeBreakdown
@@ -430,13 +477,12 @@ func (s *Service) GetBalanceSummary(ctx context.Context, tenantSlug string, curr
 
 // GetAccountBalances retrieves all balances for a specific account
 func (s *Service) GetAccountBalances(ctx context.Context, tenantSlug string, accountID uuid.UUID) ([]*AccountBalanceResponse, error) {
-	// Switch to tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
-
-	balances, err := s.db.Queries.GetAccountBalances(ctx, accountID)
+	var balances []queries.AccountBalanceTotal
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		balances, err = q.GetAccountBalanceTotals(ctx, accountID)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account balances: %w", err)
 	}
@@ -454,15 +500,82 @@ func (s *Service) GetAccountBalances(ctx context.Context, tenantSlug string, acc
 	return response, nil
 }
 
-// GetAccountHierarchy returns the complete chart of accounts hierarchy
-func (s *Service) GetAccountHierarchy(ctx context.Context, tenantSlug string) ([]*AccountResponse, error) {
-	// Switch to tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
+// EnableHighVolume spreads an account's balance updates across slots so
+// concurrent postings don't serialize on a single balance row
+func (s *Service) EnableHighVolume(ctx context.Context, tenantSlug string, accountID uuid.UUID, req HighVolumeRequest) (*HighVolumeResponse, error) {
+	slotCount := req.SlotCount
+	if slotCount == 0 {
+		slotCount = DefaultSlotCount
 	}
-	defer s.db.SetSearchPath(ctx, "public")
 
-	accounts, err := s.db.Queries.GetAccountHierarchy(ctx)
+	var highVolume queries.HighVolumeAccount
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		// Get account to ensure it exists
+		_, err := q.GetAccountByID(ctx, accountID)
+		if err != nil {
+			return ErrAccountNotFound
+		}
+
+		highVolume, err = q.UpsertHighVolumeAccount(ctx, queries.UpsertHighVolumeAccountParams{
+			AccountID:           accountID,
+			SlotCount:           int32(slotCount),
+			OverdraftProtection: req.OverdraftProtection,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to enable high-volume mode: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	requestctx.Logf(ctx, "Enabled high-volume mode for account %s in tenant %s with %d slots", accountID, tenantSlug, highVolume.SlotCount)
+
+	return &HighVolumeResponse{
+		AccountID:           accountID.String(),
+		SlotCount:           int(highVolume.SlotCount),
+		OverdraftProtection: highVolume.OverdraftProtection,
+		CreatedAt:           highVolume.CreatedAt,
+		UpdatedAt:           highVolume.UpdatedAt,
+	}, nil
+}
+
+// DisableHighVolume folds an account's balance slots back into its balance
+// rows and returns it to single-row updates
+func (s *Service) DisableHighVolume(ctx context.Context, tenantSlug string, accountID uuid.UUID) error {
+	return s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		_, err := q.GetHighVolumeAccount(ctx, accountID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrAccountNotHighVolume
+			}
+			return fmt.Errorf("failed to get high-volume account: %w", err)
+		}
+
+		// Deleting first waits for in-flight slot postings (they hold a key-share
+		// lock on the row), so nothing lands in a slot after the fold
+		if err := q.DeleteHighVolumeAccount(ctx, accountID); err != nil {
+			return fmt.Errorf("failed to disable high-volume mode: %w", err)
+		}
+
+		if _, err := q.FoldAccountBalanceSlots(ctx, accountID); err != nil {
+			return fmt.Errorf("failed to fold balance slots: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetAccountHierarchy returns the complete chart of accounts hierarchy
+func (s *Service) GetAccountHierarchy(ctx context.Context, tenantSlug string) ([]*AccountResponse, error) {
+	var accounts []queries.GetAccountHierarchyRow
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		accounts, err = q.GetAccountHierarchy(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account hierarchy: %w", err)
 	}
@@ -471,7 +584,7 @@ func (s *Service) GetAccountHierarchy(ctx context.Context, tenantSlug string) ([
 	for _, account := range accounts {
 		resp, err := s.accountToResponseWithHierarchy(account)
 		if err != nil {
-			log.Printf("Failed to convert account to response: %v", err)
+			requestctx.Logf(ctx, "Failed to convert account to response: %v", err)
 			continue
 		}
 		response = append(response, resp)
@@ -482,13 +595,12 @@ func (s *Service) GetAccountHierarchy(ctx context.Context, tenantSlug string) ([
 
 // GetAccountStats returns statistics about the chart of accounts
 func (s *Service) GetAccountStats(ctx context.Context, tenantSlug string) (*AccountStatsResponse, error) {
-	// Switch to tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
-
-	stats, err := s.db.Queries.GetAccountStats(ctx)
+	var stats queries.GetAccountStatsRow
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		stats, err = q.GetAccountStats(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account stats: %w", err)
 	}
@@ -508,18 +620,18 @@ func (s *Service) GetAccountStats(ctx context.Context, tenantSlug string) (*Acco
 func (s *Service) SetupChartOfAccounts(ctx context.Context, tenantSlug string, businessType string) error {
 	template := GetChartOfAccountsTemplate(businessType)
 
-	log.Printf("Setting up chart of accounts for tenant %s with business type %s", tenantSlug, businessType)
+	requestctx.Logf(ctx, "Setting up chart of accounts for tenant %s with business type %s", tenantSlug, businessType)
 
 	// Create accounts in order (parents first, then children)
 	for _, accountReq := range template.Accounts {
 		_, err := s.CreateAccount(ctx, tenantSlug, accountReq)
 		if err != nil {
-			log.Printf("Failed to create account %s (%s): %v", accountReq.Code, accountReq.Name, err)
+			requestctx.Logf(ctx, "Failed to create account %s (%s): %v", accountReq.Code, accountReq.Name, err)
 			return fmt.Errorf("failed to create account %s: %w", accountReq.Code, err)
 		}
 	}
 
-	log.Printf("Successfully set up chart of accounts for tenant %s", tenantSlug)
+	requestctx.Logf(ctx, "Successfully set up chart of accounts for tenant %s", tenantSlug)
 	return nil
 }
 
@@ -19,8 +19,13 @@ var (
 	ErrInvalidCurrency        = errors.New("invalid currency code")
 	ErrInvalidAccountType     = errors.New("invalid account type")
 	ErrBalanceVersionConflict = errors.New("balance version conflict - concurrent update detected")
+	ErrAccountNotHighVolume   = errors.New("account is not in high-volume mode")
 )
 
+// DefaultSlotCount is the number of balance slots used when enabling
+// high-volume mode without an explicit slot count
+const DefaultSlotCount = 16
+
 // Account Types
 const (
 	AccountTypeAsset     = "asset"
@@ -64,6 +69,11 @@ type ListAccountsRequest struct {
 	Limit       int    `json:"limit,omitempty" validate:"omitempty,min=1,max=1000"`
 }
 
+type HighVolumeRequest struct {
+	SlotCount           int  `json:"slot_count,omitempty" validate:"omitempty,min=1,max=256"`
+	OverdraftProtection bool `json:"overdraft_protection"`
+}
+
 // Response Types
 
 type AccountResponse struct {
@@ -90,6 +100,14 @@ type AccountBalanceResponse struct {
 	UpdatedAt time.Time       `json:"updated_at"`
 }
 
+type HighVolumeResponse struct {
+	AccountID           string    `json:"account_id"`
+	SlotCount           int       `json:"slot_count"`
+	OverdraftProtection bool      `json:"overdraft_protection"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
 type AccountWithBalanceResponse struct {
 	AccountResponse
 	Balance          *decimal.Decimal `json:"balance,omitempty"`
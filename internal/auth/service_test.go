@@ -296,6 +296,11 @@ func TestValidateScopes(t *testing.T) {
 			scopes: []string{"transactions:read", "accounts:write", "balances:read"},
 			want:   true,
 		},
+		{
+			name:   "Webhook scopes",
+			scopes: []string{"webhooks:read", "webhooks:manage"},
+			want:   true,
+		},
 		{
 			name:   "All valid scopes",
 			scopes: ValidScopes,
@@ -112,6 +112,8 @@ var ValidScopes = []string{
 	"accounts:write",
 	"balances:read",
 	"reports:read",
+	"events:read",
+	"webhooks:read",
 	"webhooks:manage",
 }
 
@@ -1,14 +1,21 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/subosito/gotenv"
 )
 
+const (
+	TenancyModeSchema = "schema"
+	TenancyModeRLS    = "rls"
+)
+
 type Config struct {
 	Host string
 	Port string
@@ -18,13 +25,70 @@ type Config struct {
 	DatabaseMaxConnections int
 	DatabaseMaxIdleTime    time.Duration
 
+	// TenancyMode is the storage mode for newly created tenants: "schema" gives
+	// each tenant its own schema, "rls" keeps them in shared tables guarded by
+	// row-level security. Existing tenants keep the mode they were created with.
+	TenancyMode string
+
 	RedisURL string
 
 	JWTSecret    string
 	APIKeySecret string
 
-	WebhookTimeout    time.Duration
-	WebhookMaxRetries int
+	WebhookTimeout time.Duration
+
+	// WebhookRetrySchedule is the delay before each retry of a failed
+	// delivery; once it is exhausted the delivery is dead-lettered. Empty
+	// uses webhooks.DefaultRetrySchedule.
+	WebhookRetrySchedule []time.Duration
+
+	// WebhookWorkers is how many deliveries each instance sends concurrently,
+	// at most WebhookEndpointConcurrency of them to the same endpoint
+	WebhookWorkers             int
+	WebhookEndpointConcurrency int
+
+	// An endpoint's circuit opens after WebhookCircuitFailureThreshold
+	// consecutive failures and lets a probe through every
+	// WebhookCircuitCooldown. An endpoint still failing after
+	// WebhookAutoDisableAfter is disabled; zero never disables.
+	WebhookCircuitFailureThreshold int
+	WebhookCircuitCooldown         time.Duration
+	WebhookAutoDisableAfter        time.Duration
+
+	// WebhookAllowedHosts are hostnames, IPs and CIDR ranges webhooks may be
+	// sent to even though they are internal or plain HTTP. Everything else
+	// must be a public address, over HTTPS outside development.
+	WebhookAllowedHosts []string
+
+	// WebhookAttemptRetention is how long each delivery attempt's request and
+	// response are kept
+	WebhookAttemptRetention time.Duration
+
+	// WebhookMasterKeys are the versioned master keys webhook signing secrets
+	// are encrypted under; the highest version encrypts new secrets. After a
+	// secret rotation the old secret keeps signing for WebhookSecretOverlap.
+	WebhookMasterKeys    map[int32][]byte
+	WebhookSecretOverlap time.Duration
+
+	// WebhookReplayRate is how many events per second a webhook replay
+	// resends when the request doesn't set its own rate
+	WebhookReplayRate int
+
+	// Monthly partitions for transactions and events are kept created
+	// PartitionMonthsAhead months ahead, checked every
+	// PartitionMaintenanceInterval. ArchiveStore is a local directory or an
+	// http(s) base URL that partition-archive uploads to, and ArchiveKeepMonths
+	// is how many whole months it leaves in the database.
+	PartitionMonthsAhead         int
+	PartitionMaintenanceInterval time.Duration
+	ArchiveStore                 string
+	ArchiveKeepMonths            int
+
+	// EventSinks are the external sinks every event is forwarded to, at least
+	// once and each from its own cursor: "redis" for the stream
+	// EventSinkRedisStream on RedisURL, or a file path events are appended to
+	EventSinks           []string
+	EventSinkRedisStream string
 }
 
 func Load() (*Config, error) {
@@ -40,13 +104,37 @@ func Load() (*Config, error) {
 		DatabaseMaxConnections: getEnvInt("DATABASE_MAX_CONNECTIONS", 25),
 		DatabaseMaxIdleTime:    getEnvDuration("DATABASE_MAX_IDLE_TIME", 15*time.Minute),
 
+		TenancyMode: getEnvString("TENANCY_MODE", TenancyModeSchema),
+
 		RedisURL: getEnvString("REDIS_URL", "redis://localhost:6379/0"),
 
 		JWTSecret:    getEnvString("JWT_SECRET", ""),
 		APIKeySecret: getEnvString("API_KEY_SECRET", ""),
 
-		WebhookTimeout:    getEnvDuration("WEBHOOK_TIMEOUT", 30*time.Second),
-		WebhookMaxRetries: getEnvInt("WEBHOOK_MAX_RETRIES", 3),
+		WebhookTimeout:       getEnvDuration("WEBHOOK_TIMEOUT", 30*time.Second),
+		WebhookRetrySchedule: getEnvDurations("WEBHOOK_RETRY_SCHEDULE", nil),
+
+		WebhookWorkers:             getEnvInt("WEBHOOK_WORKERS", 10),
+		WebhookEndpointConcurrency: getEnvInt("WEBHOOK_ENDPOINT_CONCURRENCY", 2),
+
+		WebhookCircuitFailureThreshold: getEnvInt("WEBHOOK_CIRCUIT_FAILURE_THRESHOLD", 5),
+		WebhookCircuitCooldown:         getEnvDuration("WEBHOOK_CIRCUIT_COOLDOWN", time.Minute),
+		WebhookAutoDisableAfter:        getEnvDuration("WEBHOOK_AUTO_DISABLE_AFTER", 72*time.Hour),
+
+		WebhookAllowedHosts:     getEnvStrings("WEBHOOK_ALLOWED_HOSTS", nil),
+		WebhookAttemptRetention: getEnvDuration("WEBHOOK_ATTEMPT_RETENTION", 30*24*time.Hour),
+
+		WebhookSecretOverlap: getEnvDuration("WEBHOOK_SECRET_OVERLAP", 24*time.Hour),
+
+		WebhookReplayRate: getEnvInt("WEBHOOK_REPLAY_RATE", 50),
+
+		PartitionMonthsAhead:         getEnvInt("PARTITION_MONTHS_AHEAD", 3),
+		PartitionMaintenanceInterval: getEnvDuration("PARTITION_MAINTENANCE_INTERVAL", 6*time.Hour),
+		ArchiveStore:                 getEnvString("ARCHIVE_STORE", "./archive"),
+		ArchiveKeepMonths:            getEnvInt("ARCHIVE_KEEP_MONTHS", 12),
+
+		EventSinks:           getEnvStrings("EVENT_SINKS", nil),
+		EventSinkRedisStream: getEnvString("EVENT_SINK_REDIS_STREAM", "ledger:events"),
 	}
 
 	if cfg.JWTSecret == "" {
@@ -57,9 +145,75 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("API_KEY_SECRET is required")
 	}
 
+	masterKeys, err := parseMasterKeys(getEnvString("WEBHOOK_MASTER_KEYS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("WEBHOOK_MASTER_KEYS: %w", err)
+	}
+	cfg.WebhookMasterKeys = masterKeys
+
+	if cfg.TenancyMode != TenancyModeSchema && cfg.TenancyMode != TenancyModeRLS {
+		return nil, fmt.Errorf("TENANCY_MODE must be %q or %q", TenancyModeSchema, TenancyModeRLS)
+	}
+
+	if cfg.WebhookWorkers < 1 || cfg.WebhookEndpointConcurrency < 1 {
+		return nil, fmt.Errorf("WEBHOOK_WORKERS and WEBHOOK_ENDPOINT_CONCURRENCY must be at least 1")
+	}
+
+	if cfg.WebhookCircuitFailureThreshold < 1 || cfg.WebhookCircuitCooldown <= 0 {
+		return nil, fmt.Errorf("WEBHOOK_CIRCUIT_FAILURE_THRESHOLD must be at least 1 and WEBHOOK_CIRCUIT_COOLDOWN positive")
+	}
+
+	if cfg.WebhookAttemptRetention <= 0 {
+		return nil, fmt.Errorf("WEBHOOK_ATTEMPT_RETENTION must be positive")
+	}
+
+	if cfg.WebhookReplayRate < 1 || cfg.WebhookReplayRate > 1000 {
+		return nil, fmt.Errorf("WEBHOOK_REPLAY_RATE must be between 1 and 1000")
+	}
+
+	if cfg.PartitionMonthsAhead < 1 {
+		return nil, fmt.Errorf("PARTITION_MONTHS_AHEAD must be at least 1")
+	}
+
 	return cfg, nil
 }
 
+// parseMasterKeys reads comma-separated "<version>:<base64 key>" pairs, each
+// key being 32 bytes
+func parseMasterKeys(value string) (map[int32][]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("at least one key is required")
+	}
+
+	keys := make(map[int32][]byte)
+	for _, pair := range strings.Split(value, ",") {
+		versionStr, encoded, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return nil, fmt.Errorf("expected <version>:<base64 key>")
+		}
+
+		version, err := strconv.ParseInt(versionStr, 10, 32)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid key version %q", versionStr)
+		}
+		if _, exists := keys[int32(version)]; exists {
+			return nil, fmt.Errorf("duplicate key version %d", version)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key version %d is not valid base64", version)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key version %d must be 32 bytes", version)
+		}
+
+		keys[int32(version)] = key
+	}
+
+	return keys, nil
+}
+
 func getEnvString(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -85,6 +239,41 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getEnvStrings reads a comma-separated list, skipping empty entries
+func getEnvStrings(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// getEnvDurations reads a comma-separated list of durations, falling back to
+// defaultValue if any of them is invalid
+func getEnvDurations(key string, defaultValue []time.Duration) []time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || d <= 0 {
+			return defaultValue
+		}
+		durations = append(durations, d)
+	}
+	return durations
+}
+
 func (c *Config) IsDevelopment() bool {
 	return c.Env == "development"
 }
@@ -0,0 +1,108 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// CloudEvents 1.0 framing of events, for consumers on a CloudEvents bus
+const (
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsContentType is the content type of a structured-mode event
+	CloudEventsContentType = "application/cloudevents+json"
+	// CloudEventsHeaderPrefix prefixes the attributes of a binary-mode event
+	CloudEventsHeaderPrefix = "ce-"
+)
+
+// CloudEvent is an event in the CloudEvents 1.0 JSON format. Besides the
+// context attributes of the spec it carries the extensions tenantid,
+// sequence, aggregateversion, dataversion and correlationid, and replayid
+// when a webhook replay resends it.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+
+	TenantID         string `json:"tenantid"`
+	Sequence         int64  `json:"sequence,omitempty"`
+	AggregateVersion int64  `json:"aggregateversion,omitempty"`
+	DataVersion      int32  `json:"dataversion,omitempty"`
+	CorrelationID    string `json:"correlationid,omitempty"`
+	ReplayID         string `json:"replayid,omitempty"`
+}
+
+// NewCloudEvent frames a stored event, with its data as is
+func NewCloudEvent(event queries.Event) CloudEvent {
+	ce := CloudEvent{
+		SpecVersion:      CloudEventsSpecVersion,
+		ID:               event.EventID.String(),
+		Source:           CloudEventSource(event.TenantID.String()),
+		Type:             event.EventType,
+		Subject:          CloudEventSubject(event.AggregateType, event.AggregateID.String()),
+		Time:             event.CreatedAt,
+		DataContentType:  "application/json",
+		Data:             event.EventData,
+		TenantID:         event.TenantID.String(),
+		Sequence:         event.SequenceNumber.Int64,
+		AggregateVersion: event.AggregateVersion.Int64,
+		DataVersion:      event.EventVersion,
+	}
+	if meta, ok := RequestMetadata(event); ok {
+		ce.CorrelationID = meta.CorrelationID
+	}
+	return ce
+}
+
+// CloudEventSource identifies the tenant ledger an event happened in
+func CloudEventSource(tenantID string) string {
+	return "/tenants/" + tenantID
+}
+
+// CloudEventSubject identifies the aggregate an event happened to
+func CloudEventSubject(aggregateType, aggregateID string) string {
+	if aggregateType == "" || aggregateID == "" {
+		return ""
+	}
+	return aggregateType + "/" + aggregateID
+}
+
+// BinaryHeaders are the ce- headers of the event in binary mode, where the
+// HTTP body is the data alone and Content-Type is its datacontenttype
+func (ce CloudEvent) BinaryHeaders() http.Header {
+	header := http.Header{}
+	set := func(attribute, value string) {
+		if value != "" {
+			header.Set(CloudEventsHeaderPrefix+attribute, value)
+		}
+	}
+
+	set("specversion", ce.SpecVersion)
+	set("id", ce.ID)
+	set("source", ce.Source)
+	set("type", ce.Type)
+	set("subject", ce.Subject)
+	set("time", ce.Time.UTC().Format(time.RFC3339Nano))
+	set("tenantid", ce.TenantID)
+	if ce.Sequence != 0 {
+		set("sequence", strconv.FormatInt(ce.Sequence, 10))
+	}
+	if ce.AggregateVersion != 0 {
+		set("aggregateversion", strconv.FormatInt(ce.AggregateVersion, 10))
+	}
+	if ce.DataVersion != 0 {
+		set("dataversion", strconv.FormatInt(int64(ce.DataVersion), 10))
+	}
+	set("correlationid", ce.CorrelationID)
+	set("replayid", ce.ReplayID)
+	header.Set("Content-Type", ce.DataContentType)
+	return header
+}
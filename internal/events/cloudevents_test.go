@@ -0,0 +1,102 @@
+// internal/events/cloudevents_test.go
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+func testCloudEventSource() queries.Event {
+	return queries.Event{
+		EventID:          uuid.New(),
+		TenantID:         uuid.New(),
+		AggregateID:      uuid.New(),
+		AggregateType:    AggregateTypeTransaction,
+		EventType:        EventTypeTransactionPosted,
+		EventVersion:     1,
+		EventData:        json.RawMessage(`{"transaction_id":"t1"}`),
+		Metadata:         json.RawMessage(`{"source":"api","request_id":"host/abc-000001","correlation_id":"order-42"}`),
+		CreatedAt:        time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		SequenceNumber:   pgtype.Int8{Int64: 11, Valid: true},
+		TxID:             900,
+		AggregateVersion: pgtype.Int8{Int64: 3, Valid: true},
+	}
+}
+
+func TestNewCloudEvent(t *testing.T) {
+	event := testCloudEventSource()
+	ce := NewCloudEvent(event)
+
+	raw, err := json.Marshal(ce)
+	require.NoError(t, err)
+
+	var structured map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &structured))
+	assert.Equal(t, "1.0", structured["specversion"])
+	assert.Equal(t, event.EventID.String(), structured["id"])
+	assert.Equal(t, "/tenants/"+event.TenantID.String(), structured["source"])
+	assert.Equal(t, "transaction/"+event.AggregateID.String(), structured["subject"])
+	assert.Equal(t, EventTypeTransactionPosted, structured["type"])
+	assert.Equal(t, "2026-10-18T12:00:00Z", structured["time"])
+	assert.Equal(t, "application/json", structured["datacontenttype"])
+	assert.Equal(t, map[string]interface{}{"transaction_id": "t1"}, structured["data"])
+	assert.Equal(t, float64(11), structured["sequence"])
+	assert.Equal(t, float64(3), structured["aggregateversion"])
+	assert.Equal(t, "order-42", structured["correlationid"])
+}
+
+func TestCloudEventBinaryHeaders(t *testing.T) {
+	event := testCloudEventSource()
+	header := NewCloudEvent(event).BinaryHeaders()
+
+	assert.Equal(t, "1.0", header.Get("ce-specversion"))
+	assert.Equal(t, event.EventID.String(), header.Get("ce-id"))
+	assert.Equal(t, "/tenants/"+event.TenantID.String(), header.Get("ce-source"))
+	assert.Equal(t, EventTypeTransactionPosted, header.Get("ce-type"))
+	assert.Equal(t, "2026-10-18T12:00:00Z", header.Get("ce-time"))
+	assert.Equal(t, "11", header.Get("ce-sequence"))
+	assert.Equal(t, "3", header.Get("ce-aggregateversion"))
+	assert.Equal(t, "1", header.Get("ce-dataversion"))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+}
+
+func TestWriteStreamEventAsCloudEvent(t *testing.T) {
+	event := testCloudEventSource()
+
+	var buf bytes.Buffer
+	require.NoError(t, writeStreamEvent(&buf, event, StreamFormatCloudEvents))
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n\n")), []byte("\n"))
+	require.Len(t, lines, 3)
+	assert.Equal(t, "id: 900-11", string(lines[0]))
+
+	var sent CloudEvent
+	require.NoError(t, json.Unmarshal(bytes.TrimPrefix(lines[2], []byte("data: ")), &sent))
+	assert.Equal(t, event.EventID.String(), sent.ID)
+	assert.Equal(t, CloudEventsSpecVersion, sent.SpecVersion)
+}
+
+func TestJSONSchema(t *testing.T) {
+	document, err := Schemas.JSONSchema(EventTypeTransactionPosted, 1)
+	require.NoError(t, err)
+	assert.Equal(t, JSONSchemaDialect, document["$schema"])
+	assert.Equal(t, "object", document["type"])
+
+	properties := document["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string", "format": "date-time"}, properties["posted_at"])
+	assert.Equal(t, []string{"string", "null"}, properties["reference"].(map[string]interface{})["type"])
+	assert.Equal(t, "array", properties["lines"].(map[string]interface{})["type"])
+	assert.Contains(t, document["required"], "transaction_id")
+	assert.NotContains(t, document["required"], "reference")
+
+	_, err = Schemas.JSONSchema(EventTypeTransactionPosted, 2)
+	assert.ErrorIs(t, err, ErrUnknownSchemaVersion)
+}
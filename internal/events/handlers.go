@@ -0,0 +1,432 @@
+// internal/events/handlers.go
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/auth"
+	"github.com/temmyjay001/ledger-service/internal/storage"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+	"github.com/temmyjay001/ledger-service/pkg/api"
+)
+
+type Handlers struct {
+	service *Service
+
+	// heartbeatInterval is how long an idle event stream waits between
+	// heartbeats
+	heartbeatInterval time.Duration
+}
+
+func NewHandlers(service *Service) *Handlers {
+	return &Handlers{service: service, heartbeatInterval: StreamHeartbeatInterval}
+}
+
+// ListEventsHandler returns a page of a tenant's event history, filtered by
+// event_type, aggregate_type, aggregate_id, from_sequence/to_sequence and
+// created_from/created_to (RFC 3339). Further pages are fetched by passing
+// the returned next_cursor as cursor.
+func (h *Handlers) ListEventsHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	req := ListEventsRequest{
+		EventType:     query.Get("event_type"),
+		AggregateType: query.Get("aggregate_type"),
+		Cursor:        query.Get("cursor"),
+	}
+	if value := query.Get("aggregate_id"); value != "" {
+		aggregateID, err := uuid.Parse(value)
+		if err != nil {
+			api.WriteBadRequestResponse(w, "Invalid aggregate ID")
+			return
+		}
+		req.AggregateID = &aggregateID
+	}
+
+	var err error
+	if req.FromSequence, err = optionalInt64(query.Get("from_sequence")); err != nil {
+		api.WriteBadRequestResponse(w, "Invalid from_sequence")
+		return
+	}
+	if req.ToSequence, err = optionalInt64(query.Get("to_sequence")); err != nil {
+		api.WriteBadRequestResponse(w, "Invalid to_sequence")
+		return
+	}
+	if req.CreatedFrom, err = optionalTime(query.Get("created_from")); err != nil {
+		api.WriteBadRequestResponse(w, "Invalid created_from, expected RFC 3339")
+		return
+	}
+	if req.CreatedTo, err = optionalTime(query.Get("created_to")); err != nil {
+		api.WriteBadRequestResponse(w, "Invalid created_to, expected RFC 3339")
+		return
+	}
+
+	h.listEvents(w, r, tenantID, req)
+}
+
+// GetEventHandler returns a single event
+func (h *Handlers) GetEventHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid event ID")
+		return
+	}
+
+	event, err := h.service.GetEvent(r.Context(), tenantID, eventID)
+	if err != nil {
+		if errors.Is(err, ErrEventNotFound) {
+			api.WriteNotFoundResponse(w, "event not found")
+			return
+		}
+		api.WriteInternalErrorResponse(w, err.Error())
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, event)
+}
+
+// ListAccountEventsHandler returns the event history of an account, including
+// the balance.updated events of its balances. Balance events of postings to
+// high-volume balance slots have no aggregate_version.
+func (h *Handlers) ListAccountEventsHandler(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	accountID, err := uuid.Parse(chi.URLParam(r, "accountId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid "+AggregateTypeAccount+" ID")
+		return
+	}
+
+	h.listEvents(w, r, tenantID, ListEventsRequest{
+		AccountID: &accountID,
+		Cursor:    r.URL.Query().Get("cursor"),
+	})
+}
+
+// ListTransactionEventsHandler returns the event history of a transaction
+func (h *Handlers) ListTransactionEventsHandler(w http.ResponseWriter, r *http.Request) {
+	h.listAggregateEvents(w, r, AggregateTypeTransaction, chi.URLParam(r, "transactionId"))
+}
+
+func (h *Handlers) listAggregateEvents(w http.ResponseWriter, r *http.Request, aggregateType, id string) {
+	tenantID, ok := tenantFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	aggregateID, err := uuid.Parse(id)
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid "+aggregateType+" ID")
+		return
+	}
+
+	h.listEvents(w, r, tenantID, ListEventsRequest{
+		AggregateType: aggregateType,
+		AggregateID:   &aggregateID,
+		Cursor:        r.URL.Query().Get("cursor"),
+	})
+}
+
+// listEvents applies the page size and writes a page of events
+func (h *Handlers) listEvents(w http.ResponseWriter, r *http.Request, tenantID uuid.UUID, req ListEventsRequest) {
+	req.Limit = DefaultListLimit
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 {
+		req.Limit = min(limit, MaxListLimit)
+	}
+
+	response, err := h.service.ListEvents(r.Context(), tenantID, req)
+	if err != nil {
+		var archived *storage.ArchivedRangeError
+		switch {
+		case errors.Is(err, ErrInvalidCursor):
+			api.WriteBadRequestResponse(w, "Invalid cursor")
+		case errors.As(err, &archived):
+			api.WriteErrorResponse(w, http.StatusGone, archived.Error())
+		default:
+			api.WriteInternalErrorResponse(w, err.Error())
+		}
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// ListEventSchemasHandler lists the payload schema versions of every event
+// type
+func (h *Handlers) ListEventSchemasHandler(w http.ResponseWriter, r *http.Request) {
+	response := EventSchemaListResponse{
+		LatestPayloadVersion: Schemas.LatestPayloadVersion(),
+		EventTypes:           []EventSchemaResponse{},
+	}
+	for _, eventType := range Schemas.EventTypes() {
+		eventSchema := EventSchemaResponse{
+			EventType:      eventType,
+			CurrentVersion: Schemas.CurrentVersion(eventType),
+		}
+		for _, schema := range Schemas.Versions(eventType) {
+			eventSchema.Versions = append(eventSchema.Versions, EventSchemaVersion{
+				Version:        schema.Version,
+				PayloadVersion: schema.PayloadVersion,
+				SchemaURL:      schemaURL(eventType, schema.Version),
+			})
+		}
+		response.EventTypes = append(response.EventTypes, eventSchema)
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, response)
+}
+
+// GetEventSchemaHandler returns the JSON Schema document of an event type's
+// payload, in its current version unless version is given
+func (h *Handlers) GetEventSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	eventType := chi.URLParam(r, "eventType")
+
+	version := Schemas.CurrentVersion(eventType)
+	if value := r.URL.Query().Get("version"); value != "" {
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			api.WriteBadRequestResponse(w, "Invalid version")
+			return
+		}
+		version = int32(v)
+	}
+
+	document, err := Schemas.JSONSchema(eventType, version)
+	if err != nil {
+		api.WriteNotFoundResponse(w, "event schema not found")
+		return
+	}
+	document["$id"] = schemaURL(eventType, version)
+
+	api.WriteJSONResponse(w, http.StatusOK, document)
+}
+
+// schemaURL is where the JSON Schema of an event type's version is published
+func schemaURL(eventType string, version int32) string {
+	return fmt.Sprintf("/api/v1/schemas/events/%s?version=%d", eventType, version)
+}
+
+// StreamEventsHandler streams a tenant's events as Server-Sent Events. A
+// client resumes after the last event it saw by sending its ID as
+// Last-Event-ID, or as the last_event_id query parameter where it cannot set
+// headers; without one only new events are sent. Events can be narrowed with
+// event_type (repeated or comma-separated) and aggregate_id, and are sent as
+// structured CloudEvents with format=cloudevents.
+func (h *Handlers) StreamEventsHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug := chi.URLParam(r, "tenantSlug")
+
+	tenantID, ok := tenantFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	switch format {
+	case "":
+		format = StreamFormatLedger
+	case StreamFormatLedger, StreamFormatCloudEvents:
+	default:
+		api.WriteBadRequestResponse(w, "Invalid format, expected ledger or cloudevents")
+		return
+	}
+
+	var filter StreamFilter
+	for _, value := range query["event_type"] {
+		for _, eventType := range strings.Split(value, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				filter.EventTypes = append(filter.EventTypes, eventType)
+			}
+		}
+	}
+	if value := query.Get("aggregate_id"); value != "" {
+		aggregateID, err := uuid.Parse(value)
+		if err != nil {
+			api.WriteBadRequestResponse(w, "Invalid aggregate ID")
+			return
+		}
+		filter.AggregateID = pgtype.UUID{Bytes: aggregateID, Valid: true}
+	}
+
+	var after *StreamCursor
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("last_event_id")
+	}
+	if lastEventID != "" {
+		cursor, err := ParseStreamCursor(lastEventID)
+		if err != nil {
+			api.WriteBadRequestResponse(w, "Invalid Last-Event-ID")
+			return
+		}
+		after = &cursor
+	}
+
+	ctx := r.Context()
+	stream, err := h.service.OpenStream(ctx, tenantID, filter, after)
+	if err != nil {
+		api.WriteInternalErrorResponse(w, "failed to open event stream")
+		return
+	}
+	defer stream.Close()
+
+	// the stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Event stream cannot lift write deadline: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// send whatever is pending, then follow notifications
+	send := func() error {
+		for {
+			events, err := stream.Next(ctx)
+			if err != nil {
+				return err
+			}
+			for _, event := range events {
+				if err := writeStreamEvent(w, event, format); err != nil {
+					return err
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return err
+			}
+			if len(events) < StreamBatchSize {
+				return nil
+			}
+		}
+	}
+
+	poll := time.NewTicker(StreamPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		if err := send(); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Event stream for tenant %s stopped: %v", tenantSlug, err)
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-stream.Wake():
+			if !ok {
+				return
+			}
+		case <-poll.C:
+		case <-heartbeat.C:
+			// flushed straight away, so proxies see traffic even while the
+			// stream has nothing to send
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// tenantFromAPIKey returns the tenant of the request's API key, writing an
+// error response if there is none or it belongs to another tenant than the
+// one in the URL
+func tenantFromAPIKey(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	claims, ok := auth.GetAPIKeyClaims(r.Context())
+	if !ok {
+		api.WriteUnauthorizedResponse(w, "API key authentication required")
+		return uuid.Nil, false
+	}
+	if claims.TenantSlug != chi.URLParam(r, "tenantSlug") {
+		api.WriteForbiddenResponse(w, "API key not authorized for this tenant")
+		return uuid.Nil, false
+	}
+	return claims.TenantID, true
+}
+
+func optionalInt64(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func optionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// writeStreamEvent writes an event in SSE framing. Its ID is the stream
+// cursor after the event, so a client reconnecting with it resumes after it.
+func writeStreamEvent(w io.Writer, event queries.Event, format string) error {
+	var body interface{} = eventToResponse(event)
+	if format == StreamFormatCloudEvents {
+		body = NewCloudEvent(event)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to serialize stream event: %w", err)
+	}
+
+	cursor := StreamCursor{TxID: event.TxID, Sequence: event.SequenceNumber.Int64}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", cursor, event.EventType, data)
+	return err
+}
+
+func eventToResponse(event queries.Event) EventResponse {
+	return EventResponse{
+		EventID:          event.EventID.String(),
+		AggregateID:      event.AggregateID.String(),
+		AggregateType:    event.AggregateType,
+		AggregateVersion: event.AggregateVersion.Int64,
+		EventType:        event.EventType,
+		EventVersion:     event.EventVersion,
+		SequenceNumber:   event.SequenceNumber.Int64,
+		Data:             event.EventData,
+		Metadata:         event.Metadata,
+		CreatedAt:        event.CreatedAt,
+	}
+}
@@ -0,0 +1,118 @@
+// internal/events/handlers_test.go
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/auth"
+)
+
+func eventRouter(claims *auth.APIKeyClaims) http.Handler {
+	h := NewHandlers(&Service{})
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if claims != nil {
+				r = r.WithContext(context.WithValue(r.Context(), auth.APIKeyContextKey, claims))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Get("/tenants/{tenantSlug}/events", h.ListEventsHandler)
+	r.Get("/tenants/{tenantSlug}/events/{eventId}", h.GetEventHandler)
+	r.Get("/tenants/{tenantSlug}/accounts/{accountId}/events", h.ListAccountEventsHandler)
+	r.Get("/tenants/{tenantSlug}/events/stream", h.StreamEventsHandler)
+	r.Get("/schemas/events", h.ListEventSchemasHandler)
+	r.Get("/schemas/events/{eventType}", h.GetEventSchemaHandler)
+	return r
+}
+
+func TestEventHandlersRequireTheKeysTenant(t *testing.T) {
+	claims := &auth.APIKeyClaims{TenantID: uuid.New(), TenantSlug: "acme"}
+
+	tests := []struct {
+		name   string
+		claims *auth.APIKeyClaims
+		path   string
+		status int
+	}{
+		{"no API key", nil, "/tenants/acme/events", http.StatusUnauthorized},
+		{"other tenant", claims, "/tenants/globex/events", http.StatusForbidden},
+		{"other tenant's event", claims, "/tenants/globex/events/" + uuid.NewString(), http.StatusForbidden},
+		{"other tenant's account", claims, "/tenants/globex/accounts/" + uuid.NewString() + "/events", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			eventRouter(tt.claims).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestListEventsHandlerRejectsInvalidFilters(t *testing.T) {
+	claims := &auth.APIKeyClaims{TenantID: uuid.New(), TenantSlug: "acme"}
+
+	for _, query := range []string{
+		"aggregate_id=not-a-uuid",
+		"from_sequence=ten",
+		"to_sequence=1.5",
+		"created_from=2026-10-18",
+		"created_to=yesterday",
+		"cursor=abc",
+	} {
+		t.Run(query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			eventRouter(claims).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tenants/acme/events?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestStreamEventsHandlerRejectsUnknownFormat(t *testing.T) {
+	claims := &auth.APIKeyClaims{TenantID: uuid.New(), TenantSlug: "acme"}
+
+	w := httptest.NewRecorder()
+	eventRouter(claims).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tenants/acme/events/stream?format=xml", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestEventSchemaHandlers(t *testing.T) {
+	w := httptest.NewRecorder()
+	eventRouter(nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/schemas/events", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var list struct {
+		Data EventSchemaListResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, Schemas.LatestPayloadVersion(), list.Data.LatestPayloadVersion)
+	require.Len(t, list.Data.EventTypes, len(Schemas.EventTypes()))
+
+	schemaURL := list.Data.EventTypes[0].Versions[0].SchemaURL
+	w = httptest.NewRecorder()
+	eventRouter(nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(schemaURL, "/api/v1"), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var document map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+	assert.Equal(t, JSONSchemaDialect, document["$schema"])
+	assert.Equal(t, schemaURL, document["$id"])
+
+	for _, path := range []string{"/schemas/events/payout.sent", "/schemas/events/transaction.posted?version=9"} {
+		w = httptest.NewRecorder()
+		eventRouter(nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+}
@@ -0,0 +1,334 @@
+// internal/events/integration_test.go
+// +build integration
+
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/auth"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+	"github.com/temmyjay001/ledger-service/internal/testUtil"
+)
+
+func TestIntegration_EventStreamPushesNewEventsAndResumes(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tenantSlug := testutil.RandomSlug()
+	tenant := testutil.CreateTestTenant(t, db, tenantSlug)
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, tenantSlug)
+	})
+
+	service := NewService(db)
+	go service.StartEventListener(ctx)
+
+	createEvent := func(eventType string) queries.Event {
+		event, err := db.Queries.CreateEvent(ctx, queries.CreateEventParams{
+			TenantID:      tenant.ID,
+			AggregateID:   uuid.New(),
+			AggregateType: AggregateTypeAccount,
+			EventType:     eventType,
+			EventVersion:  1,
+			EventData:     json.RawMessage("{}"),
+			Metadata:      json.RawMessage("{}"),
+		})
+		require.NoError(t, err)
+		return event
+	}
+
+	// created before the stream opens, so never sent without Last-Event-ID
+	before := createEvent(EventTypeBalanceUpdated)
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := &auth.APIKeyClaims{TenantID: tenant.ID, TenantSlug: tenantSlug}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), auth.APIKeyContextKey, claims)))
+		})
+	})
+	router.Get("/tenants/{tenantSlug}/events/stream", NewHandlers(service).StreamEventsHandler)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/tenants/"+tenantSlug+"/events/stream?event_type="+EventTypeBalanceUpdated, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	createEvent(EventTypeTransactionPosted)
+	pushed := createEvent(EventTypeBalanceUpdated)
+
+	type sseEvent struct{ id, name, data string }
+	received := make(chan sseEvent, 10)
+	go func() {
+		var current sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				current.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				current.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				current.data = strings.TrimPrefix(line, "data: ")
+			case line == "" && current.id != "":
+				received <- current
+				current = sseEvent{}
+			}
+		}
+	}()
+
+	var first sseEvent
+	select {
+	case first = <-received:
+	case <-time.After(10 * time.Second):
+		t.Fatal("no event received from stream")
+	}
+	assert.Equal(t, EventTypeBalanceUpdated, first.name)
+
+	var sent EventResponse
+	require.NoError(t, json.Unmarshal([]byte(first.data), &sent))
+	assert.Equal(t, pushed.EventID.String(), sent.EventID)
+	assert.Equal(t, pushed.SequenceNumber.Int64, sent.SequenceNumber)
+
+	// resuming after the first event replays everything that followed it
+	after := StreamCursor{TxID: before.TxID, Sequence: before.SequenceNumber.Int64}
+	stream, err := service.OpenStream(ctx, tenant.ID, StreamFilter{}, &after)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	resumed, err := stream.Next(ctx)
+	require.NoError(t, err)
+	require.Len(t, resumed, 2)
+	assert.Equal(t, EventTypeTransactionPosted, resumed[0].EventType)
+	assert.Equal(t, pushed.EventID, resumed[1].EventID)
+	assert.Equal(t, first.id, stream.Cursor().String())
+}
+
+func TestIntegration_IdleEventStreamSendsHeartbeats(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tenantSlug := testutil.RandomSlug()
+	tenant := testutil.CreateTestTenant(t, db, tenantSlug)
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, tenantSlug)
+	})
+
+	handlers := NewHandlers(NewService(db))
+	handlers.heartbeatInterval = 50 * time.Millisecond
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := &auth.APIKeyClaims{TenantID: tenant.ID, TenantSlug: tenantSlug}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), auth.APIKeyContextKey, claims)))
+		})
+	})
+	router.Get("/tenants/{tenantSlug}/events/stream", handlers.StreamEventsHandler)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/tenants/"+tenantSlug+"/events/stream", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the tenant has no events, so the heartbeat is the first thing sent
+	lines := make(chan string, 10)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	select {
+	case line := <-lines:
+		assert.Equal(t, ": heartbeat", line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no heartbeat received from idle stream")
+	}
+}
+
+func TestIntegration_ListEventsPagesThroughTenantHistory(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	tenantSlug := testutil.RandomSlug()
+	tenant := testutil.CreateTestTenant(t, db, tenantSlug)
+	otherSlug := testutil.RandomSlug()
+	other := testutil.CreateTestTenant(t, db, otherSlug)
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, tenantSlug)
+		testutil.CleanupTestTenant(t, db, otherSlug)
+	})
+
+	service := NewService(db)
+	account := uuid.New()
+
+	createEvent := func(tenantID, aggregateID uuid.UUID, aggregateType, eventType string) queries.Event {
+		event, err := db.Queries.CreateEvent(ctx, queries.CreateEventParams{
+			TenantID:      tenantID,
+			AggregateID:   aggregateID,
+			AggregateType: aggregateType,
+			EventType:     eventType,
+			EventVersion:  1,
+			EventData:     json.RawMessage("{}"),
+			Metadata:      json.RawMessage("{}"),
+		})
+		require.NoError(t, err)
+		return event
+	}
+
+	var created []queries.Event
+	for i := 0; i < 3; i++ {
+		created = append(created, createEvent(tenant.ID, account, AggregateTypeAccount, EventTypeBalanceUpdated))
+	}
+	posted := createEvent(tenant.ID, uuid.New(), AggregateTypeTransaction, EventTypeTransactionPosted)
+	foreign := createEvent(other.ID, account, AggregateTypeAccount, EventTypeBalanceUpdated)
+
+	// two pages of the account's history, in sequence order
+	first, err := service.ListEvents(ctx, tenant.ID, ListEventsRequest{
+		AggregateType: AggregateTypeAccount,
+		AggregateID:   &account,
+		Limit:         2,
+	})
+	require.NoError(t, err)
+	require.Len(t, first.Events, 2)
+	assert.True(t, first.HasMore)
+	assert.Equal(t, created[0].EventID.String(), first.Events[0].EventID)
+	assert.Equal(t, created[1].EventID.String(), first.Events[1].EventID)
+
+	second, err := service.ListEvents(ctx, tenant.ID, ListEventsRequest{
+		AggregateType: AggregateTypeAccount,
+		AggregateID:   &account,
+		Cursor:        first.NextCursor,
+		Limit:         2,
+	})
+	require.NoError(t, err)
+	require.Len(t, second.Events, 1)
+	assert.False(t, second.HasMore)
+	assert.Empty(t, second.NextCursor)
+	assert.Equal(t, created[2].EventID.String(), second.Events[0].EventID)
+
+	// type and sequence range filters
+	from := created[1].SequenceNumber.Int64
+	ranged, err := service.ListEvents(ctx, tenant.ID, ListEventsRequest{
+		EventType:    EventTypeBalanceUpdated,
+		FromSequence: &from,
+		Limit:        10,
+	})
+	require.NoError(t, err)
+	require.Len(t, ranged.Events, 2)
+	assert.Equal(t, created[1].EventID.String(), ranged.Events[0].EventID)
+
+	// single events are tenant-scoped
+	got, err := service.GetEvent(ctx, tenant.ID, posted.EventID)
+	require.NoError(t, err)
+	assert.Equal(t, EventTypeTransactionPosted, got.EventType)
+
+	_, err = service.GetEvent(ctx, tenant.ID, foreign.EventID)
+	assert.ErrorIs(t, err, ErrEventNotFound)
+}
+
+func TestIntegration_PublishBatchIsAtLeastOncePerSink(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	tenantSlug := testutil.RandomSlug()
+	tenant := testutil.CreateTestTenant(t, db, tenantSlug)
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, tenantSlug)
+	})
+
+	service := NewService(db)
+	publisher := NewMemoryPublisher()
+	consumer := PublisherConsumerPrefix + publisher.Name()
+	require.NoError(t, db.Queries.CreateEventConsumer(ctx, consumer))
+	t.Cleanup(func() {
+		db.Exec(ctx, "DELETE FROM event_consumers WHERE name = $1", consumer)
+	})
+
+	// drain publishes until the sink's cursor reaches the end of the outbox
+	drain := func() error {
+		for {
+			n, err := service.PublishBatch(ctx, publisher, 100)
+			if err != nil || n == 0 {
+				return err
+			}
+		}
+	}
+	require.NoError(t, drain())
+
+	aggregateID := uuid.New()
+	event, err := db.Queries.CreateEvent(ctx, queries.CreateEventParams{
+		TenantID:      tenant.ID,
+		AggregateID:   aggregateID,
+		AggregateType: AggregateTypeAccount,
+		EventType:     EventTypeAccountCreated,
+		EventVersion:  1,
+		EventData:     json.RawMessage("{}"),
+		Metadata:      json.RawMessage("{}"),
+	})
+	require.NoError(t, err)
+
+	published := func() []Message {
+		var messages []Message
+		for _, message := range publisher.Messages() {
+			if message.Headers["ce-id"] == event.EventID.String() {
+				messages = append(messages, message)
+			}
+		}
+		return messages
+	}
+
+	// a failed publish leaves the cursor where it was
+	publisher.Err = assert.AnError
+	assert.ErrorIs(t, drain(), assert.AnError)
+	assert.Empty(t, published())
+
+	publisher.Err = nil
+	require.NoError(t, drain())
+	messages := published()
+	require.Len(t, messages, 1)
+	assert.Equal(t, tenant.ID.String()+"/"+aggregateID.String(), messages[0].Key)
+
+	var ce CloudEvent
+	require.NoError(t, json.Unmarshal(messages[0].Value, &ce))
+	assert.Equal(t, EventTypeAccountCreated, ce.Type)
+	assert.Equal(t, tenant.ID.String(), ce.TenantID)
+
+	// the cursor moved past the event, so it isn't published again
+	require.NoError(t, drain())
+	assert.Len(t, published(), 1)
+}
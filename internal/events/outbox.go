@@ -0,0 +1,130 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// OutboxHandler handles one event for a consumer. Writes made through q commit
+// in the same transaction that moves the consumer's cursor past the event, so
+// each event takes effect exactly once even across restarts and replicas.
+type OutboxHandler func(ctx context.Context, q *queries.Queries, event queries.Event) error
+
+// StartDispatcher tails the events table for consumer, passing every new
+// event to handler. Several replicas may run the same consumer; only one
+// holds its cursor at a time and the others skip their turn.
+func (s *Service) StartDispatcher(ctx context.Context, consumer string, interval time.Duration, batchSize int32, handler OutboxHandler) {
+	s.runConsumer(ctx, consumer, interval, batchSize, func() (int, error) {
+		return s.DispatchBatch(ctx, consumer, batchSize, handler)
+	})
+}
+
+// runConsumer registers consumer and drains it every interval until ctx is
+// done
+func (s *Service) runConsumer(ctx context.Context, consumer string, interval time.Duration, batchSize int32, dispatch func() (int, error)) {
+	log.Printf("Starting %s event dispatcher...", consumer)
+
+	if err := s.db.Queries.CreateEventConsumer(ctx, consumer); err != nil {
+		log.Printf("Error registering %s event consumer: %v", consumer, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		drain(ctx, consumer, batchSize, dispatch)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("%s event dispatcher shutting down...", consumer)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain dispatches batches until the consumer has caught up
+func drain(ctx context.Context, consumer string, batchSize int32, dispatch func() (int, error)) {
+	for ctx.Err() == nil {
+		n, err := dispatch()
+		if err != nil {
+			log.Printf("Error dispatching events to %s: %v", consumer, err)
+			return
+		}
+		if n < int(batchSize) {
+			return
+		}
+	}
+}
+
+// DispatchBatch hands up to batchSize events after the consumer's cursor to
+// handler and advances the cursor, all in one transaction. It returns the
+// number of events handled, which is zero when nothing is pending or another
+// replica currently holds the consumer. If handler fails the whole batch is
+// rolled back and retried on the next call.
+func (s *Service) DispatchBatch(ctx context.Context, consumer string, batchSize int32, handler OutboxHandler) (int, error) {
+	return s.dispatchBatch(ctx, consumer, batchSize, func(ctx context.Context, q *queries.Queries, events []queries.Event) error {
+		for _, event := range events {
+			if err := handler(ctx, q, event); err != nil {
+				return fmt.Errorf("failed to handle event %s: %w", event.EventID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// dispatchBatch is DispatchBatch for a handler that takes the batch at once
+func (s *Service) dispatchBatch(ctx context.Context, consumer string, batchSize int32, handler func(ctx context.Context, q *queries.Queries, events []queries.Event) error) (int, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries.WithTx(tx)
+
+	cursor, err := qtx.LockEventConsumer(ctx, consumer)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to lock event consumer %s: %w", consumer, err)
+	}
+
+	events, err := qtx.GetOutboxEvents(ctx, queries.GetOutboxEventsParams{
+		AfterTxID:     cursor.LastTxID,
+		AfterSequence: cursor.LastSequence,
+		BatchSize:     batchSize,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get events for %s: %w", consumer, err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := handler(ctx, qtx, events); err != nil {
+		return 0, err
+	}
+
+	last := events[len(events)-1]
+	if err := qtx.AdvanceEventConsumer(ctx, queries.AdvanceEventConsumerParams{
+		Name:         consumer,
+		LastTxID:     last.TxID,
+		LastSequence: last.SequenceNumber.Int64,
+	}); err != nil {
+		return 0, fmt.Errorf("failed to advance event consumer %s: %w", consumer, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(events), nil
+}
@@ -0,0 +1,211 @@
+// internal/events/publisher.go
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+const (
+	// PublisherConsumerPrefix prefixes the outbox consumer of each sink, so
+	// every sink keeps its own cursor
+	PublisherConsumerPrefix = "publisher:"
+	PublishBatchSize        = 100
+	PublishInterval         = time.Second
+)
+
+// Message is an event as handed to a broker. Value is the event as a
+// structured CloudEvent. Key is "<tenant_id>/<aggregate_id>", so brokers that
+// partition by key keep each aggregate's events in order.
+type Message struct {
+	Key     string            `json:"key"`
+	Value   json.RawMessage   `json:"value"`
+	Headers map[string]string `json:"headers"`
+}
+
+// Publisher forwards events to an external sink such as a message broker.
+// Delivery is at least once: a batch is published again if the cursor past
+// it fails to commit, so Publish must be safe to repeat and consumers should
+// deduplicate by the CloudEvent id.
+type Publisher interface {
+	// Name identifies the sink; its outbox cursor is kept under this name
+	Name() string
+	// Publish sends a batch of messages in order, returning only once the sink
+	// has accepted all of them
+	Publish(ctx context.Context, messages []Message) error
+	Close() error
+}
+
+// NewPublisher opens the sink a target names: "redis" for a Redis stream on
+// redisURL (or a redis:// URL of its own), "memory", or a file path, with or
+// without file://, that messages are appended to as JSON lines
+func NewPublisher(target, redisURL, redisStream string) (Publisher, error) {
+	switch {
+	case target == "memory":
+		return NewMemoryPublisher(), nil
+	case target == "redis":
+		return NewRedisPublisher(redisURL, redisStream)
+	case strings.HasPrefix(target, "redis://"), strings.HasPrefix(target, "rediss://"):
+		return NewRedisPublisher(target, redisStream)
+	case target == "" || target == "file://":
+		return nil, fmt.Errorf("empty event sink")
+	default:
+		return NewFilePublisher(strings.TrimPrefix(target, "file://"))
+	}
+}
+
+// NewMessage turns an upcast event into a broker message
+func NewMessage(event queries.Event) (Message, error) {
+	value, err := json.Marshal(NewCloudEvent(event))
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to serialize event %s: %w", event.EventID, err)
+	}
+
+	return Message{
+		Key:   event.TenantID.String() + "/" + event.AggregateID.String(),
+		Value: value,
+		Headers: map[string]string{
+			"content-type": CloudEventsContentType,
+			"ce-id":        event.EventID.String(),
+			"ce-type":      event.EventType,
+		},
+	}, nil
+}
+
+// StartPublisher forwards every event to publisher until ctx is done, then
+// closes it. A new sink starts from the beginning of the event history.
+func (s *Service) StartPublisher(ctx context.Context, publisher Publisher) {
+	defer func() {
+		if err := publisher.Close(); err != nil {
+			log.Printf("Error closing %s event sink: %v", publisher.Name(), err)
+		}
+	}()
+
+	consumer := PublisherConsumerPrefix + publisher.Name()
+	s.runConsumer(ctx, consumer, PublishInterval, PublishBatchSize, func() (int, error) {
+		return s.PublishBatch(ctx, publisher, PublishBatchSize)
+	})
+}
+
+// PublishBatch publishes up to batchSize events after the sink's cursor and
+// moves the cursor past them once the sink has accepted them. It returns the
+// number of events published.
+func (s *Service) PublishBatch(ctx context.Context, publisher Publisher, batchSize int32) (int, error) {
+	consumer := PublisherConsumerPrefix + publisher.Name()
+	return s.dispatchBatch(ctx, consumer, batchSize, func(ctx context.Context, q *queries.Queries, events []queries.Event) error {
+		events, err := upcastEvents(events)
+		if err != nil {
+			return err
+		}
+
+		messages := make([]Message, 0, len(events))
+		for _, event := range events {
+			message, err := NewMessage(event)
+			if err != nil {
+				return err
+			}
+			messages = append(messages, message)
+		}
+
+		if err := publisher.Publish(ctx, messages); err != nil {
+			return fmt.Errorf("failed to publish to %s: %w", publisher.Name(), err)
+		}
+		return nil
+	})
+}
+
+// MemoryPublisher keeps published messages in memory, for tests
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+	// Err, when set, fails every Publish
+	Err error
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Name() string {
+	return "memory"
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, messages []Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Err != nil {
+		return p.Err
+	}
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
+// Messages returns everything published so far
+func (p *MemoryPublisher) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message(nil), p.messages...)
+}
+
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
+
+// FilePublisher appends messages to a file as JSON lines, syncing after each
+// batch
+type FilePublisher struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create event sink directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event sink file: %w", err)
+	}
+	return &FilePublisher{path: path, file: file}, nil
+}
+
+func (p *FilePublisher) Name() string {
+	return "file:" + p.path
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, messages []Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	w := bufio.NewWriter(p.file)
+	encoder := json.NewEncoder(w)
+	for _, message := range messages {
+		if err := encoder.Encode(message); err != nil {
+			return fmt.Errorf("failed to write event sink file: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write event sink file: %w", err)
+	}
+	if err := p.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync event sink file: %w", err)
+	}
+	return nil
+}
+
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
@@ -0,0 +1,198 @@
+// internal/events/publisher_test.go
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMessage(t *testing.T) {
+	event := testCloudEventSource()
+
+	message, err := NewMessage(event)
+	require.NoError(t, err)
+	assert.Equal(t, event.TenantID.String()+"/"+event.AggregateID.String(), message.Key)
+	assert.Equal(t, CloudEventsContentType, message.Headers["content-type"])
+	assert.Equal(t, event.EventID.String(), message.Headers["ce-id"])
+
+	var ce CloudEvent
+	require.NoError(t, json.Unmarshal(message.Value, &ce))
+	assert.Equal(t, event.EventID.String(), ce.ID)
+	assert.Equal(t, event.EventType, ce.Type)
+}
+
+func TestNewPublisher(t *testing.T) {
+	dir := t.TempDir()
+
+	memory, err := NewPublisher("memory", "", "")
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryPublisher{}, memory)
+
+	redis, err := NewPublisher("redis", "redis://:secret@cache:6380/2", "ledger:events")
+	require.NoError(t, err)
+	assert.Equal(t, "redis:cache:6380/ledger:events", redis.Name())
+
+	file, err := NewPublisher("file://"+filepath.Join(dir, "events.jsonl"), "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "file:"+filepath.Join(dir, "events.jsonl"), file.Name())
+	require.NoError(t, file.Close())
+
+	_, err = NewPublisher("", "", "")
+	assert.Error(t, err)
+	_, err = NewPublisher("redis", "http://cache", "ledger:events")
+	assert.Error(t, err)
+}
+
+func TestFilePublisherAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sink", "events.jsonl")
+	first := Message{Key: "t/a", Value: json.RawMessage(`{"id":"1"}`), Headers: map[string]string{"ce-id": "1"}}
+	second := Message{Key: "t/b", Value: json.RawMessage(`{"id":"2"}`)}
+
+	for _, message := range []Message{first, second} {
+		publisher, err := NewFilePublisher(path)
+		require.NoError(t, err)
+		require.NoError(t, publisher.Publish(context.Background(), []Message{message}))
+		require.NoError(t, publisher.Close())
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var got Message
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &got))
+	assert.Equal(t, "t/a", got.Key)
+	assert.JSONEq(t, `{"id":"1"}`, string(got.Value))
+	assert.Equal(t, "1", got.Headers["ce-id"])
+}
+
+func TestMemoryPublisher(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	require.NoError(t, publisher.Publish(context.Background(), []Message{{Key: "a"}, {Key: "b"}}))
+
+	publisher.Err = errors.New("broker down")
+	assert.Error(t, publisher.Publish(context.Background(), []Message{{Key: "c"}}))
+	assert.Len(t, publisher.Messages(), 2)
+}
+
+// fakeRedis answers RESP commands on a loopback listener, recording them with
+// the command name upper-cased. Like a server from before RESP3 it refuses
+// HELLO, and it accepts CLIENT without recording it.
+func fakeRedis(t *testing.T, reply func(command []string) string) (string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	commands := make(chan []string, 100)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					command, err := readRESPCommand(r)
+					if err != nil {
+						return
+					}
+					command[0] = strings.ToUpper(command[0])
+					switch command[0] {
+					case "HELLO":
+						io.WriteString(conn, "-ERR unknown command 'HELLO'\r\n")
+					case "CLIENT":
+						io.WriteString(conn, "+OK\r\n")
+					default:
+						commands <- command
+						io.WriteString(conn, reply(command))
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), commands
+}
+
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	command := make([]string, n)
+	for i := range command {
+		var size int
+		if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		command[i] = string(buf[:size])
+	}
+	return command, nil
+}
+
+func TestRedisPublisherAppendsToStream(t *testing.T) {
+	entries := 0
+	addr, commands := fakeRedis(t, func(command []string) string {
+		if command[0] != "XADD" {
+			return "+OK\r\n"
+		}
+		entries++
+		id := "1-" + strconv.Itoa(entries)
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(id), id)
+	})
+
+	publisher, err := NewRedisPublisher("redis://ledger:secret@"+addr+"/3", "ledger:events")
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	require.NoError(t, publisher.Publish(context.Background(), []Message{
+		{Key: "t/a", Value: json.RawMessage(`{"id":"1"}`), Headers: map[string]string{"ce-id": "1"}},
+		{Key: "t/b", Value: json.RawMessage(`{"id":"2"}`)},
+	}))
+
+	assert.Equal(t, []string{"AUTH", "ledger", "secret"}, <-commands)
+	assert.Equal(t, []string{"SELECT", "3"}, <-commands)
+	assert.Equal(t, []string{"XADD", "ledger:events", "*", "key", "t/a", "value", `{"id":"1"}`, "headers", `{"ce-id":"1"}`}, <-commands)
+	assert.Equal(t, "t/b", (<-commands)[4])
+}
+
+func TestRedisPublisherReportsErrorsAndReconnects(t *testing.T) {
+	fail := true
+	addr, commands := fakeRedis(t, func(command []string) string {
+		if fail {
+			return "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+		}
+		return "$3\r\n1-1\r\n"
+	})
+
+	publisher, err := NewRedisPublisher("redis://"+addr, "ledger:events")
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	err = publisher.Publish(context.Background(), []Message{{Key: "t/a", Value: json.RawMessage(`{}`)}})
+	assert.ErrorContains(t, err, "WRONGTYPE")
+	<-commands
+
+	fail = false
+	require.NoError(t, publisher.Publish(context.Background(), []Message{{Key: "t/a", Value: json.RawMessage(`{}`)}}))
+	assert.Equal(t, "XADD", (<-commands)[0])
+}
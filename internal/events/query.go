@@ -0,0 +1,140 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/storage"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 100
+)
+
+// ListEvents returns a page of a tenant's event history. Asking for a
+// creation time range that reaches into archived partitions fails with an
+// *storage.ArchivedRangeError rather than returning a partial history, and a
+// history without a lower bound reports where its archived part ends.
+func (s *Service) ListEvents(ctx context.Context, tenantID uuid.UUID, req ListEventsRequest) (*EventListResponse, error) {
+	params := queries.ListEventsParams{
+		TenantID:   tenantID,
+		MaxResults: int32(req.Limit + 1),
+	}
+	if req.EventType != "" {
+		params.EventType = pgtype.Text{String: req.EventType, Valid: true}
+	}
+	if req.AggregateType != "" {
+		params.AggregateType = pgtype.Text{String: req.AggregateType, Valid: true}
+	}
+	if req.AggregateID != nil {
+		params.AggregateID = pgtype.UUID{Bytes: *req.AggregateID, Valid: true}
+	}
+	if req.AccountID != nil {
+		params.AccountID = pgtype.UUID{Bytes: *req.AccountID, Valid: true}
+	}
+	if req.FromSequence != nil {
+		params.FromSequence = pgtype.Int8{Int64: *req.FromSequence, Valid: true}
+	}
+	if req.ToSequence != nil {
+		params.ToSequence = pgtype.Int8{Int64: *req.ToSequence, Valid: true}
+	}
+	if req.CreatedFrom != nil {
+		params.CreatedFrom = timestamptz(*req.CreatedFrom)
+	}
+	if req.CreatedTo != nil {
+		params.CreatedTo = timestamptz(*req.CreatedTo)
+	}
+	if req.Cursor != "" {
+		after, err := parseListCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		params.AfterSequence = pgtype.Int8{Int64: after, Valid: true}
+	}
+
+	var archivedUntil *time.Time
+	if req.CreatedFrom == nil {
+		var err error
+		if archivedUntil, err = storage.ArchivedUntil(ctx, s.db.Queries, "events"); err != nil {
+			return nil, err
+		}
+	}
+	if req.CreatedFrom != nil || req.CreatedTo != nil {
+		var from time.Time
+		to := time.Now()
+		if req.CreatedFrom != nil {
+			from = *req.CreatedFrom
+		}
+		if req.CreatedTo != nil {
+			to = *req.CreatedTo
+		}
+		if err := storage.CheckArchivedRange(ctx, s.db.Queries, "events", from, to); err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := s.db.Queries.ListEvents(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	if events, err = upcastEvents(events); err != nil {
+		return nil, err
+	}
+
+	response := &EventListResponse{
+		Events:        make([]EventResponse, 0, len(events)),
+		ArchivedUntil: archivedUntil,
+	}
+	if len(events) > req.Limit {
+		events = events[:req.Limit]
+		response.HasMore = true
+	}
+	for _, event := range events {
+		response.Events = append(response.Events, eventToResponse(event))
+	}
+	if response.HasMore {
+		response.NextCursor = strconv.FormatInt(events[len(events)-1].SequenceNumber.Int64, 10)
+	}
+	return response, nil
+}
+
+// GetEvent returns one of a tenant's events
+func (s *Service) GetEvent(ctx context.Context, tenantID, eventID uuid.UUID) (*EventResponse, error) {
+	event, err := s.db.Queries.GetEventByID(ctx, queries.GetEventByIDParams{
+		TenantID: tenantID,
+		EventID:  eventID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEventNotFound
+		}
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	if event, err = Schemas.Upcast(event); err != nil {
+		return nil, fmt.Errorf("failed to upcast event %s: %w", event.EventID, err)
+	}
+
+	response := eventToResponse(event)
+	return &response, nil
+}
+
+// parseListCursor reads a next_cursor returned by ListEvents
+func parseListCursor(cursor string) (int64, error) {
+	after, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+	}
+	return after, nil
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
@@ -0,0 +1,78 @@
+// internal/events/redis.go
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisTimeout bounds a publish when ctx has no deadline of its own
+const redisTimeout = 10 * time.Second
+
+// RedisPublisher appends messages to a Redis stream with XADD, one stream
+// entry per event with the fields key, value and headers
+type RedisPublisher struct {
+	client *redis.Client
+	addr   string
+	stream string
+}
+
+// NewRedisPublisher publishes to stream on the Redis server of a
+// redis://[user:password@]host[:port][/db] URL, or rediss:// for TLS
+func NewRedisPublisher(rawURL, stream string) (*RedisPublisher, error) {
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if stream == "" {
+		return nil, fmt.Errorf("redis stream name is required")
+	}
+
+	// a publish gives up at its context's deadline rather than the client's
+	// own read and write timeouts
+	opts.ContextTimeoutEnabled = true
+
+	return &RedisPublisher{
+		client: redis.NewClient(opts),
+		addr:   opts.Addr,
+		stream: stream,
+	}, nil
+}
+
+func (p *RedisPublisher) Name() string {
+	return "redis:" + p.addr + "/" + p.stream
+}
+
+// Publish pipelines one XADD per message
+func (p *RedisPublisher) Publish(ctx context.Context, messages []Message) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, redisTimeout)
+		defer cancel()
+	}
+
+	pipe := p.client.Pipeline()
+	for _, message := range messages {
+		headers, err := json.Marshal(message.Headers)
+		if err != nil {
+			return fmt.Errorf("failed to serialize message headers: %w", err)
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: p.stream,
+			Values: []any{"key", message.Key, "value", string(message.Value), "headers", string(headers)},
+		})
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis XADD failed: %w", err)
+	}
+	return nil
+}
+
+func (p *RedisPublisher) Close() error {
+	return p.client.Close()
+}
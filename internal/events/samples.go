@@ -0,0 +1,120 @@
+// internal/events/samples.go
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// SampleEvent builds an event of the given type with a realistic payload in
+// the type's current schema, for exercising webhook receivers. It is not
+// stored: every ID in it is fresh, and its metadata records the request
+// that asked for it with source SourceSynthetic.
+func SampleEvent(ctx context.Context, tenantID uuid.UUID, eventType string) (queries.Event, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+	transactionID := uuid.New()
+	cash := AccountSnapshot{
+		ID:          uuid.New().String(),
+		Code:        "1000",
+		Name:        "Cash",
+		AccountType: "asset",
+		Currency:    "USD",
+		IsActive:    true,
+		CreatedAt:   now.AddDate(0, -1, 0),
+		UpdatedAt:   now.AddDate(0, -1, 0),
+	}
+	amount := decimal.RequireFromString("125.50")
+
+	event := queries.Event{
+		EventID:      uuid.New(),
+		TenantID:     tenantID,
+		EventType:    eventType,
+		EventVersion: Schemas.CurrentVersion(eventType),
+		CreatedAt:    now,
+	}
+
+	var payload interface{}
+	switch eventType {
+	case EventTypeTransactionPosted:
+		reference := "INV-1042"
+		revenueID := uuid.New().String()
+		event.AggregateType, event.AggregateID = AggregateTypeTransaction, transactionID
+		payload = TransactionPostedEvent{
+			TransactionID:  transactionID.String(),
+			IdempotencyKey: "sample-" + transactionID.String()[:8],
+			Description:    "Invoice payment",
+			Reference:      &reference,
+			Lines: []TransactionLineEvent{
+				{ID: uuid.New().String(), AccountID: cash.ID, AccountCode: cash.Code, AccountName: cash.Name, Amount: amount, Side: "debit", Currency: "USD"},
+				{ID: uuid.New().String(), AccountID: revenueID, AccountCode: "4000", AccountName: "Sales Revenue", Amount: amount, Side: "credit", Currency: "USD"},
+			},
+			PostedAt:    now,
+			Currency:    "USD",
+			TotalAmount: amount,
+		}
+	case EventTypeBalanceUpdated:
+		accountID := uuid.MustParse(cash.ID)
+		previous := decimal.RequireFromString("1000.00")
+		event.AggregateType, event.AggregateID = AggregateTypeBalance, BalanceAggregateID(accountID, cash.Currency)
+		event.AggregateVersion = pgtype.Int8{Int64: 7, Valid: true}
+		payload = BalanceUpdatedEvent{
+			AccountID:       cash.ID,
+			AccountCode:     cash.Code,
+			AccountName:     cash.Name,
+			Currency:        cash.Currency,
+			PreviousBalance: previous,
+			NewBalance:      previous.Add(amount),
+			BalanceChange:   amount,
+			UpdatedBy:       transactionID.String(),
+			UpdatedAt:       now,
+			Version:         7,
+		}
+	case EventTypeAccountCreated:
+		cash.CreatedAt, cash.UpdatedAt = now, now
+		event.AggregateType, event.AggregateID = AggregateTypeAccount, uuid.MustParse(cash.ID)
+		payload = AccountCreatedEvent{Account: cash}
+	case EventTypeAccountUpdated:
+		after := cash
+		after.Name, after.UpdatedAt = "Operating Cash", now
+		event.AggregateType, event.AggregateID = AggregateTypeAccount, uuid.MustParse(cash.ID)
+		payload = AccountUpdatedEvent{Before: cash, After: after, ChangedFields: []string{"name"}}
+	case EventTypeAccountDeactivated:
+		after := cash
+		after.IsActive, after.UpdatedAt = false, now
+		event.AggregateType, event.AggregateID = AggregateTypeAccount, uuid.MustParse(cash.ID)
+		payload = AccountDeactivatedEvent{Before: cash, After: after}
+	case EventTypeWebhookEndpointDisabled:
+		endpointID := uuid.New()
+		event.AggregateType, event.AggregateID = AggregateTypeWebhookEndpoint, endpointID
+		payload = WebhookEndpointDisabledEvent{
+			EndpointID:          endpointID.String(),
+			URL:                 "https://example.com/webhooks",
+			Reason:              "endpoint failed continuously for 72h0m0s",
+			ConsecutiveFailures: 42,
+			FailingSince:        now.Add(-72 * time.Hour),
+			DisabledAt:          now,
+		}
+	default:
+		return queries.Event{}, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return queries.Event{}, fmt.Errorf("failed to serialize sample %s event: %w", eventType, err)
+	}
+	metadata, err := json.Marshal(newEventMetadata(ctx, SourceSynthetic))
+	if err != nil {
+		return queries.Event{}, fmt.Errorf("failed to serialize event metadata: %w", err)
+	}
+
+	event.EventData = data
+	event.Metadata = metadata
+	return event, nil
+}
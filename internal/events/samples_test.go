@@ -0,0 +1,45 @@
+// internal/events/samples_test.go
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleEventCoversEveryEventType(t *testing.T) {
+	tenantID := uuid.New()
+
+	for _, eventType := range Schemas.EventTypes() {
+		t.Run(eventType, func(t *testing.T) {
+			event, err := SampleEvent(context.Background(), tenantID, eventType)
+			require.NoError(t, err)
+			assert.Equal(t, tenantID, event.TenantID)
+			assert.Equal(t, eventType, event.EventType)
+			assert.Equal(t, Schemas.CurrentVersion(eventType), event.EventVersion)
+			assert.NotEmpty(t, event.AggregateType)
+			assert.NotEqual(t, uuid.Nil, event.AggregateID)
+
+			// the data is exactly the current schema's payload type
+			schema, ok := Schemas.Schema(eventType, event.EventVersion)
+			require.True(t, ok)
+			decoded := reflect.New(reflect.TypeOf(schema.Payload)).Interface()
+			decoder := json.NewDecoder(bytes.NewReader(event.EventData))
+			decoder.DisallowUnknownFields()
+			require.NoError(t, decoder.Decode(decoded))
+
+			var metadata EventMetadata
+			require.NoError(t, json.Unmarshal(event.Metadata, &metadata))
+			assert.Equal(t, SourceSynthetic, metadata.Source)
+		})
+	}
+
+	_, err := SampleEvent(context.Background(), tenantID, "ledger.closed")
+	assert.ErrorIs(t, err, ErrUnknownEventType)
+}
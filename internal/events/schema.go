@@ -0,0 +1,214 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// A payload's schema version is stored with every event as event_version.
+// Stored events keep the version they were written with; readers upcast them
+// to the current shape. Webhook endpoints are pinned to a payload version
+// instead: a release of the registry, like an API version, that fixes the
+// schema version of every event type they receive so changing one event's
+// shape never changes what an existing endpoint is sent.
+
+// Converter rewrites an event payload from one schema version into the next
+// (an upcaster) or the previous one (a downcaster)
+type Converter func(data json.RawMessage) (json.RawMessage, error)
+
+// Schema is one version of an event type's payload
+type Schema struct {
+	EventType string
+	Version   int32
+	// PayloadVersion is the payload version this schema was released in
+	PayloadVersion int32
+	// Payload is a value of the payload's Go type, which its JSON Schema is
+	// derived from
+	Payload interface{}
+	// Upcast turns a payload of the previous version into this one and
+	// Downcast turns this one back; both are required above version 1
+	Upcast   Converter
+	Downcast Converter
+}
+
+// SchemaRegistry holds the schema versions of every event type
+type SchemaRegistry struct {
+	mu      sync.RWMutex
+	schemas map[string][]Schema // by event type, in version order
+	latest  int32
+}
+
+// NewSchemaRegistry returns an empty registry at payload version 1
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		schemas: make(map[string][]Schema),
+		latest:  1,
+	}
+}
+
+// Register adds the next version of an event type's schema. Versions of a
+// type must be registered in order and released in non-decreasing payload
+// versions.
+func (r *SchemaRegistry) Register(schema Schema) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := r.schemas[schema.EventType]
+	if want := int32(len(versions)) + 1; schema.Version != want {
+		return fmt.Errorf("%s schema version %d registered out of order, expected %d", schema.EventType, schema.Version, want)
+	}
+	if schema.PayloadVersion < 1 {
+		return fmt.Errorf("%s schema version %d has no payload version", schema.EventType, schema.Version)
+	}
+	if len(versions) > 0 {
+		if schema.Upcast == nil || schema.Downcast == nil {
+			return fmt.Errorf("%s schema version %d needs an upcaster and a downcaster", schema.EventType, schema.Version)
+		}
+		if previous := versions[len(versions)-1]; schema.PayloadVersion < previous.PayloadVersion {
+			return fmt.Errorf("%s schema version %d released before version %d", schema.EventType, schema.Version, previous.Version)
+		}
+	}
+
+	r.schemas[schema.EventType] = append(versions, schema)
+	r.latest = max(r.latest, schema.PayloadVersion)
+	return nil
+}
+
+// LatestPayloadVersion is the payload version new webhook endpoints are
+// pinned to
+func (r *SchemaRegistry) LatestPayloadVersion() int32 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.latest
+}
+
+// CurrentVersion is the schema version events of a type are written with.
+// Unregistered types are at version 1.
+func (r *SchemaRegistry) CurrentVersion(eventType string) int32 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return max(int32(len(r.schemas[eventType])), 1)
+}
+
+// VersionAt is the schema version of an event type in a payload version
+func (r *SchemaRegistry) VersionAt(eventType string, payloadVersion int32) int32 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	version := int32(1)
+	for _, schema := range r.schemas[eventType] {
+		if schema.PayloadVersion > payloadVersion {
+			break
+		}
+		version = schema.Version
+	}
+	return version
+}
+
+// Schema returns one version of an event type's schema
+func (r *SchemaRegistry) Schema(eventType string, version int32) (Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.schemas[eventType]
+	if version < 1 || int(version) > len(versions) {
+		return Schema{}, false
+	}
+	return versions[version-1], true
+}
+
+// Versions returns every version of an event type's schema, oldest first
+func (r *SchemaRegistry) Versions(eventType string) []Schema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Schema(nil), r.schemas[eventType]...)
+}
+
+// EventTypes lists the registered event types
+func (r *SchemaRegistry) EventTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]string, 0, len(r.schemas))
+	for eventType := range r.schemas {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Convert rewrites a payload of an event type from one schema version into
+// another, one version at a time
+func (r *SchemaRegistry) Convert(eventType string, data json.RawMessage, from, to int32) (json.RawMessage, error) {
+	r.mu.RLock()
+	versions := r.schemas[eventType]
+	r.mu.RUnlock()
+
+	if from == to {
+		return data, nil
+	}
+	if from < 1 || to < 1 || int(max(from, to)) > len(versions) {
+		return nil, fmt.Errorf("%w: %s version %d to %d", ErrUnknownSchemaVersion, eventType, from, to)
+	}
+
+	var err error
+	for version := from; version < to; version++ {
+		if data, err = versions[version].Upcast(data); err != nil {
+			return nil, fmt.Errorf("failed to upcast %s from version %d: %w", eventType, version, err)
+		}
+	}
+	for version := from; version > to; version-- {
+		if data, err = versions[version-1].Downcast(data); err != nil {
+			return nil, fmt.Errorf("failed to downcast %s from version %d: %w", eventType, version, err)
+		}
+	}
+	return data, nil
+}
+
+// Upcast returns the event with its payload in the current schema version
+func (r *SchemaRegistry) Upcast(event queries.Event) (queries.Event, error) {
+	current := r.CurrentVersion(event.EventType)
+	data, err := r.Convert(event.EventType, event.EventData, event.EventVersion, current)
+	if err != nil {
+		return event, err
+	}
+	event.EventData = data
+	event.EventVersion = current
+	return event, nil
+}
+
+// Schemas is the registry of this service's event payloads. A breaking
+// change to a payload registers its next version with the converters to and
+// from the previous one, in a new payload version.
+var Schemas = NewSchemaRegistry()
+
+func init() {
+	for eventType, payload := range map[string]interface{}{
+		EventTypeTransactionPosted:       TransactionPostedEvent{},
+		EventTypeBalanceUpdated:          BalanceUpdatedEvent{},
+		EventTypeAccountCreated:          AccountCreatedEvent{},
+		EventTypeAccountUpdated:          AccountUpdatedEvent{},
+		EventTypeAccountDeactivated:      AccountDeactivatedEvent{},
+		EventTypeWebhookEndpointDisabled: WebhookEndpointDisabledEvent{},
+	} {
+		if err := Schemas.Register(Schema{EventType: eventType, Version: 1, PayloadVersion: 1, Payload: payload}); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// upcastEvents upcasts a page of stored events
+func upcastEvents(events []queries.Event) ([]queries.Event, error) {
+	for i, event := range events {
+		upcast, err := Schemas.Upcast(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upcast event %s: %w", event.EventID, err)
+		}
+		events[i] = upcast
+	}
+	return events, nil
+}
@@ -0,0 +1,95 @@
+// internal/events/schema_test.go
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// testRegistry has an event type whose amount became an object in payload
+// version 2, and another type that is unchanged
+func testRegistry(t *testing.T) *SchemaRegistry {
+	registry := NewSchemaRegistry()
+	require.NoError(t, registry.Register(Schema{EventType: "payment.made", Version: 1, PayloadVersion: 1}))
+	require.NoError(t, registry.Register(Schema{EventType: "refund.made", Version: 1, PayloadVersion: 1}))
+	require.NoError(t, registry.Register(Schema{
+		EventType:      "payment.made",
+		Version:        2,
+		PayloadVersion: 2,
+		Upcast: func(data json.RawMessage) (json.RawMessage, error) {
+			var v1 struct {
+				Amount string `json:"amount"`
+			}
+			if err := json.Unmarshal(data, &v1); err != nil {
+				return nil, err
+			}
+			return json.Marshal(map[string]interface{}{
+				"amount": map[string]string{"value": v1.Amount, "currency": "NGN"},
+			})
+		},
+		Downcast: func(data json.RawMessage) (json.RawMessage, error) {
+			var v2 struct {
+				Amount struct {
+					Value string `json:"value"`
+				} `json:"amount"`
+			}
+			if err := json.Unmarshal(data, &v2); err != nil {
+				return nil, err
+			}
+			return json.Marshal(map[string]string{"amount": v2.Amount.Value})
+		},
+	}))
+	return registry
+}
+
+func TestSchemaRegistryVersions(t *testing.T) {
+	registry := testRegistry(t)
+
+	assert.Equal(t, int32(2), registry.LatestPayloadVersion())
+	assert.Equal(t, int32(2), registry.CurrentVersion("payment.made"))
+	assert.Equal(t, int32(1), registry.CurrentVersion("refund.made"))
+	assert.Equal(t, int32(1), registry.CurrentVersion("unregistered.type"))
+
+	assert.Equal(t, int32(1), registry.VersionAt("payment.made", 1))
+	assert.Equal(t, int32(2), registry.VersionAt("payment.made", 2))
+	assert.Equal(t, int32(1), registry.VersionAt("refund.made", 2))
+	assert.Equal(t, []string{"payment.made", "refund.made"}, registry.EventTypes())
+}
+
+func TestSchemaRegistryRejectsBadRegistrations(t *testing.T) {
+	registry := testRegistry(t)
+	noop := func(data json.RawMessage) (json.RawMessage, error) { return data, nil }
+
+	assert.Error(t, registry.Register(Schema{EventType: "payment.made", Version: 4, PayloadVersion: 3, Upcast: noop, Downcast: noop}), "skips version 3")
+	assert.Error(t, registry.Register(Schema{EventType: "payment.made", Version: 3, PayloadVersion: 3}), "no converters")
+	assert.Error(t, registry.Register(Schema{EventType: "payment.made", Version: 3, PayloadVersion: 1, Upcast: noop, Downcast: noop}), "released before version 2")
+	assert.Error(t, registry.Register(Schema{EventType: "transfer.made", Version: 1}), "no payload version")
+}
+
+func TestSchemaRegistryConverts(t *testing.T) {
+	registry := testRegistry(t)
+
+	stored := queries.Event{EventType: "payment.made", EventVersion: 1, EventData: json.RawMessage(`{"amount":"10.00"}`)}
+	upcast, err := registry.Upcast(stored)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), upcast.EventVersion)
+	assert.JSONEq(t, `{"amount":{"value":"10.00","currency":"NGN"}}`, string(upcast.EventData))
+
+	// an endpoint pinned to payload version 1 keeps getting the old shape
+	downcast, err := registry.Convert("payment.made", upcast.EventData, 2, registry.VersionAt("payment.made", 1))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"10.00"}`, string(downcast))
+
+	// unchanged and unregistered types pass through
+	data := json.RawMessage(`{"anything":true}`)
+	unchanged, err := registry.Upcast(queries.Event{EventType: "unregistered.type", EventVersion: 1, EventData: data})
+	require.NoError(t, err)
+	assert.Equal(t, data, unchanged.EventData)
+
+	_, err = registry.Convert("payment.made", data, 3, 2)
+	assert.ErrorIs(t, err, ErrUnknownSchemaVersion)
+}
@@ -1,21 +1,25 @@
 package events
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"reflect"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/shopspring/decimal"
+	"github.com/temmyjay001/ledger-service/internal/auth"
+	"github.com/temmyjay001/ledger-service/internal/requestctx"
 	"github.com/temmyjay001/ledger-service/internal/storage"
 	"github.com/temmyjay001/ledger-service/internal/storage/queries"
 )
 
 type Service struct {
-	db *storage.DB
+	db            *storage.DB
+	subscriptions subscriptions
 }
 
 func NewService(db *storage.DB) *Service {
@@ -86,10 +90,7 @@ func (s *Service) PublishTransactionPosted(
 	}
 
 	// create metadata
-	metadata := EventMetadata{
-		Source: "api", // TODO: Extract from context
-	}
-	metadataBytes, err := json.Marshal(metadata)
+	metadataBytes, err := json.Marshal(newEventMetadata(ctx, SourceAPI))
 	if err != nil {
 		return fmt.Errorf("failed to serialize event metadata: %w", err)
 	}
@@ -99,7 +100,7 @@ func (s *Service) PublishTransactionPosted(
 		AggregateID:   transaction.ID,
 		AggregateType: AggregateTypeTransaction,
 		EventType:     EventTypeTransactionPosted,
-		EventVersion:  1,
+		EventVersion:  Schemas.CurrentVersion(EventTypeTransactionPosted),
 		EventData:     eventData,
 		Metadata:      metadataBytes,
 	})
@@ -108,12 +109,15 @@ func (s *Service) PublishTransactionPosted(
 		return fmt.Errorf("failed to create transaction posted event: %w", err)
 	}
 
-	log.Printf("Published transaction.posted event for transaction %s", transaction.ID)
+	requestctx.Logf(ctx, "Published transaction.posted event for transaction %s", transaction.ID)
 
 	return nil
 }
 
-// PublishBalanceUpdated published a balance.updated event
+// PublishBalanceUpdated publishes a balance.updated event. Each balance is
+// an aggregate of its own, identified by BalanceAggregateID, and version is
+// the balance's version after the update; a version of 0 publishes the event
+// without an aggregate version.
 func (s *Service) PublishBalanceUpdated(
 	ctx context.Context,
 	qtx *queries.Queries,
@@ -148,30 +152,133 @@ func (s *Service) PublishBalanceUpdated(
 	}
 
 	// Create metadata
-	metadata := EventMetadata{
-		Source: "api", // TODO: Extract from context
-	}
-	metadataBytes, err := json.Marshal(metadata)
+	metadataBytes, err := json.Marshal(newEventMetadata(ctx, SourceAPI))
 	if err != nil {
 		return fmt.Errorf("failed to serialize event metadata: %w", err)
 	}
 
 	// Create event record
+	_, err = qtx.CreateEvent(ctx, queries.CreateEventParams{
+		TenantID:         tenantID,
+		AggregateID:      BalanceAggregateID(account.ID, currency),
+		AggregateType:    AggregateTypeBalance,
+		EventType:        EventTypeBalanceUpdated,
+		EventVersion:     Schemas.CurrentVersion(EventTypeBalanceUpdated),
+		EventData:        eventData,
+		Metadata:         metadataBytes,
+		AggregateVersion: pgtype.Int8{Int64: version, Valid: version > 0},
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create balance updated event: %w", err)
+	}
+
+	requestctx.Logf(ctx, "Published balance.updated event for account %s (%s)", account.Code, account.ID)
+
+	return nil
+}
+
+// PublishAccountCreated publishes an account.created event
+func (s *Service) PublishAccountCreated(ctx context.Context, qtx *queries.Queries, tenantID uuid.UUID, account queries.Account) error {
+	return s.publishAccountEvent(ctx, qtx, tenantID, account.ID, EventTypeAccountCreated, AccountCreatedEvent{
+		Account: accountSnapshot(account),
+	})
+}
+
+// PublishAccountUpdated publishes an account.updated event. Updates that
+// change nothing publish nothing.
+func (s *Service) PublishAccountUpdated(ctx context.Context, qtx *queries.Queries, tenantID uuid.UUID, before, after queries.Account) error {
+	eventPayload := AccountUpdatedEvent{
+		Before: accountSnapshot(before),
+		After:  accountSnapshot(after),
+	}
+	eventPayload.ChangedFields = changedAccountFields(eventPayload.Before, eventPayload.After)
+	if len(eventPayload.ChangedFields) == 0 {
+		return nil
+	}
+
+	return s.publishAccountEvent(ctx, qtx, tenantID, after.ID, EventTypeAccountUpdated, eventPayload)
+}
+
+// PublishAccountDeactivated publishes an account.deactivated event
+func (s *Service) PublishAccountDeactivated(ctx context.Context, qtx *queries.Queries, tenantID uuid.UUID, before, after queries.Account) error {
+	return s.publishAccountEvent(ctx, qtx, tenantID, after.ID, EventTypeAccountDeactivated, AccountDeactivatedEvent{
+		Before: accountSnapshot(before),
+		After:  accountSnapshot(after),
+	})
+}
+
+func (s *Service) publishAccountEvent(ctx context.Context, qtx *queries.Queries, tenantID, accountID uuid.UUID, eventType string, eventPayload interface{}) error {
+	eventData, err := json.Marshal(eventPayload)
+	if err != nil {
+		return fmt.Errorf("failed to serialize account event: %w", err)
+	}
+
+	metadataBytes, err := json.Marshal(newEventMetadata(ctx, SourceAPI))
+	if err != nil {
+		return fmt.Errorf("failed to serialize event metadata: %w", err)
+	}
+
 	_, err = qtx.CreateEvent(ctx, queries.CreateEventParams{
 		TenantID:      tenantID,
-		AggregateID:   account.ID,
+		AggregateID:   accountID,
 		AggregateType: AggregateTypeAccount,
-		EventType:     EventTypeBalanceUpdated,
-		EventVersion:  1, // Could be incremented for multiple balance updates
+		EventType:     eventType,
+		EventVersion:  Schemas.CurrentVersion(eventType),
 		EventData:     eventData,
 		Metadata:      metadataBytes,
 	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s event: %w", eventType, err)
+	}
+
+	requestctx.Logf(ctx, "Published %s event for account %s", eventType, accountID)
+
+	return nil
+}
+
+// PublishWebhookEndpointDisabled publishes a webhook_endpoint.disabled event
+func (s *Service) PublishWebhookEndpointDisabled(
+	ctx context.Context,
+	qtx *queries.Queries,
+	endpoint queries.WebhookEndpoint,
+	reason string,
+	consecutiveFailures int32,
+	failingSince time.Time,
+) error {
+	eventPayload := WebhookEndpointDisabledEvent{
+		EndpointID:          endpoint.ID.String(),
+		URL:                 endpoint.Url,
+		Reason:              reason,
+		ConsecutiveFailures: consecutiveFailures,
+		FailingSince:        failingSince.UTC(),
+		DisabledAt:          endpoint.DisabledAt.Time.UTC(),
+	}
 
+	eventData, err := json.Marshal(eventPayload)
 	if err != nil {
-		return fmt.Errorf("failed to create balance updated event: %w", err)
+		return fmt.Errorf("failed to serialize webhook endpoint event: %w", err)
 	}
 
-	log.Printf("Published balance.updated event for account %s (%s)", account.Code, account.ID)
+	metadataBytes, err := json.Marshal(newEventMetadata(ctx, SourceSystem))
+	if err != nil {
+		return fmt.Errorf("failed to serialize event metadata: %w", err)
+	}
+
+	_, err = qtx.CreateEvent(ctx, queries.CreateEventParams{
+		TenantID:      endpoint.TenantID,
+		AggregateID:   endpoint.ID,
+		AggregateType: AggregateTypeWebhookEndpoint,
+		EventType:     EventTypeWebhookEndpointDisabled,
+		EventVersion:  Schemas.CurrentVersion(EventTypeWebhookEndpointDisabled),
+		EventData:     eventData,
+		Metadata:      metadataBytes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create webhook endpoint disabled event: %w", err)
+	}
+
+	requestctx.Logf(ctx, "Published webhook_endpoint.disabled event for endpoint %s", endpoint.ID)
 
 	return nil
 }
@@ -184,6 +291,26 @@ func (s *Service) GetEventsByAggregate(ctx context.Context, tenantID uuid.UUID,
 	})
 }
 
+// VerifyAggregateVersions checks that the versioned events of one aggregate,
+// in version order as GetEventsByAggregate returns them, number up without
+// gaps or duplicates. Numbering may start above 1 for aggregates that existed
+// before their events were versioned. Unversioned events are skipped, so the
+// balance.updated events of high-volume slot postings are not checked.
+func VerifyAggregateVersions(events []queries.Event) error {
+	var previous int64
+	for _, event := range events {
+		if !event.AggregateVersion.Valid {
+			continue
+		}
+		version := event.AggregateVersion.Int64
+		if previous != 0 && version != previous+1 {
+			return fmt.Errorf("%w: aggregate %s version %d follows version %d", ErrAggregateVersionGap, event.AggregateID, version, previous)
+		}
+		previous = version
+	}
+	return nil
+}
+
 // GetEventsByType retrieves events by type with pagination
 func (s *Service) GetEventsByType(ctx context.Context, tenantID uuid.UUID, eventType string, limit int32, offset int32) ([]queries.Event, error) {
 	return s.db.Queries.GetEventsByType(ctx, queries.GetEventsByTypeParams{
@@ -194,10 +321,116 @@ func (s *Service) GetEventsByType(ctx context.Context, tenantID uuid.UUID, event
 	})
 }
 
-// GetEventStream retrieves events after a specific sequence number
-func (s *Service) GetEventStream(ctx context.Context, afterSequence int64, limit int32) ([]queries.Event, error) {
-	return s.db.Queries.GetEventsAfterSequence(ctx, queries.GetEventsAfterSequenceParams{
-		SequenceNumber: pgtype.Int8{Int64: afterSequence, Valid: true},
-		Limit:          limit,
-	})
+// newEventMetadata records what caused an event. Events published while
+// serving a request carry its request and correlation IDs, client IP and the
+// API key or user it was authenticated with.
+func newEventMetadata(ctx context.Context, source string) EventMetadata {
+	metadata := EventMetadata{Source: source}
+
+	meta, ok := requestctx.FromContext(ctx)
+	if !ok {
+		return metadata
+	}
+	metadata.RequestID = optionalString(meta.RequestID)
+	metadata.CorrelationID = optionalString(meta.CorrelationID)
+	metadata.SourceIP = optionalString(meta.SourceIP)
+
+	if claims, ok := auth.GetAPIKeyClaims(ctx); ok {
+		metadata.APIKeyID = optionalString(claims.KeyID.String())
+	}
+	if claims, ok := auth.GetUserClaims(ctx); ok {
+		metadata.UserID = optionalString(claims.UserID.String())
+	}
+	return metadata
+}
+
+// RequestMetadata returns the request an event was published for, if any,
+// so work it causes later can be traced back to it
+func RequestMetadata(event queries.Event) (requestctx.Metadata, bool) {
+	var metadata EventMetadata
+	if err := json.Unmarshal(event.Metadata, &metadata); err != nil || metadata.RequestID == nil {
+		return requestctx.Metadata{}, false
+	}
+
+	meta := requestctx.Metadata{RequestID: *metadata.RequestID}
+	if metadata.CorrelationID != nil {
+		meta.CorrelationID = *metadata.CorrelationID
+	}
+	if metadata.SourceIP != nil {
+		meta.SourceIP = *metadata.SourceIP
+	}
+	return meta, true
+}
+
+func accountSnapshot(account queries.Account) AccountSnapshot {
+	snapshot := AccountSnapshot{
+		ID:          account.ID.String(),
+		Code:        account.Code,
+		Name:        account.Name,
+		AccountType: string(account.AccountType),
+		Currency:    account.Currency,
+		Metadata:    account.Metadata,
+		IsActive:    account.IsActive,
+		CreatedAt:   account.CreatedAt,
+		UpdatedAt:   account.UpdatedAt,
+	}
+	if account.ParentID != nil {
+		snapshot.ParentID = optionalString(account.ParentID.String())
+	}
+	return snapshot
+}
+
+// changedAccountFields names the fields that differ between two snapshots of
+// an account, ignoring updated_at
+func changedAccountFields(before, after AccountSnapshot) []string {
+	var changed []string
+	if before.Code != after.Code {
+		changed = append(changed, "code")
+	}
+	if before.Name != after.Name {
+		changed = append(changed, "name")
+	}
+	if before.AccountType != after.AccountType {
+		changed = append(changed, "account_type")
+	}
+	if !equalOptionalStrings(before.ParentID, after.ParentID) {
+		changed = append(changed, "parent_id")
+	}
+	if before.Currency != after.Currency {
+		changed = append(changed, "currency")
+	}
+	if !equalJSON(before.Metadata, after.Metadata) {
+		changed = append(changed, "metadata")
+	}
+	if before.IsActive != after.IsActive {
+		changed = append(changed, "is_active")
+	}
+	return changed
+}
+
+func equalOptionalStrings(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// equalJSON compares two JSON documents by value, so key order and
+// whitespace do not count as changes
+func equalJSON(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
 }
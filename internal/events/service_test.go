@@ -0,0 +1,113 @@
+// internal/events/service_test.go
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/auth"
+	"github.com/temmyjay001/ledger-service/internal/requestctx"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+func TestNewEventMetadataCarriesTheRequest(t *testing.T) {
+	keyID := uuid.New()
+	ctx := requestctx.WithMetadata(context.Background(), requestctx.Metadata{
+		RequestID:     "host/abc-000001",
+		CorrelationID: "support-ticket-4711",
+		SourceIP:      "203.0.113.7",
+	})
+	ctx = context.WithValue(ctx, auth.APIKeyContextKey, &auth.APIKeyClaims{KeyID: keyID})
+
+	metadata := newEventMetadata(ctx, SourceAPI)
+	assert.Equal(t, SourceAPI, metadata.Source)
+	require.NotNil(t, metadata.APIKeyID)
+	assert.Equal(t, keyID.String(), *metadata.APIKeyID)
+	assert.Nil(t, metadata.UserID)
+
+	raw, err := json.Marshal(metadata)
+	require.NoError(t, err)
+
+	meta, ok := RequestMetadata(queries.Event{Metadata: raw})
+	require.True(t, ok)
+	assert.Equal(t, "host/abc-000001", meta.RequestID)
+	assert.Equal(t, "support-ticket-4711", meta.CorrelationID)
+	assert.Equal(t, "203.0.113.7", meta.SourceIP)
+}
+
+func TestEventsOutsideRequestsHaveNoRequestMetadata(t *testing.T) {
+	metadata := newEventMetadata(context.Background(), SourceSystem)
+	assert.Equal(t, EventMetadata{Source: SourceSystem}, metadata)
+
+	raw, err := json.Marshal(metadata)
+	require.NoError(t, err)
+
+	_, ok := RequestMetadata(queries.Event{Metadata: raw})
+	assert.False(t, ok)
+}
+
+func TestChangedAccountFields(t *testing.T) {
+	parentID := uuid.New()
+	before := accountSnapshot(queries.Account{
+		ID:          uuid.New(),
+		Code:        "1000",
+		Name:        "Cash",
+		AccountType: queries.AccountTypeEnumAsset,
+		ParentID:    &parentID,
+		Currency:    "NGN",
+		Metadata:    json.RawMessage(`{"a": 1, "b": 2}`),
+		IsActive:    true,
+	})
+	require.NotNil(t, before.ParentID)
+	assert.Equal(t, parentID.String(), *before.ParentID)
+
+	after := before
+	after.Metadata = json.RawMessage(`{"b":2,"a":1}`)
+	after.UpdatedAt = before.UpdatedAt.Add(time.Second)
+	assert.Empty(t, changedAccountFields(before, after), "reordered metadata and updated_at are not changes")
+
+	after.Name = "Cash at bank"
+	after.Metadata = json.RawMessage(`{"a": 1}`)
+	after.ParentID = nil
+	after.IsActive = false
+	assert.Equal(t, []string{"name", "parent_id", "metadata", "is_active"}, changedAccountFields(before, after))
+}
+
+func TestBalanceAggregateID(t *testing.T) {
+	account := uuid.New()
+
+	ngn := BalanceAggregateID(account, "NGN")
+	assert.Equal(t, ngn, BalanceAggregateID(account, "NGN"))
+	assert.Equal(t, uuid.Version(5), ngn.Version())
+	assert.NotEqual(t, ngn, BalanceAggregateID(account, "USD"))
+	assert.NotEqual(t, ngn, BalanceAggregateID(uuid.New(), "NGN"))
+}
+
+func TestVerifyAggregateVersions(t *testing.T) {
+	aggregateID := uuid.New()
+	history := func(versions ...int64) []queries.Event {
+		events := make([]queries.Event, len(versions))
+		for i, version := range versions {
+			events[i] = queries.Event{
+				AggregateID:      aggregateID,
+				AggregateVersion: pgtype.Int8{Int64: version, Valid: version > 0},
+			}
+		}
+		return events
+	}
+
+	assert.NoError(t, VerifyAggregateVersions(nil))
+	assert.NoError(t, VerifyAggregateVersions(history(1, 2, 3)))
+	// unversioned events from before versioning, then numbering from the
+	// balance's version at the time
+	assert.NoError(t, VerifyAggregateVersions(history(0, 0, 7, 8)))
+
+	assert.ErrorIs(t, VerifyAggregateVersions(history(1, 3)), ErrAggregateVersionGap)
+	assert.ErrorIs(t, VerifyAggregateVersions(history(1, 2, 2)), ErrAggregateVersionGap)
+}
@@ -0,0 +1,281 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+const (
+	// EventChannel is notified with the tenant ID whenever events are created
+	EventChannel     = "events"
+	listenRetryDelay = 5 * time.Second
+
+	StreamBatchSize = 100
+	// StreamPollInterval is how often a stream re-reads without being
+	// notified. Events of a transaction only become readable once every
+	// older transaction has finished, which may be after their notification.
+	StreamPollInterval      = 5 * time.Second
+	StreamHeartbeatInterval = 15 * time.Second
+)
+
+// Formats events are streamed in
+const (
+	StreamFormatLedger      = "ledger"
+	StreamFormatCloudEvents = "cloudevents"
+)
+
+// StreamCursor is a position in the outbox order events are streamed in.
+// Sequence numbers alone are not enough: they are taken when an event is
+// written, not when its transaction commits, so a later commit can carry a
+// lower one.
+type StreamCursor struct {
+	TxID     uint64
+	Sequence int64
+}
+
+// String formats the cursor as an SSE event ID
+func (c StreamCursor) String() string {
+	return fmt.Sprintf("%d-%d", c.TxID, c.Sequence)
+}
+
+// ParseStreamCursor parses an event ID written by StreamCursor.String
+func ParseStreamCursor(id string) (StreamCursor, error) {
+	txID, sequence, ok := strings.Cut(id, "-")
+	if !ok {
+		return StreamCursor{}, fmt.Errorf("%w: %q", ErrInvalidCursor, id)
+	}
+
+	var (
+		cursor StreamCursor
+		err    error
+	)
+	if cursor.TxID, err = strconv.ParseUint(txID, 10, 64); err != nil {
+		return StreamCursor{}, fmt.Errorf("%w: %q", ErrInvalidCursor, id)
+	}
+	if cursor.Sequence, err = strconv.ParseInt(sequence, 10, 64); err != nil {
+		return StreamCursor{}, fmt.Errorf("%w: %q", ErrInvalidCursor, id)
+	}
+	return cursor, nil
+}
+
+// StreamFilter narrows a stream to some event types and/or one aggregate;
+// zero values match everything
+type StreamFilter struct {
+	EventTypes  []string
+	AggregateID pgtype.UUID
+}
+
+// Stream reads a tenant's events in commit order, from a cursor onwards
+type Stream struct {
+	service     *Service
+	tenantID    uuid.UUID
+	filter      StreamFilter
+	cursor      StreamCursor
+	wake        chan struct{}
+	unsubscribe func()
+}
+
+// OpenStream starts a stream of a tenant's events after the given cursor, or
+// of events created from now on when after is nil. The stream must be closed.
+func (s *Service) OpenStream(ctx context.Context, tenantID uuid.UUID, filter StreamFilter, after *StreamCursor) (*Stream, error) {
+	var cursor StreamCursor
+	if after != nil {
+		cursor = *after
+	} else {
+		// everything before the head is committed already, so the stream
+		// starts after its last possible event
+		head, err := s.db.Queries.GetEventStreamHead(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get event stream head: %w", err)
+		}
+		cursor = StreamCursor{TxID: head - 1, Sequence: math.MaxInt64}
+	}
+
+	wake, unsubscribe := s.subscribe(tenantID)
+	return &Stream{
+		service:     s,
+		tenantID:    tenantID,
+		filter:      filter,
+		cursor:      cursor,
+		wake:        wake,
+		unsubscribe: unsubscribe,
+	}, nil
+}
+
+// Cursor is the position after the last event read
+func (st *Stream) Cursor() StreamCursor {
+	return st.cursor
+}
+
+// Wake signals when the tenant may have new events. It is closed when the
+// server stops listening for them.
+func (st *Stream) Wake() <-chan struct{} {
+	return st.wake
+}
+
+// Next reads up to StreamBatchSize events after the cursor and moves the
+// cursor past them
+func (st *Stream) Next(ctx context.Context) ([]queries.Event, error) {
+	events, err := st.service.db.Queries.GetTenantEventsAfterCursor(ctx, queries.GetTenantEventsAfterCursorParams{
+		TenantID:      st.tenantID,
+		AfterTxID:     st.cursor.TxID,
+		AfterSequence: st.cursor.Sequence,
+		EventTypes:    st.filter.EventTypes,
+		AggregateID:   st.filter.AggregateID,
+		BatchSize:     StreamBatchSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read event stream: %w", err)
+	}
+
+	if len(events) > 0 {
+		last := events[len(events)-1]
+		st.cursor = StreamCursor{TxID: last.TxID, Sequence: last.SequenceNumber.Int64}
+	}
+	return upcastEvents(events)
+}
+
+// Close stops the stream's notifications
+func (st *Stream) Close() {
+	st.unsubscribe()
+}
+
+// subscriptions fans event notifications out to the open streams of each
+// tenant
+type subscriptions struct {
+	mu      sync.Mutex
+	tenants map[uuid.UUID]map[chan struct{}]bool
+	closed  bool
+}
+
+func (s *Service) subscribe(tenantID uuid.UUID) (chan struct{}, func()) {
+	subs := &s.subscriptions
+	ch := make(chan struct{}, 1)
+
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+
+	if subs.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if subs.tenants == nil {
+		subs.tenants = make(map[uuid.UUID]map[chan struct{}]bool)
+	}
+	if subs.tenants[tenantID] == nil {
+		subs.tenants[tenantID] = make(map[chan struct{}]bool)
+	}
+	subs.tenants[tenantID][ch] = true
+
+	return ch, func() {
+		subs.mu.Lock()
+		defer subs.mu.Unlock()
+
+		if subs.tenants[tenantID][ch] {
+			delete(subs.tenants[tenantID], ch)
+			if len(subs.tenants[tenantID]) == 0 {
+				delete(subs.tenants, tenantID)
+			}
+		}
+	}
+}
+
+// notify wakes the streams of one tenant, or of every tenant when tenantID
+// is nil. Pending wake-ups collapse into one.
+func (s *Service) notify(tenantID *uuid.UUID) {
+	subs := &s.subscriptions
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+
+	for id, chans := range subs.tenants {
+		if tenantID != nil && id != *tenantID {
+			continue
+		}
+		for ch := range chans {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// closeSubscriptions ends every open stream; later streams only poll
+func (s *Service) closeSubscriptions() {
+	subs := &s.subscriptions
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+
+	for _, chans := range subs.tenants {
+		for ch := range chans {
+			close(ch)
+		}
+	}
+	subs.tenants = nil
+	subs.closed = true
+}
+
+// StartEventListener wakes open event streams on every event notification,
+// reconnecting whenever the listening connection is lost. Streams are ended
+// when ctx is done.
+func (s *Service) StartEventListener(ctx context.Context) {
+	log.Println("Starting event stream listener...")
+	defer s.closeSubscriptions()
+
+	for ctx.Err() == nil {
+		err := s.listen(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		log.Printf("Event stream listener stopped, retrying in %s: %v", listenRetryDelay, err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(listenRetryDelay):
+		}
+	}
+
+	log.Println("Event stream listener shutting down...")
+}
+
+func (s *Service) listen(ctx context.Context) error {
+	pooled, err := s.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	// the listening session is taken out of the pool and closed when done, so
+	// no request is ever handed a connection that is still subscribed
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+EventChannel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", EventChannel, err)
+	}
+
+	// pick up anything created while nobody was listening
+	s.notify(nil)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		tenantID, err := uuid.Parse(notification.Payload)
+		if err != nil {
+			s.notify(nil)
+			continue
+		}
+		s.notify(&tenantID)
+	}
+}
@@ -0,0 +1,90 @@
+// internal/events/stream_test.go
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+func TestStreamCursorRoundTrip(t *testing.T) {
+	cursor := StreamCursor{TxID: 1234, Sequence: 56}
+	assert.Equal(t, "1234-56", cursor.String())
+
+	parsed, err := ParseStreamCursor(cursor.String())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, parsed)
+}
+
+func TestParseStreamCursorRejectsMalformedIDs(t *testing.T) {
+	for _, id := range []string{"", "42", "a-1", "1-b", "-1-2", "1-2-3"} {
+		_, err := ParseStreamCursor(id)
+		assert.ErrorIs(t, err, ErrInvalidCursor, id)
+	}
+}
+
+func TestWriteStreamEvent(t *testing.T) {
+	event := queries.Event{
+		EventID:        uuid.New(),
+		AggregateID:    uuid.New(),
+		AggregateType:  AggregateTypeAccount,
+		EventType:      EventTypeBalanceUpdated,
+		EventVersion:   1,
+		EventData:      json.RawMessage(`{"new_balance": "10.00"}`),
+		Metadata:       json.RawMessage(`{"source": "api"}`),
+		CreatedAt:      time.Now().UTC(),
+		SequenceNumber: pgtype.Int8{Int64: 7, Valid: true},
+		TxID:           900,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeStreamEvent(&buf, event, StreamFormatLedger))
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n\n")), []byte("\n"))
+	require.Len(t, lines, 3)
+	assert.Equal(t, "id: 900-7", string(lines[0]))
+	assert.Equal(t, "event: balance.updated", string(lines[1]))
+
+	var sent EventResponse
+	require.NoError(t, json.Unmarshal(bytes.TrimPrefix(lines[2], []byte("data: ")), &sent))
+	assert.Equal(t, event.EventID.String(), sent.EventID)
+	assert.Equal(t, int64(7), sent.SequenceNumber)
+	assert.JSONEq(t, `{"new_balance": "10.00"}`, string(sent.Data))
+}
+
+func TestSubscriptionsWakeOnlyTheirTenant(t *testing.T) {
+	s := &Service{}
+	tenantA, tenantB := uuid.New(), uuid.New()
+
+	wakeA, unsubscribeA := s.subscribe(tenantA)
+	wakeB, unsubscribeB := s.subscribe(tenantB)
+	defer unsubscribeB()
+
+	// repeated notifications collapse into one wake-up
+	s.notify(&tenantA)
+	s.notify(&tenantA)
+	assert.Len(t, wakeA, 1)
+	assert.Len(t, wakeB, 0)
+
+	<-wakeA
+	s.notify(nil)
+	assert.Len(t, wakeA, 1)
+	assert.Len(t, wakeB, 1)
+
+	unsubscribeA()
+	s.closeSubscriptions()
+	<-wakeB // the pending wake-up is still delivered
+	_, ok := <-wakeB
+	assert.False(t, ok, "closing subscriptions should end open streams")
+
+	late, _ := s.subscribe(tenantA)
+	_, ok = <-late
+	assert.False(t, ok, "streams opened after closing should not wait for notifications")
+}
@@ -3,8 +3,10 @@ package events
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
@@ -50,7 +52,115 @@ type BalanceUpdatedEvent struct {
 	BalanceChange   decimal.Decimal `json:"balance_change"`
 	UpdatedBy       string          `json:"updated_by"` // transaction_id that caused the change
 	UpdatedAt       time.Time       `json:"updated_at"`
-	Version         int64           `json:"version"`
+	// Version is the balance's aggregate version, or 0 for a posting to a
+	// high-volume balance slot, which isn't versioned
+	Version int64 `json:"version"`
+}
+
+// AccountSnapshot is the state of an account at one point in time
+type AccountSnapshot struct {
+	ID          string          `json:"id"`
+	Code        string          `json:"code"`
+	Name        string          `json:"name"`
+	AccountType string          `json:"account_type"`
+	ParentID    *string         `json:"parent_id,omitempty"`
+	Currency    string          `json:"currency"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+	IsActive    bool            `json:"is_active"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// AccountCreatedEvent represents a new account in the chart of accounts
+type AccountCreatedEvent struct {
+	Account AccountSnapshot `json:"account"`
+}
+
+// AccountUpdatedEvent represents a change to an account, with the fields
+// that changed between the two snapshots
+type AccountUpdatedEvent struct {
+	Before        AccountSnapshot `json:"before"`
+	After         AccountSnapshot `json:"after"`
+	ChangedFields []string        `json:"changed_fields"`
+}
+
+// AccountDeactivatedEvent represents an account that was soft deleted
+type AccountDeactivatedEvent struct {
+	Before AccountSnapshot `json:"before"`
+	After  AccountSnapshot `json:"after"`
+}
+
+// WebhookEndpointDisabledEvent reports a webhook endpoint that was turned
+// off automatically after failing for too long
+type WebhookEndpointDisabledEvent struct {
+	EndpointID          string    `json:"endpoint_id"`
+	URL                 string    `json:"url"`
+	Reason              string    `json:"reason"`
+	ConsecutiveFailures int32     `json:"consecutive_failures"`
+	FailingSince        time.Time `json:"failing_since"`
+	DisabledAt          time.Time `json:"disabled_at"`
+}
+
+// EventResponse is an event as returned by the event API and sent on event
+// streams
+type EventResponse struct {
+	EventID          string          `json:"event_id"`
+	AggregateID      string          `json:"aggregate_id"`
+	AggregateType    string          `json:"aggregate_type"`
+	AggregateVersion int64           `json:"aggregate_version,omitempty"`
+	EventType        string          `json:"event_type"`
+	EventVersion     int32           `json:"event_version"`
+	SequenceNumber   int64           `json:"sequence_number"`
+	Data             json.RawMessage `json:"data"`
+	Metadata         json.RawMessage `json:"metadata,omitempty"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+// ListEventsRequest filters a tenant's event history. Events are returned
+// in sequence order; Cursor continues after the last event of a page.
+type ListEventsRequest struct {
+	EventType     string
+	AggregateType string
+	AggregateID   *uuid.UUID
+	// AccountID matches the events of an account and of its balances
+	AccountID    *uuid.UUID
+	FromSequence *int64
+	ToSequence   *int64
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	Cursor       string
+	Limit        int
+}
+
+// EventListResponse is a page of events
+type EventListResponse struct {
+	Events     []EventResponse `json:"events"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	HasMore    bool            `json:"has_more"`
+	// ArchivedUntil is set on listings without a created_from bound once
+	// months have been archived: events created before it are not included
+	ArchivedUntil *time.Time `json:"archived_until,omitempty"`
+}
+
+// EventSchemaListResponse lists the payload schemas of every event type
+type EventSchemaListResponse struct {
+	LatestPayloadVersion int32                 `json:"latest_payload_version"`
+	EventTypes           []EventSchemaResponse `json:"event_types"`
+}
+
+// EventSchemaResponse lists the schema versions of an event type
+type EventSchemaResponse struct {
+	EventType      string               `json:"event_type"`
+	CurrentVersion int32                `json:"current_version"`
+	Versions       []EventSchemaVersion `json:"versions"`
+}
+
+// EventSchemaVersion is one schema version of an event type and where its
+// JSON Schema document is published
+type EventSchemaVersion struct {
+	Version        int32  `json:"version"`
+	PayloadVersion int32  `json:"payload_version"`
+	SchemaURL      string `json:"schema_url"`
 }
 
 // EventMetadata contains contextual information about the event
@@ -58,16 +168,28 @@ type EventMetadata struct {
 	UserID        *string `json:"user_id,omitempty"`
 	APIKeyID      *string `json:"api_key_id,omitempty"`
 	SourceIP      *string `json:"source_ip,omitempty"`
+	RequestID     *string `json:"request_id,omitempty"`
 	CorrelationID *string `json:"correlation_id,omitempty"`
 	Source        string  `json:"source"` // "api", "batch", "system", etc.
 }
 
+// Event sources
+const (
+	SourceAPI    = "api"
+	SourceSystem = "system"
+	// SourceSynthetic marks sample events that were never stored
+	SourceSynthetic = "synthetic"
+)
+
 // Event types constants
 const (
-	EventTypeTransactionPosted = "transaction.posted"
-	EventTypeBalanceUpdated    = "balance.updated"
-	EventTypeAccountCreated    = "account.created"
-	EventTypeAccountUpdated    = "account.updated"
+	EventTypeTransactionPosted  = "transaction.posted"
+	EventTypeBalanceUpdated     = "balance.updated"
+	EventTypeAccountCreated     = "account.created"
+	EventTypeAccountUpdated     = "account.updated"
+	EventTypeAccountDeactivated = "account.deactivated"
+
+	EventTypeWebhookEndpointDisabled = "webhook_endpoint.disabled"
 )
 
 // Aggregate types constants
@@ -75,4 +197,22 @@ const (
 	AggregateTypeTransaction = "transaction"
 	AggregateTypeAccount     = "account"
 	AggregateTypeBalance     = "balance"
-)
\ No newline at end of file
+
+	AggregateTypeWebhookEndpoint = "webhook_endpoint"
+)
+
+// BalanceAggregateID identifies the balance aggregate of an account in one
+// currency: the name-based (version 5) UUID of the currency in the account's
+// namespace, which uuid_generate_v5(account_id, currency) computes in SQL
+func BalanceAggregateID(accountID uuid.UUID, currency string) uuid.UUID {
+	return uuid.NewSHA1(accountID, []byte(currency))
+}
+
+// Errors
+var (
+	ErrInvalidCursor        = errors.New("invalid event cursor")
+	ErrEventNotFound        = errors.New("event not found")
+	ErrUnknownSchemaVersion = errors.New("unknown event schema version")
+	ErrAggregateVersionGap  = errors.New("aggregate versions are not contiguous")
+	ErrUnknownEventType     = errors.New("unknown event type")
+)
@@ -0,0 +1,256 @@
+// internal/partitions/integration_test.go
+// +build integration
+
+package partitions_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/events"
+	"github.com/temmyjay001/ledger-service/internal/partitions"
+	"github.com/temmyjay001/ledger-service/internal/storage"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+	"github.com/temmyjay001/ledger-service/internal/testUtil"
+	"github.com/temmyjay001/ledger-service/internal/transactions"
+)
+
+func TestIntegration_ArchiveTransactionPartition(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	slug := testutil.RandomSlug()
+	testutil.CreateTestTenant(t, db, slug)
+	t.Cleanup(func() {
+		db.Exec(ctx, "DELETE FROM archived_partitions WHERE schema_name = $1", storage.GetTenantSchema(slug))
+		testutil.CleanupTestTenant(t, db, slug)
+	})
+	schema := storage.GetTenantSchema(slug)
+
+	// A transaction from a month long gone, plus one from today that must survive
+	oldMonth := time.Date(2001, time.March, 1, 0, 0, 0, 0, time.UTC)
+	_, err := db.Exec(ctx, "SELECT ensure_monthly_partitions($1, 'transactions', $2, $2)", schema, oldMonth)
+	require.NoError(t, err)
+	var oldID uuid.UUID
+	err = db.QueryRow(ctx, `INSERT INTO `+pgx.Identifier{schema, "transactions"}.Sanitize()+` (idempotency_key, description, posted_at, created_at)
+		VALUES ('old-txn', 'Old transaction', $1, $1) RETURNING id`, oldMonth.AddDate(0, 0, 14)).Scan(&oldID)
+	require.NoError(t, err)
+	testutil.CreateTestTransaction(t, db, slug, "new-txn")
+
+	dir := t.TempDir()
+	service := partitions.NewService(db, partitions.NewLocalStore(dir))
+
+	p, err := partitions.ParsePartition(schema, "transactions", "transactions_p200103")
+	require.NoError(t, err)
+
+	result, err := service.ArchivePartition(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.RowCount)
+
+	// the exported file holds the old row as JSON
+	f, err := os.Open(strings.TrimPrefix(result.Location, "file://"))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var row map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+		lines = append(lines, row)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, lines, 1)
+	assert.Equal(t, "old-txn", lines[0]["idempotency_key"])
+
+	// the partition is gone and recorded as archived
+	var exists bool
+	require.NoError(t, db.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", pgx.Identifier{schema, "transactions_p200103"}.Sanitize()).Scan(&exists))
+	assert.False(t, exists)
+
+	archived, err := db.Queries.ListArchivedPartitions(ctx)
+	require.NoError(t, err)
+	found := false
+	for _, a := range archived {
+		if a.SchemaName == schema && a.PartitionName == "transactions_p200103" {
+			found = true
+			assert.Equal(t, result.Location, a.Location)
+			assert.Equal(t, int64(1), a.RowCount)
+		}
+	}
+	assert.True(t, found, "archived partition should be recorded")
+
+	// an archived month is never silently recreated
+	_, err = db.Exec(ctx, "SELECT ensure_monthly_partitions($1, 'transactions', $2, $2)", schema, oldMonth)
+	assert.Error(t, err)
+
+	// queries over the archived range fail, recent ones still work
+	txnService := transactions.NewService(db, events.NewService(db))
+
+	_, err = txnService.ListTransactions(ctx, slug, transactions.ListTransactionsRequest{
+		StartDate: "2001-03-01",
+		EndDate:   "2001-03-31",
+		Limit:     50,
+	})
+	assert.ErrorIs(t, err, storage.ErrArchivedRange)
+
+	today := time.Now().UTC().Format("2006-01-02")
+	list, err := txnService.ListTransactions(ctx, slug, transactions.ListTransactionsRequest{
+		StartDate: today,
+		EndDate:   time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02"),
+		Limit:     50,
+	})
+	require.NoError(t, err)
+	assert.Len(t, list.Transactions, 1)
+	assert.Nil(t, list.ArchivedUntil)
+
+	// listings without a date range flag the months they can't see
+	list, err = txnService.ListTransactions(ctx, slug, transactions.ListTransactionsRequest{Limit: 50})
+	require.NoError(t, err)
+	assert.Len(t, list.Transactions, 1)
+	require.NotNil(t, list.ArchivedUntil)
+	assert.True(t, list.ArchivedUntil.Equal(oldMonth.AddDate(0, 1, 0)))
+
+	// an archived transaction is gone, not missing
+	_, err = txnService.GetTransaction(ctx, slug, oldID)
+	assert.ErrorIs(t, err, storage.ErrArchivedRange)
+	_, err = txnService.GetTransactionLines(ctx, slug, oldID)
+	assert.ErrorIs(t, err, storage.ErrArchivedRange)
+	_, err = txnService.GetTransaction(ctx, slug, uuid.New())
+	assert.ErrorIs(t, err, transactions.ErrTransactionNotFound)
+
+	// and its idempotency key stays taken, with a conflict naming it
+	_, err = txnService.CreateSimpleTransaction(ctx, slug, transactions.CreateTransactionRequest{
+		IdempotencyKey: "old-txn",
+		Description:    "Retried old transaction",
+		AccountCode:    "1000",
+		Amount:         decimal.NewFromInt(10),
+		Side:           "debit",
+		Currency:       "NGN",
+	})
+	assert.ErrorIs(t, err, transactions.ErrDuplicateIdempotencyKey)
+	assert.ErrorContains(t, err, oldID.String())
+}
+
+func TestIntegration_ArchiveRefusesUndeliveredEvents(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	slug := testutil.RandomSlug()
+	tenant := testutil.CreateTestTenant(t, db, slug)
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, slug)
+	})
+
+	// An event written now into a month long gone: no outbox consumer has
+	// reached its transaction yet
+	oldMonth := time.Date(2001, time.May, 1, 0, 0, 0, 0, time.UTC)
+	_, err := db.Exec(ctx, "SELECT ensure_monthly_partitions('public', 'events', $1, $1)", oldMonth)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `INSERT INTO events (tenant_id, aggregate_id, aggregate_type, event_type, event_data, created_at)
+		VALUES ($1, $2, 'transaction', 'transaction.posted', '{}', $3)`, tenant.ID, uuid.New(), oldMonth.AddDate(0, 0, 3))
+	require.NoError(t, err)
+
+	service := partitions.NewService(db, partitions.NewLocalStore(t.TempDir()))
+	p, err := partitions.ParsePartition("public", "events", "events_p200105")
+	require.NoError(t, err)
+
+	_, err = service.ArchivePartition(ctx, p)
+	assert.ErrorIs(t, err, partitions.ErrEventsUndelivered)
+
+	var exists bool
+	require.NoError(t, db.QueryRow(ctx, "SELECT to_regclass('public.events_p200105') IS NOT NULL").Scan(&exists))
+	assert.True(t, exists, "a refused partition must stay attached")
+}
+
+func TestIntegration_ArchivedMonthsMoveWithTenant(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	slug := testutil.RandomSlug()
+	testutil.CreateTestTenant(t, db, slug)
+	t.Cleanup(func() {
+		db.Exec(ctx, "DELETE FROM archived_partitions WHERE schema_name = $1", storage.GetTenantSchema(slug))
+		testutil.CleanupTestTenant(t, db, slug)
+	})
+	schema := storage.GetTenantSchema(slug)
+
+	oldMonth := time.Date(2001, time.July, 1, 0, 0, 0, 0, time.UTC)
+	_, err := db.Exec(ctx, "SELECT ensure_monthly_partitions($1, 'transactions', $2, $2)", schema, oldMonth)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `INSERT INTO `+pgx.Identifier{schema, "transactions"}.Sanitize()+` (idempotency_key, description, posted_at, created_at)
+		VALUES ('old-txn', 'Old transaction', $1, $1)`, oldMonth.AddDate(0, 0, 14))
+	require.NoError(t, err)
+
+	service := partitions.NewService(db, partitions.NewLocalStore(t.TempDir()))
+	p, err := partitions.ParsePartition(schema, "transactions", "transactions_p200107")
+	require.NoError(t, err)
+	_, err = service.ArchivePartition(ctx, p)
+	require.NoError(t, err)
+
+	// history that spans a month the shared tables have archived for every
+	// rls tenant: the moves must skip it instead of recreating it
+	_, err = db.Exec(ctx, "SELECT ensure_monthly_partitions($1, 'transactions', $2, $2)", schema, oldMonth.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `INSERT INTO `+pgx.Identifier{schema, "transactions"}.Sanitize()+` (idempotency_key, description, posted_at, created_at)
+		VALUES ('later-txn', 'Later transaction', $1, $1)`, oldMonth.AddDate(0, 1, 14))
+	require.NoError(t, err)
+	sharedMonth := oldMonth.AddDate(0, 2, 0)
+	_, err = db.Exec(ctx, `INSERT INTO archived_partitions (schema_name, table_name, partition_name, range_start, range_end, row_count, location)
+		VALUES ('shared', 'transactions', 'transactions_p200109', $1, $2, 0, 'file:///dev/null')`, sharedMonth, sharedMonth.AddDate(0, 1, 0))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Exec(ctx, "DELETE FROM archived_partitions WHERE schema_name = 'shared' AND partition_name = 'transactions_p200109'")
+	})
+	testutil.CreateTestTransaction(t, db, slug, "new-txn")
+
+	assertArchived := func(t *testing.T) {
+		err := db.WithTenant(ctx, slug, func(q *queries.Queries) error {
+			assert.ErrorIs(t, storage.CheckArchivedRange(ctx, q, "transactions", oldMonth, oldMonth.AddDate(0, 0, 20)), storage.ErrArchivedRange)
+
+			until, err := storage.ArchivedUntil(ctx, q, "transactions")
+			require.NoError(t, err)
+			if assert.NotNil(t, until) {
+				assert.True(t, until.Equal(oldMonth.AddDate(0, 1, 0)))
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, db.MoveTenant(ctx, slug, queries.TenancyModeEnumRls))
+	assertArchived(t)
+
+	// the month is archived for this tenant only, not every rls tenant
+	other := testutil.RandomSlug()
+	testutil.CreateTestRLSTenant(t, db, other)
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, other)
+	})
+	err = db.WithTenant(ctx, other, func(q *queries.Queries) error {
+		return storage.CheckArchivedRange(ctx, q, "transactions", oldMonth, oldMonth.AddDate(0, 0, 20))
+	})
+	assert.NoError(t, err)
+
+	require.NoError(t, db.MoveTenant(ctx, slug, queries.TenancyModeEnumSchema))
+	assertArchived(t)
+}
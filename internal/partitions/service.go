@@ -0,0 +1,346 @@
+// internal/partitions/service.go
+package partitions
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/storage"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// monthly partitions are named <table>_pYYYYMM by monthly_partition_name()
+var partitionNamePattern = regexp.MustCompile(`^(.+)_p([0-9]{4})([0-9]{2})$`)
+
+type Service struct {
+	db    *storage.DB
+	store Store
+}
+
+func NewService(db *storage.DB, store Store) *Service {
+	return &Service{
+		db:    db,
+		store: store,
+	}
+}
+
+// StartMaintenance keeps monthsAhead months of future partitions created for
+// every partitioned table, checking once on startup and then every interval
+func (s *Service) StartMaintenance(ctx context.Context, interval time.Duration, monthsAhead int) {
+	log.Println("Starting partition maintenance worker...")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	if err := s.EnsureFuturePartitions(ctx, monthsAhead); err != nil {
+		log.Printf("Error creating future partitions: %v", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Partition maintenance worker shutting down...")
+			return
+		case <-ticker.C:
+			if err := s.EnsureFuturePartitions(ctx, monthsAhead); err != nil {
+				log.Printf("Error creating future partitions: %v", err)
+			}
+		}
+	}
+}
+
+// EnsureFuturePartitions creates the partitions for the current month and the
+// next monthsAhead months on every partitioned table. Existing partitions are
+// left alone, so it is safe to run repeatedly and from several instances.
+func (s *Service) EnsureFuturePartitions(ctx context.Context, monthsAhead int) error {
+	rows, err := s.db.Query(ctx, `
+		SELECT n.nspname, c.relname
+		FROM pg_partitioned_table pt
+		JOIN pg_class c ON c.oid = pt.partrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		ORDER BY n.nspname, c.relname`)
+	if err != nil {
+		return fmt.Errorf("failed to list partitioned tables: %w", err)
+	}
+	tables, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) ([2]string, error) {
+		var t [2]string
+		err := row.Scan(&t[0], &t[1])
+		return t, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list partitioned tables: %w", err)
+	}
+
+	for _, t := range tables {
+		if _, err := s.db.Exec(ctx,
+			"SELECT ensure_monthly_partitions($1, $2, NOW(), NOW() + make_interval(months => $3))",
+			t[0], t[1], monthsAhead,
+		); err != nil {
+			return fmt.Errorf("failed to create partitions for %s.%s: %w", t[0], t[1], err)
+		}
+	}
+
+	return nil
+}
+
+// ListPartitions returns every monthly partition currently attached to a
+// partitioned table, oldest first
+func (s *Service) ListPartitions(ctx context.Context) ([]Partition, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT pn.nspname, pc.relname, c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class pc ON pc.oid = i.inhparent
+		JOIN pg_namespace pn ON pn.oid = pc.relnamespace
+		JOIN pg_partitioned_table pt ON pt.partrelid = pc.oid
+		WHERE c.relname ~ '_p[0-9]{6}$'
+		ORDER BY pn.nspname, pc.relname, c.relname`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []Partition
+	for rows.Next() {
+		var schema, table, name string
+		if err := rows.Scan(&schema, &table, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan partition: %w", err)
+		}
+
+		p, err := ParsePartition(schema, table, name)
+		if err != nil {
+			log.Printf("Skipping partition %s.%s: %v", schema, name, err)
+			continue
+		}
+		partitions = append(partitions, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	return partitions, nil
+}
+
+// ParsePartition derives the month a partition covers from its name
+func ParsePartition(schema, table, name string) (Partition, error) {
+	m := partitionNamePattern.FindStringSubmatch(name)
+	if m == nil || m[1] != table {
+		return Partition{}, fmt.Errorf("%w: %s", ErrInvalidPartitionName, name)
+	}
+
+	start, err := time.Parse("200601", m[2]+m[3])
+	if err != nil {
+		return Partition{}, fmt.Errorf("%w: %s", ErrInvalidPartitionName, name)
+	}
+
+	return Partition{
+		Schema:     schema,
+		Table:      table,
+		Name:       name,
+		RangeStart: start,
+		RangeEnd:   start.AddDate(0, 1, 0),
+	}, nil
+}
+
+// ArchivePartitions archives every partition whose range ends on or before
+// cutoff. An events month that is still being delivered is skipped along with
+// the later months of its table, which must not be archived ahead of it. It
+// stops at any other failure; partitions already archived stay archived.
+func (s *Service) ArchivePartitions(ctx context.Context, cutoff time.Time) ([]ArchiveResult, error) {
+	partitions, err := s.ListPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ArchiveResult
+	held := make(map[string]bool)
+	for _, p := range partitions {
+		parent := p.Schema + "." + p.Table
+		if p.RangeEnd.After(cutoff) || held[parent] {
+			continue
+		}
+
+		result, err := s.ArchivePartition(ctx, p)
+		if errors.Is(err, ErrEventsUndelivered) {
+			log.Printf("Keeping %s from %s on: %v", parent, p.Name, err)
+			held[parent] = true
+			continue
+		}
+		if err != nil {
+			return results, err
+		}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+// ArchivePartition exports a partition to the store as gzip-compressed JSON
+// lines, then detaches and drops it and records the archive so queries over
+// its range are rejected. The partition is only dropped if it still holds
+// exactly the rows that were exported. A partition of the global events
+// table is refused with ErrEventsUndelivered while it holds events that an
+// outbox consumer has not reached or that have webhook deliveries pending.
+func (s *Service) ArchivePartition(ctx context.Context, p Partition) (*ArchiveResult, error) {
+	log.Printf("Archiving partition %s.%s", p.Schema, p.Name)
+
+	if err := checkEventsDelivered(ctx, s.db, p); err != nil {
+		return nil, err
+	}
+
+	key := path.Join(p.Schema, p.Table, p.Name+".jsonl.gz")
+	exported, location, err := s.export(ctx, p, key)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	parent := pgx.Identifier{p.Schema, p.Table}.Sanitize()
+	partition := pgx.Identifier{p.Schema, p.Name}.Sanitize()
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", parent, partition)); err != nil {
+		return nil, fmt.Errorf("failed to detach partition %s.%s: %w", p.Schema, p.Name, err)
+	}
+
+	var count int64
+	if err := tx.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", partition)).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count partition %s.%s: %w", p.Schema, p.Name, err)
+	}
+	if count != exported {
+		return nil, fmt.Errorf("%w: %s.%s exported %d rows, now holds %d", ErrRowCountMismatch, p.Schema, p.Name, exported, count)
+	}
+
+	// deliveries may have been queued for the partition while it was exported
+	if err := checkEventsDelivered(ctx, tx, p); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.Queries.WithTx(tx).CreateArchivedPartition(ctx, queries.CreateArchivedPartitionParams{
+		SchemaName:    p.Schema,
+		TableName:     p.Table,
+		PartitionName: p.Name,
+		RangeStart:    pgtype.Timestamptz{Time: p.RangeStart, Valid: true},
+		RangeEnd:      pgtype.Timestamptz{Time: p.RangeEnd, Valid: true},
+		RowCount:      exported,
+		Location:      location,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record archived partition: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("DROP TABLE %s", partition)); err != nil {
+		return nil, fmt.Errorf("failed to drop partition %s.%s: %w", p.Schema, p.Name, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("Archived %d rows from %s.%s to %s", exported, p.Schema, p.Name, location)
+
+	return &ArchiveResult{
+		Partition: p,
+		RowCount:  exported,
+		Location:  location,
+	}, nil
+}
+
+// checkEventsDelivered returns ErrEventsUndelivered if p is a partition of
+// the global events table holding events past an outbox consumer's cursor or
+// with webhook deliveries still waiting to be sent. Dead-lettered deliveries
+// do not hold a partition back.
+func checkEventsDelivered(ctx context.Context, db queries.DBTX, p Partition) error {
+	if p.Schema != "public" || p.Table != "events" {
+		return nil
+	}
+
+	var unconsumed, pending bool
+	err := db.QueryRow(ctx, fmt.Sprintf(`
+		SELECT
+			EXISTS (
+				SELECT 1 FROM %[1]s e, event_consumers c
+				WHERE (e.tx_id, e.sequence_number) > (c.last_tx_id, c.last_sequence)
+			),
+			EXISTS (
+				SELECT 1 FROM %[1]s e
+				JOIN webhook_deliveries d ON d.event_id = e.event_id
+				WHERE d.next_retry_at IS NOT NULL
+				AND d.delivered_at IS NULL
+			)`,
+		pgx.Identifier{p.Schema, p.Name}.Sanitize(),
+	)).Scan(&unconsumed, &pending)
+	if err != nil {
+		return fmt.Errorf("failed to check deliveries of partition %s.%s: %w", p.Schema, p.Name, err)
+	}
+
+	switch {
+	case unconsumed:
+		return fmt.Errorf("%w: %s.%s is ahead of an event consumer", ErrEventsUndelivered, p.Schema, p.Name)
+	case pending:
+		return fmt.Errorf("%w: %s.%s has pending webhook deliveries", ErrEventsUndelivered, p.Schema, p.Name)
+	}
+
+	return nil
+}
+
+// export streams the partition's rows to the store and returns how many rows
+// were written and where
+func (s *Service) export(ctx context.Context, p Partition, key string) (int64, string, error) {
+	rows, err := s.db.Query(ctx, fmt.Sprintf(
+		"SELECT row_to_json(t)::text FROM %s t ORDER BY created_at",
+		pgx.Identifier{p.Schema, p.Name}.Sanitize(),
+	))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read partition %s.%s: %w", p.Schema, p.Name, err)
+	}
+	defer rows.Close()
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	var count int64
+
+	go func() {
+		defer close(done)
+		gz := gzip.NewWriter(pw)
+		for rows.Next() {
+			var line string
+			if err := rows.Scan(&line); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := io.WriteString(gz, line+"\n"); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			count++
+		}
+		if err := rows.Err(); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(gz.Close())
+	}()
+
+	location, err := s.store.Put(ctx, key, pr)
+	// unblock the writer if the store gave up before reading everything
+	pr.CloseWithError(io.ErrClosedPipe)
+	<-done
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to export partition %s.%s: %w", p.Schema, p.Name, err)
+	}
+
+	return count, location, nil
+}
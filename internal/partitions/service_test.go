@@ -0,0 +1,31 @@
+// internal/partitions/service_test.go
+package partitions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePartition(t *testing.T) {
+	p, err := ParsePartition("tenant_acme", "transaction_lines", "transaction_lines_p202412")
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC), p.RangeStart)
+	assert.Equal(t, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), p.RangeEnd)
+
+	invalid := []struct {
+		table string
+		name  string
+	}{
+		{"transactions", "transactions_default"},
+		{"transactions", "transactions_p202413"},
+		{"transactions", "transaction_lines_p202401"},
+	}
+	for _, tc := range invalid {
+		_, err := ParsePartition("public", tc.table, tc.name)
+		assert.ErrorIs(t, err, ErrInvalidPartitionName, tc.name)
+	}
+}
@@ -0,0 +1,109 @@
+// internal/partitions/store.go
+package partitions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store receives exported partition files. Put streams body to key and
+// returns the location recorded in archived_partitions.
+type Store interface {
+	Put(ctx context.Context, key string, body io.Reader) (string, error)
+}
+
+// NewStore picks a store for target: http(s) URLs are written with PUT
+// requests (e.g. a pre-authorised object storage bucket endpoint), anything
+// else is treated as a local directory, with or without a file:// prefix.
+func NewStore(target string) Store {
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return NewHTTPStore(target, http.DefaultClient)
+	}
+	return NewLocalStore(strings.TrimPrefix(target, "file://"))
+}
+
+// LocalStore writes archives under a directory on the local filesystem
+type LocalStore struct {
+	dir string
+}
+
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{dir: dir}
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader) (string, error) {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	// write to a temp file first so a failed export never leaves a truncated
+	// archive at the final path
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write archive file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to close archive file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to move archive file into place: %w", err)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return "file://" + filepath.ToSlash(abs), nil
+}
+
+// HTTPStore uploads archives with a PUT to baseURL/key
+type HTTPStore struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewHTTPStore(baseURL string, client *http.Client) *HTTPStore {
+	return &HTTPStore{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+}
+
+func (s *HTTPStore) Put(ctx context.Context, key string, body io.Reader) (string, error) {
+	url := s.baseURL + "/" + key
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload archive: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("archive upload returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return url, nil
+}
@@ -0,0 +1,86 @@
+// internal/partitions/store_test.go
+package partitions
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorePut(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalStore(dir)
+
+	location, err := store.Put(context.Background(), "tenant_acme/transactions/transactions_p202401.jsonl.gz", strings.NewReader("archived"))
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "tenant_acme", "transactions", "transactions_p202401.jsonl.gz")
+	assert.Equal(t, "file://"+filepath.ToSlash(path), location)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "archived", string(data))
+
+	// no temp files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestLocalStorePutFailureLeavesNoFile(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalStore(dir)
+
+	_, err := store.Put(context.Background(), "public/events/events_p202401.jsonl.gz", failingReader{})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	entries, err := os.ReadDir(filepath.Join(dir, "public", "events"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestHTTPStorePut(t *testing.T) {
+	var gotPath, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		body, _ := io.ReadAll(r.Body)
+		gotPath = r.URL.Path
+		gotBody = string(body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	store := NewStore(server.URL + "/archive/")
+	location, err := store.Put(context.Background(), "public/events/events_p202401.jsonl.gz", strings.NewReader("archived"))
+	require.NoError(t, err)
+
+	assert.Equal(t, server.URL+"/archive/public/events/events_p202401.jsonl.gz", location)
+	assert.Equal(t, "/archive/public/events/events_p202401.jsonl.gz", gotPath)
+	assert.Equal(t, "archived", gotBody)
+}
+
+func TestHTTPStorePutRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "access denied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	store := NewHTTPStore(server.URL, server.Client())
+	_, err := store.Put(context.Background(), "public/events/events_p202401.jsonl.gz", strings.NewReader("archived"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 403")
+}
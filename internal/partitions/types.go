@@ -0,0 +1,37 @@
+// internal/partitions/types.go
+package partitions
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultMonthsAhead is how many future months of partitions are kept created
+	DefaultMonthsAhead = 3
+	// DefaultMaintenanceInterval is how often future partitions are topped up
+	DefaultMaintenanceInterval = 6 * time.Hour
+)
+
+var (
+	ErrInvalidPartitionName = errors.New("invalid monthly partition name")
+	ErrRowCountMismatch     = errors.New("partition row count changed during export")
+	ErrEventsUndelivered    = errors.New("partition holds events that are not yet fully delivered")
+)
+
+// Partition is one monthly partition of a partitioned ledger table. The range
+// is half-open: RangeStart <= created_at < RangeEnd.
+type Partition struct {
+	Schema     string    `json:"schema"`
+	Table      string    `json:"table"`
+	Name       string    `json:"name"`
+	RangeStart time.Time `json:"range_start"`
+	RangeEnd   time.Time `json:"range_end"`
+}
+
+// ArchiveResult describes a partition that was exported and dropped
+type ArchiveResult struct {
+	Partition Partition `json:"partition"`
+	RowCount  int64     `json:"row_count"`
+	Location  string    `json:"location"`
+}
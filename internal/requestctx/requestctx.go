@@ -0,0 +1,99 @@
+// Package requestctx carries per-request metadata from the HTTP layer down to
+// the events, log lines and webhooks a request causes, so one request can be
+// traced end to end.
+package requestctx
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// CorrelationIDHeader lets callers tie a request to their own trace; it is
+// echoed on the response and forwarded on webhooks
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// maxCorrelationIDLength bounds caller-supplied correlation IDs
+const maxCorrelationIDLength = 128
+
+type contextKey struct{}
+
+// Metadata describes the request a piece of work was done for
+type Metadata struct {
+	RequestID     string
+	CorrelationID string
+	SourceIP      string
+}
+
+// Middleware captures the request's metadata into its context. It must run
+// after chi's RequestID middleware. A request without a usable
+// X-Correlation-ID is correlated by its request ID.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta := Metadata{
+			RequestID:     middleware.GetReqID(r.Context()),
+			CorrelationID: sanitizeCorrelationID(r.Header.Get(CorrelationIDHeader)),
+			SourceIP:      clientIP(r),
+		}
+		if meta.CorrelationID == "" {
+			meta.CorrelationID = meta.RequestID
+		}
+
+		if meta.CorrelationID != "" {
+			w.Header().Set(CorrelationIDHeader, meta.CorrelationID)
+		}
+		next.ServeHTTP(w, r.WithContext(WithMetadata(r.Context(), meta)))
+	})
+}
+
+// WithMetadata returns a copy of ctx carrying meta
+func WithMetadata(ctx context.Context, meta Metadata) context.Context {
+	return context.WithValue(ctx, contextKey{}, meta)
+}
+
+// FromContext returns the metadata of the request ctx belongs to, if any
+func FromContext(ctx context.Context) (Metadata, bool) {
+	meta, ok := ctx.Value(contextKey{}).(Metadata)
+	return meta, ok
+}
+
+// Logf logs like log.Printf, tagged with the request and correlation IDs of
+// ctx when it belongs to a request
+func Logf(ctx context.Context, format string, args ...interface{}) {
+	meta, ok := FromContext(ctx)
+	if !ok {
+		log.Printf(format, args...)
+		return
+	}
+	log.Printf("%s [request_id=%s correlation_id=%s]", fmt.Sprintf(format, args...), meta.RequestID, meta.CorrelationID)
+}
+
+// sanitizeCorrelationID drops correlation IDs that are too long or contain
+// anything but printable ASCII, so they are safe to log and to forward
+func sanitizeCorrelationID(id string) string {
+	id = strings.TrimSpace(id)
+	if len(id) > maxCorrelationIDLength {
+		return ""
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return ""
+		}
+	}
+	return id
+}
+
+// clientIP is the address the request came from. Deployments behind a proxy
+// should run chi's RealIP middleware first so RemoteAddr is the client's.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,55 @@
+// internal/requestctx/requestctx_test.go
+package requestctx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, req *http.Request) (Metadata, *httptest.ResponseRecorder) {
+	t.Helper()
+
+	var (
+		meta Metadata
+		ok   bool
+	)
+	handler := middleware.RequestID(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta, ok = FromContext(r.Context())
+	})))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.True(t, ok, "metadata should be in the request context")
+	return meta, w
+}
+
+func TestMiddlewareCapturesRequestMetadata(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tenants", nil)
+	req.RemoteAddr = "203.0.113.7:52100"
+	req.Header.Set(CorrelationIDHeader, "support-ticket-4711")
+
+	meta, w := serve(t, req)
+
+	assert.NotEmpty(t, meta.RequestID)
+	assert.Equal(t, "support-ticket-4711", meta.CorrelationID)
+	assert.Equal(t, "203.0.113.7", meta.SourceIP)
+	assert.Equal(t, "support-ticket-4711", w.Header().Get(CorrelationIDHeader))
+}
+
+func TestMiddlewareCorrelatesByRequestIDByDefault(t *testing.T) {
+	for _, header := range []string{"", "has space", "tab\tinside", strings.Repeat("x", maxCorrelationIDLength+1)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(CorrelationIDHeader, header)
+
+		meta, w := serve(t, req)
+
+		assert.Equal(t, meta.RequestID, meta.CorrelationID, "header %q", header)
+		assert.Equal(t, meta.RequestID, w.Header().Get(CorrelationIDHeader))
+	}
+}
@@ -0,0 +1,173 @@
+// internal/secrets/keyring.go
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the length of master and data keys (AES-256)
+const KeySize = 32
+
+// Errors
+var (
+	ErrUnknownKeyVersion = errors.New("unknown master key version")
+	ErrInvalidKey        = errors.New("invalid encryption key")
+	ErrDecryptionFailed  = errors.New("decryption failed")
+)
+
+// Sealed is a value encrypted with envelope encryption: Ciphertext is sealed
+// with a random data key, and EncryptedKey is that data key sealed with the
+// master key numbered KeyVersion.
+type Sealed struct {
+	Ciphertext   []byte
+	EncryptedKey []byte
+	KeyVersion   int32
+}
+
+// Keyring holds the versioned master keys. New values are always sealed with
+// the highest version; older versions are kept so existing values can still
+// be opened until they are rewrapped.
+type Keyring struct {
+	keys    map[int32][]byte
+	current int32
+}
+
+func NewKeyring(keys map[int32][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no master keys configured", ErrInvalidKey)
+	}
+
+	k := &Keyring{keys: make(map[int32][]byte, len(keys))}
+	for version, key := range keys {
+		if version < 1 {
+			return nil, fmt.Errorf("%w: version %d must be positive", ErrInvalidKey, version)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("%w: version %d must be %d bytes", ErrInvalidKey, version, KeySize)
+		}
+		k.keys[version] = key
+		k.current = max(k.current, version)
+	}
+
+	return k, nil
+}
+
+// CurrentVersion is the master key version new values are sealed with
+func (k *Keyring) CurrentVersion() int32 {
+	return k.current
+}
+
+// Seal encrypts plaintext under a fresh data key wrapped by the current
+// master key
+func (k *Keyring) Seal(plaintext []byte) (Sealed, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return Sealed{}, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	ciphertext, err := seal(dataKey, plaintext)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	encryptedKey, err := seal(k.keys[k.current], dataKey)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	return Sealed{
+		Ciphertext:   ciphertext,
+		EncryptedKey: encryptedKey,
+		KeyVersion:   k.current,
+	}, nil
+}
+
+// Open decrypts a sealed value with whichever master key version sealed it
+func (k *Keyring) Open(s Sealed) ([]byte, error) {
+	dataKey, err := k.openDataKey(s)
+	if err != nil {
+		return nil, err
+	}
+	return open(dataKey, s.Ciphertext)
+}
+
+// Rewrap re-seals only the data key with the current master key, leaving the
+// ciphertext untouched. It lets a master key be retired without decrypting
+// and re-encrypting every value.
+func (k *Keyring) Rewrap(s Sealed) (Sealed, error) {
+	if s.KeyVersion == k.current {
+		return s, nil
+	}
+
+	dataKey, err := k.openDataKey(s)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	encryptedKey, err := seal(k.keys[k.current], dataKey)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	return Sealed{
+		Ciphertext:   s.Ciphertext,
+		EncryptedKey: encryptedKey,
+		KeyVersion:   k.current,
+	}, nil
+}
+
+func (k *Keyring) openDataKey(s Sealed) ([]byte, error) {
+	masterKey, ok := k.keys[s.KeyVersion]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKeyVersion, s.KeyVersion)
+	}
+	return open(masterKey, s.EncryptedKey)
+}
+
+// seal encrypts with AES-GCM, prefixing the random nonce to the output
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,98 @@
+// internal/secrets/keyring_test.go
+package secrets
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestNewKeyring(t *testing.T) {
+	k, err := NewKeyring(map[int32][]byte{1: testKey(1), 3: testKey(3), 2: testKey(2)})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), k.CurrentVersion(), "highest version encrypts")
+
+	_, err = NewKeyring(nil)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = NewKeyring(map[int32][]byte{1: []byte("short")})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = NewKeyring(map[int32][]byte{0: testKey(1)})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestSealOpen(t *testing.T) {
+	k, err := NewKeyring(map[int32][]byte{1: testKey(1)})
+	require.NoError(t, err)
+
+	sealed, err := k.Seal([]byte("whsec_secret"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), sealed.KeyVersion)
+	assert.NotContains(t, string(sealed.Ciphertext), "whsec_secret")
+
+	plaintext, err := k.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "whsec_secret", string(plaintext))
+
+	// every seal uses a fresh data key and nonce
+	again, err := k.Seal([]byte("whsec_secret"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed.Ciphertext, again.Ciphertext)
+	assert.NotEqual(t, sealed.EncryptedKey, again.EncryptedKey)
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	k, err := NewKeyring(map[int32][]byte{1: testKey(1)})
+	require.NoError(t, err)
+
+	sealed, err := k.Seal([]byte("whsec_secret"))
+	require.NoError(t, err)
+
+	tampered := sealed
+	tampered.Ciphertext = bytes.Clone(sealed.Ciphertext)
+	tampered.Ciphertext[len(tampered.Ciphertext)-1] ^= 0xff
+	_, err = k.Open(tampered)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+
+	_, err = k.Open(Sealed{Ciphertext: sealed.Ciphertext, EncryptedKey: sealed.EncryptedKey, KeyVersion: 9})
+	assert.ErrorIs(t, err, ErrUnknownKeyVersion)
+
+	other, err := NewKeyring(map[int32][]byte{1: testKey(2)})
+	require.NoError(t, err)
+	_, err = other.Open(sealed)
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestRewrap(t *testing.T) {
+	old, err := NewKeyring(map[int32][]byte{1: testKey(1)})
+	require.NoError(t, err)
+
+	sealed, err := old.Seal([]byte("whsec_secret"))
+	require.NoError(t, err)
+
+	rotated, err := NewKeyring(map[int32][]byte{1: testKey(1), 2: testKey(2)})
+	require.NoError(t, err)
+
+	rewrapped, err := rotated.Rewrap(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), rewrapped.KeyVersion)
+	assert.Equal(t, sealed.Ciphertext, rewrapped.Ciphertext, "only the data key is re-sealed")
+
+	// once rewrapped, the old master key is no longer needed
+	retired, err := NewKeyring(map[int32][]byte{2: testKey(2)})
+	require.NoError(t, err)
+	plaintext, err := retired.Open(rewrapped)
+	require.NoError(t, err)
+	assert.Equal(t, "whsec_secret", string(plaintext))
+
+	same, err := rotated.Rewrap(rewrapped)
+	require.NoError(t, err)
+	assert.Equal(t, rewrapped, same)
+}
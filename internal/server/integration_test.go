@@ -0,0 +1,141 @@
+// internal/server/integration_test.go
+// +build integration
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/auth"
+	"github.com/temmyjay001/ledger-service/internal/tenant"
+	"github.com/temmyjay001/ledger-service/internal/testUtil"
+)
+
+func TestIntegration_WebhookReadScopeReachesWebhookRoutes(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	cfg := testutil.TestConfig()
+
+	user := testutil.CreateTestUser(t, db, testutil.RandomEmail())
+	authService := auth.NewService(db, cfg)
+	tenantService := tenant.NewService(db, authService, cfg.TenancyMode)
+
+	tenantSlug := testutil.RandomSlug()
+	created, err := tenantService.CreateTenant(ctx, user.ID, tenant.CreateTenantRequest{
+		Name: "Webhook Scopes",
+		Slug: tenantSlug,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, tenantSlug)
+	})
+
+	readKey, err := tenantService.CreateAPIKey(ctx, user.ID, created.ID, tenant.CreateAPIKeyRequest{
+		Name:   "webhook reader",
+		Scopes: []string{"webhooks:read"},
+	})
+	require.NoError(t, err, "webhooks:read must be an issuable scope")
+
+	otherKey, err := tenantService.CreateAPIKey(ctx, user.ID, created.ID, tenant.CreateAPIKeyRequest{
+		Name:   "event reader",
+		Scopes: []string{"events:read"},
+	})
+	require.NoError(t, err)
+
+	router := New(cfg, db).Router()
+	get := func(path, key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tenants/"+tenantSlug+path, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("/webhooks/endpoints", readKey.Key))
+	assert.Equal(t, http.StatusOK, get("/webhooks/dead-letters", readKey.Key))
+	assert.Equal(t, http.StatusNotFound, get("/webhooks/"+uuid.NewString()+"/attempts", readKey.Key),
+		"an unknown delivery is looked up, not refused")
+
+	assert.Equal(t, http.StatusForbidden, get("/webhooks/endpoints", otherKey.Key))
+	assert.Equal(t, http.StatusForbidden, get("/webhooks/"+uuid.NewString()+"/attempts", otherKey.Key))
+}
+
+func TestIntegration_WebhookRoutesRefuseOtherTenantsKeys(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	cfg := testutil.TestConfig()
+
+	user := testutil.CreateTestUser(t, db, testutil.RandomEmail())
+	tenantService := tenant.NewService(db, auth.NewService(db, cfg), cfg.TenancyMode)
+
+	var tenantIDs []uuid.UUID
+	var tenantSlugs []string
+	for _, name := range []string{"Key Owner", "Other Tenant"} {
+		tenantSlug := testutil.RandomSlug()
+		created, err := tenantService.CreateTenant(ctx, user.ID, tenant.CreateTenantRequest{Name: name, Slug: tenantSlug})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			testutil.CleanupTestTenant(t, db, tenantSlug)
+		})
+		tenantIDs = append(tenantIDs, created.ID)
+		tenantSlugs = append(tenantSlugs, tenantSlug)
+	}
+
+	key, err := tenantService.CreateAPIKey(ctx, user.ID, tenantIDs[0], tenant.CreateAPIKeyRequest{
+		Name:   "webhook admin",
+		Scopes: []string{"webhooks:read", "webhooks:manage"},
+	})
+	require.NoError(t, err)
+
+	router := New(cfg, db).Router()
+	do := func(method, tenantSlug, path, body string) int {
+		req := httptest.NewRequest(method, "/api/v1/tenants/"+tenantSlug+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key.Key)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	someID := uuid.NewString()
+	routes := []struct{ method, path, body string }{
+		{http.MethodPost, "/webhooks/endpoints", `{"url": "https://example.com/hook", "event_types": ["*"]}`},
+		{http.MethodGet, "/webhooks/endpoints", ""},
+		{http.MethodGet, "/webhooks/endpoints/" + someID, ""},
+		{http.MethodPut, "/webhooks/endpoints/" + someID, `{"enabled": false}`},
+		{http.MethodPost, "/webhooks/endpoints/" + someID + "/disable", ""},
+		{http.MethodDelete, "/webhooks/endpoints/" + someID, ""},
+		{http.MethodPost, "/webhooks/endpoints/" + someID + "/rotate-secret", "{}"},
+		{http.MethodPost, "/webhooks/endpoints/" + someID + "/test", ""},
+		{http.MethodPost, "/webhooks/endpoints/" + someID + "/synthetic-events", `{"event_type": "transaction.posted"}`},
+		{http.MethodGet, "/webhooks/", ""},
+		{http.MethodGet, "/webhooks/" + someID, ""},
+		{http.MethodGet, "/webhooks/" + someID + "/attempts", ""},
+		{http.MethodPost, "/webhooks/" + someID + "/retry", ""},
+		{http.MethodGet, "/webhooks/dead-letters", ""},
+		{http.MethodGet, "/webhooks/dead-letters/" + someID, ""},
+		{http.MethodPost, "/webhooks/dead-letters/redeliver", "{}"},
+		{http.MethodPost, "/webhooks/replay", `{"from_sequence": 0}`},
+		{http.MethodGet, "/webhooks/replays", ""},
+		{http.MethodGet, "/webhooks/replays/" + someID, ""},
+		{http.MethodPost, "/webhooks/replays/" + someID + "/cancel", ""},
+	}
+	for _, route := range routes {
+		assert.Equal(t, http.StatusForbidden, do(route.method, tenantSlugs[1], route.path, route.body),
+			"%s %s with another tenant's key", route.method, route.path)
+	}
+
+	// the key still works on its own tenant
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, tenantSlugs[0], "/webhooks/endpoints", ""))
+}
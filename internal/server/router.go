@@ -7,6 +7,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/temmyjay001/ledger-service/internal/requestctx"
 )
 
 func (s *Server) Router() http.Handler {
@@ -14,15 +15,16 @@ func (s *Server) Router() http.Handler {
 
 	// Basic Middleware
 	r.Use(middleware.RequestID)
+	r.Use(requestctx.Middleware)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(s.timeoutMiddleware(60 * time.Second))
 
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", requestctx.CorrelationIDHeader},
+		ExposedHeaders:   []string{"Link", requestctx.CorrelationIDHeader},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
@@ -37,6 +39,10 @@ func (s *Server) Router() http.Handler {
 		r.Post("/auth/register", s.authHandlers.RegisterHandler)
 		r.Post("/auth/login", s.authHandlers.LoginHandler)
 
+		// Public event payload schemas
+		r.Get("/schemas/events", s.eventHandlers.ListEventSchemasHandler)
+		r.Get("/schemas/events/{eventType}", s.eventHandlers.GetEventSchemaHandler)
+
 		// Protected routes
 		r.Group(func(r chi.Router) {
 			r.Use(s.authMiddleware.UserAuthMiddleware)
@@ -66,6 +72,8 @@ func (s *Server) Router() http.Handler {
 			r.With(s.authMiddleware.RequireScopes("accounts:read")).Get("/accounts/code/{accountCode}", s.accountHandlers.GetAccountByCodeHandler)
 			r.With(s.authMiddleware.RequireScopes("accounts:write")).Put("/accounts/{accountId}", s.accountHandlers.UpdateAccountHandler)
 			r.With(s.authMiddleware.RequireScopes("accounts:write")).Delete("/accounts/{accountId}", s.accountHandlers.DeleteAccountHandler)
+			r.With(s.authMiddleware.RequireScopes("accounts:write")).Put("/accounts/{accountId}/high-volume", s.accountHandlers.EnableHighVolumeHandler)
+			r.With(s.authMiddleware.RequireScopes("accounts:write")).Delete("/accounts/{accountId}/high-volume", s.accountHandlers.DisableHighVolumeHandler)
 			r.With(s.authMiddleware.RequireScopes("balances:read")).Get("/accounts/{accountId}/balance", s.accountHandlers.GetAccountBalanceHandler)
 			r.With(s.authMiddleware.RequireScopes("balances:read")).Get("/accounts/{accountId}/balance/history", s.accountHandlers.GetAccountBalanceHistoryHandler)
 			r.With(s.authMiddleware.RequireScopes("balances:read")).Get("/accounts/balances/summary", s.accountHandlers.GetBalanceSummaryHandler)
@@ -86,13 +94,35 @@ func (s *Server) Router() http.Handler {
 			r.With(s.authMiddleware.RequireScopes("reports:read")).Get("/reports/transactions", s.getTransactionReportHandler)
 			r.With(s.authMiddleware.RequireScopes("reports:read")).Get("/reports/balances", s.getBalanceReportHandler)
 
+			// Event history and streaming
+			r.With(s.authMiddleware.RequireScopes("events:read")).Get("/events", s.eventHandlers.ListEventsHandler)
+			r.With(s.authMiddleware.RequireScopes("events:read")).Get("/events/stream", s.eventHandlers.StreamEventsHandler)
+			r.With(s.authMiddleware.RequireScopes("events:read")).Get("/events/{eventId}", s.eventHandlers.GetEventHandler)
+			r.With(s.authMiddleware.RequireScopes("events:read")).Get("/accounts/{accountId}/events", s.eventHandlers.ListAccountEventsHandler)
+			r.With(s.authMiddleware.RequireScopes("events:read")).Get("/transactions/{transactionId}/events", s.eventHandlers.ListTransactionEventsHandler)
+
 			// Webhook management
 			r.Route("/webhooks", func(r chi.Router) {
-				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Post("/", s.webhookHandlers.ConfigureWebhookHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Post("/endpoints", s.webhookHandlers.CreateEndpointHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:read")).Get("/endpoints", s.webhookHandlers.ListEndpointsHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:read")).Get("/endpoints/{endpointId}", s.webhookHandlers.GetEndpointHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Put("/endpoints/{endpointId}", s.webhookHandlers.UpdateEndpointHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Post("/endpoints/{endpointId}/disable", s.webhookHandlers.DisableEndpointHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Delete("/endpoints/{endpointId}", s.webhookHandlers.DeleteEndpointHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Post("/endpoints/{endpointId}/rotate-secret", s.webhookHandlers.RotateSecretHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Post("/endpoints/{endpointId}/test", s.webhookHandlers.TestWebhookHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Post("/endpoints/{endpointId}/synthetic-events", s.webhookHandlers.SendSyntheticEventsHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:read")).Get("/dead-letters", s.webhookHandlers.ListDeadLettersHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Post("/dead-letters/redeliver", s.webhookHandlers.RedeliverDeadLettersHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:read")).Get("/dead-letters/{deliveryId}", s.webhookHandlers.GetDeadLetterHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Post("/replay", s.webhookHandlers.CreateReplayHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:read")).Get("/replays", s.webhookHandlers.ListReplaysHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:read")).Get("/replays/{replayId}", s.webhookHandlers.GetReplayHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Post("/replays/{replayId}/cancel", s.webhookHandlers.CancelReplayHandler)
 				r.With(s.authMiddleware.RequireScopes("webhooks:read")).Get("/", s.webhookHandlers.ListWebhookDeliveriesHandler)
 				r.With(s.authMiddleware.RequireScopes("webhooks:read")).Get("/{deliveryId}", s.webhookHandlers.GetWebhookDeliveryHandler)
+				r.With(s.authMiddleware.RequireScopes("webhooks:read")).Get("/{deliveryId}/attempts", s.webhookHandlers.ListDeliveryAttemptsHandler)
 				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Post("/{deliveryId}/retry", s.webhookHandlers.RetryWebhookDeliveryHandler)
-				r.With(s.authMiddleware.RequireScopes("webhooks:manage")).Post("/test", s.webhookHandlers.TestWebhookHandler)
 			})
 		})
 	})
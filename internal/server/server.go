@@ -8,6 +8,8 @@ import (
 	"github.com/temmyjay001/ledger-service/internal/auth"
 	"github.com/temmyjay001/ledger-service/internal/config"
 	"github.com/temmyjay001/ledger-service/internal/events"
+	"github.com/temmyjay001/ledger-service/internal/partitions"
+	"github.com/temmyjay001/ledger-service/internal/secrets"
 	"github.com/temmyjay001/ledger-service/internal/storage"
 	"github.com/temmyjay001/ledger-service/internal/tenant"
 	"github.com/temmyjay001/ledger-service/internal/transactions"
@@ -23,8 +25,11 @@ type Server struct {
 	accountHandlers     *accounts.Handlers
 	transactionHandlers *transactions.Handlers
 	eventService        *events.Service
+	eventHandlers       *events.Handlers
 	webhookService      *webhooks.Service
 	webhookHandlers     *webhooks.Handlers
+	partitionService    *partitions.Service
+	eventPublishers     []events.Publisher
 }
 
 func New(config *config.Config, db *storage.DB) *Server {
@@ -33,19 +38,38 @@ func New(config *config.Config, db *storage.DB) *Server {
 	authMiddleware := auth.NewMiddleware(authService)
 	authHandlers := auth.NewHandlers(authService)
 
-	tenantService := tenant.NewService(db, authService)
+	tenantService := tenant.NewService(db, authService, config.TenancyMode)
 	tenantHandlers := tenant.NewHandlers(tenantService)
 
-	accountService := accounts.NewService(db)
+	eventService := events.NewService(db)
+	eventHandlers := events.NewHandlers(eventService)
+
+	accountService := accounts.NewService(db, eventService)
 	accountHandlers := accounts.NewHandlers(accountService)
 
-	eventService := events.NewService(db)
-	webhookService := webhooks.NewService(db)
+	// Load already validates the master keys, so this only fails on a
+	// hand-built config
+	keyring, err := secrets.NewKeyring(config.WebhookMasterKeys)
+	if err != nil {
+		log.Fatalf("Invalid webhook master keys: %v", err)
+	}
+	webhookService := webhooks.NewService(db, eventService, keyring, config)
 	webhookHandlers := webhooks.NewHandlers(webhookService)
 
 	transactionService := transactions.NewService(db, eventService)
 	transactionHandlers := transactions.NewHandlers(transactionService)
 
+	partitionService := partitions.NewService(db, partitions.NewStore(config.ArchiveStore))
+
+	var eventPublishers []events.Publisher
+	for _, sink := range config.EventSinks {
+		publisher, err := events.NewPublisher(sink, config.RedisURL, config.EventSinkRedisStream)
+		if err != nil {
+			log.Fatalf("Invalid event sink %q: %v", sink, err)
+		}
+		eventPublishers = append(eventPublishers, publisher)
+	}
+
 	return &Server{
 		config:              config,
 		db:                  db,
@@ -55,16 +79,55 @@ func New(config *config.Config, db *storage.DB) *Server {
 		accountHandlers:     accountHandlers,
 		transactionHandlers: transactionHandlers,
 		eventService:        eventService,
+		eventHandlers:       eventHandlers,
 		webhookService:      webhookService,
 		webhookHandlers:     webhookHandlers,
+		partitionService:    partitionService,
+		eventPublishers:     eventPublishers,
 	}
 }
 
+// ReencryptWebhookSecrets brings every webhook secret under the current
+// master key; it must finish before deliveries are signed
+func (s *Server) ReencryptWebhookSecrets(ctx context.Context) error {
+	_, err := s.webhookService.ReencryptSecrets(ctx)
+	return err
+}
+
 // StartWebhookWorker starts the background webhook delivery worker
 func (s *Server) StartWebhookWorker(ctx context.Context) {
 	s.webhookService.StartDeliveryWorker(ctx)
 }
 
+// StartEventDispatcher starts the outbox dispatchers that queue webhook
+// deliveries and forward events to the configured sinks
+func (s *Server) StartEventDispatcher(ctx context.Context) {
+	for _, publisher := range s.eventPublishers {
+		go s.eventService.StartPublisher(ctx, publisher)
+	}
+	s.webhookService.StartOutboxDispatcher(ctx)
+}
+
+// StartEventListener pushes new events to open event streams
+func (s *Server) StartEventListener(ctx context.Context) {
+	s.eventService.StartEventListener(ctx)
+}
+
+// StartWebhookAttemptPruner prunes the webhook delivery attempt log
+func (s *Server) StartWebhookAttemptPruner(ctx context.Context) {
+	s.webhookService.StartAttemptPruner(ctx)
+}
+
+// StartWebhookReplayWorker queues the deliveries of webhook replays
+func (s *Server) StartWebhookReplayWorker(ctx context.Context) {
+	s.webhookService.StartReplayWorker(ctx)
+}
+
+// StartPartitionMaintenance keeps future monthly partitions created
+func (s *Server) StartPartitionMaintenance(ctx context.Context) {
+	s.partitionService.StartMaintenance(ctx, s.config.PartitionMaintenanceInterval, s.config.PartitionMonthsAhead)
+}
+
 // EventWebhookIntegration handles event-to-webhook flow
 func (s *Server) setupEventWebhookIntegration() {
 	// This could be expanded to set up event listeners
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+var ErrArchivedRange = errors.New("requested range includes archived data")
+
+// ArchivedRangeError reports the archived partitions a query would have
+// needed, so callers can point users at the exported files instead of
+// returning a silently truncated result.
+type ArchivedRangeError struct {
+	Table      string
+	RangeStart time.Time
+	RangeEnd   time.Time
+	Locations  []string
+}
+
+func (e *ArchivedRangeError) Error() string {
+	return fmt.Sprintf("%s between %s and %s has been archived and is no longer queryable (archives: %s)",
+		e.Table,
+		e.RangeStart.UTC().Format(time.RFC3339),
+		e.RangeEnd.UTC().Format(time.RFC3339),
+		strings.Join(e.Locations, ", "))
+}
+
+func (e *ArchivedRangeError) Is(target error) bool {
+	return target == ErrArchivedRange
+}
+
+// CheckArchivedRange returns an *ArchivedRangeError if any part of [from, to]
+// of the given table in the current schema has been archived. It must run on
+// queries bound to the tenant's search_path, i.e. inside WithTenant.
+func CheckArchivedRange(ctx context.Context, q *queries.Queries, table string, from, to time.Time) error {
+	archived, err := q.ListArchivedPartitionsInRange(ctx, queries.ListArchivedPartitionsInRangeParams{
+		TableName: table,
+		RangeFrom: pgtype.Timestamptz{Time: from, Valid: true},
+		RangeTo:   pgtype.Timestamptz{Time: to, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check archived partitions: %w", err)
+	}
+	if len(archived) == 0 {
+		return nil
+	}
+
+	rangeErr := &ArchivedRangeError{
+		Table:      table,
+		RangeStart: archived[0].RangeStart.Time,
+		RangeEnd:   archived[len(archived)-1].RangeEnd.Time,
+	}
+	for _, a := range archived {
+		rangeErr.Locations = append(rangeErr.Locations, a.Location)
+	}
+	return rangeErr
+}
+
+// ArchivedUntil returns the time before which the given table's rows in the
+// current schema are only in the archives, or nil if none were archived.
+// Listings without a lower time bound report it, so callers can tell the
+// older rows are missing. Like CheckArchivedRange it must run inside
+// WithTenant for tenant tables.
+func ArchivedUntil(ctx context.Context, q *queries.Queries, table string) (*time.Time, error) {
+	until, err := q.GetArchivedUntil(ctx, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check archived partitions: %w", err)
+	}
+	if !until.Valid {
+		return nil, nil
+	}
+	return &until.Time, nil
+}
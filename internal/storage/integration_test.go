@@ -0,0 +1,252 @@
+// internal/storage/integration_test.go
+// +build integration
+
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/storage"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+	"github.com/temmyjay001/ledger-service/internal/testUtil"
+)
+
+func TestIntegration_WithTenantConcurrentIsolation(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+
+	t.Run("schema", func(t *testing.T) {
+		testConcurrentIsolation(t, db, testutil.CreateTestTenant, testutil.CreateTestTenant)
+	})
+	t.Run("rls", func(t *testing.T) {
+		testConcurrentIsolation(t, db, testutil.CreateTestRLSTenant, testutil.CreateTestRLSTenant)
+	})
+	t.Run("mixed", func(t *testing.T) {
+		testConcurrentIsolation(t, db, testutil.CreateTestTenant, testutil.CreateTestRLSTenant)
+	})
+}
+
+type createTenantFunc func(t *testing.T, db *storage.DB, slug string) queries.Tenant
+
+func testConcurrentIsolation(t *testing.T, admin *storage.DB, createFirst, createSecond createTenantFunc) {
+	// Two tenants with an identically coded account but different names
+	slugs := []string{testutil.RandomSlug(), testutil.RandomSlug()}
+	var schemas []string
+	for i, create := range []createTenantFunc{createFirst, createSecond} {
+		slug := slugs[i]
+		if create(t, admin, slug).TenancyMode == queries.TenancyModeEnumSchema {
+			schemas = append(schemas, storage.GetTenantSchema(slug))
+		}
+		t.Cleanup(func() {
+			testutil.CleanupTestTenant(t, admin, slug)
+		})
+		testutil.CreateTestAccount(t, admin, slug, "1000", "Cash "+slug, queries.AccountTypeEnumAsset)
+	}
+
+	// The test role is a superuser, which row-level security ignores; run the
+	// tenant scopes as a role the policies apply to, as the server must
+	db := testutil.SetupRLSTestDB(t, admin, schemas...)
+	require.NoError(t, db.CheckRowLevelSecurity(context.Background(), true))
+
+	ctx := context.Background()
+	workers := 200
+	errs := make(chan error, workers*2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			slug := slugs[index%len(slugs)]
+
+			// Read and write inside the tenant scope; any leakage from another
+			// request would surface as the wrong account name or a stray row
+			err := db.WithTenant(ctx, slug, func(q *queries.Queries) error {
+				account, err := q.GetAccountByCode(ctx, "1000")
+				if err != nil {
+					return err
+				}
+				if account.Name != "Cash "+slug {
+					return fmt.Errorf("tenant %s read account %q", slug, account.Name)
+				}
+
+				_, err = q.CreateTransaction(ctx, queries.CreateTransactionParams{
+					IdempotencyKey: fmt.Sprintf("%s-%d", slug, index),
+					Description:    "isolation check",
+					Metadata:       []byte("{}"),
+				})
+				return err
+			})
+			if err != nil {
+				errs <- err
+			}
+
+			// Public-schema queries on the shared pool must be unaffected by
+			// tenant scopes running on other connections
+			if _, err := db.Queries.GetTenantBySlug(ctx, slug); err != nil {
+				errs <- fmt.Errorf("public query failed: %w", err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Each tenant must hold exactly its own writes
+	for _, slug := range slugs {
+		var transactions []queries.Transaction
+		err := db.WithTenant(ctx, slug, func(q *queries.Queries) error {
+			var err error
+			transactions, err = q.ListTransactions(ctx, queries.ListTransactionsParams{
+				Limit:  int32(workers),
+				Offset: 0,
+			})
+			return err
+		})
+		require.NoError(t, err)
+		assert.Len(t, transactions, workers/len(slugs))
+
+		for _, transaction := range transactions {
+			assert.Contains(t, transaction.IdempotencyKey, slug)
+		}
+	}
+}
+
+func TestIntegration_CheckRowLevelSecurity(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	// the test role connects as postgres, a superuser exempt from the policies
+	assert.ErrorIs(t, db.CheckRowLevelSecurity(ctx, true), storage.ErrRowLevelSecurityBypassed)
+
+	tenantSlug := testutil.RandomSlug()
+	testutil.CreateTestRLSTenant(t, db, tenantSlug)
+	defer testutil.CleanupTestTenant(t, db, tenantSlug)
+	assert.ErrorIs(t, db.CheckRowLevelSecurity(ctx, false), storage.ErrRowLevelSecurityBypassed)
+
+	assert.NoError(t, testutil.SetupRLSTestDB(t, db).CheckRowLevelSecurity(ctx, true))
+}
+
+func TestIntegration_WithTenantUnknownSchema(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+
+	err := db.WithTenant(context.Background(), testutil.RandomSlug(), func(q *queries.Queries) error {
+		t.Fatal("callback must not run for an unknown tenant")
+		return nil
+	})
+	assert.ErrorIs(t, err, storage.ErrTenantNotFound)
+}
+
+func TestIntegration_MoveTenantRoundTrip(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	tenantSlug := testutil.RandomSlug()
+	testutil.CreateTestTenant(t, db, tenantSlug)
+	defer testutil.CleanupTestTenant(t, db, tenantSlug)
+
+	cash := testutil.CreateTestAccount(t, db, tenantSlug, "1000", "Cash", queries.AccountTypeEnumAsset)
+	revenue := testutil.CreateTestAccount(t, db, tenantSlug, "4000", "Revenue", queries.AccountTypeEnumRevenue)
+	transaction := testutil.CreateTestTransaction(t, db, tenantSlug, "move-1")
+	testutil.CreateTestTransactionLine(t, db, tenantSlug, transaction.ID, cash.ID, decimal.NewFromInt(100), queries.TransactionSideEnumDebit)
+	testutil.CreateTestTransactionLine(t, db, tenantSlug, transaction.ID, revenue.ID, decimal.NewFromInt(100), queries.TransactionSideEnumCredit)
+
+	assertLedger := func(t *testing.T) {
+		err := db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+			accounts, err := q.ListAccounts(ctx)
+			if err != nil {
+				return err
+			}
+			assert.Len(t, accounts, 2)
+
+			lines, err := q.GetTransactionLines(ctx, transaction.ID)
+			if err != nil {
+				return err
+			}
+			assert.Len(t, lines, 2)
+			return nil
+		})
+		require.NoError(t, err)
+		testutil.AssertAccountBalance(t, db, tenantSlug, cash.ID, "NGN", decimal.Zero)
+	}
+
+	// schema -> rls
+	require.NoError(t, db.MoveTenant(ctx, tenantSlug, queries.TenancyModeEnumRls))
+
+	tenant, err := db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	require.NoError(t, err)
+	assert.Equal(t, queries.TenancyModeEnumRls, tenant.TenancyMode)
+
+	var schemaExists bool
+	err = db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pg_namespace WHERE nspname = $1)", storage.GetTenantSchema(tenantSlug)).Scan(&schemaExists)
+	require.NoError(t, err)
+	assert.False(t, schemaExists)
+
+	assertLedger(t)
+
+	// rls -> schema
+	require.NoError(t, db.MoveTenant(ctx, tenantSlug, queries.TenancyModeEnumSchema))
+
+	tenant, err = db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	require.NoError(t, err)
+	assert.Equal(t, queries.TenancyModeEnumSchema, tenant.TenancyMode)
+
+	assertLedger(t)
+
+	// moving again to the current mode is rejected by the database function
+	assert.Error(t, db.MoveTenant(ctx, tenantSlug, queries.TenancyModeEnumSchema))
+}
+
+func TestIntegration_SharedBalanceTotalsViewIsTenantScoped(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	admin := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	// The view is owned by the migrating role, a superuser here, so the
+	// policies on its tables would not apply to reads through it
+	slugs := []string{testutil.RandomSlug(), testutil.RandomSlug()}
+	var accounts []queries.Account
+	for _, slug := range slugs {
+		testutil.CreateTestRLSTenant(t, admin, slug)
+		t.Cleanup(func() {
+			testutil.CleanupTestTenant(t, admin, slug)
+		})
+		accounts = append(accounts, testutil.CreateTestAccount(t, admin, slug, "1000", "Cash "+slug, queries.AccountTypeEnumAsset))
+	}
+
+	db := testutil.SetupRLSTestDB(t, admin)
+	err := db.WithTenant(ctx, slugs[0], func(q *queries.Queries) error {
+		own, err := q.GetAccountBalanceTotal(ctx, queries.GetAccountBalanceTotalParams{
+			AccountID: accounts[0].ID,
+			Currency:  "NGN",
+		})
+		require.NoError(t, err)
+		assert.True(t, own.Balance.Equal(decimal.Zero))
+
+		_, err = q.GetAccountBalanceTotal(ctx, queries.GetAccountBalanceTotalParams{
+			AccountID: accounts[1].ID,
+			Currency:  "NGN",
+		})
+		assert.ErrorIs(t, err, pgx.ErrNoRows, "another tenant's balance must not be visible")
+		return nil
+	})
+	require.NoError(t, err)
+}
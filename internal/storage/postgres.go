@@ -2,14 +2,37 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math/rand/v2"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/temmyjay001/ledger-service/internal/config"
 	"github.com/temmyjay001/ledger-service/internal/storage/queries"
 )
 
+// SharedSchema holds the tenant tables for rls-mode tenants
+const SharedSchema = "shared"
+
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// ErrRowLevelSecurityBypassed means the database role is exempt from the
+// row-level security policies that keep rls-mode tenants apart
+var ErrRowLevelSecurityBypassed = errors.New("database role bypasses row-level security")
+
+// Retry policy for transactions aborted by serialization failures or deadlocks
+const (
+	maxTxAttempts        = 5
+	baseRetryDelay       = 10 * time.Millisecond
+	maxRetryDelay        = 500 * time.Millisecond
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
 type DB struct {
 	*pgxpool.Pool
 	Queries *queries.Queries
@@ -61,26 +84,162 @@ func GetTenantSchema(tenantSlug string) string {
 	return fmt.Sprintf("tenant_%s", tenantSlug)
 }
 
-func (db *DB) WithTenantSchema(ctx context.Context, tenantSlug string, fn func() error) error {
-	conn, err := db.Pool.Acquire(ctx)
+// WithTenant runs fn with a query handle pinned to a single transaction scoped
+// to the tenant. Schema-mode tenants get a search_path pointing at their own
+// schema; rls-mode tenants get the shared tables, scoped by the row-level
+// security policies on app.tenant_id, which is set for both. The settings use
+// SET LOCAL semantics, so they are discarded on commit/rollback and never leak
+// onto other requests sharing the pooled connection.
+//
+// Transactions aborted by a serialization failure or deadlock are rolled back
+// and retried with backoff, so fn may run more than once and must not have
+// side effects outside the database transaction.
+func (db *DB) WithTenant(ctx context.Context, tenantSlug string, fn func(q *queries.Queries) error) error {
+	for attempt := 1; ; attempt++ {
+		err := db.withTenantOnce(ctx, tenantSlug, fn)
+		if err == nil || !IsRetryable(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		backoff := retryBackoff(attempt)
+		log.Printf("Retrying transaction for tenant %s after %v (attempt %d): %v", tenantSlug, backoff, attempt, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (db *DB) withTenantOnce(ctx context.Context, tenantSlug string, fn func(q *queries.Queries) error) error {
+	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to acquire connection: %w", err)
+		return fmt.Errorf("failed to start transaction: %w", err)
 	}
-	defer conn.Release()
+	defer tx.Rollback(ctx)
 
-	schema := GetTenantSchema(tenantSlug)
+	qtx := db.Queries.WithTx(tx)
 
-	// set search_path to tenant schema
-	if _, err := conn.Exec(ctx, fmt.Sprintf("SET search_path TO %s, public", schema)); err != nil {
+	// key-share lock on the tenant row so a concurrent mode move waits for us
+	tenant, err := qtx.GetTenantTenancyMode(ctx, tenantSlug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTenantNotFound
+		}
+		return fmt.Errorf("failed to look up tenant: %w", err)
+	}
+
+	// app.tenant_id drives the rls policies, and in either mode picks out the
+	// archived months the tenant carried along from another schema
+	if _, err := tx.Exec(ctx, "SELECT set_config('app.tenant_id', $1, true)", tenant.ID.String()); err != nil {
+		return fmt.Errorf("failed to set tenant id: %w", err)
+	}
+
+	var searchPath string
+	switch tenant.TenancyMode {
+	case queries.TenancyModeEnumRls:
+		searchPath = SharedSchema + ", public"
+	default:
+		schema := GetTenantSchema(tenantSlug)
+
+		// make sure the schema exists, otherwise unqualified table names would
+		// silently resolve to the public template tables
+		var exists bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pg_namespace WHERE nspname = $1)", schema).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to look up tenant schema: %w", err)
+		}
+		if !exists {
+			return ErrTenantNotFound
+		}
+		searchPath = pgx.Identifier{schema}.Sanitize() + ", public"
+	}
+
+	if _, err := tx.Exec(ctx, "SELECT set_config('search_path', $1, true)", searchPath); err != nil {
 		return fmt.Errorf("failed to set search_path: %w", err)
 	}
-	return fn()
+
+	if err := fn(qtx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
 }
 
-func (db *DB) SetSearchPath(ctx context.Context, schema string) error {
-	query := fmt.Sprintf("SET search_path TO '%s'", schema)
+// MoveTenant moves a tenant's ledger data to the given tenancy mode. The copy
+// runs in a single database transaction that holds the tenant row lock, so
+// requests for the tenant wait for the move and then see the new mode.
+func (db *DB) MoveTenant(ctx context.Context, tenantSlug string, mode queries.TenancyModeEnum) error {
+	var fn string
+	switch mode {
+	case queries.TenancyModeEnumRls:
+		fn = "move_tenant_to_shared"
+	case queries.TenancyModeEnumSchema:
+		fn = "move_tenant_to_schema"
+	default:
+		return fmt.Errorf("unknown tenancy mode: %s", mode)
+	}
+
+	if _, err := db.Exec(ctx, "SELECT "+fn+"($1)", tenantSlug); err != nil {
+		return fmt.Errorf("failed to move tenant to %s mode: %w", mode, err)
+	}
+
+	return nil
+}
+
+// CheckRowLevelSecurity makes sure the role the pool connects as is subject to
+// row-level security whenever rls mode is in use, either because required is
+// set (new tenants default to rls) or because a tenant is already stored in
+// the shared tables. The shared-table queries don't filter on tenant_id, so a
+// superuser or BYPASSRLS role would see every tenant's rows without any error,
+// FORCE ROW LEVEL SECURITY notwithstanding.
+func (db *DB) CheckRowLevelSecurity(ctx context.Context, required bool) error {
+	if !required {
+		if err := db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM tenants WHERE tenancy_mode = 'rls')").Scan(&required); err != nil {
+			return fmt.Errorf("failed to look up rls-mode tenants: %w", err)
+		}
+		if !required {
+			return nil
+		}
+	}
+
+	var role string
+	var superuser, bypassRLS bool
+	err := db.QueryRow(ctx, "SELECT rolname, rolsuper, rolbypassrls FROM pg_roles WHERE rolname = current_user").Scan(&role, &superuser, &bypassRLS)
+	if err != nil {
+		return fmt.Errorf("failed to look up database role: %w", err)
+	}
 
-	_, err := db.Pool.Exec(ctx, query)
+	switch {
+	case superuser:
+		return fmt.Errorf("%w: %s is a superuser", ErrRowLevelSecurityBypassed, role)
+	case bypassRLS:
+		return fmt.Errorf("%w: %s has BYPASSRLS", ErrRowLevelSecurityBypassed, role)
+	}
+
+	return nil
+}
 
-	return err
+// IsRetryable reports whether err aborted a transaction that can safely be
+// retried from the start
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected
+}
+
+// retryBackoff returns an exponential delay with full jitter, so transactions
+// that collided don't retry in lockstep
+func retryBackoff(attempt int) time.Duration {
+	delay := baseRetryDelay << (attempt - 1)
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return time.Duration(rand.Int64N(int64(delay))) + time.Millisecond
 }
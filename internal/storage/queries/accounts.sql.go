@@ -15,6 +15,38 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const addToAccountBalanceSlot = `-- name: AddToAccountBalanceSlot :exec
+INSERT INTO account_balance_slots (
+    account_id,
+    currency,
+    slot,
+    balance
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (account_id, currency, slot) DO UPDATE
+SET
+    balance = account_balance_slots.balance + EXCLUDED.balance,
+    updated_at = NOW()
+`
+
+type AddToAccountBalanceSlotParams struct {
+	AccountID uuid.UUID       `db:"account_id" json:"account_id"`
+	Currency  string          `db:"currency" json:"currency"`
+	Slot      int32           `db:"slot" json:"slot"`
+	Balance   decimal.Decimal `db:"balance" json:"balance"`
+}
+
+func (q *Queries) AddToAccountBalanceSlot(ctx context.Context, arg AddToAccountBalanceSlotParams) error {
+	_, err := q.db.Exec(ctx, addToAccountBalanceSlot,
+		arg.AccountID,
+		arg.Currency,
+		arg.Slot,
+		arg.Balance,
+	)
+	return err
+}
+
 const createAccount = `-- name: CreateAccount :one
 
 INSERT INTO accounts (
@@ -123,6 +155,88 @@ func (q *Queries) DeactivateAccount(ctx context.Context, id uuid.UUID) (Account,
 	return i, err
 }
 
+const deleteHighVolumeAccount = `-- name: DeleteHighVolumeAccount :exec
+DELETE FROM high_volume_accounts
+WHERE account_id = $1
+`
+
+func (q *Queries) DeleteHighVolumeAccount(ctx context.Context, accountID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteHighVolumeAccount, accountID)
+	return err
+}
+
+const ensureAccountBalance = `-- name: EnsureAccountBalance :exec
+
+INSERT INTO account_balances (
+    account_id,
+    currency,
+    balance
+) VALUES (
+    $1, $2, 0
+) ON CONFLICT (account_id, currency) DO NOTHING
+`
+
+type EnsureAccountBalanceParams struct {
+	AccountID uuid.UUID `db:"account_id" json:"account_id"`
+	Currency  string    `db:"currency" json:"currency"`
+}
+
+// Creates a zero balance unless the account already has one in the currency.
+// Concurrent first postings wait for each other here instead of failing with
+// a unique violation.
+func (q *Queries) EnsureAccountBalance(ctx context.Context, arg EnsureAccountBalanceParams) error {
+	_, err := q.db.Exec(ctx, ensureAccountBalance, arg.AccountID, arg.Currency)
+	return err
+}
+
+const foldAccountBalanceSlots = `-- name: FoldAccountBalanceSlots :many
+
+WITH folded AS (
+    DELETE FROM account_balance_slots
+    WHERE account_balance_slots.account_id = $1
+    RETURNING currency, balance
+)
+UPDATE account_balances ab
+SET
+    balance = ab.balance + f.total,
+    updated_at = NOW()
+FROM (
+    SELECT currency, SUM(balance) AS total
+    FROM folded
+    GROUP BY currency
+) f
+WHERE ab.account_id = $1 AND ab.currency = f.currency
+RETURNING ab.account_id, ab.currency, ab.balance, ab.version, ab.updated_at
+`
+
+// moves the slots into the base rows; the totals don't change, so no
+// balance.updated event is published and the versions are left alone
+func (q *Queries) FoldAccountBalanceSlots(ctx context.Context, accountID uuid.UUID) ([]AccountBalance, error) {
+	rows, err := q.db.Query(ctx, foldAccountBalanceSlots, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountBalance{}
+	for rows.Next() {
+		var i AccountBalance
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Currency,
+			&i.Balance,
+			&i.Version,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAccountBalance = `-- name: GetAccountBalance :one
 SELECT account_id, currency, balance, version, updated_at FROM account_balances
 WHERE account_id = $1 AND currency = $2
@@ -223,7 +337,7 @@ SELECT
     SUM(ab.balance) as total_balance,
     COUNT(a.id) as account_count
 FROM accounts a
-JOIN account_balances ab ON a.id = ab.account_id
+JOIN account_balance_totals ab ON a.id = ab.account_id
 WHERE a.is_active = true
 GROUP BY a.account_type, ab.currency
 ORDER BY a.account_type, ab.currency
@@ -261,6 +375,61 @@ func (q *Queries) GetAccountBalanceSummary(ctx context.Context) ([]GetAccountBal
 	return items, nil
 }
 
+const getAccountBalanceTotal = `-- name: GetAccountBalanceTotal :one
+SELECT account_id, currency, balance, version, updated_at FROM account_balance_totals
+WHERE account_id = $1 AND currency = $2
+`
+
+type GetAccountBalanceTotalParams struct {
+	AccountID uuid.UUID `db:"account_id" json:"account_id"`
+	Currency  string    `db:"currency" json:"currency"`
+}
+
+func (q *Queries) GetAccountBalanceTotal(ctx context.Context, arg GetAccountBalanceTotalParams) (AccountBalanceTotal, error) {
+	row := q.db.QueryRow(ctx, getAccountBalanceTotal, arg.AccountID, arg.Currency)
+	var i AccountBalanceTotal
+	err := row.Scan(
+		&i.AccountID,
+		&i.Currency,
+		&i.Balance,
+		&i.Version,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getAccountBalanceTotals = `-- name: GetAccountBalanceTotals :many
+SELECT account_id, currency, balance, version, updated_at FROM account_balance_totals
+WHERE account_id = $1
+ORDER BY currency
+`
+
+func (q *Queries) GetAccountBalanceTotals(ctx context.Context, accountID uuid.UUID) ([]AccountBalanceTotal, error) {
+	rows, err := q.db.Query(ctx, getAccountBalanceTotals, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountBalanceTotal{}
+	for rows.Next() {
+		var i AccountBalanceTotal
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Currency,
+			&i.Balance,
+			&i.Version,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAccountBalances = `-- name: GetAccountBalances :many
 SELECT account_id, currency, balance, version, updated_at FROM account_balances
 WHERE account_id = $1
@@ -339,6 +508,32 @@ func (q *Queries) GetAccountByID(ctx context.Context, id uuid.UUID) (Account, er
 	return i, err
 }
 
+const getAccountForUpdate = `-- name: GetAccountForUpdate :one
+
+SELECT id, code, name, account_type, parent_id, currency, metadata, is_active, created_at, updated_at FROM accounts
+WHERE id = $1
+FOR UPDATE
+`
+
+// locks an account, active or not, for a change that records its prior state
+func (q *Queries) GetAccountForUpdate(ctx context.Context, id uuid.UUID) (Account, error) {
+	row := q.db.QueryRow(ctx, getAccountForUpdate, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.AccountType,
+		&i.ParentID,
+		&i.Currency,
+		&i.Metadata,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getAccountHierarchy = `-- name: GetAccountHierarchy :many
 WITH RECURSIVE account_hierarchy AS (
     -- Base case: start with root accounts (no parent)
@@ -477,7 +672,7 @@ SELECT
     ab.version as balance_version,
     ab.updated_at as balance_updated_at
 FROM accounts a
-LEFT JOIN account_balances ab ON a.id = ab.account_id AND ab.currency = $2
+LEFT JOIN account_balance_totals ab ON a.id = ab.account_id AND ab.currency = $2
 WHERE a.id = $1 AND a.is_active = true
 `
 
@@ -532,36 +727,36 @@ SELECT
     COUNT(DISTINCT a.id)::bigint as total_accounts,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE a2.account_type = 'asset' AND a2.is_active = true
     ) as total_assets,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE a2.account_type = 'liability' AND a2.is_active = true
     ) as total_liabilities,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE a2.account_type = 'equity' AND a2.is_active = true
     ) as total_equity,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE a2.account_type = 'revenue' AND a2.is_active = true
     ) as total_revenue,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE a2.account_type = 'expense' AND a2.is_active = true
     ) as total_expenses,
     NOW() as generated_at
-FROM account_balances ab
+FROM account_balance_totals ab
 JOIN accounts a ON ab.account_id = a.id
 WHERE a.is_active = true
 LIMIT 1
@@ -603,7 +798,7 @@ SELECT
     COALESCE(AVG(ab.balance), 0::numeric(20,4)) as average_balance,
     COALESCE(MIN(ab.balance), 0::numeric(20,4)) as minimum_balance,
     COALESCE(MAX(ab.balance), 0::numeric(20,4)) as maximum_balance
-FROM account_balances ab
+FROM account_balance_totals ab
 JOIN accounts a ON ab.account_id = a.id
 WHERE a.is_active = true
   AND ($1::text IS NULL OR ab.currency = $1)
@@ -655,36 +850,36 @@ SELECT
     COUNT(DISTINCT a.id)::bigint as total_accounts,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE ab2.currency = $1 AND a2.account_type = 'asset' AND a2.is_active = true
     ) as total_assets,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE ab2.currency = $1 AND a2.account_type = 'liability' AND a2.is_active = true
     ) as total_liabilities,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE ab2.currency = $1 AND a2.account_type = 'equity' AND a2.is_active = true
     ) as total_equity,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE ab2.currency = $1 AND a2.account_type = 'revenue' AND a2.is_active = true
     ) as total_revenue,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE ab2.currency = $1 AND a2.account_type = 'expense' AND a2.is_active = true
     ) as total_expenses,
     NOW() as generated_at
-FROM account_balances ab
+FROM account_balance_totals ab
 JOIN accounts a ON ab.account_id = a.id
 WHERE ab.currency = $1 AND a.is_active = true
 LIMIT 1
@@ -717,6 +912,28 @@ func (q *Queries) GetBalanceSummaryByCurrency(ctx context.Context, dollar_1 stri
 	return i, err
 }
 
+const getHighVolumeAccount = `-- name: GetHighVolumeAccount :one
+
+SELECT account_id, slot_count, overdraft_protection, created_at, updated_at FROM high_volume_accounts
+WHERE account_id = $1
+FOR KEY SHARE
+`
+
+// High-Volume Account Operations
+// key-share lock so disabling waits for in-flight slot postings
+func (q *Queries) GetHighVolumeAccount(ctx context.Context, accountID uuid.UUID) (HighVolumeAccount, error) {
+	row := q.db.QueryRow(ctx, getHighVolumeAccount, accountID)
+	var i HighVolumeAccount
+	err := row.Scan(
+		&i.AccountID,
+		&i.SlotCount,
+		&i.OverdraftProtection,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const listAccountBalancesByCurrency = `-- name: ListAccountBalancesByCurrency :many
 SELECT 
     a.id as account_id,
@@ -728,7 +945,7 @@ SELECT
     ab.version,
     ab.updated_at as balance_updated_at
 FROM accounts a
-LEFT JOIN account_balances ab ON a.id = ab.account_id
+LEFT JOIN account_balance_totals ab ON a.id = ab.account_id
 WHERE ab.currency = $1 AND a.is_active = true
 ORDER BY a.code
 `
@@ -944,7 +1161,7 @@ SELECT
         '[]'::json
     ) as balances
 FROM accounts a
-LEFT JOIN account_balances ab ON a.id = ab.account_id
+LEFT JOIN account_balance_totals ab ON a.id = ab.account_id
 WHERE a.is_active = true
 GROUP BY a.id, a.code, a.name, a.account_type, a.parent_id, a.currency, a.metadata, a.created_at
 ORDER BY a.code
@@ -1109,6 +1326,41 @@ func (q *Queries) UpdateAccountBalance(ctx context.Context, arg UpdateAccountBal
 	return i, err
 }
 
+const upsertHighVolumeAccount = `-- name: UpsertHighVolumeAccount :one
+INSERT INTO high_volume_accounts (
+    account_id,
+    slot_count,
+    overdraft_protection
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (account_id) DO UPDATE
+SET
+    slot_count = EXCLUDED.slot_count,
+    overdraft_protection = EXCLUDED.overdraft_protection,
+    updated_at = NOW()
+RETURNING account_id, slot_count, overdraft_protection, created_at, updated_at
+`
+
+type UpsertHighVolumeAccountParams struct {
+	AccountID           uuid.UUID `db:"account_id" json:"account_id"`
+	SlotCount           int32     `db:"slot_count" json:"slot_count"`
+	OverdraftProtection bool      `db:"overdraft_protection" json:"overdraft_protection"`
+}
+
+func (q *Queries) UpsertHighVolumeAccount(ctx context.Context, arg UpsertHighVolumeAccountParams) (HighVolumeAccount, error) {
+	row := q.db.QueryRow(ctx, upsertHighVolumeAccount, arg.AccountID, arg.SlotCount, arg.OverdraftProtection)
+	var i HighVolumeAccount
+	err := row.Scan(
+		&i.AccountID,
+		&i.SlotCount,
+		&i.OverdraftProtection,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const validateAccountCode = `-- name: ValidateAccountCode :one
 SELECT EXISTS(
     SELECT 1 FROM accounts 
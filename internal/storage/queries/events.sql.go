@@ -17,20 +17,21 @@ const createEvent = `-- name: CreateEvent :one
 
 INSERT INTO events (
     tenant_id, aggregate_id, aggregate_type, event_type, 
-    event_version, event_data, metadata
+    event_version, event_data, metadata, aggregate_version
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
-) RETURNING event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number, tx_id, aggregate_version
 `
 
 type CreateEventParams struct {
-	TenantID      uuid.UUID       `db:"tenant_id" json:"tenant_id"`
-	AggregateID   uuid.UUID       `db:"aggregate_id" json:"aggregate_id"`
-	AggregateType string          `db:"aggregate_type" json:"aggregate_type"`
-	EventType     string          `db:"event_type" json:"event_type"`
-	EventVersion  int32           `db:"event_version" json:"event_version"`
-	EventData     json.RawMessage `db:"event_data" json:"event_data"`
-	Metadata      json.RawMessage `db:"metadata" json:"metadata"`
+	TenantID         uuid.UUID       `db:"tenant_id" json:"tenant_id"`
+	AggregateID      uuid.UUID       `db:"aggregate_id" json:"aggregate_id"`
+	AggregateType    string          `db:"aggregate_type" json:"aggregate_type"`
+	EventType        string          `db:"event_type" json:"event_type"`
+	EventVersion     int32           `db:"event_version" json:"event_version"`
+	EventData        json.RawMessage `db:"event_data" json:"event_data"`
+	Metadata         json.RawMessage `db:"metadata" json:"metadata"`
+	AggregateVersion pgtype.Int8     `db:"aggregate_version" json:"aggregate_version"`
 }
 
 // sql/queries/events.sql
@@ -43,6 +44,7 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		arg.EventVersion,
 		arg.EventData,
 		arg.Metadata,
+		arg.AggregateVersion,
 	)
 	var i Event
 	err := row.Scan(
@@ -56,12 +58,14 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.SequenceNumber,
+		&i.TxID,
+		&i.AggregateVersion,
 	)
 	return i, err
 }
 
 const getEventByID = `-- name: GetEventByID :one
-SELECT event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number FROM events 
+SELECT event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number, tx_id, aggregate_version FROM events 
 WHERE tenant_id = $1 AND event_id = $2 LIMIT 1
 `
 
@@ -84,12 +88,27 @@ func (q *Queries) GetEventByID(ctx context.Context, arg GetEventByIDParams) (Eve
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.SequenceNumber,
+		&i.TxID,
+		&i.AggregateVersion,
 	)
 	return i, err
 }
 
+const getEventStreamHead = `-- name: GetEventStreamHead :one
+
+SELECT pg_snapshot_xmin(pg_current_snapshot())::xid8 AS tx_id
+`
+
+// the outbox position behind which every event has been committed
+func (q *Queries) GetEventStreamHead(ctx context.Context) (uint64, error) {
+	row := q.db.QueryRow(ctx, getEventStreamHead)
+	var tx_id uint64
+	err := row.Scan(&tx_id)
+	return tx_id, err
+}
+
 const getEventsAfterSequence = `-- name: GetEventsAfterSequence :many
-SELECT event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number FROM events 
+SELECT event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number, tx_id, aggregate_version FROM events 
 WHERE sequence_number > $1
 ORDER BY sequence_number ASC
 LIMIT $2
@@ -120,6 +139,8 @@ func (q *Queries) GetEventsAfterSequence(ctx context.Context, arg GetEventsAfter
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.SequenceNumber,
+			&i.TxID,
+			&i.AggregateVersion,
 		); err != nil {
 			return nil, err
 		}
@@ -132,9 +153,9 @@ func (q *Queries) GetEventsAfterSequence(ctx context.Context, arg GetEventsAfter
 }
 
 const getEventsByAggregate = `-- name: GetEventsByAggregate :many
-SELECT event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number FROM events 
+SELECT event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number, tx_id, aggregate_version FROM events 
 WHERE tenant_id = $1 AND aggregate_id = $2
-ORDER BY event_version ASC
+ORDER BY aggregate_version ASC, sequence_number ASC
 `
 
 type GetEventsByAggregateParams struct {
@@ -162,6 +183,8 @@ func (q *Queries) GetEventsByAggregate(ctx context.Context, arg GetEventsByAggre
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.SequenceNumber,
+			&i.TxID,
+			&i.AggregateVersion,
 		); err != nil {
 			return nil, err
 		}
@@ -174,7 +197,7 @@ func (q *Queries) GetEventsByAggregate(ctx context.Context, arg GetEventsByAggre
 }
 
 const getEventsByType = `-- name: GetEventsByType :many
-SELECT event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number FROM events 
+SELECT event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number, tx_id, aggregate_version FROM events 
 WHERE tenant_id = $1 AND event_type = $2
 ORDER BY created_at DESC
 LIMIT $3 OFFSET $4
@@ -212,6 +235,201 @@ func (q *Queries) GetEventsByType(ctx context.Context, arg GetEventsByTypeParams
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.SequenceNumber,
+			&i.TxID,
+			&i.AggregateVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOutboxEvents = `-- name: GetOutboxEvents :many
+
+SELECT event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number, tx_id, aggregate_version FROM events
+WHERE (tx_id, sequence_number) > ($1::xid8, $2::bigint)
+AND tx_id < pg_snapshot_xmin(pg_current_snapshot())
+ORDER BY tx_id, sequence_number
+LIMIT $3
+`
+
+type GetOutboxEventsParams struct {
+	AfterTxID     uint64 `db:"after_tx_id" json:"after_tx_id"`
+	AfterSequence int64  `db:"after_sequence" json:"after_sequence"`
+	BatchSize     int32  `db:"batch_size" json:"batch_size"`
+}
+
+// events after a consumer's cursor, limited to transactions older than every
+// running one so nothing can still appear behind the cursor
+func (q *Queries) GetOutboxEvents(ctx context.Context, arg GetOutboxEventsParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getOutboxEvents, arg.AfterTxID, arg.AfterSequence, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.EventID,
+			&i.TenantID,
+			&i.AggregateID,
+			&i.AggregateType,
+			&i.EventType,
+			&i.EventVersion,
+			&i.EventData,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.SequenceNumber,
+			&i.TxID,
+			&i.AggregateVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTenantEventsAfterCursor = `-- name: GetTenantEventsAfterCursor :many
+
+SELECT event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number, tx_id, aggregate_version FROM events
+WHERE tenant_id = $1
+AND (tx_id, sequence_number) > ($2::xid8, $3::bigint)
+AND tx_id < pg_snapshot_xmin(pg_current_snapshot())
+AND ($4::TEXT[] IS NULL OR event_type = ANY($4::TEXT[]))
+AND ($5::UUID IS NULL OR aggregate_id = $5::UUID)
+ORDER BY tx_id, sequence_number
+LIMIT $6
+`
+
+type GetTenantEventsAfterCursorParams struct {
+	TenantID      uuid.UUID   `db:"tenant_id" json:"tenant_id"`
+	AfterTxID     uint64      `db:"after_tx_id" json:"after_tx_id"`
+	AfterSequence int64       `db:"after_sequence" json:"after_sequence"`
+	EventTypes    []string    `db:"event_types" json:"event_types"`
+	AggregateID   pgtype.UUID `db:"aggregate_id" json:"aggregate_id"`
+	BatchSize     int32       `db:"batch_size" json:"batch_size"`
+}
+
+// a tenant's events after a stream cursor, read in outbox order and limited
+// like GetOutboxEvents to transactions older than every running one
+func (q *Queries) GetTenantEventsAfterCursor(ctx context.Context, arg GetTenantEventsAfterCursorParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getTenantEventsAfterCursor,
+		arg.TenantID,
+		arg.AfterTxID,
+		arg.AfterSequence,
+		arg.EventTypes,
+		arg.AggregateID,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.EventID,
+			&i.TenantID,
+			&i.AggregateID,
+			&i.AggregateType,
+			&i.EventType,
+			&i.EventVersion,
+			&i.EventData,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.SequenceNumber,
+			&i.TxID,
+			&i.AggregateVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEvents = `-- name: ListEvents :many
+
+SELECT event_id, tenant_id, aggregate_id, aggregate_type, event_type, event_version, event_data, metadata, created_at, sequence_number, tx_id, aggregate_version FROM events
+WHERE tenant_id = $1
+  AND ($2::VARCHAR IS NULL OR event_type = $2::VARCHAR)
+  AND ($3::VARCHAR IS NULL OR aggregate_type = $3::VARCHAR)
+  AND ($4::UUID IS NULL OR aggregate_id = $4::UUID)
+  AND ($5::BIGINT IS NULL OR sequence_number >= $5::BIGINT)
+  AND ($6::BIGINT IS NULL OR sequence_number <= $6::BIGINT)
+  AND ($7::TIMESTAMPTZ IS NULL OR created_at >= $7::TIMESTAMPTZ)
+  AND ($8::TIMESTAMPTZ IS NULL OR created_at < $8::TIMESTAMPTZ)
+  AND ($9::BIGINT IS NULL OR sequence_number > $9::BIGINT)
+  AND ($10::UUID IS NULL
+       OR (aggregate_type = 'account' AND aggregate_id = $10::UUID)
+       OR (aggregate_type = 'balance' AND event_data->>'account_id' = $10::UUID::TEXT))
+ORDER BY sequence_number
+LIMIT $11
+`
+
+type ListEventsParams struct {
+	TenantID      uuid.UUID          `db:"tenant_id" json:"tenant_id"`
+	EventType     pgtype.Text        `db:"event_type" json:"event_type"`
+	AggregateType pgtype.Text        `db:"aggregate_type" json:"aggregate_type"`
+	AggregateID   pgtype.UUID        `db:"aggregate_id" json:"aggregate_id"`
+	FromSequence  pgtype.Int8        `db:"from_sequence" json:"from_sequence"`
+	ToSequence    pgtype.Int8        `db:"to_sequence" json:"to_sequence"`
+	CreatedFrom   pgtype.Timestamptz `db:"created_from" json:"created_from"`
+	CreatedTo     pgtype.Timestamptz `db:"created_to" json:"created_to"`
+	AfterSequence pgtype.Int8        `db:"after_sequence" json:"after_sequence"`
+	AccountID     pgtype.UUID        `db:"account_id" json:"account_id"`
+	MaxResults    int32              `db:"max_results" json:"max_results"`
+}
+
+// a page of a tenant's event history in sequence order, continuing after the
+// previous page's last sequence number
+func (q *Queries) ListEvents(ctx context.Context, arg ListEventsParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, listEvents,
+		arg.TenantID,
+		arg.EventType,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.FromSequence,
+		arg.ToSequence,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterSequence,
+		arg.AccountID,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.EventID,
+			&i.TenantID,
+			&i.AggregateID,
+			&i.AggregateType,
+			&i.EventType,
+			&i.EventVersion,
+			&i.EventData,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.SequenceNumber,
+			&i.TxID,
+			&i.AggregateVersion,
 		); err != nil {
 			return nil, err
 		}
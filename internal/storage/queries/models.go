@@ -82,6 +82,64 @@ func AllAccountTypeEnumValues() []AccountTypeEnum {
 	}
 }
 
+type TenancyModeEnum string
+
+const (
+	TenancyModeEnumSchema TenancyModeEnum = "schema"
+	TenancyModeEnumRls    TenancyModeEnum = "rls"
+)
+
+func (e *TenancyModeEnum) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TenancyModeEnum(s)
+	case string:
+		*e = TenancyModeEnum(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TenancyModeEnum: %T", src)
+	}
+	return nil
+}
+
+type NullTenancyModeEnum struct {
+	TenancyModeEnum TenancyModeEnum `json:"tenancy_mode_enum"`
+	Valid           bool            `json:"valid"` // Valid is true if TenancyModeEnum is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTenancyModeEnum) Scan(value interface{}) error {
+	if value == nil {
+		ns.TenancyModeEnum, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TenancyModeEnum.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTenancyModeEnum) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TenancyModeEnum), nil
+}
+
+func (e TenancyModeEnum) Valid() bool {
+	switch e {
+	case TenancyModeEnumSchema,
+		TenancyModeEnumRls:
+		return true
+	}
+	return false
+}
+
+func AllTenancyModeEnumValues() []TenancyModeEnum {
+	return []TenancyModeEnum{
+		TenancyModeEnumSchema,
+		TenancyModeEnumRls,
+	}
+}
+
 type TransactionSideEnum string
 
 const (
@@ -349,6 +407,23 @@ type AccountBalance struct {
 	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
 }
 
+// Template table for sqlc generation - actual data is in tenant schemas
+type AccountBalanceSlot struct {
+	AccountID uuid.UUID       `db:"account_id" json:"account_id"`
+	Currency  string          `db:"currency" json:"currency"`
+	Slot      int32           `db:"slot" json:"slot"`
+	Balance   decimal.Decimal `db:"balance" json:"balance"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+type AccountBalanceTotal struct {
+	AccountID uuid.UUID       `db:"account_id" json:"account_id"`
+	Currency  string          `db:"currency" json:"currency"`
+	Balance   decimal.Decimal `db:"balance" json:"balance"`
+	Version   int64           `db:"version" json:"version"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
 type ApiKey struct {
 	ID         uuid.UUID          `db:"id" json:"id"`
 	TenantID   uuid.UUID          `db:"tenant_id" json:"tenant_id"`
@@ -361,17 +436,136 @@ type ApiKey struct {
 	CreatedAt  time.Time          `db:"created_at" json:"created_at"`
 }
 
+type ArchivedPartition struct {
+	ID            uuid.UUID          `db:"id" json:"id"`
+	SchemaName    string             `db:"schema_name" json:"schema_name"`
+	TableName     string             `db:"table_name" json:"table_name"`
+	PartitionName string             `db:"partition_name" json:"partition_name"`
+	RangeStart    pgtype.Timestamptz `db:"range_start" json:"range_start"`
+	RangeEnd      pgtype.Timestamptz `db:"range_end" json:"range_end"`
+	RowCount      int64              `db:"row_count" json:"row_count"`
+	Location      string             `db:"location" json:"location"`
+	ArchivedAt    pgtype.Timestamptz `db:"archived_at" json:"archived_at"`
+	TenantID      *uuid.UUID         `db:"tenant_id" json:"tenant_id"`
+}
+
 type Event struct {
-	EventID        uuid.UUID       `db:"event_id" json:"event_id"`
-	TenantID       uuid.UUID       `db:"tenant_id" json:"tenant_id"`
-	AggregateID    uuid.UUID       `db:"aggregate_id" json:"aggregate_id"`
-	AggregateType  string          `db:"aggregate_type" json:"aggregate_type"`
-	EventType      string          `db:"event_type" json:"event_type"`
-	EventVersion   int32           `db:"event_version" json:"event_version"`
-	EventData      json.RawMessage `db:"event_data" json:"event_data"`
-	Metadata       json.RawMessage `db:"metadata" json:"metadata"`
-	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
-	SequenceNumber pgtype.Int8     `db:"sequence_number" json:"sequence_number"`
+	EventID          uuid.UUID       `db:"event_id" json:"event_id"`
+	TenantID         uuid.UUID       `db:"tenant_id" json:"tenant_id"`
+	AggregateID      uuid.UUID       `db:"aggregate_id" json:"aggregate_id"`
+	AggregateType    string          `db:"aggregate_type" json:"aggregate_type"`
+	EventType        string          `db:"event_type" json:"event_type"`
+	EventVersion     int32           `db:"event_version" json:"event_version"`
+	EventData        json.RawMessage `db:"event_data" json:"event_data"`
+	Metadata         json.RawMessage `db:"metadata" json:"metadata"`
+	CreatedAt        time.Time       `db:"created_at" json:"created_at"`
+	SequenceNumber   pgtype.Int8     `db:"sequence_number" json:"sequence_number"`
+	TxID             uint64          `db:"tx_id" json:"tx_id"`
+	AggregateVersion pgtype.Int8     `db:"aggregate_version" json:"aggregate_version"`
+}
+
+type EventAggregateVersion struct {
+	AggregateID uuid.UUID `db:"aggregate_id" json:"aggregate_id"`
+	Version     int64     `db:"version" json:"version"`
+	TenantID    uuid.UUID `db:"tenant_id" json:"tenant_id"`
+	EventID     uuid.UUID `db:"event_id" json:"event_id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+type EventConsumer struct {
+	Name         string    `db:"name" json:"name"`
+	LastTxID     uint64    `db:"last_tx_id" json:"last_tx_id"`
+	LastSequence int64     `db:"last_sequence" json:"last_sequence"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Template table for sqlc generation - actual data is in tenant schemas
+type HighVolumeAccount struct {
+	AccountID           uuid.UUID `db:"account_id" json:"account_id"`
+	SlotCount           int32     `db:"slot_count" json:"slot_count"`
+	OverdraftProtection bool      `db:"overdraft_protection" json:"overdraft_protection"`
+	CreatedAt           time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type SharedAccount struct {
+	ID          uuid.UUID       `db:"id" json:"id"`
+	TenantID    uuid.UUID       `db:"tenant_id" json:"tenant_id"`
+	Code        string          `db:"code" json:"code"`
+	Name        string          `db:"name" json:"name"`
+	AccountType AccountTypeEnum `db:"account_type" json:"account_type"`
+	ParentID    *uuid.UUID      `db:"parent_id" json:"parent_id"`
+	Currency    string          `db:"currency" json:"currency"`
+	Metadata    json.RawMessage `db:"metadata" json:"metadata"`
+	IsActive    bool            `db:"is_active" json:"is_active"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+type SharedAccountBalance struct {
+	AccountID uuid.UUID       `db:"account_id" json:"account_id"`
+	TenantID  uuid.UUID       `db:"tenant_id" json:"tenant_id"`
+	Currency  string          `db:"currency" json:"currency"`
+	Balance   decimal.Decimal `db:"balance" json:"balance"`
+	Version   int64           `db:"version" json:"version"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+type SharedAccountBalanceSlot struct {
+	AccountID uuid.UUID       `db:"account_id" json:"account_id"`
+	TenantID  uuid.UUID       `db:"tenant_id" json:"tenant_id"`
+	Currency  string          `db:"currency" json:"currency"`
+	Slot      int32           `db:"slot" json:"slot"`
+	Balance   decimal.Decimal `db:"balance" json:"balance"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+type SharedAccountBalanceTotal struct {
+	AccountID uuid.UUID       `db:"account_id" json:"account_id"`
+	Currency  string          `db:"currency" json:"currency"`
+	Balance   decimal.Decimal `db:"balance" json:"balance"`
+	Version   int64           `db:"version" json:"version"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+type SharedHighVolumeAccount struct {
+	AccountID           uuid.UUID `db:"account_id" json:"account_id"`
+	TenantID            uuid.UUID `db:"tenant_id" json:"tenant_id"`
+	SlotCount           int32     `db:"slot_count" json:"slot_count"`
+	OverdraftProtection bool      `db:"overdraft_protection" json:"overdraft_protection"`
+	CreatedAt           time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type SharedTransaction struct {
+	ID             uuid.UUID                 `db:"id" json:"id"`
+	TenantID       uuid.UUID                 `db:"tenant_id" json:"tenant_id"`
+	IdempotencyKey string                    `db:"idempotency_key" json:"idempotency_key"`
+	Description    string                    `db:"description" json:"description"`
+	Reference      pgtype.Text               `db:"reference" json:"reference"`
+	Status         NullTransactionStatusEnum `db:"status" json:"status"`
+	PostedAt       time.Time                 `db:"posted_at" json:"posted_at"`
+	Metadata       json.RawMessage           `db:"metadata" json:"metadata"`
+	CreatedAt      time.Time                 `db:"created_at" json:"created_at"`
+}
+
+type SharedTransactionIdempotencyKey struct {
+	TenantID       uuid.UUID `db:"tenant_id" json:"tenant_id"`
+	IdempotencyKey string    `db:"idempotency_key" json:"idempotency_key"`
+	TransactionID  uuid.UUID `db:"transaction_id" json:"transaction_id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+type SharedTransactionLine struct {
+	ID            uuid.UUID           `db:"id" json:"id"`
+	TenantID      uuid.UUID           `db:"tenant_id" json:"tenant_id"`
+	TransactionID uuid.UUID           `db:"transaction_id" json:"transaction_id"`
+	AccountID     uuid.UUID           `db:"account_id" json:"account_id"`
+	Amount        decimal.Decimal     `db:"amount" json:"amount"`
+	Side          TransactionSideEnum `db:"side" json:"side"`
+	Currency      string              `db:"currency" json:"currency"`
+	Metadata      json.RawMessage     `db:"metadata" json:"metadata"`
+	CreatedAt     time.Time           `db:"created_at" json:"created_at"`
 }
 
 type Tenant struct {
@@ -385,6 +579,7 @@ type Tenant struct {
 	Metadata     json.RawMessage `db:"metadata" json:"metadata"`
 	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time       `db:"updated_at" json:"updated_at"`
+	TenancyMode  TenancyModeEnum `db:"tenancy_mode" json:"tenancy_mode"`
 }
 
 type TenantUser struct {
@@ -408,6 +603,13 @@ type Transaction struct {
 	CreatedAt      time.Time                 `db:"created_at" json:"created_at"`
 }
 
+// Template table for sqlc generation - actual data is in tenant schemas
+type TransactionIdempotencyKey struct {
+	IdempotencyKey string    `db:"idempotency_key" json:"idempotency_key"`
+	TransactionID  uuid.UUID `db:"transaction_id" json:"transaction_id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
 // Template table for sqlc generation - actual data is in tenant schemas
 type TransactionLine struct {
 	ID            uuid.UUID           `db:"id" json:"id"`
@@ -439,7 +641,6 @@ type WebhookDelivery struct {
 	ID             uuid.UUID          `db:"id" json:"id"`
 	TenantID       uuid.UUID          `db:"tenant_id" json:"tenant_id"`
 	EventID        uuid.UUID          `db:"event_id" json:"event_id"`
-	WebhookUrl     string             `db:"webhook_url" json:"webhook_url"`
 	HttpStatusCode pgtype.Int4        `db:"http_status_code" json:"http_status_code"`
 	ResponseBody   pgtype.Text        `db:"response_body" json:"response_body"`
 	Attempts       pgtype.Int4        `db:"attempts" json:"attempts"`
@@ -448,4 +649,91 @@ type WebhookDelivery struct {
 	DeliveredAt    pgtype.Timestamptz `db:"delivered_at" json:"delivered_at"`
 	FailedAt       pgtype.Timestamptz `db:"failed_at" json:"failed_at"`
 	CreatedAt      time.Time          `db:"created_at" json:"created_at"`
+	EndpointID     uuid.UUID          `db:"endpoint_id" json:"endpoint_id"`
+	EventType      string             `db:"event_type" json:"event_type"`
+	DeadLetteredAt pgtype.Timestamptz `db:"dead_lettered_at" json:"dead_lettered_at"`
+	LockedUntil    pgtype.Timestamptz `db:"locked_until" json:"locked_until"`
+	LockedBy       pgtype.Text        `db:"locked_by" json:"locked_by"`
+	AggregateID    *uuid.UUID         `db:"aggregate_id" json:"aggregate_id"`
+	SequenceNumber pgtype.Int8        `db:"sequence_number" json:"sequence_number"`
+	Ordered        bool               `db:"ordered" json:"ordered"`
+	ReplayID       pgtype.UUID        `db:"replay_id" json:"replay_id"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID                uuid.UUID       `db:"id" json:"id"`
+	DeliveryID        uuid.UUID       `db:"delivery_id" json:"delivery_id"`
+	AttemptNumber     int32           `db:"attempt_number" json:"attempt_number"`
+	RequestUrl        string          `db:"request_url" json:"request_url"`
+	RequestHeaders    json.RawMessage `db:"request_headers" json:"request_headers"`
+	PayloadSha256     string          `db:"payload_sha256" json:"payload_sha256"`
+	ResponseStatus    pgtype.Int4     `db:"response_status" json:"response_status"`
+	ResponseBody      pgtype.Text     `db:"response_body" json:"response_body"`
+	ResponseTruncated bool            `db:"response_truncated" json:"response_truncated"`
+	LatencyMs         int32           `db:"latency_ms" json:"latency_ms"`
+	ErrorClass        pgtype.Text     `db:"error_class" json:"error_class"`
+	ErrorMessage      pgtype.Text     `db:"error_message" json:"error_message"`
+	AttemptedAt       time.Time       `db:"attempted_at" json:"attempted_at"`
+}
+
+type WebhookEndpoint struct {
+	ID             uuid.UUID          `db:"id" json:"id"`
+	TenantID       uuid.UUID          `db:"tenant_id" json:"tenant_id"`
+	Url            string             `db:"url" json:"url"`
+	Description    string             `db:"description" json:"description"`
+	EventTypes     []string           `db:"event_types" json:"event_types"`
+	Enabled        bool               `db:"enabled" json:"enabled"`
+	DisabledAt     pgtype.Timestamptz `db:"disabled_at" json:"disabled_at"`
+	CreatedAt      time.Time          `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `db:"updated_at" json:"updated_at"`
+	Ordered        bool               `db:"ordered" json:"ordered"`
+	PayloadVersion int32              `db:"payload_version" json:"payload_version"`
+	PayloadFormat  string             `db:"payload_format" json:"payload_format"`
+}
+
+type WebhookEndpointHealth struct {
+	EndpointID          uuid.UUID          `db:"endpoint_id" json:"endpoint_id"`
+	CircuitState        string             `db:"circuit_state" json:"circuit_state"`
+	ConsecutiveFailures int32              `db:"consecutive_failures" json:"consecutive_failures"`
+	FailingSince        pgtype.Timestamptz `db:"failing_since" json:"failing_since"`
+	LastSuccessAt       pgtype.Timestamptz `db:"last_success_at" json:"last_success_at"`
+	LastFailureAt       pgtype.Timestamptz `db:"last_failure_at" json:"last_failure_at"`
+	OpenedAt            pgtype.Timestamptz `db:"opened_at" json:"opened_at"`
+	RetryAt             pgtype.Timestamptz `db:"retry_at" json:"retry_at"`
+	ProbeStartedAt      pgtype.Timestamptz `db:"probe_started_at" json:"probe_started_at"`
+	AutoDisabledAt      pgtype.Timestamptz `db:"auto_disabled_at" json:"auto_disabled_at"`
+	UpdatedAt           time.Time          `db:"updated_at" json:"updated_at"`
+}
+
+type WebhookEndpointSecret struct {
+	ID              uuid.UUID          `db:"id" json:"id"`
+	EndpointID      uuid.UUID          `db:"endpoint_id" json:"endpoint_id"`
+	EncryptedSecret []byte             `db:"encrypted_secret" json:"encrypted_secret"`
+	EncryptedKey    []byte             `db:"encrypted_key" json:"encrypted_key"`
+	KeyVersion      int32              `db:"key_version" json:"key_version"`
+	ExpiresAt       pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	CreatedAt       time.Time          `db:"created_at" json:"created_at"`
+}
+
+type WebhookReplay struct {
+	ID               uuid.UUID          `db:"id" json:"id"`
+	TenantID         uuid.UUID          `db:"tenant_id" json:"tenant_id"`
+	EndpointID       *uuid.UUID         `db:"endpoint_id" json:"endpoint_id"`
+	EventTypes       []string           `db:"event_types" json:"event_types"`
+	FromSequence     pgtype.Int8        `db:"from_sequence" json:"from_sequence"`
+	ToSequence       int64              `db:"to_sequence" json:"to_sequence"`
+	CreatedFrom      pgtype.Timestamptz `db:"created_from" json:"created_from"`
+	CreatedTo        pgtype.Timestamptz `db:"created_to" json:"created_to"`
+	RateLimit        int32              `db:"rate_limit" json:"rate_limit"`
+	Status           string             `db:"status" json:"status"`
+	TotalEvents      int64              `db:"total_events" json:"total_events"`
+	EventsProcessed  int64              `db:"events_processed" json:"events_processed"`
+	DeliveriesQueued int64              `db:"deliveries_queued" json:"deliveries_queued"`
+	LastSequence     int64              `db:"last_sequence" json:"last_sequence"`
+	LockedUntil      pgtype.Timestamptz `db:"locked_until" json:"locked_until"`
+	LockedBy         pgtype.Text        `db:"locked_by" json:"locked_by"`
+	StartedAt        pgtype.Timestamptz `db:"started_at" json:"started_at"`
+	CompletedAt      pgtype.Timestamptz `db:"completed_at" json:"completed_at"`
+	CreatedAt        time.Time          `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time          `db:"updated_at" json:"updated_at"`
 }
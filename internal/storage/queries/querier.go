@@ -8,12 +8,31 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
 	APIKeyNameExist(ctx context.Context, name string) (APIKeyNameExistRow, error)
+	AddToAccountBalanceSlot(ctx context.Context, arg AddToAccountBalanceSlotParams) error
 	// sql/queries/tenant_users.sql
 	AddUserToTenant(ctx context.Context, arg AddUserToTenantParams) (TenantUser, error)
+	AdvanceEventConsumer(ctx context.Context, arg AdvanceEventConsumerParams) error
+	// Moves a leased replay's cursor past a batch and hands the lease back until
+	// locked_until. Matches nothing if the replay was cancelled meanwhile.
+	AdvanceWebhookReplay(ctx context.Context, arg AdvanceWebhookReplayParams) (int64, error)
+	CancelWebhookReplay(ctx context.Context, arg CancelWebhookReplayParams) (WebhookReplay, error)
+	// Leases up to batch_size due deliveries to a worker until locked_until. Rows
+	// another worker is claiming at the same moment are skipped, not waited on.
+	// Endpoints whose circuit is open, or already have a half-open probe in
+	// flight (one started after probe_started_after, a lease ago), are skipped too.
+	// An ordered delivery waits until every earlier delivery of its aggregate to
+	// the same endpoint has been delivered. Live deliveries and each replay are
+	// ordered separately, so a replay is not held back by the dead letter it
+	// resends, nor live traffic by a replay.
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	// Leases the oldest unfinished replay that is due for its next batch
+	ClaimWebhookReplay(ctx context.Context, arg ClaimWebhookReplayParams) (WebhookReplay, error)
+	CountReplayEvents(ctx context.Context, arg CountReplayEventsParams) (int64, error)
 	// sql/queries/api_keys.sql
 	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	// sql/queries/accounts.sql
@@ -21,8 +40,13 @@ type Querier interface {
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
 	// Account Balance Operations
 	CreateAccountBalance(ctx context.Context, arg CreateAccountBalanceParams) (AccountBalance, error)
+	// sql/queries/partitions.sql
+	CreateArchivedPartition(ctx context.Context, arg CreateArchivedPartitionParams) (ArchivedPartition, error)
 	// sql/queries/events.sql
 	CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error)
+	// sql/queries/event_consumers.sql
+	CreateEventConsumer(ctx context.Context, name string) error
+	CreateReplayWebhookDelivery(ctx context.Context, arg CreateReplayWebhookDeliveryParams) (int64, error)
 	// sql/queries/tenants.sql
 	CreateTenant(ctx context.Context, arg CreateTenantParams) (Tenant, error)
 	// sql/queries/transactions.sql
@@ -35,30 +59,80 @@ type Querier interface {
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	// sql/queries/webhooks.sql
 	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	// sql/queries/webhook_delivery_attempts.sql
+	CreateWebhookDeliveryAttempt(ctx context.Context, arg CreateWebhookDeliveryAttemptParams) error
+	CreateWebhookDeliveryIfNotExists(ctx context.Context, arg CreateWebhookDeliveryIfNotExistsParams) (int64, error)
+	// sql/queries/webhook_endpoints.sql
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
+	// sql/queries/webhook_endpoint_secrets.sql
+	CreateWebhookEndpointSecret(ctx context.Context, arg CreateWebhookEndpointSecretParams) (WebhookEndpointSecret, error)
+	// sql/queries/webhook_replays.sql
+	CreateWebhookReplay(ctx context.Context, arg CreateWebhookReplayParams) (WebhookReplay, error)
 	DeactivateAccount(ctx context.Context, id uuid.UUID) (Account, error)
 	DeleteAPIKey(ctx context.Context, arg DeleteAPIKeyParams) error
+	DeleteExpiredWebhookEndpointSecrets(ctx context.Context, endpointID uuid.UUID) error
+	DeleteHighVolumeAccount(ctx context.Context, accountID uuid.UUID) error
+	// Drops a replay's queued deliveries that have not been attempted or claimed
+	DeleteUnsentReplayDeliveries(ctx context.Context, replayID pgtype.UUID) (int64, error)
+	// Deletes at most batch_size attempts older than the cutoff, so pruning a
+	// large backlog never holds long locks
+	DeleteWebhookDeliveryAttemptsBefore(ctx context.Context, arg DeleteWebhookDeliveryAttemptsBeforeParams) (int64, error)
+	DeleteWebhookEndpoint(ctx context.Context, arg DeleteWebhookEndpointParams) (int64, error)
+	DisableWebhookEndpoint(ctx context.Context, arg DisableWebhookEndpointParams) (WebhookEndpoint, error)
+	// Creates a zero balance unless the account already has one in the currency.
+	// Concurrent first postings wait for each other here instead of failing with
+	// a unique violation.
+	EnsureAccountBalance(ctx context.Context, arg EnsureAccountBalanceParams) error
+	EnsureWebhookEndpointHealth(ctx context.Context, endpointID uuid.UUID) error
+	// Secrets already due to expire sooner keep their earlier expiry
+	ExpireWebhookEndpointSecrets(ctx context.Context, arg ExpireWebhookEndpointSecretsParams) error
+	FoldAccountBalanceSlots(ctx context.Context, accountID uuid.UUID) ([]AccountBalance, error)
 	GetAPIKeyByHash(ctx context.Context, keyHash string) (GetAPIKeyByHashRow, error)
 	GetAccountBalance(ctx context.Context, arg GetAccountBalanceParams) (AccountBalance, error)
 	GetAccountBalanceForUpdate(ctx context.Context, arg GetAccountBalanceForUpdateParams) (AccountBalance, error)
 	GetAccountBalanceHistory(ctx context.Context, arg GetAccountBalanceHistoryParams) ([]AccountBalance, error)
 	GetAccountBalanceSummary(ctx context.Context) ([]GetAccountBalanceSummaryRow, error)
+	GetAccountBalanceTotal(ctx context.Context, arg GetAccountBalanceTotalParams) (AccountBalanceTotal, error)
+	GetAccountBalanceTotals(ctx context.Context, accountID uuid.UUID) ([]AccountBalanceTotal, error)
 	GetAccountBalances(ctx context.Context, accountID uuid.UUID) ([]AccountBalance, error)
 	GetAccountByCode(ctx context.Context, code string) (Account, error)
 	GetAccountByID(ctx context.Context, id uuid.UUID) (Account, error)
+	// locks an account, active or not, for a change that records its prior state
+	GetAccountForUpdate(ctx context.Context, id uuid.UUID) (Account, error)
 	GetAccountHierarchy(ctx context.Context) ([]GetAccountHierarchyRow, error)
 	GetAccountStats(ctx context.Context) (GetAccountStatsRow, error)
 	// Utility queries for reporting and validation
 	GetAccountWithBalance(ctx context.Context, arg GetAccountWithBalanceParams) (GetAccountWithBalanceRow, error)
 	GetAllBalanceSummary(ctx context.Context) (GetAllBalanceSummaryRow, error)
+	// the end of a table's latest archived partition in the current schema; the
+	// oldest months are archived first, so rows created before it are only in
+	// the archives
+	GetArchivedUntil(ctx context.Context, tableName string) (pgtype.Timestamptz, error)
 	GetBalanceSummaryByAccountType(ctx context.Context, dollar_1 string) ([]GetBalanceSummaryByAccountTypeRow, error)
 	GetBalanceSummaryByCurrency(ctx context.Context, dollar_1 string) (GetBalanceSummaryByCurrencyRow, error)
 	GetEventByID(ctx context.Context, arg GetEventByIDParams) (Event, error)
+	// the outbox position behind which every event has been committed
+	GetEventStreamHead(ctx context.Context) (uint64, error)
 	GetEventsAfterSequence(ctx context.Context, arg GetEventsAfterSequenceParams) ([]Event, error)
 	GetEventsByAggregate(ctx context.Context, arg GetEventsByAggregateParams) ([]Event, error)
 	GetEventsByType(ctx context.Context, arg GetEventsByTypeParams) ([]Event, error)
-	GetPendingWebhookDeliveries(ctx context.Context, limit int32) ([]WebhookDelivery, error)
+	// High-Volume Account Operations
+	// key-share lock so disabling waits for in-flight slot postings
+	GetHighVolumeAccount(ctx context.Context, accountID uuid.UUID) (HighVolumeAccount, error)
+	// idempotency keys stay reserved after their transaction's month is archived
+	GetIdempotencyKey(ctx context.Context, idempotencyKey string) (TransactionIdempotencyKey, error)
+	GetIdempotencyKeyByTransactionID(ctx context.Context, transactionID uuid.UUID) (TransactionIdempotencyKey, error)
+	// events after a consumer's cursor, limited to transactions older than every
+	// running one so nothing can still appear behind the cursor
+	GetOutboxEvents(ctx context.Context, arg GetOutboxEventsParams) ([]Event, error)
 	GetTenantByID(ctx context.Context, id uuid.UUID) (Tenant, error)
 	GetTenantBySlug(ctx context.Context, slug string) (Tenant, error)
+	// the sequence number of a tenant's latest event
+	GetTenantEventHead(ctx context.Context, tenantID uuid.UUID) (int64, error)
+	// a tenant's events after a stream cursor, read in outbox order and limited
+	// like GetOutboxEvents to transactions older than every running one
+	GetTenantEventsAfterCursor(ctx context.Context, arg GetTenantEventsAfterCursorParams) ([]Event, error)
+	GetTenantTenancyMode(ctx context.Context, slug string) (GetTenantTenancyModeRow, error)
 	GetTenantUser(ctx context.Context, arg GetTenantUserParams) (TenantUser, error)
 	GetTransactionByID(ctx context.Context, id uuid.UUID) (Transaction, error)
 	GetTransactionByIdempotencyKey(ctx context.Context, idempotencyKey string) (Transaction, error)
@@ -68,6 +142,11 @@ type Querier interface {
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
 	GetWebhookDeliveriesByTenant(ctx context.Context, arg GetWebhookDeliveriesByTenantParams) ([]WebhookDelivery, error)
 	GetWebhookDeliveryByID(ctx context.Context, arg GetWebhookDeliveryByIDParams) (WebhookDelivery, error)
+	GetWebhookEndpoint(ctx context.Context, arg GetWebhookEndpointParams) (WebhookEndpoint, error)
+	// sql/queries/webhook_endpoint_health.sql
+	GetWebhookEndpointHealth(ctx context.Context, endpointID uuid.UUID) (WebhookEndpointHealth, error)
+	GetWebhookReplay(ctx context.Context, arg GetWebhookReplayParams) (WebhookReplay, error)
+	GetWebhookReplayDeliveryStats(ctx context.Context, replayID pgtype.UUID) (GetWebhookReplayDeliveryStatsRow, error)
 	IncrementFailedLoginAttempts(ctx context.Context, id uuid.UUID) error
 	ListAccountBalancesByCurrency(ctx context.Context, currency string) ([]ListAccountBalancesByCurrencyRow, error)
 	ListAccounts(ctx context.Context) ([]Account, error)
@@ -75,16 +154,48 @@ type Querier interface {
 	ListAccountsByParentCode(ctx context.Context, code string) ([]Account, error)
 	ListAccountsByType(ctx context.Context, accountType AccountTypeEnum) ([]Account, error)
 	ListAccountsWithBalances(ctx context.Context) ([]ListAccountsWithBalancesRow, error)
+	// Newest first, so the current secret leads and rotated-out ones follow until they expire
+	ListActiveWebhookEndpointSecrets(ctx context.Context, endpointID uuid.UUID) ([]WebhookEndpointSecret, error)
+	ListArchivedPartitions(ctx context.Context) ([]ArchivedPartition, error)
+	// archived partitions of a table in the current schema (the tenant's own
+	// inside WithTenant) that overlap the requested range
+	ListArchivedPartitionsInRange(ctx context.Context, arg ListArchivedPartitionsInRangeParams) ([]ArchivedPartition, error)
+	ListDeadLetteredWebhookDeliveries(ctx context.Context, arg ListDeadLetteredWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListEnabledWebhookEndpoints(ctx context.Context, tenantID uuid.UUID) ([]WebhookEndpoint, error)
+	// a page of a tenant's event history in sequence order, continuing after the
+	// previous page's last sequence number
+	ListEvents(ctx context.Context, arg ListEventsParams) ([]Event, error)
+	// the next batch of a replay's events after its cursor, in sequence order
+	ListReplayEvents(ctx context.Context, arg ListReplayEventsParams) ([]Event, error)
 	ListTenantAPIKeys(ctx context.Context, tenantID uuid.UUID) ([]ListTenantAPIKeysRow, error)
 	ListTenantUsers(ctx context.Context, tenantID uuid.UUID) ([]ListTenantUsersRow, error)
 	ListTenantsByUser(ctx context.Context, userID uuid.UUID) ([]Tenant, error)
 	// Advanced Transaction Queries
 	ListTransactions(ctx context.Context, arg ListTransactionsParams) ([]Transaction, error)
 	ListTransactionsByAccount(ctx context.Context, arg ListTransactionsByAccountParams) ([]Transaction, error)
+	// filtered on created_at like ListTransactionsByDateRange
 	ListTransactionsByAccountAndDateRange(ctx context.Context, arg ListTransactionsByAccountAndDateRangeParams) ([]Transaction, error)
+	// filtered on created_at, the partition key, so only the months in range are
+	// read; postings are posted by the database transaction creating them, so it
+	// is also their posted_at
 	ListTransactionsByDateRange(ctx context.Context, arg ListTransactionsByDateRangeParams) ([]Transaction, error)
+	ListWebhookDeliveryAttempts(ctx context.Context, deliveryID uuid.UUID) ([]WebhookDeliveryAttempt, error)
+	ListWebhookEndpointHealth(ctx context.Context, tenantID uuid.UUID) ([]WebhookEndpointHealth, error)
+	ListWebhookEndpointSecretsToReencrypt(ctx context.Context, arg ListWebhookEndpointSecretsToReencryptParams) ([]WebhookEndpointSecret, error)
+	ListWebhookEndpoints(ctx context.Context, tenantID uuid.UUID) ([]WebhookEndpoint, error)
+	ListWebhookReplays(ctx context.Context, arg ListWebhookReplaysParams) ([]WebhookReplay, error)
+	// returns no rows while another replica holds the consumer
+	LockEventConsumer(ctx context.Context, name string) (EventConsumer, error)
+	LockWebhookEndpointHealth(ctx context.Context, endpointID uuid.UUID) (WebhookEndpointHealth, error)
+	// Any success closes the circuit and ends the failure streak
+	RecordWebhookEndpointSuccess(ctx context.Context, endpointID uuid.UUID) error
+	// Gives matching dead letters a fresh retry schedule starting now
+	RedeliverDeadLetteredWebhookDeliveries(ctx context.Context, arg RedeliverDeadLetteredWebhookDeliveriesParams) (int64, error)
+	// Hands a claimed delivery back unsent; no worker picks it up before locked_until
+	ReleaseWebhookDelivery(ctx context.Context, arg ReleaseWebhookDeliveryParams) error
 	RemoveUserFromTenant(ctx context.Context, arg RemoveUserFromTenantParams) error
 	ResetWebhookDeliveryForRetry(ctx context.Context, id uuid.UUID) error
+	ResetWebhookEndpointHealth(ctx context.Context, endpointID uuid.UUID) error
 	SearchAccounts(ctx context.Context, arg SearchAccountsParams) ([]Account, error)
 	UpdateAPIKeyLastUsed(ctx context.Context, id uuid.UUID) error
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
@@ -93,8 +204,14 @@ type Querier interface {
 	UpdateTenantUserRole(ctx context.Context, arg UpdateTenantUserRoleParams) error
 	UpdateTransactionStatus(ctx context.Context, arg UpdateTransactionStatusParams) (Transaction, error)
 	UpdateUserLastLogin(ctx context.Context, id uuid.UUID) error
+	// A NULL next_retry_at means the retry schedule is exhausted and the delivery
+	// is dead-lettered
 	UpdateWebhookDeliveryFailure(ctx context.Context, arg UpdateWebhookDeliveryFailureParams) error
 	UpdateWebhookDeliverySuccess(ctx context.Context, arg UpdateWebhookDeliverySuccessParams) error
+	UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEndpointParams) (WebhookEndpoint, error)
+	UpdateWebhookEndpointHealth(ctx context.Context, arg UpdateWebhookEndpointHealthParams) error
+	UpdateWebhookEndpointSecretEncryption(ctx context.Context, arg UpdateWebhookEndpointSecretEncryptionParams) error
+	UpsertHighVolumeAccount(ctx context.Context, arg UpsertHighVolumeAccountParams) (HighVolumeAccount, error)
 	ValidateAccountCode(ctx context.Context, code string) (bool, error)
 	ValidateParentAccount(ctx context.Context, id uuid.UUID) (ValidateParentAccountRow, error)
 	VerifyUserEmail(ctx context.Context, id uuid.UUID) error
@@ -16,19 +16,20 @@ import (
 const createTenant = `-- name: CreateTenant :one
 
 INSERT INTO tenants (
-    name, slug, business_type, country_code, base_currency, timezone
+    name, slug, business_type, country_code, base_currency, timezone, tenancy_mode
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
-) RETURNING id, name, slug, business_type, country_code, base_currency, timezone, metadata, created_at, updated_at
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, name, slug, business_type, country_code, base_currency, timezone, metadata, created_at, updated_at, tenancy_mode
 `
 
 type CreateTenantParams struct {
-	Name         string      `db:"name" json:"name"`
-	Slug         string      `db:"slug" json:"slug"`
-	BusinessType pgtype.Text `db:"business_type" json:"business_type"`
-	CountryCode  pgtype.Text `db:"country_code" json:"country_code"`
-	BaseCurrency pgtype.Text `db:"base_currency" json:"base_currency"`
-	Timezone     pgtype.Text `db:"timezone" json:"timezone"`
+	Name         string          `db:"name" json:"name"`
+	Slug         string          `db:"slug" json:"slug"`
+	BusinessType pgtype.Text     `db:"business_type" json:"business_type"`
+	CountryCode  pgtype.Text     `db:"country_code" json:"country_code"`
+	BaseCurrency pgtype.Text     `db:"base_currency" json:"base_currency"`
+	Timezone     pgtype.Text     `db:"timezone" json:"timezone"`
+	TenancyMode  TenancyModeEnum `db:"tenancy_mode" json:"tenancy_mode"`
 }
 
 // sql/queries/tenants.sql
@@ -40,6 +41,7 @@ func (q *Queries) CreateTenant(ctx context.Context, arg CreateTenantParams) (Ten
 		arg.CountryCode,
 		arg.BaseCurrency,
 		arg.Timezone,
+		arg.TenancyMode,
 	)
 	var i Tenant
 	err := row.Scan(
@@ -53,12 +55,13 @@ func (q *Queries) CreateTenant(ctx context.Context, arg CreateTenantParams) (Ten
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenancyMode,
 	)
 	return i, err
 }
 
 const getTenantByID = `-- name: GetTenantByID :one
-SELECT id, name, slug, business_type, country_code, base_currency, timezone, metadata, created_at, updated_at FROM tenants 
+SELECT id, name, slug, business_type, country_code, base_currency, timezone, metadata, created_at, updated_at, tenancy_mode FROM tenants 
 WHERE id = $1 LIMIT 1
 `
 
@@ -76,12 +79,13 @@ func (q *Queries) GetTenantByID(ctx context.Context, id uuid.UUID) (Tenant, erro
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenancyMode,
 	)
 	return i, err
 }
 
 const getTenantBySlug = `-- name: GetTenantBySlug :one
-SELECT id, name, slug, business_type, country_code, base_currency, timezone, metadata, created_at, updated_at FROM tenants 
+SELECT id, name, slug, business_type, country_code, base_currency, timezone, metadata, created_at, updated_at, tenancy_mode FROM tenants 
 WHERE slug = $1 LIMIT 1
 `
 
@@ -99,12 +103,31 @@ func (q *Queries) GetTenantBySlug(ctx context.Context, slug string) (Tenant, err
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenancyMode,
 	)
 	return i, err
 }
 
+const getTenantTenancyMode = `-- name: GetTenantTenancyMode :one
+SELECT id, tenancy_mode FROM tenants
+WHERE slug = $1
+FOR KEY SHARE
+`
+
+type GetTenantTenancyModeRow struct {
+	ID          uuid.UUID       `db:"id" json:"id"`
+	TenancyMode TenancyModeEnum `db:"tenancy_mode" json:"tenancy_mode"`
+}
+
+func (q *Queries) GetTenantTenancyMode(ctx context.Context, slug string) (GetTenantTenancyModeRow, error) {
+	row := q.db.QueryRow(ctx, getTenantTenancyMode, slug)
+	var i GetTenantTenancyModeRow
+	err := row.Scan(&i.ID, &i.TenancyMode)
+	return i, err
+}
+
 const listTenantsByUser = `-- name: ListTenantsByUser :many
-SELECT t.id, t.name, t.slug, t.business_type, t.country_code, t.base_currency, t.timezone, t.metadata, t.created_at, t.updated_at FROM tenants t
+SELECT t.id, t.name, t.slug, t.business_type, t.country_code, t.base_currency, t.timezone, t.metadata, t.created_at, t.updated_at, t.tenancy_mode FROM tenants t
 JOIN tenant_users tu ON t.id = tu.tenant_id
 WHERE tu.user_id = $1
 ORDER BY t.created_at DESC
@@ -130,6 +153,7 @@ func (q *Queries) ListTenantsByUser(ctx context.Context, userID uuid.UUID) ([]Te
 			&i.Metadata,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TenancyMode,
 		); err != nil {
 			return nil, err
 		}
@@ -145,7 +169,7 @@ const updateTenantMetadata = `-- name: UpdateTenantMetadata :one
 UPDATE tenants 
 SET metadata = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING id, name, slug, business_type, country_code, base_currency, timezone, metadata, created_at, updated_at
+RETURNING id, name, slug, business_type, country_code, base_currency, timezone, metadata, created_at, updated_at, tenancy_mode
 `
 
 type UpdateTenantMetadataParams struct {
@@ -167,6 +191,7 @@ func (q *Queries) UpdateTenantMetadata(ctx context.Context, arg UpdateTenantMeta
 		&i.Metadata,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TenancyMode,
 	)
 	return i, err
 }
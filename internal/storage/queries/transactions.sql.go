@@ -96,6 +96,32 @@ func (q *Queries) CreateTransactionLine(ctx context.Context, arg CreateTransacti
 	return i, err
 }
 
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+
+SELECT idempotency_key, transaction_id, created_at FROM transaction_idempotency_keys
+WHERE idempotency_key = $1
+`
+
+// idempotency keys stay reserved after their transaction's month is archived
+func (q *Queries) GetIdempotencyKey(ctx context.Context, idempotencyKey string) (TransactionIdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKey, idempotencyKey)
+	var i TransactionIdempotencyKey
+	err := row.Scan(&i.IdempotencyKey, &i.TransactionID, &i.CreatedAt)
+	return i, err
+}
+
+const getIdempotencyKeyByTransactionID = `-- name: GetIdempotencyKeyByTransactionID :one
+SELECT idempotency_key, transaction_id, created_at FROM transaction_idempotency_keys
+WHERE transaction_id = $1
+`
+
+func (q *Queries) GetIdempotencyKeyByTransactionID(ctx context.Context, transactionID uuid.UUID) (TransactionIdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKeyByTransactionID, transactionID)
+	var i TransactionIdempotencyKey
+	err := row.Scan(&i.IdempotencyKey, &i.TransactionID, &i.CreatedAt)
+	return i, err
+}
+
 const getTransactionByID = `-- name: GetTransactionByID :one
 SELECT id, idempotency_key, description, reference, status, posted_at, metadata, created_at FROM transactions 
 WHERE id = $1
@@ -334,28 +360,30 @@ func (q *Queries) ListTransactionsByAccount(ctx context.Context, arg ListTransac
 }
 
 const listTransactionsByAccountAndDateRange = `-- name: ListTransactionsByAccountAndDateRange :many
+
 SELECT DISTINCT t.id, t.idempotency_key, t.description, t.reference, t.status, t.posted_at, t.metadata, t.created_at FROM transactions t
 JOIN transaction_lines tl ON t.id = tl.transaction_id
 JOIN accounts a ON tl.account_id = a.id
 WHERE a.code = $1 
-AND t.posted_at BETWEEN $2 AND $3
+AND t.created_at BETWEEN $2 AND $3
 ORDER BY t.created_at DESC
 LIMIT $4 OFFSET $5
 `
 
 type ListTransactionsByAccountAndDateRangeParams struct {
-	Code       string    `db:"code" json:"code"`
-	PostedAt   time.Time `db:"posted_at" json:"posted_at"`
-	PostedAt_2 time.Time `db:"posted_at_2" json:"posted_at_2"`
-	Limit      int32     `db:"limit" json:"limit"`
-	Offset     int32     `db:"offset" json:"offset"`
+	Code        string    `db:"code" json:"code"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	CreatedAt_2 time.Time `db:"created_at_2" json:"created_at_2"`
+	Limit       int32     `db:"limit" json:"limit"`
+	Offset      int32     `db:"offset" json:"offset"`
 }
 
+// filtered on created_at like ListTransactionsByDateRange
 func (q *Queries) ListTransactionsByAccountAndDateRange(ctx context.Context, arg ListTransactionsByAccountAndDateRangeParams) ([]Transaction, error) {
 	rows, err := q.db.Query(ctx, listTransactionsByAccountAndDateRange,
 		arg.Code,
-		arg.PostedAt,
-		arg.PostedAt_2,
+		arg.CreatedAt,
+		arg.CreatedAt_2,
 		arg.Limit,
 		arg.Offset,
 	)
@@ -387,23 +415,27 @@ func (q *Queries) ListTransactionsByAccountAndDateRange(ctx context.Context, arg
 }
 
 const listTransactionsByDateRange = `-- name: ListTransactionsByDateRange :many
+
 SELECT id, idempotency_key, description, reference, status, posted_at, metadata, created_at FROM transactions
-WHERE posted_at BETWEEN $1 AND $2
-ORDER BY posted_at DESC
+WHERE created_at BETWEEN $1 AND $2
+ORDER BY created_at DESC
 LIMIT $3 OFFSET $4
 `
 
 type ListTransactionsByDateRangeParams struct {
-	PostedAt   time.Time `db:"posted_at" json:"posted_at"`
-	PostedAt_2 time.Time `db:"posted_at_2" json:"posted_at_2"`
-	Limit      int32     `db:"limit" json:"limit"`
-	Offset     int32     `db:"offset" json:"offset"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	CreatedAt_2 time.Time `db:"created_at_2" json:"created_at_2"`
+	Limit       int32     `db:"limit" json:"limit"`
+	Offset      int32     `db:"offset" json:"offset"`
 }
 
+// filtered on created_at, the partition key, so only the months in range are
+// read; postings are posted by the database transaction creating them, so it
+// is also their posted_at
 func (q *Queries) ListTransactionsByDateRange(ctx context.Context, arg ListTransactionsByDateRangeParams) ([]Transaction, error) {
 	rows, err := q.db.Query(ctx, listTransactionsByDateRange,
-		arg.PostedAt,
-		arg.PostedAt_2,
+		arg.CreatedAt,
+		arg.CreatedAt_2,
 		arg.Limit,
 		arg.Offset,
 	)
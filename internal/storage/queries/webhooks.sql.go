@@ -12,21 +12,161 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimWebhookDeliveries = `-- name: ClaimWebhookDeliveries :many
+
+UPDATE webhook_deliveries
+SET locked_until = $1,
+    locked_by = $2
+WHERE id IN (
+    SELECT d.id FROM webhook_deliveries d
+    WHERE d.next_retry_at IS NOT NULL
+      AND d.next_retry_at <= NOW()
+      AND d.attempts < d.max_attempts
+      AND d.delivered_at IS NULL
+      AND (d.locked_until IS NULL OR d.locked_until <= NOW())
+      AND NOT EXISTS (
+          SELECT 1 FROM webhook_endpoint_health h
+          WHERE h.endpoint_id = d.endpoint_id
+            AND ((h.circuit_state = 'open' AND h.retry_at > NOW())
+              OR (h.circuit_state = 'half_open' AND h.probe_started_at > $3))
+      )
+      AND (NOT d.ordered OR NOT EXISTS (
+          SELECT 1 FROM webhook_deliveries prev
+          WHERE prev.endpoint_id = d.endpoint_id
+            AND prev.aggregate_id = d.aggregate_id
+            AND prev.sequence_number < d.sequence_number
+            AND prev.replay_id IS NOT DISTINCT FROM d.replay_id
+            AND prev.delivered_at IS NULL
+      ))
+    ORDER BY d.next_retry_at ASC
+    LIMIT $4
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, tenant_id, event_id, http_status_code, response_body, attempts, max_attempts, next_retry_at, delivered_at, failed_at, created_at, endpoint_id, event_type, dead_lettered_at, locked_until, locked_by, aggregate_id, sequence_number, ordered, replay_id
+`
+
+type ClaimWebhookDeliveriesParams struct {
+	LockedUntil       pgtype.Timestamptz `db:"locked_until" json:"locked_until"`
+	LockedBy          pgtype.Text        `db:"locked_by" json:"locked_by"`
+	ProbeStartedAfter pgtype.Timestamptz `db:"probe_started_after" json:"probe_started_after"`
+	BatchSize         int32              `db:"batch_size" json:"batch_size"`
+}
+
+// Leases up to batch_size due deliveries to a worker until locked_until. Rows
+// another worker is claiming at the same moment are skipped, not waited on.
+// Endpoints whose circuit is open, or already have a half-open probe in
+// flight (one started after probe_started_after, a lease ago), are skipped too.
+// An ordered delivery waits until every earlier delivery of its aggregate to
+// the same endpoint has been delivered. Live deliveries and each replay are
+// ordered separately, so a replay is not held back by the dead letter it
+// resends, nor live traffic by a replay.
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, claimWebhookDeliveries,
+		arg.LockedUntil,
+		arg.LockedBy,
+		arg.ProbeStartedAfter,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.EventID,
+			&i.HttpStatusCode,
+			&i.ResponseBody,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.NextRetryAt,
+			&i.DeliveredAt,
+			&i.FailedAt,
+			&i.CreatedAt,
+			&i.EndpointID,
+			&i.EventType,
+			&i.DeadLetteredAt,
+			&i.LockedUntil,
+			&i.LockedBy,
+			&i.AggregateID,
+			&i.SequenceNumber,
+			&i.Ordered,
+			&i.ReplayID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createReplayWebhookDelivery = `-- name: CreateReplayWebhookDelivery :execrows
+INSERT INTO webhook_deliveries (
+    tenant_id, event_id, endpoint_id, event_type, max_attempts, next_retry_at,
+    aggregate_id, sequence_number, ordered, replay_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) ON CONFLICT (replay_id, event_id, endpoint_id) WHERE replay_id IS NOT NULL DO NOTHING
+`
+
+type CreateReplayWebhookDeliveryParams struct {
+	TenantID       uuid.UUID          `db:"tenant_id" json:"tenant_id"`
+	EventID        uuid.UUID          `db:"event_id" json:"event_id"`
+	EndpointID     uuid.UUID          `db:"endpoint_id" json:"endpoint_id"`
+	EventType      string             `db:"event_type" json:"event_type"`
+	MaxAttempts    pgtype.Int4        `db:"max_attempts" json:"max_attempts"`
+	NextRetryAt    pgtype.Timestamptz `db:"next_retry_at" json:"next_retry_at"`
+	AggregateID    *uuid.UUID         `db:"aggregate_id" json:"aggregate_id"`
+	SequenceNumber pgtype.Int8        `db:"sequence_number" json:"sequence_number"`
+	Ordered        bool               `db:"ordered" json:"ordered"`
+	ReplayID       pgtype.UUID        `db:"replay_id" json:"replay_id"`
+}
+
+func (q *Queries) CreateReplayWebhookDelivery(ctx context.Context, arg CreateReplayWebhookDeliveryParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createReplayWebhookDelivery,
+		arg.TenantID,
+		arg.EventID,
+		arg.EndpointID,
+		arg.EventType,
+		arg.MaxAttempts,
+		arg.NextRetryAt,
+		arg.AggregateID,
+		arg.SequenceNumber,
+		arg.Ordered,
+		arg.ReplayID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createWebhookDelivery = `-- name: CreateWebhookDelivery :one
 
 INSERT INTO webhook_deliveries (
-    tenant_id, event_id, webhook_url, max_attempts, next_retry_at
+    tenant_id, event_id, endpoint_id, event_type, max_attempts, next_retry_at,
+    aggregate_id, sequence_number, ordered
 ) VALUES (
-    $1, $2, $3, $4, $5
-) RETURNING id, tenant_id, event_id, webhook_url, http_status_code, response_body, attempts, max_attempts, next_retry_at, delivered_at, failed_at, created_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, tenant_id, event_id, http_status_code, response_body, attempts, max_attempts, next_retry_at, delivered_at, failed_at, created_at, endpoint_id, event_type, dead_lettered_at, locked_until, locked_by, aggregate_id, sequence_number, ordered, replay_id
 `
 
 type CreateWebhookDeliveryParams struct {
-	TenantID    uuid.UUID          `db:"tenant_id" json:"tenant_id"`
-	EventID     uuid.UUID          `db:"event_id" json:"event_id"`
-	WebhookUrl  string             `db:"webhook_url" json:"webhook_url"`
-	MaxAttempts pgtype.Int4        `db:"max_attempts" json:"max_attempts"`
-	NextRetryAt pgtype.Timestamptz `db:"next_retry_at" json:"next_retry_at"`
+	TenantID       uuid.UUID          `db:"tenant_id" json:"tenant_id"`
+	EventID        uuid.UUID          `db:"event_id" json:"event_id"`
+	EndpointID     uuid.UUID          `db:"endpoint_id" json:"endpoint_id"`
+	EventType      string             `db:"event_type" json:"event_type"`
+	MaxAttempts    pgtype.Int4        `db:"max_attempts" json:"max_attempts"`
+	NextRetryAt    pgtype.Timestamptz `db:"next_retry_at" json:"next_retry_at"`
+	AggregateID    *uuid.UUID         `db:"aggregate_id" json:"aggregate_id"`
+	SequenceNumber pgtype.Int8        `db:"sequence_number" json:"sequence_number"`
+	Ordered        bool               `db:"ordered" json:"ordered"`
 }
 
 // sql/queries/webhooks.sql
@@ -34,16 +174,19 @@ func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDe
 	row := q.db.QueryRow(ctx, createWebhookDelivery,
 		arg.TenantID,
 		arg.EventID,
-		arg.WebhookUrl,
+		arg.EndpointID,
+		arg.EventType,
 		arg.MaxAttempts,
 		arg.NextRetryAt,
+		arg.AggregateID,
+		arg.SequenceNumber,
+		arg.Ordered,
 	)
 	var i WebhookDelivery
 	err := row.Scan(
 		&i.ID,
 		&i.TenantID,
 		&i.EventID,
-		&i.WebhookUrl,
 		&i.HttpStatusCode,
 		&i.ResponseBody,
 		&i.Attempts,
@@ -52,54 +195,60 @@ func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDe
 		&i.DeliveredAt,
 		&i.FailedAt,
 		&i.CreatedAt,
+		&i.EndpointID,
+		&i.EventType,
+		&i.DeadLetteredAt,
+		&i.LockedUntil,
+		&i.LockedBy,
+		&i.AggregateID,
+		&i.SequenceNumber,
+		&i.Ordered,
+		&i.ReplayID,
 	)
 	return i, err
 }
 
-const getPendingWebhookDeliveries = `-- name: GetPendingWebhookDeliveries :many
-SELECT id, tenant_id, event_id, webhook_url, http_status_code, response_body, attempts, max_attempts, next_retry_at, delivered_at, failed_at, created_at FROM webhook_deliveries
-WHERE next_retry_at IS NOT NULL 
-  AND next_retry_at <= NOW()
-  AND attempts < max_attempts
-ORDER BY created_at ASC
-LIMIT $1
+const createWebhookDeliveryIfNotExists = `-- name: CreateWebhookDeliveryIfNotExists :execrows
+INSERT INTO webhook_deliveries (
+    tenant_id, event_id, endpoint_id, event_type, max_attempts, next_retry_at,
+    aggregate_id, sequence_number, ordered
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) ON CONFLICT (event_id, endpoint_id) WHERE replay_id IS NULL DO NOTHING
 `
 
-func (q *Queries) GetPendingWebhookDeliveries(ctx context.Context, limit int32) ([]WebhookDelivery, error) {
-	rows, err := q.db.Query(ctx, getPendingWebhookDeliveries, limit)
+type CreateWebhookDeliveryIfNotExistsParams struct {
+	TenantID       uuid.UUID          `db:"tenant_id" json:"tenant_id"`
+	EventID        uuid.UUID          `db:"event_id" json:"event_id"`
+	EndpointID     uuid.UUID          `db:"endpoint_id" json:"endpoint_id"`
+	EventType      string             `db:"event_type" json:"event_type"`
+	MaxAttempts    pgtype.Int4        `db:"max_attempts" json:"max_attempts"`
+	NextRetryAt    pgtype.Timestamptz `db:"next_retry_at" json:"next_retry_at"`
+	AggregateID    *uuid.UUID         `db:"aggregate_id" json:"aggregate_id"`
+	SequenceNumber pgtype.Int8        `db:"sequence_number" json:"sequence_number"`
+	Ordered        bool               `db:"ordered" json:"ordered"`
+}
+
+func (q *Queries) CreateWebhookDeliveryIfNotExists(ctx context.Context, arg CreateWebhookDeliveryIfNotExistsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createWebhookDeliveryIfNotExists,
+		arg.TenantID,
+		arg.EventID,
+		arg.EndpointID,
+		arg.EventType,
+		arg.MaxAttempts,
+		arg.NextRetryAt,
+		arg.AggregateID,
+		arg.SequenceNumber,
+		arg.Ordered,
+	)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
-	items := []WebhookDelivery{}
-	for rows.Next() {
-		var i WebhookDelivery
-		if err := rows.Scan(
-			&i.ID,
-			&i.TenantID,
-			&i.EventID,
-			&i.WebhookUrl,
-			&i.HttpStatusCode,
-			&i.ResponseBody,
-			&i.Attempts,
-			&i.MaxAttempts,
-			&i.NextRetryAt,
-			&i.DeliveredAt,
-			&i.FailedAt,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
 const getWebhookDeliveriesByTenant = `-- name: GetWebhookDeliveriesByTenant :many
-SELECT id, tenant_id, event_id, webhook_url, http_status_code, response_body, attempts, max_attempts, next_retry_at, delivered_at, failed_at, created_at FROM webhook_deliveries
+SELECT id, tenant_id, event_id, http_status_code, response_body, attempts, max_attempts, next_retry_at, delivered_at, failed_at, created_at, endpoint_id, event_type, dead_lettered_at, locked_until, locked_by, aggregate_id, sequence_number, ordered, replay_id FROM webhook_deliveries
 WHERE tenant_id = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -123,7 +272,6 @@ func (q *Queries) GetWebhookDeliveriesByTenant(ctx context.Context, arg GetWebho
 			&i.ID,
 			&i.TenantID,
 			&i.EventID,
-			&i.WebhookUrl,
 			&i.HttpStatusCode,
 			&i.ResponseBody,
 			&i.Attempts,
@@ -132,6 +280,15 @@ func (q *Queries) GetWebhookDeliveriesByTenant(ctx context.Context, arg GetWebho
 			&i.DeliveredAt,
 			&i.FailedAt,
 			&i.CreatedAt,
+			&i.EndpointID,
+			&i.EventType,
+			&i.DeadLetteredAt,
+			&i.LockedUntil,
+			&i.LockedBy,
+			&i.AggregateID,
+			&i.SequenceNumber,
+			&i.Ordered,
+			&i.ReplayID,
 		); err != nil {
 			return nil, err
 		}
@@ -144,7 +301,7 @@ func (q *Queries) GetWebhookDeliveriesByTenant(ctx context.Context, arg GetWebho
 }
 
 const getWebhookDeliveryByID = `-- name: GetWebhookDeliveryByID :one
-SELECT id, tenant_id, event_id, webhook_url, http_status_code, response_body, attempts, max_attempts, next_retry_at, delivered_at, failed_at, created_at FROM webhook_deliveries
+SELECT id, tenant_id, event_id, http_status_code, response_body, attempts, max_attempts, next_retry_at, delivered_at, failed_at, created_at, endpoint_id, event_type, dead_lettered_at, locked_until, locked_by, aggregate_id, sequence_number, ordered, replay_id FROM webhook_deliveries
 WHERE id = $1 AND tenant_id = $2
 LIMIT 1
 `
@@ -161,7 +318,6 @@ func (q *Queries) GetWebhookDeliveryByID(ctx context.Context, arg GetWebhookDeli
 		&i.ID,
 		&i.TenantID,
 		&i.EventID,
-		&i.WebhookUrl,
 		&i.HttpStatusCode,
 		&i.ResponseBody,
 		&i.Attempts,
@@ -170,14 +326,153 @@ func (q *Queries) GetWebhookDeliveryByID(ctx context.Context, arg GetWebhookDeli
 		&i.DeliveredAt,
 		&i.FailedAt,
 		&i.CreatedAt,
+		&i.EndpointID,
+		&i.EventType,
+		&i.DeadLetteredAt,
+		&i.LockedUntil,
+		&i.LockedBy,
+		&i.AggregateID,
+		&i.SequenceNumber,
+		&i.Ordered,
+		&i.ReplayID,
 	)
 	return i, err
 }
 
+const listDeadLetteredWebhookDeliveries = `-- name: ListDeadLetteredWebhookDeliveries :many
+SELECT id, tenant_id, event_id, http_status_code, response_body, attempts, max_attempts, next_retry_at, delivered_at, failed_at, created_at, endpoint_id, event_type, dead_lettered_at, locked_until, locked_by, aggregate_id, sequence_number, ordered, replay_id FROM webhook_deliveries
+WHERE tenant_id = $1
+  AND dead_lettered_at IS NOT NULL
+  AND ($2::UUID IS NULL OR endpoint_id = $2::UUID)
+  AND ($3::VARCHAR IS NULL OR event_type = $3::VARCHAR)
+  AND ($4::TIMESTAMPTZ IS NULL OR dead_lettered_at >= $4::TIMESTAMPTZ)
+  AND ($5::TIMESTAMPTZ IS NULL OR dead_lettered_at < $5::TIMESTAMPTZ)
+ORDER BY dead_lettered_at DESC
+LIMIT $6
+`
+
+type ListDeadLetteredWebhookDeliveriesParams struct {
+	TenantID         uuid.UUID          `db:"tenant_id" json:"tenant_id"`
+	EndpointID       pgtype.UUID        `db:"endpoint_id" json:"endpoint_id"`
+	EventType        pgtype.Text        `db:"event_type" json:"event_type"`
+	DeadLetteredFrom pgtype.Timestamptz `db:"dead_lettered_from" json:"dead_lettered_from"`
+	DeadLetteredTo   pgtype.Timestamptz `db:"dead_lettered_to" json:"dead_lettered_to"`
+	MaxResults       int32              `db:"max_results" json:"max_results"`
+}
+
+func (q *Queries) ListDeadLetteredWebhookDeliveries(ctx context.Context, arg ListDeadLetteredWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listDeadLetteredWebhookDeliveries,
+		arg.TenantID,
+		arg.EndpointID,
+		arg.EventType,
+		arg.DeadLetteredFrom,
+		arg.DeadLetteredTo,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.TenantID,
+			&i.EventID,
+			&i.HttpStatusCode,
+			&i.ResponseBody,
+			&i.Attempts,
+			&i.MaxAttempts,
+			&i.NextRetryAt,
+			&i.DeliveredAt,
+			&i.FailedAt,
+			&i.CreatedAt,
+			&i.EndpointID,
+			&i.EventType,
+			&i.DeadLetteredAt,
+			&i.LockedUntil,
+			&i.LockedBy,
+			&i.AggregateID,
+			&i.SequenceNumber,
+			&i.Ordered,
+			&i.ReplayID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const redeliverDeadLetteredWebhookDeliveries = `-- name: RedeliverDeadLetteredWebhookDeliveries :execrows
+
+UPDATE webhook_deliveries
+SET attempts = 0,
+    max_attempts = $1,
+    next_retry_at = NOW(),
+    failed_at = NULL,
+    dead_lettered_at = NULL
+WHERE tenant_id = $2
+  AND dead_lettered_at IS NOT NULL
+  AND ($3::UUID IS NULL OR endpoint_id = $3::UUID)
+  AND ($4::VARCHAR IS NULL OR event_type = $4::VARCHAR)
+  AND ($5::TIMESTAMPTZ IS NULL OR dead_lettered_at >= $5::TIMESTAMPTZ)
+  AND ($6::TIMESTAMPTZ IS NULL OR dead_lettered_at < $6::TIMESTAMPTZ)
+`
+
+type RedeliverDeadLetteredWebhookDeliveriesParams struct {
+	MaxAttempts      pgtype.Int4        `db:"max_attempts" json:"max_attempts"`
+	TenantID         uuid.UUID          `db:"tenant_id" json:"tenant_id"`
+	EndpointID       pgtype.UUID        `db:"endpoint_id" json:"endpoint_id"`
+	EventType        pgtype.Text        `db:"event_type" json:"event_type"`
+	DeadLetteredFrom pgtype.Timestamptz `db:"dead_lettered_from" json:"dead_lettered_from"`
+	DeadLetteredTo   pgtype.Timestamptz `db:"dead_lettered_to" json:"dead_lettered_to"`
+}
+
+// Gives matching dead letters a fresh retry schedule starting now
+func (q *Queries) RedeliverDeadLetteredWebhookDeliveries(ctx context.Context, arg RedeliverDeadLetteredWebhookDeliveriesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, redeliverDeadLetteredWebhookDeliveries,
+		arg.MaxAttempts,
+		arg.TenantID,
+		arg.EndpointID,
+		arg.EventType,
+		arg.DeadLetteredFrom,
+		arg.DeadLetteredTo,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const releaseWebhookDelivery = `-- name: ReleaseWebhookDelivery :exec
+
+UPDATE webhook_deliveries
+SET locked_until = $2,
+    locked_by = NULL
+WHERE id = $1
+`
+
+type ReleaseWebhookDeliveryParams struct {
+	ID          uuid.UUID          `db:"id" json:"id"`
+	LockedUntil pgtype.Timestamptz `db:"locked_until" json:"locked_until"`
+}
+
+// Hands a claimed delivery back unsent; no worker picks it up before locked_until
+func (q *Queries) ReleaseWebhookDelivery(ctx context.Context, arg ReleaseWebhookDeliveryParams) error {
+	_, err := q.db.Exec(ctx, releaseWebhookDelivery, arg.ID, arg.LockedUntil)
+	return err
+}
+
 const resetWebhookDeliveryForRetry = `-- name: ResetWebhookDeliveryForRetry :exec
 UPDATE webhook_deliveries
 SET next_retry_at = NOW(),
-    failed_at = NULL
+    failed_at = NULL,
+    dead_lettered_at = NULL
 WHERE id = $1
 `
 
@@ -187,29 +482,41 @@ func (q *Queries) ResetWebhookDeliveryForRetry(ctx context.Context, id uuid.UUID
 }
 
 const updateWebhookDeliveryFailure = `-- name: UpdateWebhookDeliveryFailure :exec
+
 UPDATE webhook_deliveries 
 SET http_status_code = $2,
     response_body = $3,
     attempts = attempts + 1,
-    next_retry_at = CASE 
-        WHEN attempts + 1 >= max_attempts THEN NULL
-        ELSE NOW() + (INTERVAL '1 minute' * POWER(2, attempts + 1))
-    END,
+    next_retry_at = $4,
     failed_at = CASE 
-        WHEN attempts + 1 >= max_attempts THEN NOW()
+        WHEN $4::TIMESTAMPTZ IS NULL THEN NOW()
         ELSE failed_at
-    END
+    END,
+    dead_lettered_at = CASE
+        WHEN $4::TIMESTAMPTZ IS NULL THEN NOW()
+        ELSE NULL
+    END,
+    locked_until = NULL,
+    locked_by = NULL
 WHERE id = $1
 `
 
 type UpdateWebhookDeliveryFailureParams struct {
-	ID             uuid.UUID   `db:"id" json:"id"`
-	HttpStatusCode pgtype.Int4 `db:"http_status_code" json:"http_status_code"`
-	ResponseBody   pgtype.Text `db:"response_body" json:"response_body"`
+	ID             uuid.UUID          `db:"id" json:"id"`
+	HttpStatusCode pgtype.Int4        `db:"http_status_code" json:"http_status_code"`
+	ResponseBody   pgtype.Text        `db:"response_body" json:"response_body"`
+	NextRetryAt    pgtype.Timestamptz `db:"next_retry_at" json:"next_retry_at"`
 }
 
+// A NULL next_retry_at means the retry schedule is exhausted and the delivery
+// is dead-lettered
 func (q *Queries) UpdateWebhookDeliveryFailure(ctx context.Context, arg UpdateWebhookDeliveryFailureParams) error {
-	_, err := q.db.Exec(ctx, updateWebhookDeliveryFailure, arg.ID, arg.HttpStatusCode, arg.ResponseBody)
+	_, err := q.db.Exec(ctx, updateWebhookDeliveryFailure,
+		arg.ID,
+		arg.HttpStatusCode,
+		arg.ResponseBody,
+		arg.NextRetryAt,
+	)
 	return err
 }
 
@@ -219,7 +526,9 @@ SET http_status_code = $2,
     response_body = $3,
     attempts = attempts + 1,
     delivered_at = NOW(),
-    next_retry_at = NULL
+    next_retry_at = NULL,
+    locked_until = NULL,
+    locked_by = NULL
 WHERE id = $1
 `
 
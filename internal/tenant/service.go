@@ -20,12 +20,14 @@ import (
 type Service struct {
 	db          *storage.DB
 	authService *auth.Service
+	tenancyMode queries.TenancyModeEnum
 }
 
-func NewService(db *storage.DB, authService *auth.Service) *Service {
+func NewService(db *storage.DB, authService *auth.Service, tenancyMode string) *Service {
 	return &Service{
 		db:          db,
 		authService: authService,
+		tenancyMode: queries.TenancyModeEnum(tenancyMode),
 	}
 }
 
@@ -90,6 +92,7 @@ func (s *Service) CreateTenant(ctx context.Context, userID uuid.UUID, req Create
 		CountryCode:  countryCode,
 		BaseCurrency: baseCurrency,
 		Timezone:     timezone,
+		TenancyMode:  s.tenancyMode,
 	})
 	if err != nil {
 		log.Printf("Failed to create tenant in database: %v", err)
@@ -115,13 +118,15 @@ func (s *Service) CreateTenant(ctx context.Context, userID uuid.UUID, req Create
 	}
 	log.Printf("User added to tenant successfully")
 
-	// Create tenant schema in database
-	log.Printf("Creating tenant schema for slug: %s", slug)
-	if err := s.CreateTenantSchema(ctx, slug); err != nil {
-		log.Printf("Failed to create tenant schema: %v", err)
-		return nil, fmt.Errorf("failed to create tenant schema: %w", err)
+	// Create tenant schema in database (rls tenants live in the shared tables)
+	if s.tenancyMode == queries.TenancyModeEnumSchema {
+		log.Printf("Creating tenant schema for slug: %s", slug)
+		if err := s.CreateTenantSchema(ctx, slug); err != nil {
+			log.Printf("Failed to create tenant schema: %v", err)
+			return nil, fmt.Errorf("failed to create tenant schema: %w", err)
+		}
+		log.Printf("Tenant schema created successfully")
 	}
-	log.Printf("Tenant schema created successfully")
 
 	// Commit transaction
 	log.Printf("Committing transaction...")
@@ -320,6 +325,7 @@ func (s *Service) tenantToResponse(tenant queries.Tenant) *TenantResponse {
 		CountryCode:  tenant.CountryCode.String,
 		BaseCurrency: tenant.BaseCurrency.String,
 		Timezone:     tenant.Timezone.String,
+		TenancyMode:  string(tenant.TenancyMode),
 		CreatedAt:    tenant.CreatedAt,
 		UpdatedAt:    tenant.UpdatedAt,
 	}
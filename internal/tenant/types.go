@@ -45,6 +45,7 @@ type TenantResponse struct {
 	CountryCode  string    `json:"country_code"`
 	BaseCurrency string    `json:"base_currency"`
 	Timezone     string    `json:"timezone"`
+	TenancyMode  string    `json:"tenancy_mode"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 	"github.com/temmyjay001/ledger-service/internal/config"
@@ -38,6 +40,55 @@ func SetupTestDB(t *testing.T) *storage.DB {
 	return db
 }
 
+// RLSTestRole is a role with neither SUPERUSER nor BYPASSRLS, so row-level
+// security applies to queries run as it
+const RLSTestRole = "ledger_rls_test"
+
+// SetupRLSTestDB returns a connection whose sessions run as RLSTestRole, for
+// tests of rls-mode isolation: the default test role is a superuser and ignores
+// the policies. The role is granted the public and shared tables plus those of
+// the given tenant schemas.
+func SetupRLSTestDB(t *testing.T, db *storage.DB, schemas ...string) *storage.DB {
+	ctx := context.Background()
+
+	_, err := db.Exec(ctx, `DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = '`+RLSTestRole+`') THEN
+        CREATE ROLE `+RLSTestRole+` NOLOGIN NOSUPERUSER NOBYPASSRLS;
+    END IF;
+END $$`)
+	require.NoError(t, err)
+
+	for _, schema := range append([]string{"public", storage.SharedSchema}, schemas...) {
+		schema = pgx.Identifier{schema}.Sanitize()
+		for _, grant := range []string{
+			"GRANT USAGE ON SCHEMA %s TO %s",
+			"GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA %s TO %s",
+			"GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA %s TO %s",
+		} {
+			_, err := db.Exec(ctx, fmt.Sprintf(grant, schema, RLSTestRole))
+			require.NoError(t, err)
+		}
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(GetTestDatabaseURL())
+	require.NoError(t, err)
+	poolConfig.MaxConns = 5
+	poolConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, "SET ROLE "+RLSTestRole)
+		return err
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	require.NoError(t, err, "Failed to connect to test database as %s", RLSTestRole)
+	t.Cleanup(pool.Close)
+
+	return &storage.DB{
+		Pool:    pool,
+		Queries: queries.New(pool),
+	}
+}
+
 // GetTestDatabaseURL returns the test database URL from environment or default
 func GetTestDatabaseURL() string {
 	// Default test database URL
@@ -55,6 +106,7 @@ func CreateTestTenant(t *testing.T, db *storage.DB, slug string) queries.Tenant
 		CountryCode:  pgtype.Text{String: "NG", Valid: true},
 		BaseCurrency: pgtype.Text{String: "NGN", Valid: true},
 		Timezone:     pgtype.Text{String: "Africa/Lagos", Valid: true},
+		TenancyMode:  queries.TenancyModeEnumSchema,
 	})
 	require.NoError(t, err)
 
@@ -65,6 +117,24 @@ func CreateTestTenant(t *testing.T, db *storage.DB, slug string) queries.Tenant
 	return tenant
 }
 
+// CreateTestRLSTenant creates a tenant stored in the shared row-level-security tables
+func CreateTestRLSTenant(t *testing.T, db *storage.DB, slug string) queries.Tenant {
+	ctx := context.Background()
+
+	tenant, err := db.Queries.CreateTenant(ctx, queries.CreateTenantParams{
+		Name:         "Test Tenant " + slug,
+		Slug:         slug,
+		BusinessType: pgtype.Text{String: "wallet", Valid: true},
+		CountryCode:  pgtype.Text{String: "NG", Valid: true},
+		BaseCurrency: pgtype.Text{String: "NGN", Valid: true},
+		Timezone:     pgtype.Text{String: "Africa/Lagos", Valid: true},
+		TenancyMode:  queries.TenancyModeEnumRls,
+	})
+	require.NoError(t, err)
+
+	return tenant
+}
+
 // CreateTestUser creates a user for testing
 func CreateTestUser(t *testing.T, db *storage.DB, email string) queries.User {
 	ctx := context.Background()
@@ -84,25 +154,27 @@ func CreateTestUser(t *testing.T, db *storage.DB, email string) queries.User {
 func CreateTestAccount(t *testing.T, db *storage.DB, tenantSlug, code, name string, accountType queries.AccountTypeEnum) queries.Account {
 	ctx := context.Background()
 
-	// Set tenant schema
-	err := db.SetSearchPath(ctx, "tenant_"+tenantSlug)
-	require.NoError(t, err)
-	defer db.SetSearchPath(ctx, "public")
-
-	account, err := db.Queries.CreateAccount(ctx, queries.CreateAccountParams{
-		Code:        code,
-		Name:        name,
-		AccountType: accountType,
-		Currency:    "NGN",
-		Metadata:    json.RawMessage("{}"),
-	})
-	require.NoError(t, err)
+	var account queries.Account
+	err := db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		account, err = q.CreateAccount(ctx, queries.CreateAccountParams{
+			Code:        code,
+			Name:        name,
+			AccountType: accountType,
+			Currency:    "NGN",
+			Metadata:    json.RawMessage("{}"),
+		})
+		if err != nil {
+			return err
+		}
 
-	// Create initial balance
-	_, err = db.Queries.CreateAccountBalance(ctx, queries.CreateAccountBalanceParams{
-		AccountID: account.ID,
-		Currency:  "NGN",
-		Balance:   decimal.Zero,
+		// Create initial balance
+		_, err = q.CreateAccountBalance(ctx, queries.CreateAccountBalanceParams{
+			AccountID: account.ID,
+			Currency:  "NGN",
+			Balance:   decimal.Zero,
+		})
+		return err
 	})
 	require.NoError(t, err)
 
@@ -113,16 +185,16 @@ func CreateTestAccount(t *testing.T, db *storage.DB, tenantSlug, code, name stri
 func CreateTestTransaction(t *testing.T, db *storage.DB, tenantSlug string, idempotencyKey string) queries.Transaction {
 	ctx := context.Background()
 
-	// Set tenant schema
-	err := db.SetSearchPath(ctx, "tenant_"+tenantSlug)
-	require.NoError(t, err)
-	defer db.SetSearchPath(ctx, "public")
-
-	transaction, err := db.Queries.CreateTransaction(ctx, queries.CreateTransactionParams{
-		IdempotencyKey: idempotencyKey,
-		Description:    "Test transaction",
-		Reference:      pgtype.Text{String: "TEST-REF", Valid: true},
-		Metadata:       json.RawMessage("{}"),
+	var transaction queries.Transaction
+	err := db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		transaction, err = q.CreateTransaction(ctx, queries.CreateTransactionParams{
+			IdempotencyKey: idempotencyKey,
+			Description:    "Test transaction",
+			Reference:      pgtype.Text{String: "TEST-REF", Valid: true},
+			Metadata:       json.RawMessage("{}"),
+		})
+		return err
 	})
 	require.NoError(t, err)
 
@@ -133,18 +205,18 @@ func CreateTestTransaction(t *testing.T, db *storage.DB, tenantSlug string, idem
 func CreateTestTransactionLine(t *testing.T, db *storage.DB, tenantSlug string, transactionID, accountID uuid.UUID, amount decimal.Decimal, side queries.TransactionSideEnum) queries.TransactionLine {
 	ctx := context.Background()
 
-	// Set tenant schema
-	err := db.SetSearchPath(ctx, "tenant_"+tenantSlug)
-	require.NoError(t, err)
-	defer db.SetSearchPath(ctx, "public")
-
-	line, err := db.Queries.CreateTransactionLine(ctx, queries.CreateTransactionLineParams{
-		TransactionID: transactionID,
-		AccountID:     accountID,
-		Amount:        amount,
-		Side:          side,
-		Currency:      "NGN",
-		Metadata:      json.RawMessage("{}"),
+	var line queries.TransactionLine
+	err := db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		line, err = q.CreateTransactionLine(ctx, queries.CreateTransactionLineParams{
+			TransactionID: transactionID,
+			AccountID:     accountID,
+			Amount:        amount,
+			Side:          side,
+			Currency:      "NGN",
+			Metadata:      json.RawMessage("{}"),
+		})
+		return err
 	})
 	require.NoError(t, err)
 
@@ -155,13 +227,14 @@ func CreateTestTransactionLine(t *testing.T, db *storage.DB, tenantSlug string,
 func AssertAccountBalance(t *testing.T, db *storage.DB, tenantSlug string, accountID uuid.UUID, currency string, expectedBalance decimal.Decimal) {
 	ctx := context.Background()
 
-	err := db.SetSearchPath(ctx, "tenant_"+tenantSlug)
-	require.NoError(t, err)
-	defer db.SetSearchPath(ctx, "public")
-
-	balance, err := db.Queries.GetAccountBalance(ctx, queries.GetAccountBalanceParams{
-		AccountID: accountID,
-		Currency:  currency,
+	var balance queries.AccountBalanceTotal
+	err := db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		balance, err = q.GetAccountBalanceTotal(ctx, queries.GetAccountBalanceTotalParams{
+			AccountID: accountID,
+			Currency:  currency,
+		})
+		return err
 	})
 	require.NoError(t, err)
 
@@ -174,7 +247,7 @@ func CleanupTestTenant(t *testing.T, db *storage.DB, slug string) {
 	ctx := context.Background()
 
 	// Drop schema
-	_, err := db.Exec(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS tenant_%s CASCADE", slug))
+	_, err := db.Exec(ctx, "DROP SCHEMA IF EXISTS "+pgx.Identifier{storage.GetTenantSchema(slug)}.Sanitize()+" CASCADE")
 	if err != nil {
 		t.Logf("Warning: Failed to drop tenant schema: %v", err)
 	}
@@ -210,11 +283,31 @@ func TestConfig() *config.Config {
 		DatabaseURL:            GetTestDatabaseURL(),
 		DatabaseMaxConnections: 5,
 		DatabaseMaxIdleTime:    time.Minute * 5,
+		TenancyMode:            config.TenancyModeSchema,
 		RedisURL:              "redis://localhost:6379/1",
 		JWTSecret:             "test-jwt-secret-key-for-testing",
 		APIKeySecret:          "test-api-key-secret-for-testing",
 		WebhookTimeout:        30 * time.Second,
-		WebhookMaxRetries:     3,
+		WebhookRetrySchedule:  []time.Duration{time.Minute, 5 * time.Minute},
+		WebhookWorkers:        4,
+		WebhookEndpointConcurrency: 2,
+		WebhookCircuitFailureThreshold: 5,
+		WebhookCircuitCooldown:         time.Minute,
+		WebhookAutoDisableAfter:        72 * time.Hour,
+		WebhookAttemptRetention:        30 * 24 * time.Hour,
+		WebhookAllowedHosts:            []string{"127.0.0.1"},
+		WebhookMasterKeys: map[int32][]byte{
+			1: []byte("test-webhook-master-key-32-bytes"),
+		},
+		WebhookSecretOverlap: 24 * time.Hour,
+		WebhookReplayRate:    50,
+
+		PartitionMonthsAhead:         3,
+		PartitionMaintenanceInterval: time.Hour,
+		ArchiveStore:                 "./archive",
+		ArchiveKeepMonths:            12,
+
+		EventSinkRedisStream: "ledger:events:test",
 	}
 }
 
@@ -3,12 +3,14 @@ package transactions
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/temmyjay001/ledger-service/internal/storage"
 	"github.com/temmyjay001/ledger-service/pkg/api"
 	cV "github.com/temmyjay001/ledger-service/pkg/validator"
 )
@@ -43,14 +45,18 @@ func (h *Handlers) CreateTransactionHandler(w http.ResponseWriter, r *http.Reque
 	response, err := h.service.CreateSimpleTransaction(r.Context(), tenantSlug, req)
 	if err != nil {
 		// Handle specific error types
-		if err == ErrDuplicateIdempotencyKey {
-			api.WriteConflictResponse(w, "Transaction with this idempotency key already exists")
+		if errors.Is(err, ErrDuplicateIdempotencyKey) {
+			api.WriteConflictResponse(w, "Transaction with this "+err.Error())
 			return
 		}
 		if err == ErrInvalidAccountCode {
 			api.WriteBadRequestResponse(w, "Invalid account code")
 			return
 		}
+		if errors.Is(err, ErrInsufficientFunds) {
+			api.WriteErrorResponse(w, http.StatusUnprocessableEntity, "Insufficient funds")
+			return
+		}
 		api.WriteInternalErrorResponse(w, err.Error())
 		return
 	}
@@ -76,8 +82,8 @@ func (h *Handlers) CreateDoubleEntryTransactionHandler(w http.ResponseWriter, r
 	response, err := h.service.CreateDoubleEntryTransaction(r.Context(), tenantSlug, req)
 	if err != nil {
 		// Handle specific error types
-		if err == ErrDuplicateIdempotencyKey {
-			api.WriteConflictResponse(w, "Transaction with this idempotency key already exists")
+		if errors.Is(err, ErrDuplicateIdempotencyKey) {
+			api.WriteConflictResponse(w, "Transaction with this "+err.Error())
 			return
 		}
 		if err == ErrUnbalancedTransaction {
@@ -92,6 +98,10 @@ func (h *Handlers) CreateDoubleEntryTransactionHandler(w http.ResponseWriter, r
 			api.WriteBadRequestResponse(w, "One or more account codes are invalid")
 			return
 		}
+		if errors.Is(err, ErrInsufficientFunds) {
+			api.WriteErrorResponse(w, http.StatusUnprocessableEntity, "Insufficient funds")
+			return
+		}
 		api.WriteInternalErrorResponse(w, err.Error())
 		return
 	}
@@ -112,7 +122,12 @@ func (h *Handlers) GetTransactionHandler(w http.ResponseWriter, r *http.Request)
 
 	response, err := h.service.GetTransaction(r.Context(), tenantSlug, id)
 	if err != nil {
-		if err == ErrTransactionNotFound {
+		var archived *storage.ArchivedRangeError
+		if errors.As(err, &archived) {
+			api.WriteErrorResponse(w, http.StatusGone, archived.Error())
+			return
+		}
+		if errors.Is(err, ErrTransactionNotFound) {
 			api.WriteNotFoundResponse(w, "Transaction not found")
 			return
 		}
@@ -136,6 +151,11 @@ func (h *Handlers) GetTransactionLinesHandler(w http.ResponseWriter, r *http.Req
 
 	lines, err := h.service.GetTransactionLines(r.Context(), tenantSlug, id)
 	if err != nil {
+		var archived *storage.ArchivedRangeError
+		if errors.As(err, &archived) {
+			api.WriteErrorResponse(w, http.StatusGone, archived.Error())
+			return
+		}
 		api.WriteInternalErrorResponse(w, err.Error())
 		return
 	}
@@ -173,6 +193,11 @@ func (h *Handlers) ListTransactionsHandler(w http.ResponseWriter, r *http.Reques
 
 	response, err := h.service.ListTransactions(r.Context(), tenantSlug, filters)
 	if err != nil {
+		var archived *storage.ArchivedRangeError
+		if errors.As(err, &archived) {
+			api.WriteErrorResponse(w, http.StatusGone, archived.Error())
+			return
+		}
 		api.WriteInternalErrorResponse(w, err.Error())
 		return
 	}
@@ -5,6 +5,10 @@ package transactions
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,7 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/temmyjay001/ledger-service/internal/events"
 	"github.com/temmyjay001/ledger-service/internal/storage/queries"
-	"github.com/temmyjay001/ledger-service/internal/testutil"
+	"github.com/temmyjay001/ledger-service/internal/testUtil"
 )
 
 func TestIntegration_CreateSimpleTransaction(t *testing.T) {
@@ -30,7 +34,7 @@ func TestIntegration_CreateSimpleTransaction(t *testing.T) {
 
 	// Create test accounts
 	cashAccount := testutil.CreateTestAccount(t, db, tenantSlug, "1000", "Cash", queries.AccountTypeEnumAsset)
-	revenueAccount := testutil.CreateTestAccount(t, db, tenantSlug, "4000", "Revenue", queries.AccountTypeEnumRevenue)
+	testutil.CreateTestAccount(t, db, tenantSlug, "4000", "Revenue", queries.AccountTypeEnumRevenue)
 
 	// Create services
 	eventService := events.NewService(db)
@@ -66,9 +70,6 @@ func TestIntegration_CreateSimpleTransaction(t *testing.T) {
 	assert.Equal(t, response.ID, response2.ID, "Should return same transaction for duplicate idempotency key")
 
 	// Verify events were created
-	err = db.SetSearchPath(ctx, "public")
-	require.NoError(t, err)
-	
 	events, err := db.Queries.GetEventsByAggregate(ctx, queries.GetEventsByAggregateParams{
 		TenantID:    tenant.ID,
 		AggregateID: testutil.MustParseUUID(response.ID),
@@ -139,7 +140,7 @@ func TestIntegration_CreateDoubleEntryTransaction(t *testing.T) {
 	// Setup
 	db := testutil.SetupTestDB(t)
 	tenantSlug := testutil.RandomSlug()
-	tenant := testutil.CreateTestTenant(t, db, tenantSlug)
+	testutil.CreateTestTenant(t, db, tenantSlug)
 	
 	t.Cleanup(func() {
 		testutil.CleanupTestTenant(t, db, tenantSlug)
@@ -303,6 +304,258 @@ func TestIntegration_ConcurrentTransactions(t *testing.T) {
 	testutil.AssertAccountBalance(t, db, tenantSlug, cashAccount.ID, "NGN", expectedBalance)
 }
 
+func TestIntegration_HighVolumeAccountConcurrentTransactions(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	// Setup
+	db := testutil.SetupTestDB(t)
+	tenantSlug := testutil.RandomSlug()
+	testutil.CreateTestTenant(t, db, tenantSlug)
+
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, tenantSlug)
+	})
+
+	// Create test account and spread its balance across slots
+	cashAccount := testutil.CreateTestAccount(t, db, tenantSlug, "1000", "Cash", queries.AccountTypeEnumAsset)
+
+	ctx := context.Background()
+	err := db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		_, err := q.UpsertHighVolumeAccount(ctx, queries.UpsertHighVolumeAccountParams{
+			AccountID:           cashAccount.ID,
+			SlotCount:           8,
+			OverdraftProtection: true,
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	// Create services
+	eventService := events.NewService(db)
+	service := NewService(db, eventService)
+
+	amount := decimal.NewFromInt(100)
+	post := func(count int, side string) []error {
+		done := make(chan error, count)
+		for i := 0; i < count; i++ {
+			go func() {
+				_, err := service.CreateSimpleTransaction(ctx, tenantSlug, CreateTransactionRequest{
+					IdempotencyKey: testutil.RandomString(20),
+					Description:    "High-volume transaction",
+					AccountCode:    cashAccount.Code,
+					Amount:         amount,
+					Side:           side,
+					Currency:       "NGN",
+				})
+				done <- err
+			}()
+		}
+
+		errs := make([]error, 0, count)
+		for i := 0; i < count; i++ {
+			errs = append(errs, <-done)
+		}
+		return errs
+	}
+
+	// Deposits land in slots and must all be counted
+	for _, err := range post(50, "debit") {
+		require.NoError(t, err)
+	}
+	testutil.AssertAccountBalance(t, db, tenantSlug, cashAccount.ID, "NGN", amount.Mul(decimal.NewFromInt(50)))
+
+	// More withdrawals than the balance covers; the excess must be rejected
+	var succeeded, rejected int
+	for _, err := range post(60, "credit") {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrInsufficientFunds):
+			rejected++
+		default:
+			require.NoError(t, err)
+		}
+	}
+	assert.Equal(t, 50, succeeded)
+	assert.Equal(t, 10, rejected)
+	testutil.AssertAccountBalance(t, db, tenantSlug, cashAccount.ID, "NGN", decimal.Zero)
+
+	// Folding the slots keeps the balance intact
+	err = db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		if err := q.DeleteHighVolumeAccount(ctx, cashAccount.ID); err != nil {
+			return err
+		}
+		_, err := q.FoldAccountBalanceSlots(ctx, cashAccount.ID)
+		return err
+	})
+	require.NoError(t, err)
+	testutil.AssertAccountBalance(t, db, tenantSlug, cashAccount.ID, "NGN", decimal.Zero)
+
+	// Only the base row postings are versioned, and the fold publishes nothing,
+	// so the balance's versions still number up without gaps
+	for _, err := range post(1, "debit") {
+		require.NoError(t, err)
+	}
+	tenant, err := db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	require.NoError(t, err)
+	balanceEvents, err := eventService.GetEventsByAggregate(ctx, tenant.ID, events.BalanceAggregateID(cashAccount.ID, "NGN"))
+	require.NoError(t, err)
+	require.NoError(t, events.VerifyAggregateVersions(balanceEvents))
+
+	versioned := 0
+	for _, event := range balanceEvents {
+		if event.AggregateVersion.Valid {
+			versioned++
+		}
+	}
+	assert.Equal(t, succeeded+1, versioned)
+}
+
+func TestIntegration_OpposingTransfersStress(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	// Setup
+	db := testutil.SetupTestDB(t)
+	tenantSlug := testutil.RandomSlug()
+	testutil.CreateTestTenant(t, db, tenantSlug)
+
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, tenantSlug)
+	})
+
+	// Create test accounts
+	walletA := testutil.CreateTestAccount(t, db, tenantSlug, "2001", "Wallet A", queries.AccountTypeEnumLiability)
+	walletB := testutil.CreateTestAccount(t, db, tenantSlug, "2002", "Wallet B", queries.AccountTypeEnumLiability)
+
+	// Create services
+	eventService := events.NewService(db)
+	service := NewService(db, eventService)
+
+	// Transfers alternate direction and list their entries in transfer
+	// order, which used to lock the two balances in opposite orders
+	numTransfers := 2000
+	workers := 50
+	amount := decimal.NewFromInt(10)
+
+	jobs := make(chan int)
+	errs := make(chan error, numTransfers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				from, to := walletA, walletB
+				if i%2 == 1 {
+					from, to = walletB, walletA
+				}
+
+				_, err := service.CreateDoubleEntryTransaction(context.Background(), tenantSlug, CreateDoubleEntryRequest{
+					IdempotencyKey: fmt.Sprintf("stress-%d", i),
+					Description:    "Opposing transfer",
+					Entries: []TransactionLineEntry{
+						{AccountCode: from.Code, Amount: amount, Side: "debit", Currency: "NGN"},
+						{AccountCode: to.Code, Amount: amount, Side: "credit", Currency: "NGN"},
+					},
+				})
+				if err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < numTransfers; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Equal numbers of transfers in each direction cancel out
+	testutil.AssertAccountBalance(t, db, tenantSlug, walletA.ID, "NGN", decimal.Zero)
+	testutil.AssertAccountBalance(t, db, tenantSlug, walletB.ID, "NGN", decimal.Zero)
+}
+
+func TestIntegration_ConcurrentFirstPostingsInACurrency(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	// Setup
+	db := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	tenantSlug := testutil.RandomSlug()
+	testutil.CreateTestTenant(t, db, tenantSlug)
+
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, tenantSlug)
+	})
+
+	eventService := events.NewService(db)
+	service := NewService(db, eventService)
+
+	// Each round posts to a fresh pair of accounts with no balance rows yet,
+	// so all of its transfers race to create them
+	rounds := 5
+	transfers := 10
+	amount := decimal.NewFromInt(10)
+
+	for round := 0; round < rounds; round++ {
+		var accounts []queries.Account
+		for i, accountType := range []queries.AccountTypeEnum{queries.AccountTypeEnumAsset, queries.AccountTypeEnumLiability} {
+			var account queries.Account
+			err := db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+				var err error
+				account, err = q.CreateAccount(ctx, queries.CreateAccountParams{
+					Code:        fmt.Sprintf("3%d%d", round, i),
+					Name:        "Fresh account",
+					AccountType: accountType,
+					Currency:    "USD",
+					Metadata:    json.RawMessage("{}"),
+				})
+				return err
+			})
+			require.NoError(t, err)
+			accounts = append(accounts, account)
+		}
+
+		start := make(chan struct{})
+		errs := make(chan error, transfers)
+		var wg sync.WaitGroup
+		for i := 0; i < transfers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				_, err := service.CreateDoubleEntryTransaction(ctx, tenantSlug, CreateDoubleEntryRequest{
+					IdempotencyKey: fmt.Sprintf("first-%d-%d", round, i),
+					Description:    "First transfer",
+					Entries: []TransactionLineEntry{
+						{AccountCode: accounts[0].Code, Amount: amount, Side: "debit", Currency: "USD"},
+						{AccountCode: accounts[1].Code, Amount: amount, Side: "credit", Currency: "USD"},
+					},
+				})
+				errs <- err
+			}()
+		}
+		close(start)
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		total := amount.Mul(decimal.NewFromInt(int64(transfers)))
+		testutil.AssertAccountBalance(t, db, tenantSlug, accounts[0].ID, "USD", total)
+		testutil.AssertAccountBalance(t, db, tenantSlug, accounts[1].ID, "USD", total)
+	}
+}
+
 func TestIntegration_TransactionHistory(t *testing.T) {
 	testutil.SkipIfShort(t)
 
@@ -361,4 +614,91 @@ func TestIntegration_TransactionHistory(t *testing.T) {
 			response.Transactions[i].CreatedAt.Equal(response.Transactions[i+1].CreatedAt),
 			"Transactions should be ordered by creation time descending")
 	}
-}
\ No newline at end of file
+}
+func TestIntegration_BalanceEventsCarryAggregateVersions(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	tenantSlug := testutil.RandomSlug()
+	tenant := testutil.CreateTestTenant(t, db, tenantSlug)
+
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, tenantSlug)
+	})
+
+	cashAccount := testutil.CreateTestAccount(t, db, tenantSlug, "1000", "Cash", queries.AccountTypeEnumAsset)
+
+	eventService := events.NewService(db)
+	service := NewService(db, eventService)
+	ctx := context.Background()
+
+	numTransactions := 5
+	var wg sync.WaitGroup
+	errs := make(chan error, numTransactions+1)
+	post := func(currency string) {
+		defer wg.Done()
+		_, err := service.CreateSimpleTransaction(ctx, tenantSlug, CreateTransactionRequest{
+			IdempotencyKey: testutil.RandomString(20),
+			Description:    "Versioned balance",
+			AccountCode:    cashAccount.Code,
+			Amount:         decimal.NewFromInt(100),
+			Side:           "debit",
+			Currency:       currency,
+		})
+		errs <- err
+	}
+	for i := 0; i < numTransactions; i++ {
+		wg.Add(1)
+		go post("NGN")
+	}
+	wg.Add(1)
+	go post("USD")
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// Each currency is its own aggregate, versioned like the balance row
+	ngnEvents, err := eventService.GetEventsByAggregate(ctx, tenant.ID, events.BalanceAggregateID(cashAccount.ID, "NGN"))
+	require.NoError(t, err)
+	require.Len(t, ngnEvents, numTransactions)
+	for i, event := range ngnEvents {
+		assert.Equal(t, events.AggregateTypeBalance, event.AggregateType)
+		assert.Equal(t, int64(i+1), event.AggregateVersion.Int64)
+	}
+	require.NoError(t, events.VerifyAggregateVersions(ngnEvents))
+
+	usdEvents, err := eventService.GetEventsByAggregate(ctx, tenant.ID, events.BalanceAggregateID(cashAccount.ID, "USD"))
+	require.NoError(t, err)
+	require.Len(t, usdEvents, 1)
+	assert.Equal(t, int64(1), usdEvents[0].AggregateVersion.Int64)
+
+	// A second event at a taken version is rejected
+	_, err = db.Queries.CreateEvent(ctx, queries.CreateEventParams{
+		TenantID:         tenant.ID,
+		AggregateID:      ngnEvents[0].AggregateID,
+		AggregateType:    events.AggregateTypeBalance,
+		EventType:        events.EventTypeBalanceUpdated,
+		EventVersion:     1,
+		EventData:        ngnEvents[0].EventData,
+		Metadata:         ngnEvents[0].Metadata,
+		AggregateVersion: ngnEvents[0].AggregateVersion,
+	})
+	assert.Error(t, err)
+
+	// The account's history includes the events of its balances
+	history, err := eventService.ListEvents(ctx, tenant.ID, events.ListEventsRequest{
+		AccountID: &cashAccount.ID,
+		Limit:     events.MaxListLimit,
+	})
+	require.NoError(t, err)
+	balanceEvents := 0
+	for _, event := range history.Events {
+		if event.EventType == events.EventTypeBalanceUpdated {
+			balanceEvents++
+			assert.NotZero(t, event.AggregateVersion)
+		}
+	}
+	assert.Equal(t, numTransactions+1, balanceEvents)
+}
@@ -2,15 +2,22 @@
 package transactions
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/shopspring/decimal"
 	"github.com/temmyjay001/ledger-service/internal/events"
+	"github.com/temmyjay001/ledger-service/internal/requestctx"
 	"github.com/temmyjay001/ledger-service/internal/storage"
 	"github.com/temmyjay001/ledger-service/internal/storage/queries"
 )
@@ -26,137 +33,110 @@ func NewService(db *storage.DB, eventService *events.Service) *Service {
 
 // CreateSimpleTransaction creates a single-entry transaction
 func (s *Service) CreateSimpleTransaction(ctx context.Context, tenantSlug string, req CreateTransactionRequest) (*TransactionResponse, error) {
-	// Get tenant ID for events
-	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get tenant: %w", err)
-	}
+	var transaction queries.Transaction
+	var duplicate bool
 
-	// Set tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
+	err := s.db.WithTenant(ctx, tenantSlug, func(qtx *queries.Queries) error {
+		// Get tenant ID for events
+		tenant, err := qtx.GetTenantBySlug(ctx, tenantSlug)
+		if err != nil {
+			return fmt.Errorf("failed to get tenant: %w", err)
+		}
 
-	// Check idempotency
-	existing, err := s.db.Queries.GetTransactionByIdempotencyKey(ctx, req.IdempotencyKey)
-	if err == nil {
-		log.Printf("Transaction with idempotency key %s already exists", req.IdempotencyKey)
-		return s.transactionToResponse(existing)
-	}
+		// Check idempotency
+		existing, err := getTransactionByIdempotencyKey(ctx, qtx, req.IdempotencyKey)
+		if err == nil {
+			requestctx.Logf(ctx, "Transaction with idempotency key %s already exists", req.IdempotencyKey)
+			transaction, duplicate = existing, true
+			return nil
+		}
+		if !errors.Is(err, ErrTransactionNotFound) {
+			return err
+		}
 
-	// Start database transaction
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to start transaction: %w", err)
-	}
-	defer tx.Rollback(ctx)
+		// Validate account exists
+		account, err := qtx.GetAccountByCode(ctx, req.AccountCode)
+		if err != nil {
+			return fmt.Errorf("account not found: %w", err)
+		}
 
-	// Create transaction queries with tx
-	qtx := s.db.Queries.WithTx(tx)
+		// Create transaction record
+		reference := pgtype.Text{}
+		if req.Reference != "" {
+			reference = pgtype.Text{String: req.Reference, Valid: true}
+		}
 
-	// Validate account exists
-	account, err := qtx.GetAccountByCode(ctx, req.AccountCode)
-	if err != nil {
-		return nil, fmt.Errorf("account not found: %w", err)
-	}
+		transaction, err = qtx.CreateTransaction(ctx, queries.CreateTransactionParams{
+			IdempotencyKey: req.IdempotencyKey,
+			Description:    req.Description,
+			Reference:      reference,
+			Metadata:       req.Metadata,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
 
-	// Create transaction record
-	reference := pgtype.Text{}
-	if req.Reference != "" {
-		reference = pgtype.Text{String: req.Reference, Valid: true}
-	}
+		// Create transaction line
+		line, err := qtx.CreateTransactionLine(ctx, queries.CreateTransactionLineParams{
+			TransactionID: transaction.ID,
+			AccountID:     account.ID,
+			Amount:        req.Amount,
+			Side:          queries.TransactionSideEnum(req.Side),
+			Currency:      req.Currency,
+			Metadata:      req.Metadata,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create transaction line: %w", err)
+		}
 
-	transaction, err := qtx.CreateTransaction(ctx, queries.CreateTransactionParams{
-		IdempotencyKey: req.IdempotencyKey,
-		Description:    req.Description,
-		Reference:      reference,
-		Metadata:       req.Metadata,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
-	}
+		// Update account balance, keeping old and new balances for the event
+		delta := s.calculateNewBalance(decimal.Zero, req.Amount, req.Side, account.AccountType)
+		change, err := s.updateAccountBalance(ctx, qtx, account, delta, req.Currency)
+		if err != nil {
+			return fmt.Errorf("failed to update balance: %w", err)
+		}
 
-	// Create transaction line
-	line, err := qtx.CreateTransactionLine(ctx, queries.CreateTransactionLineParams{
-		TransactionID: transaction.ID,
-		AccountID:     account.ID,
-		Amount:        req.Amount,
-		Side:          queries.TransactionSideEnum(req.Side),
-		Currency:      req.Currency,
-		Metadata:      req.Metadata,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create transaction line: %w", err)
-	}
+		// Mark transaction as posted
+		transaction, err = qtx.UpdateTransactionStatus(ctx, queries.UpdateTransactionStatusParams{
+			ID:     transaction.ID,
+			Status: queries.NullTransactionStatusEnum{TransactionStatusEnum: queries.TransactionStatusEnumPosted, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to post transaction: %w", err)
+		}
 
-	// Get old balance for event
-	oldBalance := decimal.Zero
-	balance, err := qtx.GetAccountBalanceForUpdate(ctx, queries.GetAccountBalanceForUpdateParams{
-		AccountID: account.ID,
-		Currency:  req.Currency,
-	})
-	if err == nil {
-		oldBalance = balance.Balance
-	}
+		// Create account map for event publishing
+		accounts := map[uuid.UUID]queries.Account{
+			account.ID: account,
+		}
 
-	// Update account balance with optimistic locking
-	if err := s.updateAccountBalance(ctx, qtx, account, req.Amount, req.Side, req.Currency); err != nil {
-		return nil, fmt.Errorf("failed to update balance: %w", err)
-	}
+		// Create lines slice for event publishing
+		lines := []queries.TransactionLine{line}
 
-	// Get new balance for event
-	newBalance := s.calculateNewBalance(oldBalance, req.Amount, req.Side, account.AccountType)
+		// Publish transaction posted event
+		if err := s.eventService.PublishTransactionPosted(ctx, qtx, tenant.ID, transaction, lines, accounts); err != nil {
+			return fmt.Errorf("failed to publish transaction event: %w", err)
+		}
+
+		// Publish balance updated event
+		if err := s.eventService.PublishBalanceUpdated(ctx, qtx, tenant.ID, account, change.oldBalance, change.newBalance, transaction.ID, req.Currency, change.version); err != nil {
+			return fmt.Errorf("failed to publish balance event: %w", err)
+		}
 
-	// Mark transaction as posted
-	transaction, err = qtx.UpdateTransactionStatus(ctx, queries.UpdateTransactionStatusParams{
-		ID:     transaction.ID,
-		Status: queries.NullTransactionStatusEnum{TransactionStatusEnum: queries.TransactionStatusEnumPosted, Valid: true},
+		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to post transaction: %w", err)
-	}
-
-	// Create account map for event publishing
-	accounts := map[uuid.UUID]queries.Account{
-		account.ID: account,
-	}
-
-	// Create lines slice for event publishing
-	lines := []queries.TransactionLine{line}
-
-	// Publish transaction posted event
-	if err := s.eventService.PublishTransactionPosted(ctx, qtx, tenant.ID, transaction, lines, accounts); err != nil {
-		return nil, fmt.Errorf("failed to publish transaction event: %w", err)
-	}
-
-	// Publish balance updated event
-	if err := s.eventService.PublishBalanceUpdated(ctx, qtx, tenant.ID, account, oldBalance, newBalance, transaction.ID, req.Currency, 1); err != nil {
-		return nil, fmt.Errorf("failed to publish balance event: %w", err)
+		return nil, err
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	if !duplicate {
+		requestctx.Logf(ctx, "Simple transaction created successfully: %s", transaction.ID)
 	}
-
-	log.Printf("Simple transaction created successfully: %s", transaction.ID)
 	return s.transactionToResponse(transaction)
 }
 
 // CreateDoubleEntryTransaction creates a double-entry transaction
 func (s *Service) CreateDoubleEntryTransaction(ctx context.Context, tenantSlug string, req CreateDoubleEntryRequest) (*TransactionResponse, error) {
-	// Get tenant ID for events
-	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get tenant: %w", err)
-	}
-
-	// Set tenant schema
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
-
 	// Validate double-entry balance
 	if err := s.validateDoubleEntryBalance(req.Entries); err != nil {
 		return nil, err
@@ -167,139 +147,141 @@ func (s *Service) CreateDoubleEntryTransaction(ctx context.Context, tenantSlug s
 		return nil, err
 	}
 
-	// Check idempotency
-	existing, err := s.db.Queries.GetTransactionByIdempotencyKey(ctx, req.IdempotencyKey)
-	if err == nil {
-		log.Printf("Transaction with idempotency key %s already exists", req.IdempotencyKey)
-		return s.transactionToResponse(existing)
-	}
+	var transaction queries.Transaction
+	var duplicate bool
 
-	// Start database transaction
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to start transaction: %w", err)
-	}
-	defer tx.Rollback(ctx)
+	err := s.db.WithTenant(ctx, tenantSlug, func(qtx *queries.Queries) error {
+		// Get tenant ID for events
+		tenant, err := qtx.GetTenantBySlug(ctx, tenantSlug)
+		if err != nil {
+			return fmt.Errorf("failed to get tenant: %w", err)
+		}
+
+		// Check idempotency
+		existing, err := getTransactionByIdempotencyKey(ctx, qtx, req.IdempotencyKey)
+		if err == nil {
+			requestctx.Logf(ctx, "Transaction with idempotency key %s already exists", req.IdempotencyKey)
+			transaction, duplicate = existing, true
+			return nil
+		}
+		if !errors.Is(err, ErrTransactionNotFound) {
+			return err
+		}
 
-	qtx := s.db.Queries.WithTx(tx)
+		// Validate all accounts exist
+		accountMap := make(map[uuid.UUID]queries.Account)
+		accountCodeMap := make(map[string]queries.Account)
+		for _, entry := range req.Entries {
+			account, err := qtx.GetAccountByCode(ctx, entry.AccountCode)
+			if err != nil {
+				return fmt.Errorf("account %s not found: %w", entry.AccountCode, err)
+			}
+			accountMap[account.ID] = account
+			accountCodeMap[entry.AccountCode] = account
+		}
 
-	// Validate all accounts exist
-	accountMap := make(map[uuid.UUID]queries.Account)
-	accountCodeMap := make(map[string]queries.Account)
-	for _, entry := range req.Entries {
-		account, err := qtx.GetAccountByCode(ctx, entry.AccountCode)
+		// Create transaction record
+		reference := pgtype.Text{}
+		if req.Reference != "" {
+			reference = pgtype.Text{String: req.Reference, Valid: true}
+		}
+
+		transaction, err = qtx.CreateTransaction(ctx, queries.CreateTransactionParams{
+			IdempotencyKey: req.IdempotencyKey,
+			Description:    req.Description,
+			Reference:      reference,
+			Metadata:       req.Metadata,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("account %s not found: %w", entry.AccountCode, err)
+			return fmt.Errorf("failed to create transaction: %w", err)
 		}
-		accountMap[account.ID] = account
-		accountCodeMap[entry.AccountCode] = account
-	}
 
-	// Create transaction record
-	reference := pgtype.Text{}
-	if req.Reference != "" {
-		reference = pgtype.Text{String: req.Reference, Valid: true}
-	}
+		// Net the entries per balance and lock every balance up front in a
+		// stable order, so concurrent postings touching the same accounts
+		// (e.g. opposing transfers) always queue instead of deadlocking
+		deltas := make(map[balanceKey]decimal.Decimal)
+		for _, entry := range req.Entries {
+			account := accountCodeMap[entry.AccountCode]
+			key := balanceKey{accountID: account.ID, currency: entry.Currency}
+			deltas[key] = deltas[key].Add(s.calculateNewBalance(decimal.Zero, entry.Amount, entry.Side, account.AccountType))
+		}
 
-	transaction, err := qtx.CreateTransaction(ctx, queries.CreateTransactionParams{
-		IdempotencyKey: req.IdempotencyKey,
-		Description:    req.Description,
-		Reference:      reference,
-		Metadata:       req.Metadata,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
-	}
+		var balanceChanges []balanceChange
+		for _, key := range slices.SortedFunc(maps.Keys(deltas), balanceKey.compare) {
+			account := accountMap[key.accountID]
 
-	// Create transaction lines and collect balance changes
-	var lines []queries.TransactionLine
-	balanceChanges := make(map[uuid.UUID]struct {
-		oldBalance decimal.Decimal
-		newBalance decimal.Decimal
-		currency   string
-	})
+			change, err := s.updateAccountBalance(ctx, qtx, account, deltas[key], key.currency)
+			if err != nil {
+				return fmt.Errorf("failed to update balance for account %s: %w", account.Code, err)
+			}
 
-	for _, entry := range req.Entries {
-		account := accountCodeMap[entry.AccountCode]
+			balanceChanges = append(balanceChanges, change)
+		}
 
-		// Get old balance for event tracking
-		oldBalance := decimal.Zero
-		balance, err := qtx.GetAccountBalanceForUpdate(ctx, queries.GetAccountBalanceForUpdateParams{
-			AccountID: account.ID,
-			Currency:  entry.Currency,
-		})
-		if err == nil {
-			oldBalance = balance.Balance
+		// Create transaction lines
+		var lines []queries.TransactionLine
+		for _, entry := range req.Entries {
+			account := accountCodeMap[entry.AccountCode]
+
+			// Create transaction line
+			line, err := qtx.CreateTransactionLine(ctx, queries.CreateTransactionLineParams{
+				TransactionID: transaction.ID,
+				AccountID:     account.ID,
+				Amount:        entry.Amount,
+				Side:          queries.TransactionSideEnum(entry.Side),
+				Currency:      entry.Currency,
+				Metadata:      entry.Metadata,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create transaction line for account %s: %w", entry.AccountCode, err)
+			}
+			lines = append(lines, line)
 		}
 
-		// Create transaction line
-		line, err := qtx.CreateTransactionLine(ctx, queries.CreateTransactionLineParams{
-			TransactionID: transaction.ID,
-			AccountID:     account.ID,
-			Amount:        entry.Amount,
-			Side:          queries.TransactionSideEnum(entry.Side),
-			Currency:      entry.Currency,
-			Metadata:      entry.Metadata,
+		// Mark transaction as posted
+		transaction, err = qtx.UpdateTransactionStatus(ctx, queries.UpdateTransactionStatusParams{
+			ID:     transaction.ID,
+			Status: queries.NullTransactionStatusEnum{TransactionStatusEnum: queries.TransactionStatusEnumPosted, Valid: true},
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to create transaction line for account %s: %w", entry.AccountCode, err)
+			return fmt.Errorf("failed to post transaction: %w", err)
 		}
-		lines = append(lines, line)
 
-		// Update account balance
-		if err := s.updateAccountBalance(ctx, qtx, account, entry.Amount, entry.Side, entry.Currency); err != nil {
-			return nil, fmt.Errorf("failed to update balance for account %s: %w", entry.AccountCode, err)
+		// Publish transaction posted event
+		if err := s.eventService.PublishTransactionPosted(ctx, qtx, tenant.ID, transaction, lines, accountMap); err != nil {
+			return fmt.Errorf("failed to publish transaction event: %w", err)
 		}
 
-		// Calculate new balance for event
-		newBalance := s.calculateNewBalance(oldBalance, entry.Amount, entry.Side, account.AccountType)
-		balanceChanges[account.ID] = struct {
-			oldBalance decimal.Decimal
-			newBalance decimal.Decimal
-			currency   string
-		}{oldBalance, newBalance, entry.Currency}
-	}
+		// Publish balance updated events for each affected account
+		for _, change := range balanceChanges {
+			if err := s.eventService.PublishBalanceUpdated(ctx, qtx, tenant.ID, change.account, change.oldBalance, change.newBalance, transaction.ID, change.currency, change.version); err != nil {
+				return fmt.Errorf("failed to publish balance event for account %s: %w", change.account.Code, err)
+			}
+		}
 
-	// Mark transaction as posted
-	transaction, err = qtx.UpdateTransactionStatus(ctx, queries.UpdateTransactionStatusParams{
-		ID:     transaction.ID,
-		Status: queries.NullTransactionStatusEnum{TransactionStatusEnum: queries.TransactionStatusEnumPosted, Valid: true},
+		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to post transaction: %w", err)
-	}
-
-	// Publish transaction posted event
-	if err := s.eventService.PublishTransactionPosted(ctx, qtx, tenant.ID, transaction, lines, accountMap); err != nil {
-		return nil, fmt.Errorf("failed to publish transaction event: %w", err)
-	}
-
-	// Publish balance updated events for each affected account
-	for accountID, change := range balanceChanges {
-		account := accountMap[accountID]
-		if err := s.eventService.PublishBalanceUpdated(ctx, qtx, tenant.ID, account, change.oldBalance, change.newBalance, transaction.ID, change.currency, 1); err != nil {
-			return nil, fmt.Errorf("failed to publish balance event for account %s: %w", account.Code, err)
-		}
+		return nil, err
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	if !duplicate {
+		requestctx.Logf(ctx, "Double-entry transaction created successfully: %s", transaction.ID)
 	}
-
-	log.Printf("Double-entry transaction created successfully: %s", transaction.ID)
 	return s.transactionToResponse(transaction)
 }
 
-// GetTransaction retrieves a single transaction by ID
+// GetTransaction retrieves a single transaction by ID. A transaction whose
+// month has been archived fails with an *storage.ArchivedRangeError.
 func (s *Service) GetTransaction(ctx context.Context, tenantSlug string, id uuid.UUID) (*TransactionResponse, error) {
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
-
-	transaction, err := s.db.Queries.GetTransactionByID(ctx, id)
+	var transaction queries.Transaction
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		transaction, err = getTransactionByID(ctx, q, id)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("transaction not found: %w", err)
+		return nil, err
 	}
 
 	return s.transactionToResponse(transaction)
@@ -307,12 +289,20 @@ func (s *Service) GetTransaction(ctx context.Context, tenantSlug string, id uuid
 
 // GetTransactionLines retrieves lines for a transaction
 func (s *Service) GetTransactionLines(ctx context.Context, tenantSlug string, transactionID uuid.UUID) ([]TransactionLineResponse, error) {
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
+	var lines []queries.GetTransactionLinesRow
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		lines, err = q.GetTransactionLines(ctx, transactionID)
+		if err != nil || len(lines) > 0 {
+			return err
+		}
 
-	lines, err := s.db.Queries.GetTransactionLines(ctx, transactionID)
+		// the lines may have been archived with their transaction
+		if _, err := getTransactionByID(ctx, q, transactionID); errors.Is(err, storage.ErrArchivedRange) {
+			return err
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction lines: %w", err)
 	}
@@ -335,55 +325,70 @@ func (s *Service) GetTransactionLines(ctx context.Context, tenantSlug string, tr
 	return response, nil
 }
 
-// ListTransactions retrieves transactions with filtering
+// ListTransactions retrieves transactions with filtering. Date ranges that
+// reach into archived months fail with an *storage.ArchivedRangeError, and
+// listings without one report the months they are missing.
 func (s *Service) ListTransactions(ctx context.Context, tenantSlug string, req ListTransactionsRequest) (*TransactionListResponse, error) {
-	if err := s.db.SetSearchPath(ctx, "tenant_"+tenantSlug); err != nil {
-		return nil, fmt.Errorf("failed to set tenant schema: %w", err)
-	}
-	defer s.db.SetSearchPath(ctx, "public")
-
 	var transactions []queries.Transaction
-	var err error
-
-	// Apply different query strategies based on filters
-	if req.AccountCode != "" && req.StartDate != "" && req.EndDate != "" {
-		// Account + Date range
-		startDate, _ := time.Parse("2006-01-02", req.StartDate)
-		endDate, _ := time.Parse("2006-01-02", req.EndDate)
-
-		transactions, err = s.db.Queries.ListTransactionsByAccountAndDateRange(ctx, queries.ListTransactionsByAccountAndDateRangeParams{
-			Code:       req.AccountCode,
-			PostedAt:   startDate,
-			PostedAt_2: endDate,
-			Limit:      int32(req.Limit),
-			Offset:     int32(req.Offset),
-		})
-	} else if req.AccountCode != "" {
-		// Account only
-		transactions, err = s.db.Queries.ListTransactionsByAccount(ctx, queries.ListTransactionsByAccountParams{
-			Code:   req.AccountCode,
-			Limit:  int32(req.Limit),
-			Offset: int32(req.Offset),
-		})
-	} else if req.StartDate != "" && req.EndDate != "" {
-		// Date range only
-		startDate, _ := time.Parse("2006-01-02", req.StartDate)
-		endDate, _ := time.Parse("2006-01-02", req.EndDate)
-
-		transactions, err = s.db.Queries.ListTransactionsByDateRange(ctx, queries.ListTransactionsByDateRangeParams{
-			PostedAt:   startDate,
-			PostedAt_2: endDate,
-			Limit:      int32(req.Limit),
-			Offset:     int32(req.Offset),
-		})
-	} else {
-		// No filters
-		transactions, err = s.db.Queries.ListTransactions(ctx, queries.ListTransactionsParams{
-			Limit:  int32(req.Limit),
-			Offset: int32(req.Offset),
-		})
-	}
+	var archivedUntil *time.Time
+
+	err := s.db.WithTenant(ctx, tenantSlug, func(q *queries.Queries) error {
+		var err error
+		if req.StartDate == "" || req.EndDate == "" {
+			if archivedUntil, err = storage.ArchivedUntil(ctx, q, "transactions"); err != nil {
+				return err
+			}
+		}
+
+		// Apply different query strategies based on filters
+		if req.AccountCode != "" && req.StartDate != "" && req.EndDate != "" {
+			// Account + Date range
+			startDate, _ := time.Parse("2006-01-02", req.StartDate)
+			endDate, _ := time.Parse("2006-01-02", req.EndDate)
+
+			if err := storage.CheckArchivedRange(ctx, q, "transactions", startDate, endDate); err != nil {
+				return err
+			}
+
+			transactions, err = q.ListTransactionsByAccountAndDateRange(ctx, queries.ListTransactionsByAccountAndDateRangeParams{
+				Code:        req.AccountCode,
+				CreatedAt:   startDate,
+				CreatedAt_2: endDate,
+				Limit:       int32(req.Limit),
+				Offset:      int32(req.Offset),
+			})
+		} else if req.AccountCode != "" {
+			// Account only
+			transactions, err = q.ListTransactionsByAccount(ctx, queries.ListTransactionsByAccountParams{
+				Code:   req.AccountCode,
+				Limit:  int32(req.Limit),
+				Offset: int32(req.Offset),
+			})
+		} else if req.StartDate != "" && req.EndDate != "" {
+			// Date range only
+			startDate, _ := time.Parse("2006-01-02", req.StartDate)
+			endDate, _ := time.Parse("2006-01-02", req.EndDate)
+
+			if err := storage.CheckArchivedRange(ctx, q, "transactions", startDate, endDate); err != nil {
+				return err
+			}
+
+			transactions, err = q.ListTransactionsByDateRange(ctx, queries.ListTransactionsByDateRangeParams{
+				CreatedAt:   startDate,
+				CreatedAt_2: endDate,
+				Limit:       int32(req.Limit),
+				Offset:      int32(req.Offset),
+			})
+		} else {
+			// No filters
+			transactions, err = q.ListTransactions(ctx, queries.ListTransactionsParams{
+				Limit:  int32(req.Limit),
+				Offset: int32(req.Offset),
+			})
+		}
 
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list transactions: %w", err)
 	}
@@ -392,7 +397,7 @@ func (s *Service) ListTransactions(ctx context.Context, tenantSlug string, req L
 	for _, t := range transactions {
 		txnResp, err := s.transactionToResponse(t)
 		if err != nil {
-			log.Printf("Failed to convert transaction to response: %v", err)
+			requestctx.Logf(ctx, "Failed to convert transaction to response: %v", err)
 			continue
 		}
 		response = append(response, *txnResp)
@@ -406,52 +411,232 @@ func (s *Service) ListTransactions(ctx context.Context, tenantSlug string, req L
 			Offset:  req.Offset,
 			HasMore: len(response) == req.Limit,
 		},
+		ArchivedUntil: archivedUntil,
 	}, nil
 }
 
+// getTransactionByID reads a transaction, telling one whose month has been
+// archived (an *storage.ArchivedRangeError) from one that never existed
+// (ErrTransactionNotFound) by the idempotency key table, which is never
+// archived
+func getTransactionByID(ctx context.Context, q *queries.Queries, id uuid.UUID) (queries.Transaction, error) {
+	transaction, err := q.GetTransactionByID(ctx, id)
+	if err == nil {
+		return transaction, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return transaction, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	reserved, err := q.GetIdempotencyKeyByTransactionID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return transaction, ErrTransactionNotFound
+		}
+		return transaction, fmt.Errorf("failed to look up transaction: %w", err)
+	}
+	if err := storage.CheckArchivedRange(ctx, q, "transactions", reserved.CreatedAt, reserved.CreatedAt); err != nil {
+		return transaction, err
+	}
+	return transaction, ErrTransactionNotFound
+}
+
+// getTransactionByIdempotencyKey returns the transaction an idempotency key
+// was used for, or ErrTransactionNotFound if it is unused. Keys stay reserved
+// after their transaction's month is archived; a retry with one then fails
+// with ErrDuplicateIdempotencyKey naming the archived transaction.
+func getTransactionByIdempotencyKey(ctx context.Context, q *queries.Queries, key string) (queries.Transaction, error) {
+	reserved, err := q.GetIdempotencyKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return queries.Transaction{}, ErrTransactionNotFound
+		}
+		return queries.Transaction{}, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+
+	transaction, err := getTransactionByID(ctx, q, reserved.TransactionID)
+	if errors.Is(err, storage.ErrArchivedRange) {
+		return transaction, fmt.Errorf("%w: transaction %s was created %s and has since been archived",
+			ErrDuplicateIdempotencyKey, reserved.TransactionID, reserved.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	return transaction, err
+}
+
 // Helper functions
-func (s *Service) updateAccountBalance(ctx context.Context, qtx *queries.Queries, account queries.Account, amount decimal.Decimal, side, currency string) error {
-	// Get current balance with version for optimistic locking
-	balance, err := qtx.GetAccountBalanceForUpdate(ctx, queries.GetAccountBalanceForUpdateParams{
+
+// balanceKey identifies a balance row. Postings lock balances in key order.
+type balanceKey struct {
+	accountID uuid.UUID
+	currency  string
+}
+
+func (k balanceKey) compare(other balanceKey) int {
+	if c := bytes.Compare(k.accountID[:], other.accountID[:]); c != 0 {
+		return c
+	}
+	return strings.Compare(k.currency, other.currency)
+}
+
+// balanceChange records a balance update for the balance updated event.
+// version is the balance's version after the update, or 0 when the update
+// went to a high-volume balance slot without versioning the balance.
+type balanceChange struct {
+	account    queries.Account
+	currency   string
+	oldBalance decimal.Decimal
+	newBalance decimal.Decimal
+	version    int64
+}
+
+// updateAccountBalance adds delta to an account balance and returns the balance
+// before and after. High-volume accounts are routed to their balance slots.
+func (s *Service) updateAccountBalance(ctx context.Context, qtx *queries.Queries, account queries.Account, delta decimal.Decimal, currency string) (balanceChange, error) {
+	change := balanceChange{account: account, currency: currency}
+
+	highVolume, err := qtx.GetHighVolumeAccount(ctx, account.ID)
+	if err == nil {
+		return s.updateHighVolumeBalance(ctx, qtx, change, highVolume, delta)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return change, fmt.Errorf("failed to get high-volume settings: %w", err)
+	}
+
+	balance, err := s.lockAccountBalance(ctx, qtx, account.ID, currency)
+	if err != nil {
+		return change, err
+	}
+
+	// Update with optimistic locking
+	updated, err := qtx.UpdateAccountBalance(ctx, queries.UpdateAccountBalanceParams{
 		AccountID: account.ID,
 		Currency:  currency,
+		Balance:   balance.Balance.Add(delta),
+		Version:   balance.Version,
 	})
 	if err != nil {
-		// Create balance if it doesn't exist
-		_, err = qtx.CreateAccountBalance(ctx, queries.CreateAccountBalanceParams{
-			AccountID: account.ID,
+		return change, fmt.Errorf("failed to update balance (possible version conflict): %w", err)
+	}
+
+	change.oldBalance = balance.Balance
+	change.newBalance = updated.Balance
+	change.version = updated.Version
+	return change, nil
+}
+
+// updateHighVolumeBalance adds the posting to a random balance slot so that
+// concurrent postings rarely contend on the same row. Decreases on accounts
+// with overdraft protection are applied to the base row instead: locking it
+// serializes them against each other, and since only increases go to the
+// slots of such accounts the total read under that lock is a safe lower bound
+// for the overdraft check. Only postings to the base row version the
+// balance; slot postings are not ordered against each other.
+func (s *Service) updateHighVolumeBalance(ctx context.Context, qtx *queries.Queries, change balanceChange, highVolume queries.HighVolumeAccount, delta decimal.Decimal) (balanceChange, error) {
+	accountID, currency := change.account.ID, change.currency
+
+	if highVolume.OverdraftProtection && delta.IsNegative() {
+		balance, err := s.lockAccountBalance(ctx, qtx, accountID, currency)
+		if err != nil {
+			return change, err
+		}
+
+		total, err := qtx.GetAccountBalanceTotal(ctx, queries.GetAccountBalanceTotalParams{
+			AccountID: accountID,
 			Currency:  currency,
-			Balance:   decimal.Zero,
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create balance: %w", err)
+			return change, fmt.Errorf("failed to get balance total: %w", err)
 		}
 
-		// Retry getting balance
-		balance, err = qtx.GetAccountBalanceForUpdate(ctx, queries.GetAccountBalanceForUpdateParams{
-			AccountID: account.ID,
+		newBalance := total.Balance.Add(delta)
+		if newBalance.IsNegative() {
+			return change, ErrInsufficientFunds
+		}
+
+		updated, err := qtx.UpdateAccountBalance(ctx, queries.UpdateAccountBalanceParams{
+			AccountID: accountID,
 			Currency:  currency,
+			Balance:   balance.Balance.Add(delta),
+			Version:   balance.Version,
 		})
 		if err != nil {
-			return fmt.Errorf("failed to get balance after creation: %w", err)
+			return change, fmt.Errorf("failed to update balance (possible version conflict): %w", err)
 		}
+
+		change.oldBalance = total.Balance
+		change.newBalance = newBalance
+		change.version = updated.Version
+		return change, nil
 	}
 
-	// Calculate new balance using the correct accounting logic
-	newBalance := s.calculateNewBalance(balance.Balance, amount, side, account.AccountType)
+	// Slots are only summed through the base row, so make sure it exists
+	if _, err := qtx.GetAccountBalance(ctx, queries.GetAccountBalanceParams{AccountID: accountID, Currency: currency}); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return change, fmt.Errorf("failed to get balance: %w", err)
+		}
+		if err := qtx.EnsureAccountBalance(ctx, queries.EnsureAccountBalanceParams{
+			AccountID: accountID,
+			Currency:  currency,
+		}); err != nil {
+			return change, fmt.Errorf("failed to create balance: %w", err)
+		}
+	}
 
-	// Update with optimistic locking
-	_, err = qtx.UpdateAccountBalance(ctx, queries.UpdateAccountBalanceParams{
-		AccountID: account.ID,
+	err := qtx.AddToAccountBalanceSlot(ctx, queries.AddToAccountBalanceSlotParams{
+		AccountID: accountID,
 		Currency:  currency,
-		Balance:   newBalance,
-		Version:   balance.Version,
+		Slot:      int32(rand.IntN(int(highVolume.SlotCount))),
+		Balance:   delta,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to update balance (possible version conflict): %w", err)
+		return change, fmt.Errorf("failed to update balance slot: %w", err)
 	}
 
-	return nil
+	// Other slots may change concurrently, so the reported balance is the
+	// total as seen by this transaction
+	total, err := qtx.GetAccountBalanceTotal(ctx, queries.GetAccountBalanceTotalParams{
+		AccountID: accountID,
+		Currency:  currency,
+	})
+	if err != nil {
+		return change, fmt.Errorf("failed to get balance total: %w", err)
+	}
+
+	change.oldBalance = total.Balance.Sub(delta)
+	change.newBalance = total.Balance
+	return change, nil
+}
+
+// lockAccountBalance locks the account's base balance row, creating it first if needed
+func (s *Service) lockAccountBalance(ctx context.Context, qtx *queries.Queries, accountID uuid.UUID, currency string) (queries.AccountBalance, error) {
+	balance, err := qtx.GetAccountBalanceForUpdate(ctx, queries.GetAccountBalanceForUpdateParams{
+		AccountID: accountID,
+		Currency:  currency,
+	})
+	if err == nil {
+		return balance, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return queries.AccountBalance{}, fmt.Errorf("failed to lock balance: %w", err)
+	}
+
+	// First posting in this currency; another one may be creating the row too
+	if err := qtx.EnsureAccountBalance(ctx, queries.EnsureAccountBalanceParams{
+		AccountID: accountID,
+		Currency:  currency,
+	}); err != nil {
+		return queries.AccountBalance{}, fmt.Errorf("failed to create balance: %w", err)
+	}
+
+	// Retry getting balance
+	balance, err = qtx.GetAccountBalanceForUpdate(ctx, queries.GetAccountBalanceForUpdateParams{
+		AccountID: accountID,
+		Currency:  currency,
+	})
+	if err != nil {
+		return queries.AccountBalance{}, fmt.Errorf("failed to get balance after creation: %w", err)
+	}
+
+	return balance, nil
 }
 
 // Calculate new balance based on account type and transaction side
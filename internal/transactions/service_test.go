@@ -2,6 +2,7 @@
 package transactions
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/google/uuid"
@@ -329,6 +330,28 @@ func TestTransactionToResponse(t *testing.T) {
 	assert.Equal(t, "posted", response.Status)
 }
 
+func TestBalanceKeyOrdering(t *testing.T) {
+	first := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	second := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+
+	keys := []balanceKey{
+		{accountID: second, currency: "NGN"},
+		{accountID: first, currency: "USD"},
+		{accountID: second, currency: "EUR"},
+		{accountID: first, currency: "NGN"},
+	}
+
+	// Any two postings over the same balances must lock them in the same order
+	slices.SortFunc(keys, balanceKey.compare)
+
+	assert.Equal(t, []balanceKey{
+		{accountID: first, currency: "NGN"},
+		{accountID: first, currency: "USD"},
+		{accountID: second, currency: "EUR"},
+		{accountID: second, currency: "NGN"},
+	}, keys)
+}
+
 func BenchmarkCalculateNewBalance(b *testing.B) {
 	service := &Service{}
 	currentBalance := decimal.NewFromInt(1000)
@@ -17,6 +17,7 @@ var (
 	ErrDuplicateIdempotencyKey = errors.New("idempotency key already exists")
 	ErrInvalidCurrency         = errors.New("all entries must use the same currency")
 	ErrEmptyTransactionLines   = errors.New("transaction must have at least one entry")
+	ErrInsufficientFunds       = errors.New("insufficient funds")
 )
 
 // Simple Transaction Request
@@ -85,6 +86,9 @@ type TransactionLineResponse struct {
 type TransactionListResponse struct {
 	Transactions []TransactionResponse `json:"transactions"`
 	Pagination   PaginationInfo        `json:"pagination"`
+	// ArchivedUntil is set on listings without a date range once months have
+	// been archived: transactions created before it are not included
+	ArchivedUntil *time.Time `json:"archived_until,omitempty"`
 }
 
 type PaginationInfo struct {
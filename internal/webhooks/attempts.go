@@ -0,0 +1,248 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+const (
+	// MaxAttemptResponseBytes is how much of a response body is read and kept
+	MaxAttemptResponseBytes = 4096
+
+	AttemptPruneInterval  = time.Hour
+	AttemptPruneBatchSize = 1000
+)
+
+// Error classes of attempts that got no HTTP response
+const (
+	ErrorClassRequest           = "request"
+	ErrorClassBlocked           = "blocked"
+	ErrorClassCanceled          = "canceled"
+	ErrorClassTimeout           = "timeout"
+	ErrorClassDNS               = "dns"
+	ErrorClassConnectionRefused = "connection_refused"
+	ErrorClassConnectionReset   = "connection_reset"
+	ErrorClassTLS               = "tls"
+	ErrorClassNetwork           = "network"
+)
+
+// ListDeliveryAttempts returns every logged attempt of a delivery, oldest first
+func (s *Service) ListDeliveryAttempts(ctx context.Context, tenantSlug string, deliveryID uuid.UUID) ([]DeliveryAttemptResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	delivery, err := s.db.Queries.GetWebhookDeliveryByID(ctx, queries.GetWebhookDeliveryByIDParams{
+		ID:       deliveryID,
+		TenantID: tenant.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	attempts, err := s.db.Queries.ListWebhookDeliveryAttempts(ctx, delivery.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list delivery attempts: %w", err)
+	}
+
+	response := make([]DeliveryAttemptResponse, 0, len(attempts))
+	for _, attempt := range attempts {
+		response = append(response, attemptToResponse(attempt))
+	}
+	return response, nil
+}
+
+// recordAttempt logs one HTTP attempt of a delivery. attemptNumber counts
+// from 1.
+func (s *Service) recordAttempt(ctx context.Context, deliveryID uuid.UUID, attemptNumber int32, url string, result WebhookDeliveryResult) error {
+	headers, err := json.Marshal(result.RequestHeaders)
+	if err != nil {
+		return fmt.Errorf("failed to serialize request headers: %w", err)
+	}
+
+	params := queries.CreateWebhookDeliveryAttemptParams{
+		DeliveryID:     deliveryID,
+		AttemptNumber:  attemptNumber,
+		RequestUrl:     url,
+		RequestHeaders: headers,
+		PayloadSha256:  result.PayloadHash,
+		LatencyMs:      int32(result.DeliveryTimeMs),
+	}
+	if result.StatusCode != 0 {
+		params.ResponseStatus = pgtype.Int4{Int32: int32(result.StatusCode), Valid: true}
+		params.ResponseBody = pgtype.Text{String: result.ResponseBody, Valid: true}
+		params.ResponseTruncated = result.ResponseTruncated
+	}
+	if result.ErrorClass != "" {
+		params.ErrorClass = pgtype.Text{String: result.ErrorClass, Valid: true}
+		params.ErrorMessage = pgtype.Text{String: result.ErrorMessage, Valid: true}
+	}
+
+	if err := s.db.Queries.CreateWebhookDeliveryAttempt(ctx, params); err != nil {
+		return fmt.Errorf("failed to record delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// StartAttemptPruner deletes logged attempts older than the configured
+// retention every AttemptPruneInterval
+func (s *Service) StartAttemptPruner(ctx context.Context) {
+	log.Println("Starting webhook attempt pruner...")
+
+	ticker := time.NewTicker(AttemptPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PruneDeliveryAttempts(ctx, time.Now().Add(-s.config.WebhookAttemptRetention)); err != nil {
+			log.Printf("Error pruning webhook delivery attempts: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook attempt pruner shutting down...")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PruneDeliveryAttempts deletes attempts made before cutoff, in batches of
+// AttemptPruneBatchSize, and returns how many were deleted
+func (s *Service) PruneDeliveryAttempts(ctx context.Context, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		deleted, err := s.db.Queries.DeleteWebhookDeliveryAttemptsBefore(ctx, queries.DeleteWebhookDeliveryAttemptsBeforeParams{
+			Cutoff:    cutoff,
+			BatchSize: AttemptPruneBatchSize,
+		})
+		if err != nil {
+			return total, fmt.Errorf("failed to prune delivery attempts: %w", err)
+		}
+		total += deleted
+
+		if deleted < AttemptPruneBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Pruned %d webhook delivery attempts older than %s", total, cutoff.Format(time.RFC3339))
+	}
+	return total, nil
+}
+
+// payloadHash is the hex SHA-256 of a delivered payload
+func payloadHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// readResponseBody reads no more of a response body than is kept, so a
+// receiver can't make the sender buffer an arbitrarily large reply
+func readResponseBody(body io.Reader) (string, bool, error) {
+	// one byte past the limit tells a cut body from one that fits exactly
+	b, err := io.ReadAll(io.LimitReader(body, MaxAttemptResponseBytes+1))
+	if err != nil {
+		return "", false, err
+	}
+	text, truncated := truncateResponseBody(string(b))
+	return text, truncated, nil
+}
+
+// truncateResponseBody cuts a response body to MaxAttemptResponseBytes and
+// makes it storable as text, dropping invalid UTF-8 and NUL bytes
+func truncateResponseBody(body string) (string, bool) {
+	truncated := len(body) > MaxAttemptResponseBytes
+	if truncated {
+		body = body[:MaxAttemptResponseBytes]
+	}
+	body = strings.ToValidUTF8(body, "")
+	return strings.ReplaceAll(body, "\x00", ""), truncated
+}
+
+// classifyError names the kind of failure behind an HTTP client error
+func classifyError(err error) string {
+	var (
+		dnsErr       *net.DNSError
+		netErr       net.Error
+		certErr      *tls.CertificateVerificationError
+		headerErr    tls.RecordHeaderError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+
+	switch {
+	case errors.Is(err, ErrBlockedDestination), errors.Is(err, ErrInvalidURL):
+		return ErrorClassBlocked
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorClassConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorClassConnectionReset
+	case errors.As(err, &certErr),
+		errors.As(err, &headerErr),
+		errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr):
+		return ErrorClassTLS
+	default:
+		return ErrorClassNetwork
+	}
+}
+
+func attemptToResponse(attempt queries.WebhookDeliveryAttempt) DeliveryAttemptResponse {
+	response := DeliveryAttemptResponse{
+		ID:                attempt.ID.String(),
+		AttemptNumber:     int(attempt.AttemptNumber),
+		RequestURL:        attempt.RequestUrl,
+		RequestHeaders:    attempt.RequestHeaders,
+		PayloadSHA256:     attempt.PayloadSha256,
+		ResponseTruncated: attempt.ResponseTruncated,
+		LatencyMs:         int(attempt.LatencyMs),
+		AttemptedAt:       attempt.AttemptedAt,
+	}
+	if attempt.ResponseStatus.Valid {
+		status := int(attempt.ResponseStatus.Int32)
+		response.ResponseStatus = &status
+	}
+	if attempt.ResponseBody.Valid {
+		response.ResponseBody = &attempt.ResponseBody.String
+	}
+	if attempt.ErrorClass.Valid {
+		response.ErrorClass = &attempt.ErrorClass.String
+	}
+	if attempt.ErrorMessage.Valid {
+		response.ErrorMessage = &attempt.ErrorMessage.String
+	}
+	return response
+}
@@ -0,0 +1,84 @@
+// internal/webhooks/attempts_test.go
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	wrap := func(err error) error {
+		return &url.Error{Op: "Post", URL: "https://example.com/hook", Err: err}
+	}
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{wrap(context.Canceled), ErrorClassCanceled},
+		{wrap(context.DeadlineExceeded), ErrorClassTimeout},
+		{wrap(&net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}), ErrorClassDNS},
+		{wrap(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}), ErrorClassConnectionRefused},
+		{wrap(&net.OpError{Op: "read", Err: syscall.ECONNRESET}), ErrorClassConnectionReset},
+		{wrap(io.ErrUnexpectedEOF), ErrorClassConnectionReset},
+		{wrap(fmt.Errorf("something else")), ErrorClassNetwork},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, classifyError(tt.err), tt.err.Error())
+	}
+}
+
+func TestTruncateResponseBody(t *testing.T) {
+	body, truncated := truncateResponseBody("ok")
+	assert.Equal(t, "ok", body)
+	assert.False(t, truncated)
+
+	// A multi-byte rune cut in half and NUL bytes are dropped
+	long := strings.Repeat("a", MaxAttemptResponseBytes-1) + "é" + "tail"
+	body, truncated = truncateResponseBody(long)
+	assert.True(t, truncated)
+	assert.Equal(t, strings.Repeat("a", MaxAttemptResponseBytes-1), body)
+
+	body, _ = truncateResponseBody("a\x00b")
+	assert.Equal(t, "ab", body)
+}
+
+// endlessBody is a response body that never ends
+type endlessBody struct{}
+
+func (endlessBody) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'x'
+	}
+	return len(p), nil
+}
+
+func TestReadResponseBody(t *testing.T) {
+	body, truncated, err := readResponseBody(strings.NewReader("ok"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", body)
+	assert.False(t, truncated)
+
+	body, truncated, err = readResponseBody(strings.NewReader(strings.Repeat("x", MaxAttemptResponseBytes)))
+	assert.NoError(t, err)
+	assert.Len(t, body, MaxAttemptResponseBytes)
+	assert.False(t, truncated)
+
+	// Only the kept part of an oversized body is read
+	body, truncated, err = readResponseBody(endlessBody{})
+	assert.NoError(t, err)
+	assert.Len(t, body, MaxAttemptResponseBytes)
+	assert.True(t, truncated)
+}
+
+func TestPayloadHash(t *testing.T) {
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", payloadHash(nil))
+}
@@ -0,0 +1,205 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// blockedPrefixes are special-purpose ranges not covered by the netip
+// predicates checked in URLPolicy.addrAllowed
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// URLPolicy decides which destinations webhooks may be sent to. Private,
+// loopback, link-local and other internal addresses are refused, and plain
+// HTTP is refused when RequireHTTPS is set. Hosts and networks on the
+// operator allowlist are trusted and exempt from both rules.
+type URLPolicy struct {
+	RequireHTTPS bool
+
+	allowedHosts map[string]bool
+	allowedNets  []netip.Prefix
+}
+
+// NewURLPolicy builds a policy from an allowlist of hostnames, IP addresses
+// and CIDR ranges
+func NewURLPolicy(requireHTTPS bool, allowlist []string) *URLPolicy {
+	p := &URLPolicy{
+		RequireHTTPS: requireHTTPS,
+		allowedHosts: make(map[string]bool),
+	}
+	for _, entry := range allowlist {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			p.allowedNets = append(p.allowedNets, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(entry); err == nil {
+			p.allowedNets = append(p.allowedNets, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		} else if entry != "" {
+			p.allowedHosts[strings.TrimSuffix(entry, ".")] = true
+		}
+	}
+	return p
+}
+
+// CheckURL rejects URLs whose scheme or host the policy does not allow.
+// Hostnames are only resolved when dialing, so CheckURL alone cannot tell
+// that a name points at an internal address; the client's dialer does.
+func (p *URLPolicy) CheckURL(u *url.URL) error {
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrInvalidURL)
+	}
+	allowlisted := p.hostAllowed(host)
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if p.RequireHTTPS && !allowlisted {
+			return fmt.Errorf("%w: HTTPS is required", ErrInvalidURL)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported scheme %q", ErrInvalidURL, u.Scheme)
+	}
+
+	if allowlisted {
+		return nil
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+	if name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrBlockedDestination, host)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !p.addrAllowed(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedDestination, host)
+	}
+	return nil
+}
+
+// checkRawURL is CheckURL for a URL that has not been parsed yet
+func (p *URLPolicy) checkRawURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	return p.CheckURL(u)
+}
+
+// hostAllowed reports whether host, a name or an IP literal, is allowlisted
+func (p *URLPolicy) hostAllowed(host string) bool {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.netAllowed(addr)
+	}
+	return p.allowedHosts[strings.TrimSuffix(strings.ToLower(host), ".")]
+}
+
+func (p *URLPolicy) netAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p.allowedNets {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// addrAllowed reports whether webhooks may connect to addr
+func (p *URLPolicy) addrAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if p.netAllowed(addr) {
+		return true
+	}
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialContext connects to addr, refusing blocked addresses. The check runs
+// on the address actually being connected to, after DNS resolution, so a
+// name that re-resolves to an internal address is still refused.
+func (p *URLPolicy) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !p.hostAllowed(host) {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !p.addrAllowed(ip) {
+				return fmt.Errorf("%w: %s", ErrBlockedDestination, ip)
+			}
+			return nil
+		}
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// guardedTransport checks every request against the policy before sending
+// it, which covers the redirects the client follows as well
+type guardedTransport struct {
+	policy *URLPolicy
+	base   http.RoundTripper
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.CheckURL(req.URL); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// newHTTPClient returns the outbound client webhooks are sent with. It never
+// uses a proxy, which would connect on its behalf and bypass the dial check.
+func newHTTPClient(policy *URLPolicy, timeout time.Duration) *http.Client {
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           policy.dialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &guardedTransport{policy: policy, base: transport},
+	}
+}
@@ -0,0 +1,95 @@
+// internal/webhooks/client_test.go
+package webhooks
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLPolicyCheckURL(t *testing.T) {
+	policy := NewURLPolicy(true, []string{"hooks.internal", "10.1.0.0/16"})
+
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"https://example.com/hook", nil},
+		{"http://example.com/hook", ErrInvalidURL},
+		{"ftp://example.com/hook", ErrInvalidURL},
+		{"https://169.254.169.254/latest/meta-data", ErrBlockedDestination},
+		{"https://127.0.0.1:5432/", ErrBlockedDestination},
+		{"https://[::1]/", ErrBlockedDestination},
+		{"https://[::ffff:10.0.0.1]/", ErrBlockedDestination},
+		{"https://localhost/", ErrBlockedDestination},
+		{"https://api.localhost./", ErrBlockedDestination},
+		{"https://100.64.0.1/", ErrBlockedDestination},
+		{"https://192.168.1.1/", ErrBlockedDestination},
+		{"http://hooks.internal:8080/", nil},
+		{"http://10.1.2.3/", nil},
+		{"https://10.2.0.1/", ErrBlockedDestination},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		require.NoError(t, err)
+		if tt.want == nil {
+			assert.NoError(t, policy.CheckURL(u), tt.url)
+		} else {
+			assert.ErrorIs(t, policy.CheckURL(u), tt.want, tt.url)
+		}
+	}
+
+	u, _ := url.Parse("http://example.com/hook")
+	assert.NoError(t, NewURLPolicy(false, nil).CheckURL(u), "plain HTTP in development")
+}
+
+func TestURLPolicyAddrAllowed(t *testing.T) {
+	policy := NewURLPolicy(true, nil)
+
+	for _, addr := range []string{"93.184.216.34", "2606:2800:220:1::1"} {
+		assert.True(t, policy.addrAllowed(netip.MustParseAddr(addr)), addr)
+	}
+	for _, addr := range []string{"0.0.0.0", "10.0.0.1", "172.16.0.1", "127.0.0.53", "169.254.169.254", "224.0.0.1", "255.255.255.255", "fe80::1", "fd00::1", "64:ff9b::a00:1"} {
+		assert.False(t, policy.addrAllowed(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestHTTPClientBlocksInternalDestinations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "https://169.254.169.254/latest/meta-data", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	// A name resolving to loopback is refused when dialing
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	_, err = NewURLPolicy(false, nil).dialContext(context.Background(), "tcp", net.JoinHostPort("localhost", port))
+	assert.ErrorIs(t, err, ErrBlockedDestination)
+
+	blocked := newHTTPClient(NewURLPolicy(false, nil), time.Second)
+	_, err = blocked.Get(server.URL)
+	assert.ErrorIs(t, err, ErrBlockedDestination)
+
+	allowed := newHTTPClient(NewURLPolicy(true, []string{"127.0.0.1"}), time.Second)
+	resp, err := allowed.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Redirects are checked like the original request
+	_, err = allowed.Get(server.URL + "/redirect")
+	assert.ErrorIs(t, err, ErrBlockedDestination)
+	assert.Equal(t, ErrorClassBlocked, classifyError(err))
+}
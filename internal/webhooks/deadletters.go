@@ -0,0 +1,172 @@
+// internal/webhooks/deadletters.go
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// ListDeadLetters returns a tenant's dead-lettered deliveries matching
+// filter, most recently dead-lettered first
+func (s *Service) ListDeadLetters(ctx context.Context, tenantSlug string, filter DeadLetterFilter, limit int) ([]WebhookDeliveryResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	endpointID, eventType, from, to := filter.params()
+	deliveries, err := s.db.Queries.ListDeadLetteredWebhookDeliveries(ctx, queries.ListDeadLetteredWebhookDeliveriesParams{
+		TenantID:         tenant.ID,
+		EndpointID:       endpointID,
+		EventType:        eventType,
+		DeadLetteredFrom: from,
+		DeadLetteredTo:   to,
+		MaxResults:       int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead-lettered deliveries: %w", err)
+	}
+
+	endpoints, err := s.db.Queries.ListWebhookEndpoints(ctx, tenant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	endpointURLs := make(map[uuid.UUID]string, len(endpoints))
+	for _, endpoint := range endpoints {
+		endpointURLs[endpoint.ID] = endpoint.Url
+	}
+
+	response := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, deliveryToResponse(delivery, endpointURLs[delivery.EndpointID]))
+	}
+
+	return response, nil
+}
+
+// GetDeadLetter returns a dead-lettered delivery including the last response
+// or error it failed with
+func (s *Service) GetDeadLetter(ctx context.Context, tenantSlug string, deliveryID uuid.UUID) (*WebhookDeliveryResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	delivery, err := s.db.Queries.GetWebhookDeliveryByID(ctx, queries.GetWebhookDeliveryByIDParams{
+		ID:       deliveryID,
+		TenantID: tenant.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrDeadLetterNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	if !delivery.DeadLetteredAt.Valid {
+		return nil, ErrDeadLetterNotFound
+	}
+
+	endpoint, err := s.db.Queries.GetWebhookEndpoint(ctx, queries.GetWebhookEndpointParams{
+		ID:       delivery.EndpointID,
+		TenantID: tenant.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+
+	response := deliveryToResponse(delivery, endpoint.Url)
+	if delivery.ResponseBody.Valid {
+		response.ResponseBody = &delivery.ResponseBody.String
+	}
+	return &response, nil
+}
+
+// RedeliverDeadLetters puts every dead letter matching filter back on the
+// current retry schedule and returns how many were requeued
+func (s *Service) RedeliverDeadLetters(ctx context.Context, tenantSlug string, filter DeadLetterFilter) (int64, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return 0, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	endpointID, eventType, from, to := filter.params()
+	redelivered, err := s.db.Queries.RedeliverDeadLetteredWebhookDeliveries(ctx, queries.RedeliverDeadLetteredWebhookDeliveriesParams{
+		MaxAttempts:      pgtype.Int4{Int32: int32(s.retryPolicy.MaxAttempts()), Valid: true},
+		TenantID:         tenant.ID,
+		EndpointID:       endpointID,
+		EventType:        eventType,
+		DeadLetteredFrom: from,
+		DeadLetteredTo:   to,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to redeliver dead-lettered deliveries: %w", err)
+	}
+
+	log.Printf("Requeued %d dead-lettered webhook deliveries for tenant %s", redelivered, tenantSlug)
+	return redelivered, nil
+}
+
+// params converts the filter to query arguments, unset fields becoming NULL
+func (f DeadLetterFilter) params() (pgtype.UUID, pgtype.Text, pgtype.Timestamptz, pgtype.Timestamptz) {
+	var (
+		endpointID pgtype.UUID
+		eventType  pgtype.Text
+		from, to   pgtype.Timestamptz
+	)
+	if f.EndpointID != nil {
+		endpointID = pgtype.UUID{Bytes: *f.EndpointID, Valid: true}
+	}
+	if f.EventType != "" {
+		eventType = pgtype.Text{String: f.EventType, Valid: true}
+	}
+	if f.From != nil {
+		from = pgtype.Timestamptz{Time: *f.From, Valid: true}
+	}
+	if f.To != nil {
+		to = pgtype.Timestamptz{Time: *f.To, Valid: true}
+	}
+	return endpointID, eventType, from, to
+}
+
+func deliveryToResponse(delivery queries.WebhookDelivery, url string) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:          delivery.ID.String(),
+		EventID:     delivery.EventID.String(),
+		EventType:   delivery.EventType,
+		EndpointID:  delivery.EndpointID.String(),
+		URL:         url,
+		Attempts:    int(delivery.Attempts.Int32),
+		MaxAttempts: int(delivery.MaxAttempts.Int32),
+		CreatedAt:   delivery.CreatedAt,
+	}
+
+	if delivery.HttpStatusCode.Valid {
+		statusCode := int(delivery.HttpStatusCode.Int32)
+		response.StatusCode = &statusCode
+	}
+	if delivery.NextRetryAt.Valid {
+		response.NextRetryAt = &delivery.NextRetryAt.Time
+	}
+	if delivery.DeliveredAt.Valid {
+		response.DeliveredAt = &delivery.DeliveredAt.Time
+	}
+	if delivery.FailedAt.Valid {
+		response.FailedAt = &delivery.FailedAt.Time
+	}
+	if delivery.DeadLetteredAt.Valid {
+		response.DeadLetteredAt = &delivery.DeadLetteredAt.Time
+	}
+	if delivery.ReplayID.Valid {
+		replayID := uuid.UUID(delivery.ReplayID.Bytes).String()
+		response.ReplayID = &replayID
+	}
+
+	return response
+}
@@ -0,0 +1,352 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/events"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// CreateEndpoint registers a webhook endpoint for a tenant. A signing secret
+// is generated when none is supplied; it is stored encrypted and only ever
+// returned here.
+func (s *Service) CreateEndpoint(ctx context.Context, tenantSlug string, req CreateEndpointRequest) (*EndpointResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	if err := validateEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+
+	if err := s.urlPolicy.checkRawURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	payloadVersion := events.Schemas.LatestPayloadVersion()
+	if req.PayloadVersion != nil {
+		if err := validatePayloadVersion(*req.PayloadVersion); err != nil {
+			return nil, err
+		}
+		payloadVersion = *req.PayloadVersion
+	}
+
+	payloadFormat := PayloadFormatLedger
+	if req.PayloadFormat != "" {
+		if err := validatePayloadFormat(req.PayloadFormat); err != nil {
+			return nil, err
+		}
+		payloadFormat = req.PayloadFormat
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret, err = generateSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries.WithTx(tx)
+
+	endpoint, err := qtx.CreateWebhookEndpoint(ctx, queries.CreateWebhookEndpointParams{
+		TenantID:    tenant.ID,
+		Url:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		Enabled:     enabled,
+		Ordered:     req.Ordered,
+
+		PayloadVersion: payloadVersion,
+		PayloadFormat:  payloadFormat,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	if err := s.storeSecret(ctx, qtx, endpoint.ID, secret); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("Webhook endpoint %s created for tenant %s: %s (events: %v)", endpoint.ID, tenantSlug, endpoint.Url, endpoint.EventTypes)
+
+	response := endpointToResponse(endpoint)
+	response.Secret = secret
+	return response, nil
+}
+
+// ListEndpoints returns all webhook endpoints of a tenant
+func (s *Service) ListEndpoints(ctx context.Context, tenantSlug string) ([]EndpointResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	endpoints, err := s.db.Queries.ListWebhookEndpoints(ctx, tenant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+
+	health, err := s.endpointHealth(ctx, tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]EndpointResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		endpointResponse := endpointToResponse(endpoint)
+		h, ok := health[endpoint.ID]
+		endpointResponse.Health = healthToResponse(h, ok)
+		response = append(response, *endpointResponse)
+	}
+
+	return response, nil
+}
+
+// GetEndpoint returns a single webhook endpoint
+func (s *Service) GetEndpoint(ctx context.Context, tenantSlug string, endpointID uuid.UUID) (*EndpointResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	endpoint, err := s.db.Queries.GetWebhookEndpoint(ctx, queries.GetWebhookEndpointParams{
+		ID:       endpointID,
+		TenantID: tenant.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+
+	health, err := s.db.Queries.GetWebhookEndpointHealth(ctx, endpoint.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get endpoint health: %w", err)
+	}
+
+	response := endpointToResponse(endpoint)
+	response.Health = healthToResponse(health, err == nil)
+	return response, nil
+}
+
+// UpdateEndpoint changes the fields set in req. Re-enabling an endpoint
+// clears its disabled_at timestamp and resets its circuit breaker.
+func (s *Service) UpdateEndpoint(ctx context.Context, tenantSlug string, endpointID uuid.UUID, req UpdateEndpointRequest) (*EndpointResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	if req.EventTypes != nil {
+		if err := validateEventTypes(req.EventTypes); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.URL != nil {
+		if err := s.urlPolicy.checkRawURL(*req.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.PayloadVersion != nil {
+		if err := validatePayloadVersion(*req.PayloadVersion); err != nil {
+			return nil, err
+		}
+	}
+	if req.PayloadFormat != nil {
+		if err := validatePayloadFormat(*req.PayloadFormat); err != nil {
+			return nil, err
+		}
+	}
+
+	params := queries.UpdateWebhookEndpointParams{
+		ID:         endpointID,
+		TenantID:   tenant.ID,
+		EventTypes: req.EventTypes,
+	}
+	if req.URL != nil {
+		params.Url = pgtype.Text{String: *req.URL, Valid: true}
+	}
+	if req.Description != nil {
+		params.Description = pgtype.Text{String: *req.Description, Valid: true}
+	}
+	if req.Enabled != nil {
+		params.Enabled = pgtype.Bool{Bool: *req.Enabled, Valid: true}
+	}
+	if req.Ordered != nil {
+		params.Ordered = pgtype.Bool{Bool: *req.Ordered, Valid: true}
+	}
+	if req.PayloadVersion != nil {
+		params.PayloadVersion = pgtype.Int4{Int32: *req.PayloadVersion, Valid: true}
+	}
+	if req.PayloadFormat != nil {
+		params.PayloadFormat = pgtype.Text{String: *req.PayloadFormat, Valid: true}
+	}
+
+	endpoint, err := s.db.Queries.UpdateWebhookEndpoint(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+
+	if req.Enabled != nil && *req.Enabled {
+		if err := s.db.Queries.ResetWebhookEndpointHealth(ctx, endpoint.ID); err != nil {
+			return nil, fmt.Errorf("failed to reset endpoint health: %w", err)
+		}
+	}
+
+	log.Printf("Webhook endpoint %s updated for tenant %s", endpoint.ID, tenantSlug)
+	return endpointToResponse(endpoint), nil
+}
+
+// DisableEndpoint stops deliveries to an endpoint without deleting it
+func (s *Service) DisableEndpoint(ctx context.Context, tenantSlug string, endpointID uuid.UUID) (*EndpointResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	endpoint, err := s.db.Queries.DisableWebhookEndpoint(ctx, queries.DisableWebhookEndpointParams{
+		ID:       endpointID,
+		TenantID: tenant.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to disable webhook endpoint: %w", err)
+	}
+
+	log.Printf("Webhook endpoint %s disabled for tenant %s", endpoint.ID, tenantSlug)
+	return endpointToResponse(endpoint), nil
+}
+
+// DeleteEndpoint removes an endpoint together with its delivery history
+func (s *Service) DeleteEndpoint(ctx context.Context, tenantSlug string, endpointID uuid.UUID) error {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return fmt.Errorf("tenant not found: %w", err)
+	}
+
+	deleted, err := s.db.Queries.DeleteWebhookEndpoint(ctx, queries.DeleteWebhookEndpointParams{
+		ID:       endpointID,
+		TenantID: tenant.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	if deleted == 0 {
+		return ErrEndpointNotFound
+	}
+
+	log.Printf("Webhook endpoint %s deleted for tenant %s", endpointID, tenantSlug)
+	return nil
+}
+
+// MatchesEventType reports whether an endpoint subscribed to patterns should
+// receive eventType. Patterns are exact types, "*", or a prefix followed by
+// ".*" which matches every type under that prefix.
+func MatchesEventType(patterns []string, eventType string) bool {
+	for _, pattern := range patterns {
+		if pattern == WildcardEventType || pattern == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, ".*"); ok && strings.HasPrefix(eventType, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// validateEventTypes rejects patterns that match no supported event type
+func validateEventTypes(patterns []string) error {
+	if len(patterns) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", ErrInvalidEventType)
+	}
+	for _, pattern := range patterns {
+		if !slices.ContainsFunc(SupportedEventTypes, func(eventType string) bool {
+			return MatchesEventType([]string{pattern}, eventType)
+		}) {
+			return fmt.Errorf("%w: %q", ErrInvalidEventType, pattern)
+		}
+	}
+	return nil
+}
+
+// validatePayloadVersion rejects payload versions the schema registry has not
+// released
+func validatePayloadVersion(version int32) error {
+	if latest := events.Schemas.LatestPayloadVersion(); version < 1 || version > latest {
+		return fmt.Errorf("%w: %d, latest is %d", ErrInvalidPayloadVersion, version, latest)
+	}
+	return nil
+}
+
+// validatePayloadFormat rejects unknown payload formats
+func validatePayloadFormat(format string) error {
+	switch format {
+	case PayloadFormatLedger, PayloadFormatCloudEventsStructured, PayloadFormatCloudEventsBinary:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidPayloadFormat, format)
+	}
+}
+
+// generateSecret creates a random endpoint signing secret
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func endpointToResponse(endpoint queries.WebhookEndpoint) *EndpointResponse {
+	response := &EndpointResponse{
+		ID:             endpoint.ID.String(),
+		URL:            endpoint.Url,
+		Description:    endpoint.Description,
+		EventTypes:     endpoint.EventTypes,
+		Enabled:        endpoint.Enabled,
+		Ordered:        endpoint.Ordered,
+		PayloadVersion: endpoint.PayloadVersion,
+		PayloadFormat:  endpoint.PayloadFormat,
+		CreatedAt:      endpoint.CreatedAt,
+		UpdatedAt:      endpoint.UpdatedAt,
+	}
+	if endpoint.DisabledAt.Valid {
+		response.DisabledAt = &endpoint.DisabledAt.Time
+	}
+	return response
+}
@@ -0,0 +1,57 @@
+// internal/webhooks/endpoints_test.go
+package webhooks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/temmyjay001/ledger-service/internal/events"
+)
+
+func TestMatchesEventType(t *testing.T) {
+	tests := []struct {
+		name      string
+		patterns  []string
+		eventType string
+		want      bool
+	}{
+		{"exact match", []string{"transaction.posted"}, "transaction.posted", true},
+		{"exact mismatch", []string{"transaction.posted"}, "balance.updated", false},
+		{"catch-all", []string{"*"}, "account.created", true},
+		{"prefix wildcard", []string{"account.*"}, "account.updated", true},
+		{"prefix wildcard other family", []string{"account.*"}, "balance.updated", false},
+		{"prefix must end at a dot", []string{"account.*"}, "accounting.closed", false},
+		{"any pattern matches", []string{"balance.updated", "transaction.*"}, "transaction.posted", true},
+		{"no patterns", nil, "transaction.posted", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchesEventType(tt.patterns, tt.eventType))
+		})
+	}
+}
+
+func TestValidateEventTypes(t *testing.T) {
+	assert.NoError(t, validateEventTypes([]string{"*"}))
+	assert.NoError(t, validateEventTypes([]string{"transaction.posted", "account.*"}))
+
+	assert.ErrorIs(t, validateEventTypes(nil), ErrInvalidEventType)
+	assert.ErrorIs(t, validateEventTypes([]string{"transaction.reversed"}), ErrInvalidEventType)
+	assert.ErrorIs(t, validateEventTypes([]string{"payout.*"}), ErrInvalidEventType)
+	assert.ErrorIs(t, validateEventTypes([]string{"transaction*"}), ErrInvalidEventType)
+}
+
+func TestValidatePayloadVersion(t *testing.T) {
+	assert.NoError(t, validatePayloadVersion(1))
+	assert.NoError(t, validatePayloadVersion(events.Schemas.LatestPayloadVersion()))
+
+	assert.ErrorIs(t, validatePayloadVersion(0), ErrInvalidPayloadVersion)
+	assert.ErrorIs(t, validatePayloadVersion(events.Schemas.LatestPayloadVersion()+1), ErrInvalidPayloadVersion)
+}
+
+func TestSupportedEventTypesHaveSchemas(t *testing.T) {
+	for _, eventType := range SupportedEventTypes {
+		assert.Contains(t, events.Schemas.EventTypes(), eventType)
+	}
+}
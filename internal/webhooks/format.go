@@ -0,0 +1,46 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/temmyjay001/ledger-service/internal/events"
+)
+
+// encodePayload serializes a payload in an endpoint's payload format,
+// returning the body and the headers describing it
+func encodePayload(format string, payload WebhookPayload) ([]byte, http.Header, error) {
+	switch format {
+	case PayloadFormatCloudEventsStructured:
+		body, err := json.Marshal(payloadToCloudEvent(payload))
+		return body, http.Header{"Content-Type": {events.CloudEventsContentType}}, err
+	case PayloadFormatCloudEventsBinary:
+		ce := payloadToCloudEvent(payload)
+		return ce.Data, ce.BinaryHeaders(), nil
+	default:
+		body, err := json.Marshal(payload)
+		return body, http.Header{"Content-Type": {"application/json"}}, err
+	}
+}
+
+// payloadToCloudEvent frames a payload as a CloudEvent, matching
+// events.NewCloudEvent for the same event
+func payloadToCloudEvent(payload WebhookPayload) events.CloudEvent {
+	return events.CloudEvent{
+		SpecVersion:      events.CloudEventsSpecVersion,
+		ID:               payload.ID,
+		Source:           events.CloudEventSource(payload.TenantID),
+		Type:             payload.Type,
+		Subject:          events.CloudEventSubject(payload.AggregateType, payload.AggregateID),
+		Time:             time.Unix(payload.Created, 0).UTC(),
+		DataContentType:  "application/json",
+		Data:             payload.Data,
+		TenantID:         payload.TenantID,
+		Sequence:         payload.SequenceNumber,
+		AggregateVersion: payload.AggregateVersion,
+		DataVersion:      payload.DataVersion,
+		CorrelationID:    payload.CorrelationID,
+		ReplayID:         payload.ReplayID,
+	}
+}
@@ -0,0 +1,95 @@
+// internal/webhooks/format_test.go
+package webhooks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/events"
+)
+
+func testPayload() WebhookPayload {
+	return WebhookPayload{
+		ID:             "5b0e8a4e-0d7a-4a53-9f55-0f3c0f6f1f11",
+		Type:           "transaction.posted",
+		Created:        1792324800,
+		Data:           json.RawMessage(`{"transaction_id":"t1"}`),
+		TenantID:       "7d1b6c2a-3f4e-4c5d-8e9f-0a1b2c3d4e5f",
+		LiveMode:       true,
+		AggregateID:    "0c9d8e7f-6a5b-4c3d-2e1f-0a9b8c7d6e5f",
+		AggregateType:  "transaction",
+		SequenceNumber: 11,
+		DataVersion:    1,
+		CorrelationID:  "order-42",
+	}
+}
+
+func TestEncodePayloadLedger(t *testing.T) {
+	body, header, err := encodePayload(PayloadFormatLedger, testPayload())
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+
+	var sent WebhookPayload
+	require.NoError(t, json.Unmarshal(body, &sent))
+	assert.Equal(t, testPayload(), sent)
+}
+
+func TestEncodePayloadCloudEventsStructured(t *testing.T) {
+	body, header, err := encodePayload(PayloadFormatCloudEventsStructured, testPayload())
+	require.NoError(t, err)
+	assert.Equal(t, events.CloudEventsContentType, header.Get("Content-Type"))
+
+	var ce map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &ce))
+	assert.Equal(t, "1.0", ce["specversion"])
+	assert.Equal(t, testPayload().ID, ce["id"])
+	assert.Equal(t, "/tenants/7d1b6c2a-3f4e-4c5d-8e9f-0a1b2c3d4e5f", ce["source"])
+	assert.Equal(t, "transaction/0c9d8e7f-6a5b-4c3d-2e1f-0a9b8c7d6e5f", ce["subject"])
+	assert.Equal(t, "transaction.posted", ce["type"])
+	assert.Equal(t, "2026-10-18T12:00:00Z", ce["time"])
+	assert.Equal(t, "application/json", ce["datacontenttype"])
+	assert.Equal(t, map[string]interface{}{"transaction_id": "t1"}, ce["data"])
+	assert.Equal(t, "order-42", ce["correlationid"])
+}
+
+func TestEncodePayloadCloudEventsBinary(t *testing.T) {
+	body, header, err := encodePayload(PayloadFormatCloudEventsBinary, testPayload())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"transaction_id":"t1"}`, string(body))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "1.0", header.Get("ce-specversion"))
+	assert.Equal(t, testPayload().ID, header.Get("ce-id"))
+	assert.Equal(t, "transaction.posted", header.Get("ce-type"))
+	assert.Equal(t, "11", header.Get("ce-sequence"))
+}
+
+func TestValidatePayloadFormat(t *testing.T) {
+	assert.NoError(t, validatePayloadFormat(PayloadFormatLedger))
+	assert.NoError(t, validatePayloadFormat(PayloadFormatCloudEventsStructured))
+	assert.NoError(t, validatePayloadFormat(PayloadFormatCloudEventsBinary))
+	assert.ErrorIs(t, validatePayloadFormat("cloudevents"), ErrInvalidPayloadFormat)
+}
+
+func TestEncodePayloadMarksReplays(t *testing.T) {
+	payload := testPayload()
+	payload.Replayed = true
+	payload.ReplayID = "3e4f5a6b-7c8d-4e9f-a0b1-c2d3e4f5a6b7"
+
+	body, _, err := encodePayload(PayloadFormatLedger, payload)
+	require.NoError(t, err)
+	var sent map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &sent))
+	assert.Equal(t, true, sent["replayed"])
+	assert.Equal(t, payload.ReplayID, sent["replay_id"])
+
+	_, header, err := encodePayload(PayloadFormatCloudEventsBinary, payload)
+	require.NoError(t, err)
+	assert.Equal(t, payload.ReplayID, header.Get("ce-replayid"))
+
+	// live deliveries carry neither
+	body, _, err = encodePayload(PayloadFormatLedger, testPayload())
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "replay")
+}
@@ -3,12 +3,16 @@ package webhooks
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"github.com/temmyjay001/ledger-service/internal/auth"
+	"github.com/temmyjay001/ledger-service/internal/storage"
 	"github.com/temmyjay001/ledger-service/pkg/api"
 	cV "github.com/temmyjay001/ledger-service/pkg/validator"
 )
@@ -25,11 +29,14 @@ func NewHandlers(service *Service) *Handlers {
 	}
 }
 
-// ConfigureWebhookHandler handles webhook configuration for a tenant
-func (h *Handlers) ConfigureWebhookHandler(w http.ResponseWriter, r *http.Request) {
-	tenantSlug := chi.URLParam(r, "tenantSlug")
+// CreateEndpointHandler registers a webhook endpoint for a tenant
+func (h *Handlers) CreateEndpointHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
 
-	var req WebhookConfigRequest
+	var req CreateEndpointRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		api.WriteBadRequestResponse(w, "invalid JSON payload")
 		return
@@ -40,19 +47,304 @@ func (h *Handlers) ConfigureWebhookHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Call service method
-	response, err := h.service.ConfigureWebhook(r.Context(), tenantSlug, req)
+	endpoint, err := h.service.CreateEndpoint(r.Context(), tenantSlug, req)
+	if err != nil {
+		writeEndpointError(w, err)
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusCreated, endpoint)
+}
+
+// ListEndpointsHandler returns the webhook endpoints of a tenant
+func (h *Handlers) ListEndpointsHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	endpoints, err := h.service.ListEndpoints(r.Context(), tenantSlug)
 	if err != nil {
 		api.WriteInternalErrorResponse(w, err.Error())
 		return
 	}
 
+	api.WriteSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"endpoints": endpoints,
+		"total":     len(endpoints),
+	})
+}
+
+// GetEndpointHandler returns a single webhook endpoint
+func (h *Handlers) GetEndpointHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpointId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid endpoint ID")
+		return
+	}
+
+	endpoint, err := h.service.GetEndpoint(r.Context(), tenantSlug, endpointID)
+	if err != nil {
+		writeEndpointError(w, err)
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, endpoint)
+}
+
+// UpdateEndpointHandler changes a webhook endpoint's URL, description,
+// subscriptions or enabled flag
+func (h *Handlers) UpdateEndpointHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpointId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid endpoint ID")
+		return
+	}
+
+	var req UpdateEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteBadRequestResponse(w, "invalid JSON payload")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		api.WriteValidationErrorResponse(w, err)
+		return
+	}
+
+	endpoint, err := h.service.UpdateEndpoint(r.Context(), tenantSlug, endpointID, req)
+	if err != nil {
+		writeEndpointError(w, err)
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, endpoint)
+}
+
+// DisableEndpointHandler stops deliveries to a webhook endpoint
+func (h *Handlers) DisableEndpointHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpointId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid endpoint ID")
+		return
+	}
+
+	endpoint, err := h.service.DisableEndpoint(r.Context(), tenantSlug, endpointID)
+	if err != nil {
+		writeEndpointError(w, err)
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, endpoint)
+}
+
+// DeleteEndpointHandler removes a webhook endpoint
+func (h *Handlers) DeleteEndpointHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpointId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid endpoint ID")
+		return
+	}
+
+	if err := h.service.DeleteEndpoint(r.Context(), tenantSlug, endpointID); err != nil {
+		writeEndpointError(w, err)
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"message":     "Webhook endpoint deleted",
+		"endpoint_id": endpointID,
+	})
+}
+
+// RotateSecretHandler replaces a webhook endpoint's signing secret. The body
+// is optional; without one a secret is generated and the configured overlap
+// window applies.
+func (h *Handlers) RotateSecretHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpointId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid endpoint ID")
+		return
+	}
+
+	var req RotateSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		api.WriteBadRequestResponse(w, "invalid JSON payload")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		api.WriteValidationErrorResponse(w, err)
+		return
+	}
+
+	response, err := h.service.RotateSecret(r.Context(), tenantSlug, endpointID, req)
+	if err != nil {
+		writeEndpointError(w, err)
+		return
+	}
+
 	api.WriteSuccessResponse(w, http.StatusOK, response)
 }
 
+// ListDeadLettersHandler returns dead-lettered deliveries, optionally
+// filtered by endpoint_id, event_type and a from/to dead-letter time range
+func (h *Handlers) ListDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	req := DeadLetterFilterRequest{
+		EndpointID: query.Get("endpoint_id"),
+		EventType:  query.Get("event_type"),
+		From:       query.Get("from"),
+		To:         query.Get("to"),
+	}
+	if err := h.validator.Struct(req); err != nil {
+		api.WriteValidationErrorResponse(w, err)
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	deliveries, err := h.service.ListDeadLetters(r.Context(), tenantSlug, req.Filter(), limit)
+	if err != nil {
+		api.WriteInternalErrorResponse(w, err.Error())
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+		"total":      len(deliveries),
+	})
+}
+
+// GetDeadLetterHandler returns a dead-lettered delivery with its last response
+func (h *Handlers) GetDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.service.GetDeadLetter(r.Context(), tenantSlug, deliveryID)
+	if err != nil {
+		if errors.Is(err, ErrDeadLetterNotFound) {
+			api.WriteNotFoundResponse(w, err.Error())
+			return
+		}
+		api.WriteInternalErrorResponse(w, err.Error())
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, delivery)
+}
+
+// RedeliverDeadLettersHandler requeues every dead letter matching the filter
+// in the body; an empty filter requeues them all
+func (h *Handlers) RedeliverDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	var req DeadLetterFilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		api.WriteBadRequestResponse(w, "invalid JSON payload")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		api.WriteValidationErrorResponse(w, err)
+		return
+	}
+
+	redelivered, err := h.service.RedeliverDeadLetters(r.Context(), tenantSlug, req.Filter())
+	if err != nil {
+		api.WriteInternalErrorResponse(w, err.Error())
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"message":     "Dead-lettered deliveries requeued",
+		"redelivered": redelivered,
+	})
+}
+
+// writeEndpointError maps endpoint service errors to responses
+// tenantSlugFromAPIKey returns the tenant in the URL, writing an error
+// response if the request's API key belongs to another tenant
+func tenantSlugFromAPIKey(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tenantSlug := chi.URLParam(r, "tenantSlug")
+
+	claims, ok := auth.GetAPIKeyClaims(r.Context())
+	if !ok {
+		api.WriteUnauthorizedResponse(w, "API key authentication required")
+		return "", false
+	}
+	if claims.TenantSlug != tenantSlug {
+		api.WriteForbiddenResponse(w, "API key not authorized for this tenant")
+		return "", false
+	}
+	return tenantSlug, true
+}
+
+func writeEndpointError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrEndpointNotFound):
+		api.WriteNotFoundResponse(w, "webhook endpoint not found")
+	case errors.Is(err, ErrInvalidEventType),
+		errors.Is(err, ErrInvalidURL),
+		errors.Is(err, ErrInvalidPayloadVersion),
+		errors.Is(err, ErrInvalidPayloadFormat),
+		errors.Is(err, ErrBlockedDestination):
+		api.WriteBadRequestResponse(w, err.Error())
+	default:
+		api.WriteInternalErrorResponse(w, err.Error())
+	}
+}
+
 // ListWebhookDeliveriesHandler returns webhook delivery history for a tenant
 func (h *Handlers) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
-	tenantSlug := chi.URLParam(r, "tenantSlug")
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
 
 	// Parse query parameters
 	limitStr := r.URL.Query().Get("limit")
@@ -78,7 +370,10 @@ func (h *Handlers) ListWebhookDeliveriesHandler(w http.ResponseWriter, r *http.R
 
 // GetWebhookDeliveryHandler returns details of a specific webhook delivery
 func (h *Handlers) GetWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
-	tenantSlug := chi.URLParam(r, "tenantSlug")
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
 	deliveryID := chi.URLParam(r, "deliveryId")
 
 	// Parse delivery ID
@@ -98,9 +393,42 @@ func (h *Handlers) GetWebhookDeliveryHandler(w http.ResponseWriter, r *http.Requ
 	api.WriteSuccessResponse(w, http.StatusOK, delivery)
 }
 
+// ListDeliveryAttemptsHandler returns the request and response of every
+// attempt of a delivery
+func (h *Handlers) ListDeliveryAttemptsHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid delivery ID")
+		return
+	}
+
+	attempts, err := h.service.ListDeliveryAttempts(r.Context(), tenantSlug, deliveryID)
+	if err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			api.WriteNotFoundResponse(w, err.Error())
+			return
+		}
+		api.WriteInternalErrorResponse(w, err.Error())
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"attempts": attempts,
+		"count":    len(attempts),
+	})
+}
+
 // RetryWebhookDeliveryHandler manually retries a failed webhook delivery
 func (h *Handlers) RetryWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
-	tenantSlug := chi.URLParam(r, "tenantSlug")
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
 	deliveryID := chi.URLParam(r, "deliveryId")
 
 	// Parse delivery ID
@@ -123,14 +451,23 @@ func (h *Handlers) RetryWebhookDeliveryHandler(w http.ResponseWriter, r *http.Re
 	})
 }
 
-// TestWebhookHandler sends a test webhook to verify configuration
+// TestWebhookHandler sends a test webhook to verify an endpoint's configuration
 func (h *Handlers) TestWebhookHandler(w http.ResponseWriter, r *http.Request) {
-	tenantSlug := chi.URLParam(r, "tenantSlug")
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpointId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid endpoint ID")
+		return
+	}
 
 	// Call service method
-	result, err := h.service.TestWebhook(r.Context(), tenantSlug)
+	result, err := h.service.TestWebhook(r.Context(), tenantSlug, endpointID)
 	if err != nil {
-		api.WriteBadRequestResponse(w, err.Error())
+		writeEndpointError(w, err)
 		return
 	}
 
@@ -153,3 +490,162 @@ func (h *Handlers) TestWebhookHandler(w http.ResponseWriter, r *http.Request) {
 		api.WriteErrorResponse(w, http.StatusBadRequest, "Test webhook failed")
 	}
 }
+
+// SendSyntheticEventsHandler sends sample events of the requested types to
+// an endpoint
+func (h *Handlers) SendSyntheticEventsHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpointId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid endpoint ID")
+		return
+	}
+
+	var req SyntheticEventsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		api.WriteBadRequestResponse(w, "invalid JSON payload")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		api.WriteValidationErrorResponse(w, err)
+		return
+	}
+
+	results, err := h.service.SendSyntheticEvents(r.Context(), tenantSlug, endpointID, req)
+	if err != nil {
+		writeEndpointError(w, err)
+		return
+	}
+
+	delivered := 0
+	for _, result := range results {
+		if result.Success {
+			delivered++
+		}
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"events":    results,
+		"sent":      len(results),
+		"delivered": delivered,
+	})
+}
+
+// CreateReplayHandler starts resending a range of a tenant's events to its
+// webhook endpoints; the replay runs in the background
+func (h *Handlers) CreateReplayHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	var req ReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteBadRequestResponse(w, "invalid JSON payload")
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		api.WriteValidationErrorResponse(w, err)
+		return
+	}
+
+	replay, err := h.service.CreateReplay(r.Context(), tenantSlug, req)
+	if err != nil {
+		writeReplayError(w, err)
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusAccepted, replay)
+}
+
+// ListReplaysHandler returns a tenant's webhook replays
+func (h *Handlers) ListReplaysHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	replays, err := h.service.ListReplays(r.Context(), tenantSlug, limit)
+	if err != nil {
+		api.WriteInternalErrorResponse(w, err.Error())
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, map[string]interface{}{
+		"replays": replays,
+		"total":   len(replays),
+	})
+}
+
+// GetReplayHandler returns a webhook replay's progress
+func (h *Handlers) GetReplayHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	replayID, err := uuid.Parse(chi.URLParam(r, "replayId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid replay ID")
+		return
+	}
+
+	replay, err := h.service.GetReplay(r.Context(), tenantSlug, replayID)
+	if err != nil {
+		writeReplayError(w, err)
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, replay)
+}
+
+// CancelReplayHandler stops a webhook replay that has not finished
+func (h *Handlers) CancelReplayHandler(w http.ResponseWriter, r *http.Request) {
+	tenantSlug, ok := tenantSlugFromAPIKey(w, r)
+	if !ok {
+		return
+	}
+
+	replayID, err := uuid.Parse(chi.URLParam(r, "replayId"))
+	if err != nil {
+		api.WriteBadRequestResponse(w, "Invalid replay ID")
+		return
+	}
+
+	replay, err := h.service.CancelReplay(r.Context(), tenantSlug, replayID)
+	if err != nil {
+		writeReplayError(w, err)
+		return
+	}
+
+	api.WriteSuccessResponse(w, http.StatusOK, replay)
+}
+
+func writeReplayError(w http.ResponseWriter, err error) {
+	var archived *storage.ArchivedRangeError
+	switch {
+	case errors.Is(err, ErrReplayNotFound), errors.Is(err, ErrEndpointNotFound):
+		api.WriteNotFoundResponse(w, err.Error())
+	case errors.Is(err, ErrInvalidReplay),
+		errors.Is(err, ErrInvalidEventType),
+		errors.Is(err, ErrEndpointDisabled):
+		api.WriteBadRequestResponse(w, err.Error())
+	case errors.Is(err, ErrReplayFinished):
+		api.WriteErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.As(err, &archived):
+		api.WriteErrorResponse(w, http.StatusGone, archived.Error())
+	default:
+		api.WriteInternalErrorResponse(w, err.Error())
+	}
+}
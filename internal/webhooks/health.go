@@ -0,0 +1,252 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// Circuit states of an endpoint. A closed circuit sends normally, an open one
+// holds deliveries back until its cooldown ends, and a half-open one has a
+// single probe delivery in flight deciding whether to close or reopen.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// AutoDisabledReason is reported when an endpoint is disabled for failing
+const AutoDisabledReason = "consecutive_failures"
+
+// CircuitBreaker decides, from an endpoint's health, whether a delivery may
+// be sent and how a failed one changes the circuit. Health rows are shared
+// by every replica and only changed under a row lock, so its methods are pure.
+type CircuitBreaker struct {
+	// FailureThreshold consecutive failures open the circuit
+	FailureThreshold int32
+	// Cooldown is how long an open circuit waits before a probe
+	Cooldown time.Duration
+	// DisableAfter is how long an endpoint may fail before it is disabled;
+	// zero never disables
+	DisableAfter time.Duration
+}
+
+// admit reports whether a delivery may be sent to an endpoint in health h and
+// returns its health afterwards. An open circuit whose cooldown has passed
+// turns half-open and admits the delivery as its probe; a probe that has not
+// reported back within a delivery lease is presumed lost and replaced. A
+// refused delivery should wait until the returned time.
+func (b CircuitBreaker) admit(h queries.WebhookEndpointHealth, now time.Time) (queries.WebhookEndpointHealth, bool, time.Time) {
+	switch h.CircuitState {
+	case CircuitOpen:
+		if h.RetryAt.Valid && now.Before(h.RetryAt.Time) {
+			return h, false, h.RetryAt.Time
+		}
+	case CircuitHalfOpen:
+		if h.ProbeStartedAt.Valid && now.Before(h.ProbeStartedAt.Time.Add(DeliveryLease)) {
+			return h, false, now.Add(EndpointBusyDelay)
+		}
+	default:
+		return h, true, time.Time{}
+	}
+
+	h.CircuitState = CircuitHalfOpen
+	h.ProbeStartedAt = timestamptz(now)
+	return h, true, time.Time{}
+}
+
+// recordFailure returns an endpoint's health after a failed delivery and
+// whether the endpoint has now been failing long enough to be disabled. A
+// failed probe reopens the circuit for another cooldown.
+func (b CircuitBreaker) recordFailure(h queries.WebhookEndpointHealth, now time.Time) (queries.WebhookEndpointHealth, bool) {
+	h.ConsecutiveFailures++
+	h.LastFailureAt = timestamptz(now)
+	if !h.FailingSince.Valid {
+		h.FailingSince = timestamptz(now)
+	}
+
+	switch {
+	case h.CircuitState == CircuitHalfOpen,
+		h.CircuitState == CircuitClosed && h.ConsecutiveFailures >= b.FailureThreshold:
+		h.CircuitState = CircuitOpen
+		h.OpenedAt = timestamptz(now)
+		h.RetryAt = timestamptz(now.Add(b.Cooldown))
+		h.ProbeStartedAt = pgtype.Timestamptz{}
+	}
+
+	disable := b.DisableAfter > 0 &&
+		!h.AutoDisabledAt.Valid &&
+		now.Sub(h.FailingSince.Time) >= b.DisableAfter
+	if disable {
+		h.AutoDisabledAt = timestamptz(now)
+	}
+	return h, disable
+}
+
+// admitDelivery checks an endpoint's circuit before a send. Healthy endpoints
+// are admitted without taking a lock.
+func (s *Service) admitDelivery(ctx context.Context, endpointID uuid.UUID) (bool, time.Time, error) {
+	health, err := s.db.Queries.GetWebhookEndpointHealth(ctx, endpointID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return true, time.Time{}, nil
+	}
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("failed to get endpoint health: %w", err)
+	}
+	if health.CircuitState == CircuitClosed {
+		return true, time.Time{}, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries.WithTx(tx)
+
+	health, err = qtx.LockWebhookEndpointHealth(ctx, endpointID)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("failed to lock endpoint health: %w", err)
+	}
+
+	next, ok, retryAt := s.circuit.admit(health, time.Now())
+	if !ok {
+		return false, retryAt, nil
+	}
+	if next.CircuitState != health.CircuitState || next.ProbeStartedAt != health.ProbeStartedAt {
+		if err := qtx.UpdateWebhookEndpointHealth(ctx, healthParams(next)); err != nil {
+			return false, time.Time{}, fmt.Errorf("failed to update endpoint health: %w", err)
+		}
+		log.Printf("Webhook endpoint %s circuit half-open, sending probe", endpointID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, time.Time{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, time.Time{}, nil
+}
+
+// recordEndpointResult feeds a delivery's outcome into its endpoint's
+// circuit, disabling the endpoint and publishing webhook_endpoint.disabled
+// once it has failed for longer than the configured period
+func (s *Service) recordEndpointResult(ctx context.Context, endpoint queries.WebhookEndpoint, success bool) error {
+	if success {
+		if err := s.db.Queries.RecordWebhookEndpointSuccess(ctx, endpoint.ID); err != nil {
+			return fmt.Errorf("failed to record endpoint success: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries.WithTx(tx)
+
+	if err := qtx.EnsureWebhookEndpointHealth(ctx, endpoint.ID); err != nil {
+		return fmt.Errorf("failed to create endpoint health: %w", err)
+	}
+	health, err := qtx.LockWebhookEndpointHealth(ctx, endpoint.ID)
+	if err != nil {
+		return fmt.Errorf("failed to lock endpoint health: %w", err)
+	}
+
+	previous := health.CircuitState
+	health, disable := s.circuit.recordFailure(health, time.Now())
+	if err := qtx.UpdateWebhookEndpointHealth(ctx, healthParams(health)); err != nil {
+		return fmt.Errorf("failed to update endpoint health: %w", err)
+	}
+
+	if disable {
+		disabled, err := qtx.DisableWebhookEndpoint(ctx, queries.DisableWebhookEndpointParams{
+			ID:       endpoint.ID,
+			TenantID: endpoint.TenantID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to disable webhook endpoint: %w", err)
+		}
+		if err := s.eventService.PublishWebhookEndpointDisabled(ctx, qtx, disabled, AutoDisabledReason, health.ConsecutiveFailures, health.FailingSince.Time); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if health.CircuitState == CircuitOpen && previous != CircuitOpen {
+		log.Printf("Webhook endpoint %s circuit opened after %d consecutive failures", endpoint.ID, health.ConsecutiveFailures)
+	}
+	if disable {
+		log.Printf("Webhook endpoint %s disabled after failing since %s", endpoint.ID, health.FailingSince.Time.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// endpointHealth loads the health of a tenant's endpoints, keyed by endpoint
+func (s *Service) endpointHealth(ctx context.Context, tenantID uuid.UUID) (map[uuid.UUID]queries.WebhookEndpointHealth, error) {
+	rows, err := s.db.Queries.ListWebhookEndpointHealth(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpoint health: %w", err)
+	}
+
+	health := make(map[uuid.UUID]queries.WebhookEndpointHealth, len(rows))
+	for _, row := range rows {
+		health[row.EndpointID] = row
+	}
+	return health, nil
+}
+
+func healthParams(h queries.WebhookEndpointHealth) queries.UpdateWebhookEndpointHealthParams {
+	return queries.UpdateWebhookEndpointHealthParams{
+		EndpointID:          h.EndpointID,
+		CircuitState:        h.CircuitState,
+		ConsecutiveFailures: h.ConsecutiveFailures,
+		FailingSince:        h.FailingSince,
+		LastSuccessAt:       h.LastSuccessAt,
+		LastFailureAt:       h.LastFailureAt,
+		OpenedAt:            h.OpenedAt,
+		RetryAt:             h.RetryAt,
+		ProbeStartedAt:      h.ProbeStartedAt,
+		AutoDisabledAt:      h.AutoDisabledAt,
+	}
+}
+
+// healthToResponse describes an endpoint's health; endpoints that have never
+// had a delivery have no row and are reported healthy
+func healthToResponse(h queries.WebhookEndpointHealth, ok bool) *EndpointHealth {
+	if !ok {
+		return &EndpointHealth{CircuitState: CircuitClosed}
+	}
+	return &EndpointHealth{
+		CircuitState:        h.CircuitState,
+		ConsecutiveFailures: h.ConsecutiveFailures,
+		FailingSince:        optionalTime(h.FailingSince),
+		LastSuccessAt:       optionalTime(h.LastSuccessAt),
+		LastFailureAt:       optionalTime(h.LastFailureAt),
+		OpenedAt:            optionalTime(h.OpenedAt),
+		RetryAt:             optionalTime(h.RetryAt),
+		AutoDisabledAt:      optionalTime(h.AutoDisabledAt),
+	}
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func optionalTime(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,72 @@
+// internal/webhooks/health_test.go
+package webhooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+func TestCircuitBreakerOpensAndProbes(t *testing.T) {
+	breaker := CircuitBreaker{FailureThreshold: 3, Cooldown: time.Minute}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	health := queries.WebhookEndpointHealth{CircuitState: CircuitClosed}
+
+	for i := 0; i < 2; i++ {
+		health, _ = breaker.recordFailure(health, now)
+		assert.Equal(t, CircuitClosed, health.CircuitState)
+	}
+	health, _ = breaker.recordFailure(health, now)
+	assert.Equal(t, CircuitOpen, health.CircuitState)
+	assert.Equal(t, now.Add(time.Minute), health.RetryAt.Time)
+
+	// Held back for the cooldown, then exactly one probe goes out
+	_, ok, retryAt := breaker.admit(health, now.Add(30*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, now.Add(time.Minute), retryAt)
+
+	probeAt := now.Add(time.Minute)
+	health, ok, _ = breaker.admit(health, probeAt)
+	assert.True(t, ok)
+	assert.Equal(t, CircuitHalfOpen, health.CircuitState)
+
+	_, ok, _ = breaker.admit(health, probeAt.Add(time.Second))
+	assert.False(t, ok, "probe already in flight")
+
+	_, ok, _ = breaker.admit(health, probeAt.Add(DeliveryLease))
+	assert.True(t, ok, "lost probe is replaced")
+
+	// A failed probe reopens the circuit for another cooldown
+	failedAt := probeAt.Add(5 * time.Second)
+	health, _ = breaker.recordFailure(health, failedAt)
+	assert.Equal(t, CircuitOpen, health.CircuitState)
+	assert.Equal(t, failedAt.Add(time.Minute), health.RetryAt.Time)
+	assert.False(t, health.ProbeStartedAt.Valid)
+	assert.Equal(t, int32(4), health.ConsecutiveFailures)
+	assert.Equal(t, now, health.FailingSince.Time)
+}
+
+func TestCircuitBreakerDisablesAfterSustainedFailure(t *testing.T) {
+	breaker := CircuitBreaker{FailureThreshold: 1, Cooldown: time.Minute, DisableAfter: time.Hour}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	health := queries.WebhookEndpointHealth{CircuitState: CircuitClosed}
+
+	health, disable := breaker.recordFailure(health, now)
+	assert.False(t, disable)
+
+	health, disable = breaker.recordFailure(health, now.Add(59*time.Minute))
+	assert.False(t, disable)
+
+	health, disable = breaker.recordFailure(health, now.Add(time.Hour))
+	assert.True(t, disable)
+	assert.Equal(t, now.Add(time.Hour), health.AutoDisabledAt.Time)
+
+	_, disable = breaker.recordFailure(health, now.Add(2*time.Hour))
+	assert.False(t, disable, "only disabled once")
+
+	never := CircuitBreaker{FailureThreshold: 1, Cooldown: time.Minute}
+	_, disable = never.recordFailure(health, now.Add(1000*time.Hour))
+	assert.False(t, disable)
+}
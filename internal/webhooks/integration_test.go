@@ -0,0 +1,908 @@
+// internal/webhooks/integration_test.go
+// +build integration
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temmyjay001/ledger-service/internal/config"
+	"github.com/temmyjay001/ledger-service/internal/events"
+	"github.com/temmyjay001/ledger-service/internal/secrets"
+	"github.com/temmyjay001/ledger-service/internal/storage"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+	"github.com/temmyjay001/ledger-service/internal/testUtil"
+	"github.com/temmyjay001/ledger-service/pkg/webhook"
+)
+
+// webhookTest is a fresh tenant with a webhook service to test against
+type webhookTest struct {
+	db           *storage.DB
+	tenant       queries.Tenant
+	tenantSlug   string
+	eventService *events.Service
+	service      *Service
+}
+
+// newWebhookTest creates a tenant and a service built from the test config,
+// once configure (if any) has adjusted it
+func newWebhookTest(t *testing.T, configure func(cfg *config.Config)) *webhookTest {
+	t.Helper()
+	testutil.SkipIfShort(t)
+
+	db := testutil.SetupTestDB(t)
+	tenantSlug := testutil.RandomSlug()
+	tenant := testutil.CreateTestTenant(t, db, tenantSlug)
+	t.Cleanup(func() {
+		testutil.CleanupTestTenant(t, db, tenantSlug)
+	})
+
+	cfg := testutil.TestConfig()
+	if configure != nil {
+		configure(cfg)
+	}
+	keyring, err := secrets.NewKeyring(cfg.WebhookMasterKeys)
+	require.NoError(t, err)
+
+	eventService := events.NewService(db)
+	return &webhookTest{
+		db:           db,
+		tenant:       tenant,
+		tenantSlug:   tenantSlug,
+		eventService: eventService,
+		service:      NewService(db, eventService, keyring, cfg),
+	}
+}
+
+// createEvent stores an event for the tenant. Unset fields default to a new
+// transaction aggregate with empty data and metadata.
+func (wt *webhookTest) createEvent(t *testing.T, params queries.CreateEventParams) queries.Event {
+	t.Helper()
+
+	params.TenantID = wt.tenant.ID
+	if params.AggregateID == uuid.Nil {
+		params.AggregateID = uuid.New()
+	}
+	if params.AggregateType == "" {
+		params.AggregateType = events.AggregateTypeTransaction
+	}
+	if params.EventVersion == 0 {
+		params.EventVersion = 1
+	}
+	if params.EventData == nil {
+		params.EventData = json.RawMessage("{}")
+	}
+	if params.Metadata == nil {
+		params.Metadata = json.RawMessage("{}")
+	}
+
+	event, err := wt.db.Queries.CreateEvent(context.Background(), params)
+	require.NoError(t, err)
+	return event
+}
+
+// queueEvent stores an event and queues its deliveries
+func (wt *webhookTest) queueEvent(t *testing.T, params queries.CreateEventParams) queries.Event {
+	t.Helper()
+
+	event := wt.createEvent(t, params)
+	require.NoError(t, wt.service.QueueWebhookDelivery(context.Background(), wt.db.Queries, event))
+	return event
+}
+
+// newReceiver starts a webhook receiver for the test. handle runs on the
+// server's goroutines, where require can't stop the test, so the errors it
+// returns are kept and asserted from the test goroutine once the test ends.
+func newReceiver(t *testing.T, handle func(w http.ResponseWriter, r *http.Request, body []byte) error) *httptest.Server {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.NoError(t, errors.Join(errs...), "webhook receiver failed")
+	})
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err == nil {
+			err = handle(w, r, body)
+		}
+		if err != nil {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+func TestIntegration_OutboxDispatcherQueuesEachEventOnce(t *testing.T) {
+	wt := newWebhookTest(t, nil)
+	db, tenant, service := wt.db, wt.tenant, wt.service
+	ctx := context.Background()
+
+	// One endpoint per subscription style, plus a disabled one that gets nothing
+	disabled := false
+	for _, req := range []CreateEndpointRequest{
+		{URL: "https://example.com/transactions", EventTypes: []string{"transaction.*"}},
+		{URL: "https://example.com/everything", EventTypes: []string{"*"}},
+		{URL: "https://example.com/off", EventTypes: []string{"*"}, Enabled: &disabled},
+	} {
+		_, err := service.CreateEndpoint(ctx, wt.tenantSlug, req)
+		require.NoError(t, err)
+	}
+
+	// Transaction events plus one only the catch-all endpoint subscribes to
+	const eventCount = 20
+	var eventIDs []uuid.UUID
+	for i := 0; i < eventCount+1; i++ {
+		eventType := "transaction.posted"
+		if i == eventCount {
+			eventType = "balance.updated"
+		}
+		event := wt.createEvent(t, queries.CreateEventParams{EventType: eventType})
+		eventIDs = append(eventIDs, event.EventID)
+	}
+
+	// Several replicas race on a fresh consumer; each of our events must be
+	// handled by exactly one committed batch
+	consumer := "test-" + testutil.RandomString(8)
+	require.NoError(t, db.Queries.CreateEventConsumer(ctx, consumer))
+	t.Cleanup(func() {
+		db.Exec(ctx, "DELETE FROM event_consumers WHERE name = $1", consumer)
+	})
+
+	var mu sync.Mutex
+	handled := make(map[uuid.UUID]int)
+	handler := func(ctx context.Context, q *queries.Queries, event queries.Event) error {
+		if err := service.QueueWebhookDelivery(ctx, q, event); err != nil {
+			return err
+		}
+		if event.TenantID == tenant.ID {
+			mu.Lock()
+			handled[event.EventID]++
+			mu.Unlock()
+		}
+		return nil
+	}
+
+	done := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled) == len(eventIDs)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deadline := time.Now().Add(30 * time.Second)
+			for !done() && time.Now().Before(deadline) {
+				if _, err := wt.eventService.DispatchBatch(ctx, consumer, 50, handler); err != nil {
+					t.Errorf("dispatch failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.True(t, done(), "all events should be dispatched")
+	for _, id := range eventIDs {
+		assert.Equal(t, 1, handled[id], "event %s should be handled once", id)
+	}
+
+	deliveries, err := db.Queries.GetWebhookDeliveriesByTenant(ctx, queries.GetWebhookDeliveriesByTenantParams{
+		TenantID: tenant.ID,
+		Limit:    100,
+	})
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 2*eventCount+1, "each enabled endpoint gets the events it subscribed to")
+
+	// Nothing is handed out again once the consumer has moved past it
+	_, err = wt.eventService.DispatchBatch(ctx, consumer, 50, handler)
+	require.NoError(t, err)
+	for _, id := range eventIDs {
+		assert.Equal(t, 1, handled[id], "event %s should not be handled again", id)
+	}
+}
+
+func TestIntegration_RotateSecretOverlapsThenExpires(t *testing.T) {
+	wt := newWebhookTest(t, func(cfg *config.Config) {
+		cfg.WebhookSecretOverlap = time.Hour
+	})
+	db, tenantSlug, service := wt.db, wt.tenantSlug, wt.service
+	ctx := context.Background()
+
+	endpoint, err := service.CreateEndpoint(ctx, tenantSlug, CreateEndpointRequest{
+		URL:        "https://example.com/hook",
+		EventTypes: []string{"*"},
+	})
+	require.NoError(t, err)
+	endpointID := uuid.MustParse(endpoint.ID)
+
+	// the secret is never stored in plaintext
+	var stored []byte
+	require.NoError(t, db.QueryRow(ctx, "SELECT encrypted_secret FROM webhook_endpoint_secrets WHERE endpoint_id = $1", endpointID).Scan(&stored))
+	assert.NotContains(t, string(stored), endpoint.Secret)
+
+	rotated, err := service.RotateSecret(ctx, tenantSlug, endpointID, RotateSecretRequest{})
+	require.NoError(t, err)
+	assert.NotEqual(t, endpoint.Secret, rotated.Secret)
+
+	signing, err := service.signingSecrets(ctx, endpointID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{rotated.Secret, endpoint.Secret}, signing, "both secrets sign during the overlap")
+
+	// a zero overlap retires the previous secret straight away
+	overlap := 0
+	final, err := service.RotateSecret(ctx, tenantSlug, endpointID, RotateSecretRequest{OverlapSeconds: &overlap})
+	require.NoError(t, err)
+
+	signing, err = service.signingSecrets(ctx, endpointID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{final.Secret}, signing)
+}
+
+func TestIntegration_ExhaustedDeliveriesAreDeadLetteredAndRedelivered(t *testing.T) {
+	wt := newWebhookTest(t, func(cfg *config.Config) {
+		cfg.WebhookRetrySchedule = []time.Duration{time.Millisecond}
+	})
+	tenantSlug, service := wt.tenantSlug, wt.service
+	ctx := context.Background()
+
+	receiver := newReceiver(t, func(w http.ResponseWriter, r *http.Request, body []byte) error {
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil
+	})
+
+	endpoint, err := service.CreateEndpoint(ctx, tenantSlug, CreateEndpointRequest{
+		URL:        receiver.URL,
+		EventTypes: []string{"*"},
+	})
+	require.NoError(t, err)
+
+	wt.queueEvent(t, queries.CreateEventParams{EventType: "transaction.posted"})
+
+	// The first attempt and its single scheduled retry both fail
+	for i := 0; i < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+	}
+
+	endpointID := uuid.MustParse(endpoint.ID)
+	deadLetters, err := service.ListDeadLetters(ctx, tenantSlug, DeadLetterFilter{EndpointID: &endpointID, EventType: "transaction.posted"}, 10)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, 2, deadLetters[0].Attempts)
+	assert.NotNil(t, deadLetters[0].DeadLetteredAt)
+
+	deadLetter, err := service.GetDeadLetter(ctx, tenantSlug, uuid.MustParse(deadLetters[0].ID))
+	require.NoError(t, err)
+	require.NotNil(t, deadLetter.ResponseBody)
+	assert.Contains(t, *deadLetter.ResponseBody, "HTTP 500")
+
+	none, err := service.ListDeadLetters(ctx, tenantSlug, DeadLetterFilter{EventType: "balance.updated"}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	redelivered, err := service.RedeliverDeadLetters(ctx, tenantSlug, DeadLetterFilter{EndpointID: &endpointID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), redelivered)
+
+	delivery, err := service.GetWebhookDelivery(ctx, tenantSlug, uuid.MustParse(deadLetters[0].ID))
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivery.Attempts)
+	assert.Nil(t, delivery.DeadLetteredAt)
+	assert.NotNil(t, delivery.NextRetryAt)
+}
+
+func TestIntegration_DeliveriesAreListedOnceTheirEventIsGone(t *testing.T) {
+	wt := newWebhookTest(t, nil)
+	tenantSlug, service := wt.tenantSlug, wt.service
+	ctx := context.Background()
+
+	endpoint, err := service.CreateEndpoint(ctx, tenantSlug, CreateEndpointRequest{
+		URL:        "https://example.com/hook",
+		EventTypes: []string{"*"},
+	})
+	require.NoError(t, err)
+
+	// archiving removes the events of old months but keeps their deliveries
+	event := wt.queueEvent(t, queries.CreateEventParams{EventType: events.EventTypeTransactionPosted})
+	_, err = wt.db.Exec(ctx, "DELETE FROM events WHERE event_id = $1", event.EventID)
+	require.NoError(t, err)
+
+	deliveries, err := service.ListWebhookDeliveries(ctx, tenantSlug, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, event.EventID.String(), deliveries[0].EventID)
+	assert.Equal(t, events.EventTypeTransactionPosted, deliveries[0].EventType)
+	assert.Equal(t, endpoint.URL, deliveries[0].URL)
+
+	delivery, err := service.GetWebhookDelivery(ctx, tenantSlug, uuid.MustParse(deliveries[0].ID))
+	require.NoError(t, err)
+	assert.Equal(t, deliveries[0], *delivery)
+}
+
+func TestIntegration_ConcurrentWorkersClaimEachDeliveryOnce(t *testing.T) {
+	wt := newWebhookTest(t, nil)
+	tenant, service := wt.tenant, wt.service
+	ctx := context.Background()
+
+	_, err := service.CreateEndpoint(ctx, wt.tenantSlug, CreateEndpointRequest{
+		URL:        "https://example.com/hook",
+		EventTypes: []string{"*"},
+	})
+	require.NoError(t, err)
+
+	const deliveryCount = 30
+	for i := 0; i < deliveryCount; i++ {
+		wt.queueEvent(t, queries.CreateEventParams{EventType: "transaction.posted"})
+	}
+
+	// Replicas claiming at once never lease the same delivery
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		claimed = make(map[uuid.UUID]int)
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				deliveries, err := service.claimDeliveries(ctx, 5)
+				if err != nil {
+					t.Errorf("claim failed: %v", err)
+					return
+				}
+				if len(deliveries) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, d := range deliveries {
+					if d.TenantID == tenant.ID {
+						claimed[d.ID]++
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claimed, deliveryCount)
+	for id, n := range claimed {
+		assert.Equal(t, 1, n, "delivery %s claimed more than once", id)
+	}
+}
+
+func TestIntegration_FailingEndpointOpensCircuitAndIsDisabled(t *testing.T) {
+	wt := newWebhookTest(t, func(cfg *config.Config) {
+		cfg.WebhookRetrySchedule = []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+		cfg.WebhookCircuitFailureThreshold = 2
+		cfg.WebhookCircuitCooldown = time.Hour
+		cfg.WebhookAutoDisableAfter = 5 * time.Millisecond
+	})
+	tenant, tenantSlug, service := wt.tenant, wt.tenantSlug, wt.service
+	ctx := context.Background()
+
+	var (
+		mu   sync.Mutex
+		hits int
+	)
+	receiver := newReceiver(t, func(w http.ResponseWriter, r *http.Request, body []byte) error {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadGateway)
+		return nil
+	})
+
+	endpoint, err := service.CreateEndpoint(ctx, tenantSlug, CreateEndpointRequest{
+		URL:        receiver.URL,
+		EventTypes: []string{"transaction.*"},
+	})
+	require.NoError(t, err)
+	endpointID := uuid.MustParse(endpoint.ID)
+
+	wt.queueEvent(t, queries.CreateEventParams{EventType: "transaction.posted"})
+
+	// The second failure opens the circuit; later rounds send nothing
+	for i := 0; i < 4; i++ {
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+	}
+	mu.Lock()
+	assert.Equal(t, 2, hits)
+	mu.Unlock()
+
+	got, err := service.GetEndpoint(ctx, tenantSlug, endpointID)
+	require.NoError(t, err)
+	assert.False(t, got.Enabled, "failing past the auto-disable period")
+	require.NotNil(t, got.Health)
+	assert.Equal(t, CircuitOpen, got.Health.CircuitState)
+	assert.Equal(t, int32(2), got.Health.ConsecutiveFailures)
+	assert.NotNil(t, got.Health.AutoDisabledAt)
+
+	disabledEvents, err := wt.eventService.GetEventsByType(ctx, tenant.ID, events.EventTypeWebhookEndpointDisabled, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, disabledEvents, 1)
+	assert.Equal(t, endpointID, disabledEvents[0].AggregateID)
+
+	// Re-enabling starts the endpoint over with a closed circuit
+	enabled := true
+	got, err = service.UpdateEndpoint(ctx, tenantSlug, endpointID, UpdateEndpointRequest{Enabled: &enabled})
+	require.NoError(t, err)
+	got, err = service.GetEndpoint(ctx, tenantSlug, endpointID)
+	require.NoError(t, err)
+	assert.True(t, got.Enabled)
+	assert.Equal(t, CircuitClosed, got.Health.CircuitState)
+	assert.Zero(t, got.Health.ConsecutiveFailures)
+}
+
+func TestIntegration_DeliveryAttemptsAreLoggedAndPruned(t *testing.T) {
+	wt := newWebhookTest(t, func(cfg *config.Config) {
+		cfg.WebhookRetrySchedule = []time.Duration{time.Millisecond}
+	})
+	tenantSlug, service := wt.tenantSlug, wt.service
+	ctx := context.Background()
+
+	// Fails once with a large body, then accepts
+	var (
+		mu   sync.Mutex
+		hits int
+	)
+	receiver := newReceiver(t, func(w http.ResponseWriter, r *http.Request, body []byte) error {
+		mu.Lock()
+		hits++
+		first := hits == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(strings.Repeat("x", 2*MaxAttemptResponseBytes)))
+			return nil
+		}
+		w.Write([]byte("ok"))
+		return nil
+	})
+
+	_, err := service.CreateEndpoint(ctx, tenantSlug, CreateEndpointRequest{
+		URL:        receiver.URL,
+		EventTypes: []string{"*"},
+	})
+	require.NoError(t, err)
+
+	wt.queueEvent(t, queries.CreateEventParams{EventType: "transaction.posted"})
+
+	require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+
+	// The delivery keeps no more of the large body than the attempt log does
+	deliveries, err := service.ListWebhookDeliveries(ctx, tenantSlug, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	deliveryID := uuid.MustParse(deliveries[0].ID)
+	delivery, err := wt.db.Queries.GetWebhookDeliveryByID(ctx, queries.GetWebhookDeliveryByIDParams{
+		ID:       deliveryID,
+		TenantID: wt.tenant.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP 500: "+strings.Repeat("x", MaxAttemptResponseBytes), delivery.ResponseBody.String)
+
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+
+	attempts, err := service.ListDeliveryAttempts(ctx, tenantSlug, deliveryID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+
+	assert.Equal(t, 1, attempts[0].AttemptNumber)
+	require.NotNil(t, attempts[0].ResponseStatus)
+	assert.Equal(t, http.StatusInternalServerError, *attempts[0].ResponseStatus)
+	assert.True(t, attempts[0].ResponseTruncated)
+	assert.Len(t, *attempts[0].ResponseBody, MaxAttemptResponseBytes)
+	assert.Contains(t, string(attempts[0].RequestHeaders), "X-Ledger-Signature")
+
+	assert.Equal(t, 2, attempts[1].AttemptNumber)
+	assert.Equal(t, http.StatusOK, *attempts[1].ResponseStatus)
+	assert.Equal(t, attempts[0].PayloadSHA256, attempts[1].PayloadSHA256)
+	assert.Nil(t, attempts[1].ErrorClass)
+
+	_, err = service.ListDeliveryAttempts(ctx, tenantSlug, uuid.New())
+	assert.ErrorIs(t, err, ErrDeliveryNotFound)
+
+	_, err = service.PruneDeliveryAttempts(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	attempts, err = service.ListDeliveryAttempts(ctx, tenantSlug, deliveryID)
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+}
+
+func TestIntegration_OrderedEndpointDeliversEachAggregateInSequence(t *testing.T) {
+	wt := newWebhookTest(t, func(cfg *config.Config) {
+		cfg.WebhookRetrySchedule = []time.Duration{time.Millisecond}
+	})
+	service := wt.service
+	ctx := context.Background()
+
+	blocked, other := uuid.New(), uuid.New()
+
+	// The first delivery of the blocked aggregate fails once
+	var (
+		mu       sync.Mutex
+		failed   bool
+		received = make(map[string][]int64)
+	)
+	receiver := newReceiver(t, func(w http.ResponseWriter, r *http.Request, body []byte) error {
+		var payload WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if payload.AggregateID == blocked.String() && !failed {
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return nil
+		}
+		received[payload.AggregateID] = append(received[payload.AggregateID], payload.SequenceNumber)
+		return nil
+	})
+
+	endpoint, err := service.CreateEndpoint(ctx, wt.tenantSlug, CreateEndpointRequest{
+		URL:        receiver.URL,
+		EventTypes: []string{"*"},
+		Ordered:    true,
+	})
+	require.NoError(t, err)
+	assert.True(t, endpoint.Ordered)
+
+	var sequence []int64
+	for _, aggregateID := range []uuid.UUID{blocked, other, blocked, other} {
+		event := wt.queueEvent(t, queries.CreateEventParams{
+			AggregateID:   aggregateID,
+			AggregateType: events.AggregateTypeAccount,
+			EventType:     "balance.updated",
+		})
+		sequence = append(sequence, event.SequenceNumber.Int64)
+	}
+
+	// Round one sends the head of each aggregate; only the other aggregate
+	// moves on in round two while the blocked one retries its first event
+	require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+	mu.Lock()
+	assert.Empty(t, received[blocked.String()])
+	assert.Equal(t, []int64{sequence[1]}, received[other.String()])
+	mu.Unlock()
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int64{sequence[0], sequence[2]}, received[blocked.String()])
+	assert.Equal(t, []int64{sequence[1], sequence[3]}, received[other.String()])
+}
+
+func TestIntegration_OrderedReplayIsNotHeldBackByDeadLetter(t *testing.T) {
+	wt := newWebhookTest(t, func(cfg *config.Config) {
+		cfg.WebhookRetrySchedule = []time.Duration{time.Millisecond}
+	})
+	tenantSlug, service := wt.tenantSlug, wt.service
+	ctx := context.Background()
+
+	// The receiver is down until the aggregate's first delivery is dead-lettered
+	var (
+		mu       sync.Mutex
+		down     = true
+		received []WebhookPayload
+	)
+	receiver := newReceiver(t, func(w http.ResponseWriter, r *http.Request, body []byte) error {
+		var payload WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return nil
+		}
+		received = append(received, payload)
+		return nil
+	})
+
+	endpoint, err := service.CreateEndpoint(ctx, tenantSlug, CreateEndpointRequest{
+		URL:        receiver.URL,
+		EventTypes: []string{"*"},
+		Ordered:    true,
+	})
+	require.NoError(t, err)
+	endpointID := uuid.MustParse(endpoint.ID)
+
+	aggregateID := uuid.New()
+	var created []queries.Event
+	for i := 0; i < 2; i++ {
+		created = append(created, wt.queueEvent(t, queries.CreateEventParams{
+			AggregateID:   aggregateID,
+			AggregateType: events.AggregateTypeAccount,
+			EventType:     "balance.updated",
+		}))
+	}
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+	}
+	deadLetters, err := service.ListDeadLetters(ctx, tenantSlug, DeadLetterFilter{EndpointID: &endpointID}, 10)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, created[0].EventID.String(), deadLetters[0].EventID)
+
+	mu.Lock()
+	down = false
+	mu.Unlock()
+
+	// The replay resends both events in order although the live dead letter,
+	// and the live delivery queued behind it, are still undelivered
+	from, to := created[0].SequenceNumber.Int64, created[1].SequenceNumber.Int64
+	replay, err := service.CreateReplay(ctx, tenantSlug, ReplayRequest{
+		FromSequence: &from,
+		ToSequence:   &to,
+		EndpointID:   &endpointID,
+	})
+	require.NoError(t, err)
+	for {
+		claimed, err := service.ProcessReplayBatch(ctx)
+		require.NoError(t, err)
+		if !claimed {
+			break
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 2)
+	for i, payload := range received {
+		assert.Equal(t, created[i].EventID.String(), payload.ID)
+		assert.Equal(t, replay.ID, payload.ReplayID)
+	}
+}
+
+func TestIntegration_DeliveriesCarryTheOriginatingRequest(t *testing.T) {
+	wt := newWebhookTest(t, nil)
+	service := wt.service
+	ctx := context.Background()
+
+	received := make(chan *http.Request, 1)
+	payloads := make(chan WebhookPayload, 1)
+	receiver := newReceiver(t, func(w http.ResponseWriter, r *http.Request, body []byte) error {
+		var payload WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return err
+		}
+		received <- r
+		payloads <- payload
+		return nil
+	})
+
+	_, err := service.CreateEndpoint(ctx, wt.tenantSlug, CreateEndpointRequest{
+		URL:        receiver.URL,
+		EventTypes: []string{"*"},
+	})
+	require.NoError(t, err)
+
+	requestID, correlationID := "host/abc-000042", "support-ticket-4711"
+	metadata, err := json.Marshal(events.EventMetadata{
+		RequestID:     &requestID,
+		CorrelationID: &correlationID,
+		Source:        events.SourceAPI,
+	})
+	require.NoError(t, err)
+
+	wt.queueEvent(t, queries.CreateEventParams{
+		EventType: events.EventTypeTransactionPosted,
+		Metadata:  metadata,
+	})
+	require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+
+	select {
+	case r := <-received:
+		assert.Equal(t, correlationID, r.Header.Get(webhook.CorrelationIDHeader))
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	payload := <-payloads
+	assert.Equal(t, requestID, payload.RequestID)
+	assert.Equal(t, correlationID, payload.CorrelationID)
+}
+
+func TestIntegration_CloudEventsBinaryEndpointReceivesSignedData(t *testing.T) {
+	wt := newWebhookTest(t, nil)
+	service := wt.service
+	ctx := context.Background()
+
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	received := make(chan delivery, 1)
+	receiver := newReceiver(t, func(w http.ResponseWriter, r *http.Request, body []byte) error {
+		received <- delivery{header: r.Header, body: body}
+		return nil
+	})
+
+	endpoint, err := service.CreateEndpoint(ctx, wt.tenantSlug, CreateEndpointRequest{
+		URL:           receiver.URL,
+		EventTypes:    []string{"*"},
+		PayloadFormat: PayloadFormatCloudEventsBinary,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PayloadFormatCloudEventsBinary, endpoint.PayloadFormat)
+	assert.Equal(t, events.Schemas.LatestPayloadVersion(), endpoint.PayloadVersion)
+
+	event := wt.queueEvent(t, queries.CreateEventParams{
+		EventType: events.EventTypeTransactionPosted,
+		EventData: json.RawMessage(`{"transaction_id": "t1"}`),
+	})
+	require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+
+	var got delivery
+	select {
+	case got = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	assert.JSONEq(t, `{"transaction_id": "t1"}`, string(got.body))
+	assert.Equal(t, "application/json", got.header.Get("Content-Type"))
+	assert.Equal(t, "1.0", got.header.Get("ce-specversion"))
+	assert.Equal(t, event.EventID.String(), got.header.Get("ce-id"))
+	assert.Equal(t, events.CloudEventSource(wt.tenant.ID.String()), got.header.Get("ce-source"))
+	assert.Equal(t, "transaction/"+event.AggregateID.String(), got.header.Get("ce-subject"))
+	assert.NoError(t, webhook.Verify(got.body, got.header.Get(webhook.SignatureHeader), endpoint.Secret, time.Minute))
+	assert.True(t, strings.HasPrefix(got.header.Get(webhook.SignatureHeader), "t="+got.header.Get(webhook.TimestampHeader)+","),
+		"the timestamp header is the signing time")
+}
+
+func TestIntegration_ReplayQueuesThrottledMarkedDeliveries(t *testing.T) {
+	wt := newWebhookTest(t, nil)
+	db, tenantSlug, service := wt.db, wt.tenantSlug, wt.service
+	ctx := context.Background()
+
+	payloads := make(chan WebhookPayload, 10)
+	receiver := newReceiver(t, func(w http.ResponseWriter, r *http.Request, body []byte) error {
+		var payload WebhookPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return err
+		}
+		payloads <- payload
+		return nil
+	})
+
+	_, err := service.CreateEndpoint(ctx, tenantSlug, CreateEndpointRequest{
+		URL:        receiver.URL,
+		EventTypes: []string{"*"},
+	})
+	require.NoError(t, err)
+
+	var created []queries.Event
+	for _, eventType := range []string{
+		events.EventTypeTransactionPosted,
+		events.EventTypeTransactionPosted,
+		events.EventTypeBalanceUpdated,
+		events.EventTypeTransactionPosted,
+		events.EventTypeTransactionPosted,
+	} {
+		created = append(created, wt.createEvent(t, queries.CreateEventParams{EventType: eventType}))
+	}
+
+	// the first event was already delivered live
+	require.NoError(t, service.QueueWebhookDelivery(ctx, db.Queries, created[0]))
+	require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+	live := <-payloads
+	assert.False(t, live.Replayed)
+
+	rate := 2
+	from, to := created[0].SequenceNumber.Int64, created[3].SequenceNumber.Int64
+	replay, err := service.CreateReplay(ctx, tenantSlug, ReplayRequest{
+		FromSequence:  &from,
+		ToSequence:    &to,
+		EventTypes:    []string{"transaction.*"},
+		RatePerSecond: &rate,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ReplayStatusPending, replay.Status)
+	assert.Equal(t, int64(3), replay.Progress.TotalEvents)
+
+	processReplays := func() {
+		for {
+			claimed, err := service.ProcessReplayBatch(ctx)
+			require.NoError(t, err)
+			if !claimed {
+				return
+			}
+		}
+	}
+
+	// one batch of rate events, then the replay waits for the next second
+	processReplays()
+	progress, err := service.GetReplay(ctx, tenantSlug, uuid.MustParse(replay.ID))
+	require.NoError(t, err)
+	assert.Equal(t, ReplayStatusRunning, progress.Status)
+	assert.Equal(t, int64(2), progress.Progress.EventsProcessed)
+
+	time.Sleep(ReplayInterval + 100*time.Millisecond)
+	processReplays()
+	progress, err = service.GetReplay(ctx, tenantSlug, uuid.MustParse(replay.ID))
+	require.NoError(t, err)
+	assert.Equal(t, ReplayStatusCompleted, progress.Status)
+	assert.Equal(t, int64(3), progress.Progress.DeliveriesQueued)
+	assert.Equal(t, to, progress.Progress.LastSequence)
+	assert.Equal(t, 100.0, progress.Progress.Percent)
+
+	// replayed deliveries go out alongside the live one, marked as replays
+	require.NoError(t, service.ProcessPendingDeliveries(ctx, 10))
+	replayed := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		select {
+		case payload := <-payloads:
+			assert.True(t, payload.Replayed)
+			assert.Equal(t, replay.ID, payload.ReplayID)
+			replayed[payload.ID] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("replayed webhook was not delivered")
+		}
+	}
+	assert.Equal(t, map[string]bool{
+		created[0].EventID.String(): true,
+		created[1].EventID.String(): true,
+		created[3].EventID.String(): true,
+	}, replayed)
+
+	progress, err = service.GetReplay(ctx, tenantSlug, uuid.MustParse(replay.ID))
+	require.NoError(t, err)
+	require.NotNil(t, progress.Deliveries)
+	assert.Equal(t, int64(3), progress.Deliveries.Delivered)
+
+	// finished replays cannot be cancelled, unfinished ones stop
+	_, err = service.CancelReplay(ctx, tenantSlug, uuid.MustParse(replay.ID))
+	assert.ErrorIs(t, err, ErrReplayFinished)
+
+	pending, err := service.CreateReplay(ctx, tenantSlug, ReplayRequest{FromSequence: &from})
+	require.NoError(t, err)
+	cancelled, err := service.CancelReplay(ctx, tenantSlug, uuid.MustParse(pending.ID))
+	require.NoError(t, err)
+	assert.Equal(t, ReplayStatusCancelled, cancelled.Status)
+
+	_, err = service.GetReplay(ctx, tenantSlug, uuid.New())
+	assert.ErrorIs(t, err, ErrReplayNotFound)
+}
@@ -0,0 +1,459 @@
+// internal/webhooks/replay.go
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/storage"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+const (
+	// ReplayInterval is how often the replay worker looks for replays due
+	// a batch, and how long a replay waits between batches. Each batch is
+	// at most the replay's rate of events, which makes the rate per second.
+	ReplayInterval = time.Second
+	// ReplayLease is how long a claimed replay is reserved for the worker
+	// that claimed it; it has to outlast queuing one batch
+	ReplayLease = time.Minute
+)
+
+// CreateReplay starts a replay of a tenant's past events to its webhook
+// endpoints. The events are queued as deliveries in the background by the
+// replay worker; the returned replay reports how many events matched.
+func (s *Service) CreateReplay(ctx context.Context, tenantSlug string, req ReplayRequest) (*ReplayResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	if err := validateReplay(req); err != nil {
+		return nil, err
+	}
+
+	if req.EndpointID != nil {
+		endpoint, err := s.db.Queries.GetWebhookEndpoint(ctx, queries.GetWebhookEndpointParams{
+			ID:       *req.EndpointID,
+			TenantID: tenant.ID,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, ErrEndpointNotFound
+			}
+			return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+		}
+		if !endpoint.Enabled {
+			return nil, ErrEndpointDisabled
+		}
+	}
+
+	var createdFrom, createdTo pgtype.Timestamptz
+	if req.From != nil || req.To != nil {
+		var from time.Time
+		to := time.Now()
+		if req.From != nil {
+			from = *req.From
+			createdFrom = timestamptz(*req.From)
+		}
+		if req.To != nil {
+			to = *req.To
+			createdTo = timestamptz(*req.To)
+		}
+		if err := storage.CheckArchivedRange(ctx, s.db.Queries, "events", from, to); err != nil {
+			return nil, err
+		}
+	}
+
+	// Events that arrive after the replay is created are sent live, so the
+	// range never extends past the tenant's latest event
+	head, err := s.db.Queries.GetTenantEventHead(ctx, tenant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest event: %w", err)
+	}
+	toSequence := head
+	if req.ToSequence != nil && *req.ToSequence < head {
+		toSequence = *req.ToSequence
+	}
+
+	var fromSequence pgtype.Int8
+	var afterSequence int64
+	if req.FromSequence != nil {
+		fromSequence = pgtype.Int8{Int64: *req.FromSequence, Valid: true}
+		afterSequence = max(*req.FromSequence-1, 0)
+	}
+
+	patterns := eventTypeLikePatterns(req.EventTypes)
+	total, err := s.db.Queries.CountReplayEvents(ctx, queries.CountReplayEventsParams{
+		TenantID:          tenant.ID,
+		AfterSequence:     afterSequence,
+		ToSequence:        toSequence,
+		EventTypePatterns: patterns,
+		CreatedFrom:       createdFrom,
+		CreatedTo:         createdTo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events to replay: %w", err)
+	}
+
+	rate := s.config.WebhookReplayRate
+	if req.RatePerSecond != nil {
+		rate = *req.RatePerSecond
+	}
+
+	eventTypes := req.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	replay, err := s.db.Queries.CreateWebhookReplay(ctx, queries.CreateWebhookReplayParams{
+		TenantID:     tenant.ID,
+		EndpointID:   req.EndpointID,
+		EventTypes:   eventTypes,
+		FromSequence: fromSequence,
+		ToSequence:   toSequence,
+		CreatedFrom:  createdFrom,
+		CreatedTo:    createdTo,
+		RateLimit:    int32(rate),
+		TotalEvents:  total,
+		LastSequence: afterSequence,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook replay: %w", err)
+	}
+
+	log.Printf("Created webhook replay %s of %d events for tenant %s", replay.ID, total, tenantSlug)
+	return replayToResponse(replay, nil), nil
+}
+
+// ListReplays returns a tenant's replays, most recent first
+func (s *Service) ListReplays(ctx context.Context, tenantSlug string, limit int) ([]ReplayResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	replays, err := s.db.Queries.ListWebhookReplays(ctx, queries.ListWebhookReplaysParams{
+		TenantID: tenant.ID,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook replays: %w", err)
+	}
+
+	response := make([]ReplayResponse, 0, len(replays))
+	for _, replay := range replays {
+		response = append(response, *replayToResponse(replay, nil))
+	}
+	return response, nil
+}
+
+// GetReplay returns a replay's progress along with where the deliveries it
+// queued stand
+func (s *Service) GetReplay(ctx context.Context, tenantSlug string, replayID uuid.UUID) (*ReplayResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	replay, err := s.db.Queries.GetWebhookReplay(ctx, queries.GetWebhookReplayParams{
+		ID:       replayID,
+		TenantID: tenant.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReplayNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook replay: %w", err)
+	}
+
+	return s.replayWithDeliveries(ctx, replay)
+}
+
+// CancelReplay stops a pending or running replay. Deliveries it queued that
+// have not been attempted yet are dropped; ones already in flight finish.
+func (s *Service) CancelReplay(ctx context.Context, tenantSlug string, replayID uuid.UUID) (*ReplayResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	replay, err := s.db.Queries.CancelWebhookReplay(ctx, queries.CancelWebhookReplayParams{
+		ID:       replayID,
+		TenantID: tenant.ID,
+	})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to cancel webhook replay: %w", err)
+		}
+		if _, err := s.GetReplay(ctx, tenantSlug, replayID); err != nil {
+			return nil, err
+		}
+		return nil, ErrReplayFinished
+	}
+
+	dropped, err := s.db.Queries.DeleteUnsentReplayDeliveries(ctx, pgtype.UUID{Bytes: replay.ID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to drop queued replay deliveries: %w", err)
+	}
+
+	log.Printf("Cancelled webhook replay %s for tenant %s, dropping %d queued deliveries", replay.ID, tenantSlug, dropped)
+	return s.replayWithDeliveries(ctx, replay)
+}
+
+func (s *Service) replayWithDeliveries(ctx context.Context, replay queries.WebhookReplay) (*ReplayResponse, error) {
+	stats, err := s.db.Queries.GetWebhookReplayDeliveryStats(ctx, pgtype.UUID{Bytes: replay.ID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook replay deliveries: %w", err)
+	}
+
+	return replayToResponse(replay, &ReplayDeliveryStats{
+		Delivered:    stats.Delivered,
+		DeadLettered: stats.DeadLettered,
+		Pending:      stats.Pending,
+	}), nil
+}
+
+// StartReplayWorker queues the deliveries of running replays, a batch of each
+// replay every ReplayInterval. Replays are leased like deliveries, so any
+// number of replicas can run the worker and a replay still goes no faster
+// than its rate.
+func (s *Service) StartReplayWorker(ctx context.Context) {
+	log.Println("Starting webhook replay worker...")
+
+	ticker := time.NewTicker(ReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			claimed, err := s.ProcessReplayBatch(ctx)
+			if err != nil {
+				log.Printf("Error processing webhook replay: %v", err)
+				break
+			}
+			if !claimed {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook replay worker shutting down...")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessReplayBatch claims the replay that has waited longest for its next
+// batch and queues that batch. It reports whether there was one to claim.
+func (s *Service) ProcessReplayBatch(ctx context.Context) (bool, error) {
+	claimedAt := time.Now()
+	replay, err := s.db.Queries.ClaimWebhookReplay(ctx, queries.ClaimWebhookReplayParams{
+		LockedUntil: timestamptz(claimedAt.Add(ReplayLease)),
+		LockedBy:    pgtype.Text{String: s.workerID, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim webhook replay: %w", err)
+	}
+
+	// An error leaves the replay leased, so its batch is retried once the
+	// lease runs out
+	if err := s.queueReplayBatch(ctx, replay, claimedAt.Add(ReplayInterval)); err != nil {
+		return true, fmt.Errorf("replay %s: %w", replay.ID, err)
+	}
+	return true, nil
+}
+
+// queueReplayBatch queues deliveries for the next rate_limit events of a
+// claimed replay and moves its cursor past them in the same transaction,
+// holding the replay back until nextBatchAt
+func (s *Service) queueReplayBatch(ctx context.Context, replay queries.WebhookReplay, nextBatchAt time.Time) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries.WithTx(tx)
+
+	events, err := qtx.ListReplayEvents(ctx, queries.ListReplayEventsParams{
+		TenantID:          replay.TenantID,
+		AfterSequence:     replay.LastSequence,
+		ToSequence:        replay.ToSequence,
+		EventTypePatterns: eventTypeLikePatterns(replay.EventTypes),
+		CreatedFrom:       replay.CreatedFrom,
+		CreatedTo:         replay.CreatedTo,
+		BatchSize:         replay.RateLimit,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list events to replay: %w", err)
+	}
+
+	endpoints, err := qtx.ListEnabledWebhookEndpoints(ctx, replay.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+
+	replayID := pgtype.UUID{Bytes: replay.ID, Valid: true}
+	var queued int64
+	for _, event := range events {
+		for _, endpoint := range endpoints {
+			if replay.EndpointID != nil && endpoint.ID != *replay.EndpointID {
+				continue
+			}
+			if !MatchesEventType(endpoint.EventTypes, event.EventType) {
+				continue
+			}
+
+			n, err := qtx.CreateReplayWebhookDelivery(ctx, queries.CreateReplayWebhookDeliveryParams{
+				TenantID:    event.TenantID,
+				EventID:     event.EventID,
+				EndpointID:  endpoint.ID,
+				EventType:   event.EventType,
+				MaxAttempts: pgtype.Int4{Int32: int32(s.retryPolicy.MaxAttempts()), Valid: true},
+				NextRetryAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+
+				AggregateID:    &event.AggregateID,
+				SequenceNumber: event.SequenceNumber,
+				Ordered:        endpoint.Ordered,
+				ReplayID:       replayID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create webhook delivery: %w", err)
+			}
+			queued += n
+		}
+	}
+
+	lastSequence := replay.LastSequence
+	if len(events) > 0 {
+		lastSequence = events[len(events)-1].SequenceNumber.Int64
+	}
+	completed := len(events) < int(replay.RateLimit)
+
+	advanced, err := qtx.AdvanceWebhookReplay(ctx, queries.AdvanceWebhookReplayParams{
+		LastSequence:     lastSequence,
+		EventsProcessed:  int64(len(events)),
+		DeliveriesQueued: queued,
+		Completed:        completed,
+		LockedUntil:      timestamptz(nextBatchAt),
+		ID:               replay.ID,
+		LockedBy:         pgtype.Text{String: s.workerID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to advance webhook replay: %w", err)
+	}
+	if advanced == 0 {
+		// cancelled while the batch was being queued
+		return nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if completed {
+		log.Printf("Webhook replay %s completed", replay.ID)
+	}
+	return nil
+}
+
+// validateReplay rejects replays without a start or with an empty range
+func validateReplay(req ReplayRequest) error {
+	if req.FromSequence == nil && req.From == nil {
+		return fmt.Errorf("%w: from_sequence or from is required", ErrInvalidReplay)
+	}
+	if req.FromSequence != nil && req.ToSequence != nil && *req.ToSequence < *req.FromSequence {
+		return fmt.Errorf("%w: to_sequence is before from_sequence", ErrInvalidReplay)
+	}
+	if req.From != nil && req.To != nil && !req.To.After(*req.From) {
+		return fmt.Errorf("%w: to must be after from", ErrInvalidReplay)
+	}
+	if len(req.EventTypes) > 0 {
+		if err := validateEventTypes(req.EventTypes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventTypeLikePatterns converts event type patterns to the LIKE patterns
+// matching the same types, or nil when they match every type
+func eventTypeLikePatterns(patterns []string) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	like := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern == WildcardEventType {
+			return nil
+		}
+		if prefix, ok := strings.CutSuffix(pattern, ".*"); ok {
+			like = append(like, escaper.Replace(prefix)+".%")
+			continue
+		}
+		like = append(like, escaper.Replace(pattern))
+	}
+	return like
+}
+
+func replayToResponse(replay queries.WebhookReplay, deliveries *ReplayDeliveryStats) *ReplayResponse {
+	response := &ReplayResponse{
+		ID:            replay.ID.String(),
+		Status:        replay.Status,
+		EventTypes:    replay.EventTypes,
+		ToSequence:    replay.ToSequence,
+		RatePerSecond: int(replay.RateLimit),
+		Progress: ReplayProgress{
+			TotalEvents:      replay.TotalEvents,
+			EventsProcessed:  replay.EventsProcessed,
+			DeliveriesQueued: replay.DeliveriesQueued,
+			LastSequence:     replay.LastSequence,
+		},
+		Deliveries: deliveries,
+		CreatedAt:  replay.CreatedAt,
+		UpdatedAt:  replay.UpdatedAt,
+	}
+
+	switch {
+	case replay.Status == ReplayStatusCompleted:
+		response.Progress.Percent = 100
+	case replay.TotalEvents > 0:
+		response.Progress.Percent = min(float64(replay.EventsProcessed)*100/float64(replay.TotalEvents), 100)
+	}
+
+	if replay.EndpointID != nil {
+		endpointID := replay.EndpointID.String()
+		response.EndpointID = &endpointID
+	}
+	if replay.FromSequence.Valid {
+		response.FromSequence = &replay.FromSequence.Int64
+	}
+	if replay.CreatedFrom.Valid {
+		response.From = &replay.CreatedFrom.Time
+	}
+	if replay.CreatedTo.Valid {
+		response.To = &replay.CreatedTo.Time
+	}
+	if replay.StartedAt.Valid {
+		response.StartedAt = &replay.StartedAt.Time
+	}
+	if replay.CompletedAt.Valid {
+		response.CompletedAt = &replay.CompletedAt.Time
+	}
+
+	return response
+}
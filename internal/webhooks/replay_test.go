@@ -0,0 +1,67 @@
+// internal/webhooks/replay_test.go
+package webhooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+func TestEventTypeLikePatterns(t *testing.T) {
+	assert.Nil(t, eventTypeLikePatterns(nil))
+	assert.Nil(t, eventTypeLikePatterns([]string{"account.*", "*"}))
+
+	assert.Equal(t,
+		[]string{"transaction.posted", "account.%", `webhook\_endpoint.disabled`},
+		eventTypeLikePatterns([]string{"transaction.posted", "account.*", "webhook_endpoint.disabled"}),
+	)
+}
+
+func TestValidateReplay(t *testing.T) {
+	seq := func(n int64) *int64 { return &n }
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	assert.NoError(t, validateReplay(ReplayRequest{FromSequence: seq(1)}))
+	assert.NoError(t, validateReplay(ReplayRequest{From: &from, To: &to, EventTypes: []string{"account.*"}}))
+	assert.NoError(t, validateReplay(ReplayRequest{FromSequence: seq(5), ToSequence: seq(5)}))
+
+	assert.ErrorIs(t, validateReplay(ReplayRequest{}), ErrInvalidReplay)
+	assert.ErrorIs(t, validateReplay(ReplayRequest{FromSequence: seq(5), ToSequence: seq(4)}), ErrInvalidReplay)
+	assert.ErrorIs(t, validateReplay(ReplayRequest{From: &to, To: &from}), ErrInvalidReplay)
+	assert.ErrorIs(t, validateReplay(ReplayRequest{FromSequence: seq(1), EventTypes: []string{"ledger.closed"}}), ErrInvalidEventType)
+}
+
+func TestReplayToResponseProgress(t *testing.T) {
+	endpointID := uuid.New()
+	replay := queries.WebhookReplay{
+		ID:               uuid.New(),
+		EndpointID:       &endpointID,
+		EventTypes:       []string{},
+		FromSequence:     pgtype.Int8{Int64: 10, Valid: true},
+		ToSequence:       50,
+		RateLimit:        25,
+		Status:           ReplayStatusRunning,
+		TotalEvents:      40,
+		EventsProcessed:  10,
+		DeliveriesQueued: 12,
+		LastSequence:     19,
+	}
+
+	response := replayToResponse(replay, nil)
+	assert.Equal(t, endpointID.String(), *response.EndpointID)
+	assert.Equal(t, int64(10), *response.FromSequence)
+	assert.Equal(t, 25, response.RatePerSecond)
+	assert.Equal(t, 25.0, response.Progress.Percent)
+	assert.Nil(t, response.From)
+	assert.Nil(t, response.Deliveries)
+
+	// a completed replay is done even when fewer events matched than counted
+	replay.Status = ReplayStatusCompleted
+	replay.TotalEvents = 0
+	assert.Equal(t, 100.0, replayToResponse(replay, nil).Progress.Percent)
+}
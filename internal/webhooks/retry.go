@@ -0,0 +1,97 @@
+// internal/webhooks/retry.go
+package webhooks
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRetrySchedule is the delay before each retry of a failed delivery,
+// spreading attempts over roughly three days
+var DefaultRetrySchedule = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+	32 * time.Hour,
+}
+
+// RetryJitter spreads each delay by up to this fraction either way, so
+// deliveries that failed together do not all retry together
+const RetryJitter = 0.2
+
+// RetryPolicy decides when a failed delivery is attempted again
+type RetryPolicy struct {
+	Schedule []time.Duration
+	Jitter   float64
+}
+
+func NewRetryPolicy(schedule []time.Duration) RetryPolicy {
+	if len(schedule) == 0 {
+		schedule = DefaultRetrySchedule
+	}
+	return RetryPolicy{
+		Schedule: schedule,
+		Jitter:   RetryJitter,
+	}
+}
+
+// MaxAttempts is the first attempt plus one retry per schedule entry
+func (p RetryPolicy) MaxAttempts() int {
+	return len(p.Schedule) + 1
+}
+
+// NextDelay returns how long to wait after the given number of failed
+// attempts, or false once the schedule is exhausted. A retryAfter from the
+// receiver replaces the scheduled delay, capped at the longest scheduled one.
+func (p RetryPolicy) NextDelay(attempts int, retryAfter time.Duration) (time.Duration, bool) {
+	if attempts < 1 || attempts > len(p.Schedule) {
+		return 0, false
+	}
+
+	if retryAfter > 0 {
+		return min(retryAfter, p.longest()), true
+	}
+
+	delay := p.Schedule[attempts-1]
+	if p.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + p.Jitter*(2*rand.Float64()-1)))
+	}
+	return delay, true
+}
+
+func (p RetryPolicy) longest() time.Duration {
+	var longest time.Duration
+	for _, d := range p.Schedule {
+		longest = max(longest, d)
+	}
+	return longest
+}
+
+// parseRetryAfter reads a Retry-After header, given either in seconds or as
+// an HTTP date. It only applies to 429 and 503 responses.
+func parseRetryAfter(statusCode int, header string, now time.Time) time.Duration {
+	if statusCode != http.StatusTooManyRequests && statusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if at, err := http.ParseTime(header); err == nil {
+		return max(at.Sub(now), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,63 @@
+// internal/webhooks/retry_test.go
+package webhooks
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyNextDelay(t *testing.T) {
+	policy := NewRetryPolicy([]time.Duration{time.Minute, 10 * time.Minute, time.Hour})
+	assert.Equal(t, 4, policy.MaxAttempts())
+
+	for attempts, scheduled := range policy.Schedule {
+		delay, ok := policy.NextDelay(attempts+1, 0)
+		assert.True(t, ok)
+		assert.InDelta(t, float64(scheduled), float64(delay), float64(scheduled)*RetryJitter, "attempt %d", attempts+1)
+	}
+
+	_, ok := policy.NextDelay(4, 0)
+	assert.False(t, ok, "schedule exhausted")
+
+	// Retry-After replaces the schedule but cannot exceed its longest delay
+	delay, ok := policy.NextDelay(1, 30*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, delay)
+
+	delay, _ = policy.NextDelay(1, 48*time.Hour)
+	assert.Equal(t, time.Hour, delay)
+
+	assert.Equal(t, len(DefaultRetrySchedule)+1, NewRetryPolicy(nil).MaxAttempts())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 120*time.Second, parseRetryAfter(http.StatusTooManyRequests, "120", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter(http.StatusServiceUnavailable, now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter(http.StatusServiceUnavailable, now.Add(-time.Minute).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter(http.StatusInternalServerError, "120", now), "only 429 and 503 are honored")
+	assert.Zero(t, parseRetryAfter(http.StatusTooManyRequests, "soon", now))
+	assert.Zero(t, parseRetryAfter(http.StatusTooManyRequests, "", now))
+}
+
+func TestDeadLetterFilterRequest(t *testing.T) {
+	filter := DeadLetterFilterRequest{}.Filter()
+	assert.Nil(t, filter.EndpointID)
+	assert.Nil(t, filter.From)
+	assert.Nil(t, filter.To)
+
+	filter = DeadLetterFilterRequest{
+		EndpointID: "7a1d9c1e-8f0b-4c8e-9d64-3b2f4a7c5e10",
+		EventType:  "transaction.posted",
+		From:       "2026-10-01T00:00:00Z",
+		To:         "2026-10-18T00:00:00Z",
+	}.Filter()
+	assert.Equal(t, "7a1d9c1e-8f0b-4c8e-9d64-3b2f4a7c5e10", filter.EndpointID.String())
+	assert.Equal(t, "transaction.posted", filter.EventType)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), filter.From.UTC())
+	assert.Equal(t, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), filter.To.UTC())
+}
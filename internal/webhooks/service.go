@@ -3,90 +3,121 @@ package webhooks
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/config"
+	"github.com/temmyjay001/ledger-service/internal/events"
+	"github.com/temmyjay001/ledger-service/internal/requestctx"
+	"github.com/temmyjay001/ledger-service/internal/secrets"
 	"github.com/temmyjay001/ledger-service/internal/storage"
 	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+	"github.com/temmyjay001/ledger-service/pkg/webhook"
 )
 
 type Service struct {
-	db         *storage.DB
-	httpClient *http.Client
+	db           *storage.DB
+	eventService *events.Service
+	keyring      *secrets.Keyring
+	config       *config.Config
+	retryPolicy  RetryPolicy
+	circuit      CircuitBreaker
+	urlPolicy    *URLPolicy
+	httpClient   *http.Client
+
+	// workerID identifies this instance's leases on claimed deliveries
+	workerID        string
+	endpointLimiter *endpointLimiter
 }
 
-func NewService(db *storage.DB) *Service {
+func NewService(db *storage.DB, eventService *events.Service, keyring *secrets.Keyring, config *config.Config) *Service {
+	urlPolicy := NewURLPolicy(!config.IsDevelopment(), config.WebhookAllowedHosts)
+	timeout := config.WebhookTimeout
+	if timeout <= 0 {
+		timeout = DefaultTimeoutSeconds * time.Second
+	}
+
 	return &Service{
-		db: db,
-		httpClient: &http.Client{
-			Timeout: DefaultTimeoutSeconds * time.Second,
+		db:           db,
+		eventService: eventService,
+		keyring:      keyring,
+		config:       config,
+		retryPolicy:  NewRetryPolicy(config.WebhookRetrySchedule),
+		circuit: CircuitBreaker{
+			FailureThreshold: int32(config.WebhookCircuitFailureThreshold),
+			Cooldown:         config.WebhookCircuitCooldown,
+			DisableAfter:     config.WebhookAutoDisableAfter,
 		},
+		urlPolicy:       urlPolicy,
+		httpClient:      newHTTPClient(urlPolicy, timeout),
+		workerID:        newWorkerID(),
+		endpointLimiter: newEndpointLimiter(config.WebhookEndpointConcurrency),
 	}
 }
 
-// QueueWebhookDelivery creates a webhook delivery record for an event
-func (s *Service) QueueWebhookDelivery(ctx context.Context, event queries.Event) error {
-	// Get tenant from database
-	tenant, err := s.db.Queries.GetTenantByID(ctx, event.TenantID)
+// newWorkerID names this process in delivery leases
+func newWorkerID() string {
+	host, err := os.Hostname()
 	if err != nil {
-		return fmt.Errorf("failed to get tenant: %w", err)
+		host = "unknown"
 	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.New().String()[:8])
+}
 
-	// Parse webhook configuration from tenant metadata
-	config, err := s.parseWebhookConfig(tenant.Metadata)
+// QueueWebhookDelivery creates a delivery record for every enabled endpoint
+// of the event's tenant subscribed to its type. It is the outbox handler for
+// the webhooks consumer, so it runs inside the dispatcher's transaction and
+// must only write through q.
+func (s *Service) QueueWebhookDelivery(ctx context.Context, q *queries.Queries, event queries.Event) error {
+	endpoints, err := q.ListEnabledWebhookEndpoints(ctx, event.TenantID)
 	if err != nil {
-		log.Printf("No webhook config for tenant %s: %v", tenant.ID, err)
-		return nil // Not an error - tenant just doesn't have webhooks configured
-	}
-
-	if !config.Enabled {
-		log.Printf("Webhooks disabled for tenant %s", tenant.ID)
-		return nil
+		return fmt.Errorf("failed to list webhook endpoints: %w", err)
 	}
 
-	// Check if this event type should trigger webhooks
-	if !s.shouldDeliverEvent(config, event.EventType) {
-		log.Printf("Event type %s not configured for webhook delivery", event.EventType)
-		return nil
-	}
+	for _, endpoint := range endpoints {
+		if !MatchesEventType(endpoint.EventTypes, event.EventType) {
+			continue
+		}
 
-	// Create webhook delivery record
-	nextRetryAt := pgtype.Timestamptz{
-		Time:  time.Now(),
-		Valid: true,
-	}
+		queued, err := q.CreateWebhookDeliveryIfNotExists(ctx, queries.CreateWebhookDeliveryIfNotExistsParams{
+			TenantID:    event.TenantID,
+			EventID:     event.EventID,
+			EndpointID:  endpoint.ID,
+			EventType:   event.EventType,
+			MaxAttempts: pgtype.Int4{Int32: int32(s.retryPolicy.MaxAttempts()), Valid: true},
+			NextRetryAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
 
-	_, err = s.db.Queries.CreateWebhookDelivery(ctx, queries.CreateWebhookDeliveryParams{
-		TenantID:    event.TenantID,
-		EventID:     event.EventID,
-		WebhookUrl:  config.WebhookURL,
-		MaxAttempts: pgtype.Int4{Int32: int32(DefaultMaxAttempts), Valid: true},
-		NextRetryAt: nextRetryAt,
-	})
+			AggregateID:    &event.AggregateID,
+			SequenceNumber: event.SequenceNumber,
+			Ordered:        endpoint.Ordered,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create webhook delivery: %w", err)
+		}
 
-	if err != nil {
-		return fmt.Errorf("failed to create webhook delivery: %w", err)
+		if queued > 0 {
+			log.Printf("Queued webhook delivery for event %s to endpoint %s", event.EventID, endpoint.ID)
+		}
 	}
 
-	log.Printf("Queued webhook delivery for event %s to %s", event.EventID, config.WebhookURL)
 	return nil
 }
 
-// ProcessPendingDeliveries processes webhook deliveries that are ready to be sent
+// ProcessPendingDeliveries claims and sends, one at a time, webhook
+// deliveries that are ready to be sent
 func (s *Service) ProcessPendingDeliveries(ctx context.Context, batchSize int32) error {
-	deliveries, err := s.db.Queries.GetPendingWebhookDeliveries(ctx, batchSize)
+	deliveries, err := s.claimDeliveries(ctx, batchSize)
 	if err != nil {
-		return fmt.Errorf("failed to get pending deliveries: %w", err)
+		return err
 	}
 
 	if len(deliveries) == 0 {
@@ -111,33 +142,72 @@ func (s *Service) processDelivery(ctx context.Context, delivery queries.WebhookD
 		TenantID: delivery.TenantID,
 		EventID:  delivery.EventID,
 	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Nothing can ever be sent; dead-letter it rather than let the lease
+		// expire and reclaim it forever
+		log.Printf("Webhook delivery %s dead-lettered: event %s is gone", delivery.ID, delivery.EventID)
+		if err := s.db.Queries.UpdateWebhookDeliveryFailure(ctx, queries.UpdateWebhookDeliveryFailureParams{
+			ID:           delivery.ID,
+			ResponseBody: pgtype.Text{String: ErrEventGone.Error(), Valid: true},
+		}); err != nil {
+			return fmt.Errorf("failed to dead-letter delivery: %w", err)
+		}
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get event %s for tenant %s: %w", delivery.EventID, delivery.TenantID, err)
 	}
 
-	// Get tenant for webhook config
-	tenant, err := s.db.Queries.GetTenantByID(ctx, delivery.TenantID)
+	endpoint, err := s.db.Queries.GetWebhookEndpoint(ctx, queries.GetWebhookEndpointParams{
+		ID:       delivery.EndpointID,
+		TenantID: delivery.TenantID,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get tenant: %w", err)
+		return fmt.Errorf("failed to get webhook endpoint %s: %w", delivery.EndpointID, err)
 	}
 
-	config, err := s.parseWebhookConfig(tenant.Metadata)
+	payload, err := newPayload(event, endpoint)
 	if err != nil {
-		return fmt.Errorf("failed to parse webhook config: %w", err)
+		return err
 	}
 
-	// Create webhook payload
-	payload := WebhookPayload{
-		ID:       event.EventID.String(),
-		Type:     event.EventType,
-		Created:  event.CreatedAt.Unix(),
-		Data:     event.EventData,
-		TenantID: delivery.TenantID.String(),
-		LiveMode: true,
+	// Log under the request that caused the event
+	if meta, ok := events.RequestMetadata(event); ok {
+		ctx = requestctx.WithMetadata(ctx, meta)
+	}
+	if delivery.ReplayID.Valid {
+		payload.Replayed = true
+		payload.ReplayID = uuid.UUID(delivery.ReplayID.Bytes).String()
 	}
 
-	// Attempt delivery
-	result := s.deliverWebhook(ctx, config, payload)
+	// Attempt delivery; disabled endpoints fail the attempt without a request
+	var result WebhookDeliveryResult
+	if endpoint.Enabled {
+		// An open circuit holds the delivery back without using an attempt
+		ok, retryAt, err := s.admitDelivery(ctx, endpoint.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if err := s.db.Queries.ReleaseWebhookDelivery(ctx, queries.ReleaseWebhookDeliveryParams{
+				ID:          delivery.ID,
+				LockedUntil: timestamptz(retryAt),
+			}); err != nil {
+				return fmt.Errorf("failed to release delivery: %w", err)
+			}
+			return nil
+		}
+
+		result = s.deliverWebhook(ctx, endpoint, delivery.ID.String(), payload)
+		if err := s.recordAttempt(ctx, delivery.ID, delivery.Attempts.Int32+1, endpoint.Url, result); err != nil {
+			requestctx.Logf(ctx, "Failed to log attempt of delivery %s: %v", delivery.ID, err)
+		}
+		if err := s.recordEndpointResult(ctx, endpoint, result.Success); err != nil {
+			requestctx.Logf(ctx, "Failed to record health of webhook endpoint %s: %v", endpoint.ID, err)
+		}
+	} else {
+		result = WebhookDeliveryResult{ErrorMessage: ErrEndpointDisabled.Error()}
+	}
 
 	// Update delivery record based on result
 	if result.Success {
@@ -147,26 +217,75 @@ func (s *Service) processDelivery(ctx context.Context, delivery queries.WebhookD
 			ResponseBody:   pgtype.Text{String: result.ResponseBody, Valid: true},
 		})
 	} else {
+		// Retry on schedule until the delivery's attempts run out, then
+		// dead-letter it by leaving next_retry_at unset
+		var nextRetryAt pgtype.Timestamptz
+		attempts := int(delivery.Attempts.Int32) + 1
+		if attempts < int(delivery.MaxAttempts.Int32) {
+			if delay, ok := s.retryPolicy.NextDelay(attempts, result.RetryAfter); ok {
+				nextRetryAt = pgtype.Timestamptz{Time: time.Now().Add(delay), Valid: true}
+			}
+		}
+		if !nextRetryAt.Valid {
+			requestctx.Logf(ctx, "Webhook delivery %s dead-lettered after %d attempts", delivery.ID, attempts)
+		}
+
 		err = s.db.Queries.UpdateWebhookDeliveryFailure(ctx, queries.UpdateWebhookDeliveryFailureParams{
 			ID:             delivery.ID,
 			HttpStatusCode: pgtype.Int4{Int32: int32(result.StatusCode), Valid: true},
 			ResponseBody:   pgtype.Text{String: result.ErrorMessage, Valid: true},
+			NextRetryAt:    nextRetryAt,
 		})
 	}
 
 	if err != nil {
-		log.Printf("Failed to update webhook delivery status: %v", err)
+		requestctx.Logf(ctx, "Failed to update webhook delivery status: %v", err)
 	}
 
 	return nil
 }
 
-// deliverWebhook sends the webhook HTTP request
-func (s *Service) deliverWebhook(ctx context.Context, config *WebhookConfig, payload WebhookPayload) WebhookDeliveryResult {
+// newPayload builds the payload of an event for an endpoint, with the data
+// in the schema the endpoint is pinned to and the request that caused the
+// event forwarded
+func newPayload(event queries.Event, endpoint queries.WebhookEndpoint) (WebhookPayload, error) {
+	dataVersion := events.Schemas.VersionAt(event.EventType, endpoint.PayloadVersion)
+	data, err := events.Schemas.Convert(event.EventType, event.EventData, event.EventVersion, dataVersion)
+	if err != nil {
+		return WebhookPayload{}, fmt.Errorf("failed to convert event %s for webhook endpoint %s: %w", event.EventID, endpoint.ID, err)
+	}
+
+	payload := WebhookPayload{
+		ID:       event.EventID.String(),
+		Type:     event.EventType,
+		Created:  event.CreatedAt.Unix(),
+		Data:     data,
+		TenantID: event.TenantID.String(),
+		LiveMode: true,
+
+		AggregateID:      event.AggregateID.String(),
+		AggregateType:    event.AggregateType,
+		AggregateVersion: event.AggregateVersion.Int64,
+		SequenceNumber:   event.SequenceNumber.Int64,
+
+		PayloadVersion: endpoint.PayloadVersion,
+		DataVersion:    dataVersion,
+	}
+
+	if meta, ok := events.RequestMetadata(event); ok {
+		payload.RequestID = meta.RequestID
+		payload.CorrelationID = meta.CorrelationID
+	}
+	return payload, nil
+}
+
+// deliverWebhook sends the webhook HTTP request, signed as described in
+// pkg/webhook so receivers can reject tampered or replayed deliveries
+func (s *Service) deliverWebhook(ctx context.Context, endpoint queries.WebhookEndpoint, deliveryID string, payload WebhookPayload) WebhookDeliveryResult {
 	startTime := time.Now()
 
-	// Serialize payload
-	payloadBytes, err := json.Marshal(payload)
+	// Serialize payload in the endpoint's format
+	payloadBytes, header, err := encodePayload(endpoint.PayloadFormat, payload)
 	if err != nil {
 		return WebhookDeliveryResult{
 			Success:      false,
@@ -175,25 +294,48 @@ func (s *Service) deliverWebhook(ctx context.Context, config *WebhookConfig, pay
 		}
 	}
 
+	hash := payloadHash(payloadBytes)
+
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", config.WebhookURL, bytes.NewBuffer(payloadBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint.Url, bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		return WebhookDeliveryResult{
 			Success:      false,
 			StatusCode:   0,
 			ErrorMessage: fmt.Sprintf("Failed to create request: %v", err),
+			PayloadHash:  hash,
+			ErrorClass:   ErrorClassRequest,
 		}
 	}
 
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
+	// Add headers. The timestamp header repeats the signing time, not the
+	// event's creation time, so receivers reading either see the same value.
+	signedAt := time.Now()
+	for key, values := range header {
+		req.Header[key] = values
+	}
 	req.Header.Set("User-Agent", "LedgerService-Webhooks/1.0")
-	req.Header.Set("X-Ledger-Event-ID", payload.ID)
-	req.Header.Set("X-Ledger-Timestamp", strconv.FormatInt(payload.Created, 10))
+	req.Header.Set(webhook.DeliveryIDHeader, deliveryID)
+	req.Header.Set(webhook.EventIDHeader, payload.ID)
+	req.Header.Set(webhook.EventTypeHeader, payload.Type)
+	req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(signedAt.Unix(), 10))
+	if payload.CorrelationID != "" {
+		req.Header.Set(webhook.CorrelationIDHeader, payload.CorrelationID)
+	}
 
-	// Add signature header
-	signature := s.generateSignature(payloadBytes, config.WebhookSecret)
-	req.Header.Set("X-Ledger-Signature", "sha256="+signature)
+	// Sign with every active secret; more than one only during a rotation
+	signingSecrets, err := s.signingSecrets(ctx, endpoint.ID)
+	if err != nil {
+		return WebhookDeliveryResult{
+			Success:        false,
+			StatusCode:     0,
+			ErrorMessage:   fmt.Sprintf("Failed to sign request: %v", err),
+			RequestHeaders: req.Header,
+			PayloadHash:    hash,
+			ErrorClass:     ErrorClassRequest,
+		}
+	}
+	req.Header.Set(webhook.SignatureHeader, webhook.GenerateHeader(payloadBytes, signedAt, signingSecrets...))
 
 	// Send request
 	resp, err := s.httpClient.Do(req)
@@ -204,153 +346,43 @@ func (s *Service) deliverWebhook(ctx context.Context, config *WebhookConfig, pay
 			StatusCode:     0,
 			ErrorMessage:   fmt.Sprintf("HTTP request failed: %v", err),
 			DeliveryTimeMs: deliveryTime,
+			RequestHeaders: req.Header,
+			PayloadHash:    hash,
+			ErrorClass:     classifyError(err),
 		}
 	}
 	defer resp.Body.Close()
 
-	// Read response
-	bodyBytes, err := io.ReadAll(resp.Body)
+	// Read response, cut to MaxAttemptResponseBytes
+	responseBody, truncated, err := readResponseBody(resp.Body)
 	if err != nil {
-		bodyBytes = []byte("Failed to read response body")
+		responseBody = "Failed to read response body"
 	}
 
 	deliveryTime := time.Since(startTime).Milliseconds()
-	responseBody := string(bodyBytes)
 
 	// Check if delivery was successful (2xx status codes)
 	success := resp.StatusCode >= 200 && resp.StatusCode < 300
 
 	result := WebhookDeliveryResult{
-		Success:        success,
-		StatusCode:     resp.StatusCode,
-		ResponseBody:   responseBody,
-		DeliveryTimeMs: deliveryTime,
+		Success:           success,
+		StatusCode:        resp.StatusCode,
+		ResponseBody:      responseBody,
+		DeliveryTimeMs:    deliveryTime,
+		ResponseTruncated: truncated,
+		RequestHeaders:    req.Header,
+		PayloadHash:       hash,
 	}
 
 	if !success {
 		result.ErrorMessage = fmt.Sprintf("HTTP %d: %s", resp.StatusCode, responseBody)
+		result.RetryAfter = parseRetryAfter(resp.StatusCode, resp.Header.Get("Retry-After"), time.Now())
 	}
 
-	log.Printf("Webhook delivery to %s: %d (%dms)", config.WebhookURL, resp.StatusCode, deliveryTime)
+	log.Printf("Webhook delivery to %s: %d (%dms)", endpoint.Url, resp.StatusCode, deliveryTime)
 	return result
 }
 
-// generateSignature creates HMAC-SHA256 signature for webhook payload
-func (s *Service) generateSignature(payload []byte, secret string) string {
-	h := hmac.New(sha256.New, []byte(secret))
-	h.Write(payload)
-	return hex.EncodeToString(h.Sum(nil))
-}
-
-// parseWebhookConfig extracts webhook configuration from tenant metadata
-func (s *Service) parseWebhookConfig(metadata json.RawMessage) (*WebhookConfig, error) {
-	if len(metadata) == 0 {
-		return nil, fmt.Errorf("no metadata found")
-	}
-
-	var tenantMeta map[string]interface{}
-	if err := json.Unmarshal(metadata, &tenantMeta); err != nil {
-		return nil, fmt.Errorf("failed to parse metadata: %w", err)
-	}
-
-	// Extract webhook configuration
-	webhookURL, ok := tenantMeta["webhook_url"].(string)
-	if !ok || webhookURL == "" {
-		return nil, fmt.Errorf("webhook_url not found or empty")
-	}
-
-	webhookSecret, ok := tenantMeta["webhook_secret"].(string)
-	if !ok || webhookSecret == "" {
-		return nil, fmt.Errorf("webhook_secret not found or empty")
-	}
-
-	// Parse webhook events (optional, defaults to all events)
-	var webhookEvents []string
-	if events, ok := tenantMeta["webhook_events"].([]interface{}); ok {
-		for _, event := range events {
-			if eventStr, ok := event.(string); ok {
-				webhookEvents = append(webhookEvents, eventStr)
-			}
-		}
-	} else {
-		webhookEvents = SupportedEventTypes // Default to all supported events
-	}
-
-	// Parse enabled flag (defaults to true)
-	enabled := true
-	if enabledVal, ok := tenantMeta["webhook_enabled"].(bool); ok {
-		enabled = enabledVal
-	}
-
-	return &WebhookConfig{
-		WebhookURL:    webhookURL,
-		WebhookSecret: webhookSecret,
-		WebhookEvents: webhookEvents,
-		Enabled:       enabled,
-	}, nil
-}
-
-// shouldDeliverEvent checks if the event type should trigger webhook delivery
-func (s *Service) shouldDeliverEvent(config *WebhookConfig, eventType string) bool {
-	for _, configuredEvent := range config.WebhookEvents {
-		if configuredEvent == eventType {
-			return true
-		}
-	}
-	return false
-}
-
-// ConfigureWebhook updates webhook configuration for a tenant
-func (s *Service) ConfigureWebhook(ctx context.Context, tenantSlug string, req WebhookConfigRequest) (*WebhookConfigResponse, error) {
-	// Get tenant
-	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
-	if err != nil {
-		return nil, fmt.Errorf("tenant not found: %w", err)
-	}
-
-	// Parse existing metadata
-	var metadata map[string]interface{}
-	if len(tenant.Metadata) > 0 {
-		if err := json.Unmarshal(tenant.Metadata, &metadata); err != nil {
-			return nil, fmt.Errorf("failed to parse existing metadata: %w", err)
-		}
-	} else {
-		metadata = make(map[string]interface{})
-	}
-
-	// Update with webhook configuration
-	metadata["webhook_url"] = req.URL
-	metadata["webhook_secret"] = req.Secret
-	metadata["webhook_events"] = req.Events
-	metadata["webhook_enabled"] = req.Enabled
-
-	// Serialize updated metadata
-	updatedMetadata, err := json.Marshal(metadata)
-	if err != nil {
-		return nil, fmt.Errorf("failed to serialize metadata: %w", err)
-	}
-
-	// Update tenant metadata in database
-	updatedTenant, err := s.db.Queries.UpdateTenantMetadata(ctx, queries.UpdateTenantMetadataParams{
-		ID:       tenant.ID,
-		Metadata: updatedMetadata,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to update tenant metadata: %w", err)
-	}
-
-	response := &WebhookConfigResponse{
-		URL:       req.URL,
-		Events:    req.Events,
-		Enabled:   req.Enabled,
-		CreatedAt: tenant.CreatedAt,
-		UpdatedAt: updatedTenant.UpdatedAt,
-	}
-
-	log.Printf("Webhook configured for tenant %s: %s (events: %v)", tenantSlug, req.URL, req.Events)
-	return response, nil
-}
-
 // ListWebhookDeliveries returns webhook delivery history for a tenant
 func (s *Service) ListWebhookDeliveries(ctx context.Context, tenantSlug string, limit int) ([]WebhookDeliveryResponse, error) {
 	// Get tenant
@@ -368,46 +400,18 @@ func (s *Service) ListWebhookDeliveries(ctx context.Context, tenantSlug string,
 		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
 	}
 
-	// Convert to response format
-	var response []WebhookDeliveryResponse
-	for _, delivery := range deliveries {
-		// Get event details for this delivery
-		event, err := s.db.Queries.GetEventByID(ctx, queries.GetEventByIDParams{
-			TenantID: delivery.TenantID,
-			EventID:  delivery.EventID,
-		})
-		if err != nil {
-			continue // Skip if event not found
-		}
-
-		deliveryResponse := WebhookDeliveryResponse{
-			ID:          delivery.ID.String(),
-			EventID:     delivery.EventID.String(),
-			EventType:   event.EventType,
-			URL:         delivery.WebhookUrl,
-			Attempts:    int(delivery.Attempts.Int32),
-			MaxAttempts: int(delivery.MaxAttempts.Int32),
-			CreatedAt:   delivery.CreatedAt,
-		}
-
-		if delivery.HttpStatusCode.Valid {
-			statusCode := int(delivery.HttpStatusCode.Int32)
-			deliveryResponse.StatusCode = &statusCode
-		}
-
-		if delivery.NextRetryAt.Valid {
-			deliveryResponse.NextRetryAt = &delivery.NextRetryAt.Time
-		}
-
-		if delivery.DeliveredAt.Valid {
-			deliveryResponse.DeliveredAt = &delivery.DeliveredAt.Time
-		}
-
-		if delivery.FailedAt.Valid {
-			deliveryResponse.FailedAt = &delivery.FailedAt.Time
-		}
+	endpoints, err := s.db.Queries.ListWebhookEndpoints(ctx, tenant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	endpointURLs := make(map[uuid.UUID]string, len(endpoints))
+	for _, endpoint := range endpoints {
+		endpointURLs[endpoint.ID] = endpoint.Url
+	}
 
-		response = append(response, deliveryResponse)
+	response := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, deliveryToResponse(delivery, endpointURLs[delivery.EndpointID]))
 	}
 
 	return response, nil
@@ -430,44 +434,16 @@ func (s *Service) GetWebhookDelivery(ctx context.Context, tenantSlug string, del
 		return nil, fmt.Errorf("webhook delivery not found: %w", err)
 	}
 
-	// Get event details
-	event, err := s.db.Queries.GetEventByID(ctx, queries.GetEventByIDParams{
-		TenantID: delivery.TenantID,
-		EventID:  delivery.EventID,
+	endpoint, err := s.db.Queries.GetWebhookEndpoint(ctx, queries.GetWebhookEndpointParams{
+		ID:       delivery.EndpointID,
+		TenantID: tenant.ID,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get event details: %w", err)
-	}
-
-	// Build response
-	response := &WebhookDeliveryResponse{
-		ID:          delivery.ID.String(),
-		EventID:     delivery.EventID.String(),
-		EventType:   event.EventType,
-		URL:         delivery.WebhookUrl,
-		Attempts:    int(delivery.Attempts.Int32),
-		MaxAttempts: int(delivery.MaxAttempts.Int32),
-		CreatedAt:   delivery.CreatedAt,
-	}
-
-	if delivery.HttpStatusCode.Valid {
-		statusCode := int(delivery.HttpStatusCode.Int32)
-		response.StatusCode = &statusCode
-	}
-
-	if delivery.NextRetryAt.Valid {
-		response.NextRetryAt = &delivery.NextRetryAt.Time
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
 	}
 
-	if delivery.DeliveredAt.Valid {
-		response.DeliveredAt = &delivery.DeliveredAt.Time
-	}
-
-	if delivery.FailedAt.Valid {
-		response.FailedAt = &delivery.FailedAt.Time
-	}
-
-	return response, nil
+	response := deliveryToResponse(delivery, endpoint.Url)
+	return &response, nil
 }
 
 // RetryWebhookDelivery manually retries a failed webhook delivery
@@ -506,33 +482,38 @@ func (s *Service) RetryWebhookDelivery(ctx context.Context, tenantSlug string, d
 	return nil
 }
 
-// TestWebhook sends a test webhook to verify configuration
-func (s *Service) TestWebhook(ctx context.Context, tenantSlug string) (*WebhookDeliveryResult, error) {
+// TestWebhook sends a test webhook to verify an endpoint's configuration
+func (s *Service) TestWebhook(ctx context.Context, tenantSlug string, endpointID uuid.UUID) (*WebhookDeliveryResult, error) {
 	// Get tenant
 	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
 	if err != nil {
 		return nil, fmt.Errorf("tenant not found: %w", err)
 	}
 
-	// Parse webhook configuration
-	config, err := s.parseWebhookConfig(tenant.Metadata)
+	endpoint, err := s.db.Queries.GetWebhookEndpoint(ctx, queries.GetWebhookEndpointParams{
+		ID:       endpointID,
+		TenantID: tenant.ID,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("no webhook configuration found for tenant: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
 	}
 
 	// Create test webhook payload
 	testPayload := WebhookPayload{
 		ID:       "evt_test_" + uuid.New().String()[:8],
 		Type:     "webhook.test",
-		Created:  tenant.CreatedAt.Unix(),
+		Created:  time.Now().Unix(),
 		Data:     json.RawMessage(`{"message": "This is a test webhook from LedgerService"}`),
 		TenantID: tenant.ID.String(),
 		LiveMode: false, // Test webhooks are not live mode
 	}
 
 	// Send test webhook
-	result := s.deliverWebhook(ctx, config, testPayload)
+	result := s.deliverWebhook(ctx, endpoint, "whdel_test_"+uuid.New().String()[:8], testPayload)
 
-	log.Printf("Test webhook sent to %s: success=%t, status=%d", config.WebhookURL, result.Success, result.StatusCode)
+	log.Printf("Test webhook sent to %s: success=%t, status=%d", endpoint.Url, result.Success, result.StatusCode)
 	return &result, nil
 }
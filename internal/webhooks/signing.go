@@ -0,0 +1,190 @@
+// internal/webhooks/signing.go
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/secrets"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// legacyKeyVersion marks secrets carried over in plaintext by the migration
+// that introduced encryption; ReencryptSecrets seals them
+const legacyKeyVersion = 0
+
+// ReencryptBatchSize is how many secrets ReencryptSecrets updates per query
+const ReencryptBatchSize = 100
+
+// RotateSecret gives an endpoint a new signing secret. Deliveries are signed
+// with both the new and the old secrets until the overlap window ends, after
+// which the old ones stop being used and are removed on the next rotation.
+func (s *Service) RotateSecret(ctx context.Context, tenantSlug string, endpointID uuid.UUID, req RotateSecretRequest) (*RotateSecretResponse, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret, err = generateSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	overlap := s.config.WebhookSecretOverlap
+	if req.OverlapSeconds != nil {
+		overlap = time.Duration(*req.OverlapSeconds) * time.Second
+	}
+	expiresAt := time.Now().Add(overlap)
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries.WithTx(tx)
+
+	if _, err := qtx.GetWebhookEndpoint(ctx, queries.GetWebhookEndpointParams{
+		ID:       endpointID,
+		TenantID: tenant.ID,
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+
+	if err := qtx.DeleteExpiredWebhookEndpointSecrets(ctx, endpointID); err != nil {
+		return nil, fmt.Errorf("failed to delete expired webhook secrets: %w", err)
+	}
+
+	if err := qtx.ExpireWebhookEndpointSecrets(ctx, queries.ExpireWebhookEndpointSecretsParams{
+		ExpiresAt:  pgtype.Timestamptz{Time: expiresAt, Valid: true},
+		EndpointID: endpointID,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to expire webhook secrets: %w", err)
+	}
+
+	if err := s.storeSecret(ctx, qtx, endpointID, secret); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("Webhook secret rotated for endpoint %s of tenant %s; previous secret expires at %s", endpointID, tenantSlug, expiresAt.Format(time.RFC3339))
+
+	return &RotateSecretResponse{
+		EndpointID:              endpointID.String(),
+		Secret:                  secret,
+		PreviousSecretExpiresAt: expiresAt,
+	}, nil
+}
+
+// ReencryptSecrets seals secrets left in plaintext by the migration and
+// rewraps the data keys of secrets sealed under an older master key, so old
+// master keys can be retired once it has run. It returns how many secrets
+// were updated.
+func (s *Service) ReencryptSecrets(ctx context.Context) (int, error) {
+	updated := 0
+	for {
+		rows, err := s.db.Queries.ListWebhookEndpointSecretsToReencrypt(ctx, queries.ListWebhookEndpointSecretsToReencryptParams{
+			KeyVersion: s.keyring.CurrentVersion(),
+			BatchSize:  ReencryptBatchSize,
+		})
+		if err != nil {
+			return updated, fmt.Errorf("failed to list webhook secrets: %w", err)
+		}
+
+		for _, row := range rows {
+			var sealed secrets.Sealed
+			if row.KeyVersion == legacyKeyVersion {
+				sealed, err = s.keyring.Seal(row.EncryptedSecret)
+			} else {
+				sealed, err = s.keyring.Rewrap(sealedFromRow(row))
+			}
+			if err != nil {
+				return updated, fmt.Errorf("failed to re-encrypt webhook secret %s: %w", row.ID, err)
+			}
+
+			if err := s.db.Queries.UpdateWebhookEndpointSecretEncryption(ctx, queries.UpdateWebhookEndpointSecretEncryptionParams{
+				ID:              row.ID,
+				EncryptedSecret: sealed.Ciphertext,
+				EncryptedKey:    sealed.EncryptedKey,
+				KeyVersion:      sealed.KeyVersion,
+			}); err != nil {
+				return updated, fmt.Errorf("failed to update webhook secret %s: %w", row.ID, err)
+			}
+			updated++
+		}
+
+		if len(rows) < ReencryptBatchSize {
+			break
+		}
+	}
+
+	if updated > 0 {
+		log.Printf("Re-encrypted %d webhook secrets with master key version %d", updated, s.keyring.CurrentVersion())
+	}
+	return updated, nil
+}
+
+// storeSecret seals secret and saves it as the endpoint's current secret
+func (s *Service) storeSecret(ctx context.Context, q *queries.Queries, endpointID uuid.UUID, secret string) error {
+	sealed, err := s.keyring.Seal([]byte(secret))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	if _, err := q.CreateWebhookEndpointSecret(ctx, queries.CreateWebhookEndpointSecretParams{
+		EndpointID:      endpointID,
+		EncryptedSecret: sealed.Ciphertext,
+		EncryptedKey:    sealed.EncryptedKey,
+		KeyVersion:      sealed.KeyVersion,
+	}); err != nil {
+		return fmt.Errorf("failed to store webhook secret: %w", err)
+	}
+
+	return nil
+}
+
+// signingSecrets decrypts the secrets an endpoint's deliveries are currently
+// signed with, newest first
+func (s *Service) signingSecrets(ctx context.Context, endpointID uuid.UUID) ([]string, error) {
+	rows, err := s.db.Queries.ListActiveWebhookEndpointSecrets(ctx, endpointID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook secrets: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoSigningSecret
+	}
+
+	signingSecrets := make([]string, 0, len(rows))
+	for _, row := range rows {
+		secret, err := s.keyring.Open(sealedFromRow(row))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt webhook secret %s: %w", row.ID, err)
+		}
+		signingSecrets = append(signingSecrets, string(secret))
+	}
+
+	return signingSecrets, nil
+}
+
+func sealedFromRow(row queries.WebhookEndpointSecret) secrets.Sealed {
+	return secrets.Sealed{
+		Ciphertext:   row.EncryptedSecret,
+		EncryptedKey: row.EncryptedKey,
+		KeyVersion:   row.KeyVersion,
+	}
+}
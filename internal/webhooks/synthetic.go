@@ -0,0 +1,76 @@
+// internal/webhooks/synthetic.go
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/temmyjay001/ledger-service/internal/events"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
+)
+
+// SendSyntheticEvents sends sample events to one endpoint, signed and framed
+// as the endpoint's real deliveries but with livemode false. The events are
+// not stored, so nothing else receives them and failures are not retried.
+func (s *Service) SendSyntheticEvents(ctx context.Context, tenantSlug string, endpointID uuid.UUID, req SyntheticEventsRequest) ([]SyntheticEventResult, error) {
+	tenant, err := s.db.Queries.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("tenant not found: %w", err)
+	}
+
+	endpoint, err := s.db.Queries.GetWebhookEndpoint(ctx, queries.GetWebhookEndpointParams{
+		ID:       endpointID,
+		TenantID: tenant.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+
+	patterns := endpoint.EventTypes
+	if len(req.EventTypes) > 0 {
+		if err := validateEventTypes(req.EventTypes); err != nil {
+			return nil, err
+		}
+		patterns = req.EventTypes
+	}
+	eventTypes := slices.DeleteFunc(slices.Clone(SupportedEventTypes), func(eventType string) bool {
+		return !MatchesEventType(patterns, eventType)
+	})
+
+	count := max(req.Count, 1)
+	results := make([]SyntheticEventResult, 0, len(eventTypes)*count)
+	for _, eventType := range eventTypes {
+		for i := 0; i < count; i++ {
+			event, err := events.SampleEvent(ctx, tenant.ID, eventType)
+			if err != nil {
+				return nil, err
+			}
+			payload, err := newPayload(event, endpoint)
+			if err != nil {
+				return nil, err
+			}
+			payload.LiveMode = false
+
+			result := s.deliverWebhook(ctx, endpoint, "whdel_test_"+uuid.New().String()[:8], payload)
+			results = append(results, SyntheticEventResult{
+				EventID:        payload.ID,
+				EventType:      eventType,
+				Success:        result.Success,
+				StatusCode:     result.StatusCode,
+				DeliveryTimeMs: result.DeliveryTimeMs,
+				Error:          result.ErrorMessage,
+			})
+		}
+	}
+
+	log.Printf("Sent %d synthetic events to webhook endpoint %s", len(results), endpoint.ID)
+	return results, nil
+}
@@ -3,34 +3,80 @@ package webhooks
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// WebhookConfig represents tenant webhook configuration
-type WebhookConfig struct {
-	WebhookURL    string   `json:"webhook_url"`
-	WebhookSecret string   `json:"webhook_secret"`
-	WebhookEvents []string `json:"webhook_events"`
-	Enabled       bool     `json:"enabled"`
-}
+// Errors
+var (
+	ErrEndpointNotFound   = errors.New("webhook endpoint not found")
+	ErrEndpointDisabled   = errors.New("webhook endpoint is disabled")
+	ErrInvalidEventType   = errors.New("invalid webhook event type")
+	ErrNoSigningSecret    = errors.New("webhook endpoint has no active signing secret")
+	ErrDeadLetterNotFound = errors.New("dead-lettered webhook delivery not found")
+	ErrDeliveryNotFound   = errors.New("webhook delivery not found")
+	ErrEventGone          = errors.New("event no longer exists, it may have been archived")
+	ErrInvalidURL         = errors.New("invalid webhook URL")
+	ErrBlockedDestination = errors.New("webhook destination is not allowed")
+
+	ErrInvalidPayloadVersion = errors.New("invalid webhook payload version")
+	ErrInvalidPayloadFormat  = errors.New("invalid webhook payload format")
+
+	ErrReplayNotFound = errors.New("webhook replay not found")
+	ErrInvalidReplay  = errors.New("invalid webhook replay")
+	ErrReplayFinished = errors.New("webhook replay has already finished")
+)
+
+// Payload formats of webhook endpoints
+const (
+	// PayloadFormatLedger sends WebhookPayload
+	PayloadFormatLedger = "ledger"
+	// PayloadFormatCloudEventsStructured sends a CloudEvents 1.0 event as the
+	// body, and PayloadFormatCloudEventsBinary the event's data as the body
+	// with its attributes in ce- headers
+	PayloadFormatCloudEventsStructured = "cloudevents_structured"
+	PayloadFormatCloudEventsBinary     = "cloudevents_binary"
+)
 
 // WebhookPayload represents the payload sent to webhook endpoints
 type WebhookPayload struct {
-	ID         string          `json:"id"`         // event_id
-	Type       string          `json:"type"`       // event_type  
-	Created    int64           `json:"created"`    // unix timestamp
-	Data       json.RawMessage `json:"data"`       // event_data
-	TenantID   string          `json:"tenant_id"`
-	LiveMode   bool            `json:"livemode"`   // always true for now
+	ID       string          `json:"id"`      // event_id
+	Type     string          `json:"type"`    // event_type
+	Created  int64           `json:"created"` // unix timestamp
+	Data     json.RawMessage `json:"data"`    // event_data
+	TenantID string          `json:"tenant_id"`
+	LiveMode bool            `json:"livemode"` // always true for now
+
+	// Consumers can detect gaps and reordering from these: SequenceNumber
+	// increases across all events, AggregateVersion within one aggregate.
+	// Balance events of high-volume slot postings carry no AggregateVersion.
+	AggregateID      string `json:"aggregate_id,omitempty"`
+	AggregateType    string `json:"aggregate_type,omitempty"`
+	AggregateVersion int64  `json:"aggregate_version,omitempty"`
+	SequenceNumber   int64  `json:"sequence_number,omitempty"`
+
+	// Data is in DataVersion of its event type's schema, the version in the
+	// PayloadVersion the endpoint is pinned to
+	PayloadVersion int32 `json:"payload_version,omitempty"`
+	DataVersion    int32 `json:"data_version,omitempty"`
+
+	// The API request that caused the event, if one did
+	RequestID     string `json:"request_id,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+
+	// Set when the event is resent by a replay rather than sent live
+	Replayed bool   `json:"replayed,omitempty"`
+	ReplayID string `json:"replay_id,omitempty"`
 }
 
 // WebhookDeliveryRequest represents a webhook delivery request
 type WebhookDeliveryRequest struct {
 	TenantID    uuid.UUID `json:"tenant_id"`
 	EventID     uuid.UUID `json:"event_id"`
-	WebhookURL  string    `json:"webhook_url"`
+	EndpointID  uuid.UUID `json:"endpoint_id"`
 	MaxAttempts int       `json:"max_attempts"`
 	NextRetryAt time.Time `json:"next_retry_at"`
 }
@@ -42,23 +88,90 @@ type WebhookDeliveryResult struct {
 	ResponseBody   string `json:"response_body"`
 	ErrorMessage   string `json:"error_message,omitempty"`
 	DeliveryTimeMs int64  `json:"delivery_time_ms"`
+
+	// RetryAfter is the delay a 429 or 503 response asked for
+	RetryAfter time.Duration `json:"-"`
+
+	// Captured for the delivery attempt log. ErrorClass is set when no
+	// response was received; ResponseTruncated when ResponseBody was cut to
+	// MaxAttemptResponseBytes.
+	ResponseTruncated bool        `json:"-"`
+	RequestHeaders    http.Header `json:"-"`
+	PayloadHash       string      `json:"-"`
+	ErrorClass        string      `json:"-"`
 }
 
-// WebhookConfigRequest represents a request to configure webhooks
-type WebhookConfigRequest struct {
-	URL       string   `json:"url" validate:"required,url"`
-	Secret    string   `json:"secret" validate:"required,min=32"`
-	Events    []string `json:"events" validate:"required,min=1"`
-	Enabled   bool     `json:"enabled"`
+// CreateEndpointRequest represents a request to register a webhook endpoint.
+// EventTypes may contain exact types, prefix wildcards such as
+// "transaction.*", or "*" for every event.
+type CreateEndpointRequest struct {
+	URL         string   `json:"url" validate:"required,url,max=2048"`
+	Description string   `json:"description,omitempty" validate:"max=255"`
+	Secret      string   `json:"secret,omitempty" validate:"omitempty,min=32,max=128"`
+	EventTypes  []string `json:"event_types" validate:"required,min=1"`
+	Enabled     *bool    `json:"enabled,omitempty"`
+	// Ordered sends each aggregate's events one at a time, in sequence
+	Ordered bool `json:"ordered,omitempty"`
+	// PayloadVersion pins the payload schemas sent; defaults to the latest
+	PayloadVersion *int32 `json:"payload_version,omitempty"`
+	// PayloadFormat is one of the PayloadFormat constants; defaults to ledger
+	PayloadFormat string `json:"payload_format,omitempty"`
 }
 
-// WebhookConfigResponse represents webhook configuration response
-type WebhookConfigResponse struct {
-	URL       string   `json:"url"`
-	Events    []string `json:"events"`
-	Enabled   bool     `json:"enabled"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+// UpdateEndpointRequest changes the given fields of a webhook endpoint
+type UpdateEndpointRequest struct {
+	URL            *string  `json:"url,omitempty" validate:"omitempty,url,max=2048"`
+	Description    *string  `json:"description,omitempty" validate:"omitempty,max=255"`
+	EventTypes     []string `json:"event_types,omitempty" validate:"omitempty,min=1"`
+	Enabled        *bool    `json:"enabled,omitempty"`
+	Ordered        *bool    `json:"ordered,omitempty"`
+	PayloadVersion *int32   `json:"payload_version,omitempty"`
+	PayloadFormat  *string  `json:"payload_format,omitempty"`
+}
+
+// EndpointResponse represents a webhook endpoint. Secret is only returned
+// when the endpoint is created.
+type EndpointResponse struct {
+	ID             string          `json:"id"`
+	URL            string          `json:"url"`
+	Description    string          `json:"description"`
+	EventTypes     []string        `json:"event_types"`
+	Enabled        bool            `json:"enabled"`
+	Ordered        bool            `json:"ordered"`
+	PayloadVersion int32           `json:"payload_version"`
+	PayloadFormat  string          `json:"payload_format"`
+	Secret         string          `json:"secret,omitempty"`
+	DisabledAt     *time.Time      `json:"disabled_at,omitempty"`
+	Health         *EndpointHealth `json:"health,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// EndpointHealth is the state of an endpoint's circuit breaker
+type EndpointHealth struct {
+	CircuitState        string     `json:"circuit_state"`
+	ConsecutiveFailures int32      `json:"consecutive_failures"`
+	FailingSince        *time.Time `json:"failing_since,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+	AutoDisabledAt      *time.Time `json:"auto_disabled_at,omitempty"`
+}
+
+// RotateSecretRequest replaces an endpoint's signing secret. A new secret is
+// generated when none is supplied. The old secret keeps signing alongside the
+// new one for OverlapSeconds, defaulting to the configured overlap window.
+type RotateSecretRequest struct {
+	Secret         string `json:"secret,omitempty" validate:"omitempty,min=32,max=128"`
+	OverlapSeconds *int   `json:"overlap_seconds,omitempty" validate:"omitempty,min=0,max=604800"`
+}
+
+// RotateSecretResponse carries the new secret, which is only returned here
+type RotateSecretResponse struct {
+	EndpointID              string    `json:"endpoint_id"`
+	Secret                  string    `json:"secret"`
+	PreviousSecretExpiresAt time.Time `json:"previous_secret_expires_at"`
 }
 
 // WebhookDeliveryResponse represents a webhook delivery record
@@ -66,6 +179,7 @@ type WebhookDeliveryResponse struct {
 	ID             string     `json:"id"`
 	EventID        string     `json:"event_id"`
 	EventType      string     `json:"event_type"`
+	EndpointID     string     `json:"endpoint_id"`
 	URL            string     `json:"url"`
 	StatusCode     *int       `json:"status_code,omitempty"`
 	Attempts       int        `json:"attempts"`
@@ -73,21 +187,159 @@ type WebhookDeliveryResponse struct {
 	NextRetryAt    *time.Time `json:"next_retry_at,omitempty"`
 	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
 	FailedAt       *time.Time `json:"failed_at,omitempty"`
+	DeadLetteredAt *time.Time `json:"dead_lettered_at,omitempty"`
+	ResponseBody   *string    `json:"response_body,omitempty"`
+	ReplayID       *string    `json:"replay_id,omitempty"`
 	CreatedAt      time.Time  `json:"created_at"`
 }
 
+// DeliveryAttemptResponse is one HTTP attempt of a webhook delivery.
+// ResponseBody is cut to MaxAttemptResponseBytes, flagged by ResponseTruncated.
+type DeliveryAttemptResponse struct {
+	ID                string          `json:"id"`
+	AttemptNumber     int             `json:"attempt_number"`
+	RequestURL        string          `json:"request_url"`
+	RequestHeaders    json.RawMessage `json:"request_headers"`
+	PayloadSHA256     string          `json:"payload_sha256"`
+	ResponseStatus    *int            `json:"response_status,omitempty"`
+	ResponseBody      *string         `json:"response_body,omitempty"`
+	ResponseTruncated bool            `json:"response_truncated"`
+	LatencyMs         int             `json:"latency_ms"`
+	ErrorClass        *string         `json:"error_class,omitempty"`
+	ErrorMessage      *string         `json:"error_message,omitempty"`
+	AttemptedAt       time.Time       `json:"attempted_at"`
+}
+
+// DeadLetterFilter selects dead-lettered deliveries; unset fields match
+// everything. From and To bound when the delivery was dead-lettered.
+type DeadLetterFilter struct {
+	EndpointID *uuid.UUID
+	EventType  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// DeadLetterFilterRequest selects dead letters to list or redeliver. It is
+// read from the query string when listing and from the body when
+// redelivering; From and To are RFC 3339 timestamps.
+type DeadLetterFilterRequest struct {
+	EndpointID string `json:"endpoint_id,omitempty" validate:"omitempty,uuid"`
+	EventType  string `json:"event_type,omitempty" validate:"max=100"`
+	From       string `json:"from,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To         string `json:"to,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+// Filter converts a validated request to a DeadLetterFilter
+func (r DeadLetterFilterRequest) Filter() DeadLetterFilter {
+	filter := DeadLetterFilter{EventType: r.EventType}
+	if id, err := uuid.Parse(r.EndpointID); err == nil {
+		filter.EndpointID = &id
+	}
+	if from, err := time.Parse(time.RFC3339, r.From); err == nil {
+		filter.From = &from
+	}
+	if to, err := time.Parse(time.RFC3339, r.To); err == nil {
+		filter.To = &to
+	}
+	return filter
+}
+
+// SyntheticEventsRequest sends sample events to an endpoint. EventTypes
+// takes the same patterns as an endpoint's subscription and defaults to the
+// endpoint's own; Count events of each matching type are sent.
+type SyntheticEventsRequest struct {
+	EventTypes []string `json:"event_types,omitempty"`
+	Count      int      `json:"count,omitempty" validate:"omitempty,min=1,max=10"`
+}
+
+// SyntheticEventResult is the outcome of sending one synthetic event
+type SyntheticEventResult struct {
+	EventID        string `json:"event_id"`
+	EventType      string `json:"event_type"`
+	Success        bool   `json:"success"`
+	StatusCode     int    `json:"status_code,omitempty"`
+	DeliveryTimeMs int64  `json:"delivery_time_ms"`
+	Error          string `json:"error,omitempty"`
+}
+
+// Statuses of a webhook replay
+const (
+	ReplayStatusPending   = "pending"
+	ReplayStatusRunning   = "running"
+	ReplayStatusCompleted = "completed"
+	ReplayStatusCancelled = "cancelled"
+)
+
+// ReplayRequest resends a tenant's past events to its webhook endpoints as
+// new deliveries. The range starts after FromSequence or at From, whichever
+// is given, and ends at ToSequence and To; ToSequence defaults to the
+// tenant's latest event. EventTypes takes the same patterns as an endpoint's
+// subscription and narrows what is resent; EndpointID resends to one
+// endpoint instead of every enabled endpoint subscribed to the event.
+type ReplayRequest struct {
+	FromSequence  *int64     `json:"from_sequence,omitempty" validate:"omitempty,min=0"`
+	ToSequence    *int64     `json:"to_sequence,omitempty" validate:"omitempty,min=1"`
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+	EventTypes    []string   `json:"event_types,omitempty"`
+	EndpointID    *uuid.UUID `json:"endpoint_id,omitempty"`
+	RatePerSecond *int       `json:"rate_per_second,omitempty" validate:"omitempty,min=1,max=1000"`
+}
+
+// ReplayResponse represents a webhook replay and how far it has got
+type ReplayResponse struct {
+	ID            string     `json:"id"`
+	Status        string     `json:"status"`
+	EndpointID    *string    `json:"endpoint_id,omitempty"`
+	EventTypes    []string   `json:"event_types"`
+	FromSequence  *int64     `json:"from_sequence,omitempty"`
+	ToSequence    int64      `json:"to_sequence"`
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+	RatePerSecond int        `json:"rate_per_second"`
+
+	Progress   ReplayProgress       `json:"progress"`
+	Deliveries *ReplayDeliveryStats `json:"deliveries,omitempty"`
+
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ReplayProgress counts the events a replay has walked through. TotalEvents
+// is how many matched when the replay was created.
+type ReplayProgress struct {
+	TotalEvents      int64   `json:"total_events"`
+	EventsProcessed  int64   `json:"events_processed"`
+	DeliveriesQueued int64   `json:"deliveries_queued"`
+	LastSequence     int64   `json:"last_sequence"`
+	Percent          float64 `json:"percent"`
+}
+
+// ReplayDeliveryStats is where a replay's queued deliveries stand
+type ReplayDeliveryStats struct {
+	Delivered    int64 `json:"delivered"`
+	DeadLettered int64 `json:"dead_lettered"`
+	Pending      int64 `json:"pending"`
+}
+
 // Default webhook configuration
 const (
-	DefaultMaxAttempts    = 3
 	DefaultTimeoutSeconds = 30
 	MaxWebhookURLLength   = 2048
 	MaxWebhookSecretLength = 128
 )
 
+// WildcardEventType subscribes an endpoint to every event
+const WildcardEventType = "*"
+
 // Supported event types
 var SupportedEventTypes = []string{
 	"transaction.posted",
 	"balance.updated",
 	"account.created", 
 	"account.updated",
+	"account.deactivated",
+	"webhook_endpoint.disabled",
 }
\ No newline at end of file
@@ -2,37 +2,204 @@ package webhooks
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/temmyjay001/ledger-service/internal/storage/queries"
 )
 
 const (
 	WorkerBatchSize = 10
 	WorkerInterval  = 10 * time.Second
+
+	// DeliveryLease is how long a claimed delivery is reserved for the worker
+	// that claimed it; it has to outlast a send
+	DeliveryLease = 2 * time.Minute
+	// EndpointBusyDelay is how long a delivery is set aside when its endpoint
+	// already has as many sends in flight as it is allowed
+	EndpointBusyDelay = time.Second
+	// DeliveryChannel is notified whenever deliveries are queued or rescheduled
+	DeliveryChannel  = "webhook_deliveries"
+	listenRetryDelay = 5 * time.Second
+
+	// OutboxConsumer is the events cursor the webhook dispatcher advances
+	OutboxConsumer    = "webhooks"
+	DispatchBatchSize = 100
+	DispatchInterval  = time.Second
 )
 
-// StartDeliveryWorker starts the background worker to process webhook deliveries
+// StartOutboxDispatcher fans new events out to webhook deliveries
+func (s *Service) StartOutboxDispatcher(ctx context.Context) {
+	s.eventService.StartDispatcher(ctx, OutboxConsumer, DispatchInterval, DispatchBatchSize, s.QueueWebhookDelivery)
+}
+
+// StartDeliveryWorker sends due deliveries with up to WebhookWorkers
+// concurrent senders. Deliveries are leased before they are sent, so any
+// number of replicas can run the worker without sending one twice. It wakes
+// on NOTIFY when new work is queued and polls every WorkerInterval in case a
+// notification is missed.
 func (s *Service) StartDeliveryWorker(ctx context.Context) {
-	log.Println("Starting webhook delivery worker...")
+	senders := max(s.config.WebhookWorkers, 1)
+	log.Printf("Starting webhook delivery worker %s with %d senders...", s.workerID, senders)
+
+	wake := make(chan struct{}, 1)
+	go s.listenForDeliveries(ctx, wake)
+
+	slots := make(chan struct{}, senders)
+	var (
+		wg      sync.WaitGroup
+		backlog atomic.Bool
+	)
 
 	ticker := time.NewTicker(WorkerInterval)
 	defer ticker.Stop()
 
-	// Process any pending deliveries immediately on startup
-	if err := s.ProcessPendingDeliveries(ctx, WorkerBatchSize); err != nil {
-		log.Printf("Error processing initial pending deliveries: %v", err)
-	}
-
 	for {
+		full, err := s.dispatchDeliveries(ctx, slots, &wg, func() {
+			// a sender freed up while more work was waiting
+			if backlog.Load() {
+				wakeUp(wake)
+			}
+		})
+		if err != nil {
+			log.Printf("Error claiming webhook deliveries: %v", err)
+		}
+		backlog.Store(full)
+
 		select {
 		case <-ctx.Done():
+			wg.Wait()
 			log.Println("Webhook delivery worker shutting down...")
 			return
 		case <-ticker.C:
-			if err := s.ProcessPendingDeliveries(ctx, WorkerBatchSize); err != nil {
-				log.Printf("Error processing pending deliveries: %v", err)
-			}
+		case <-wake:
+		}
+	}
+}
+
+// dispatchDeliveries claims as many due deliveries as there are idle senders
+// and sends each on its own goroutine. It reports whether every sender is
+// busy, meaning more deliveries may be waiting.
+func (s *Service) dispatchDeliveries(ctx context.Context, slots chan struct{}, wg *sync.WaitGroup, done func()) (bool, error) {
+	for ctx.Err() == nil {
+		idle := cap(slots) - len(slots)
+		if idle == 0 {
+			return true, nil
+		}
+
+		deliveries, err := s.claimDeliveries(ctx, int32(idle))
+		if err != nil {
+			return false, err
+		}
+
+		for _, delivery := range deliveries {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-slots
+					wg.Done()
+					done()
+				}()
+				s.sendClaimed(ctx, delivery)
+			}()
+		}
+
+		if len(deliveries) < idle {
+			return false, nil
+		}
+	}
+	return false, nil
+}
+
+// sendClaimed sends a claimed delivery unless its endpoint is at its
+// concurrency cap, in which case the delivery is handed back for later
+func (s *Service) sendClaimed(ctx context.Context, delivery queries.WebhookDelivery) {
+	if !s.endpointLimiter.acquire(delivery.EndpointID) {
+		if err := s.db.Queries.ReleaseWebhookDelivery(ctx, queries.ReleaseWebhookDeliveryParams{
+			ID:          delivery.ID,
+			LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(EndpointBusyDelay), Valid: true},
+		}); err != nil {
+			log.Printf("Failed to release delivery %s: %v", delivery.ID, err)
+		}
+		return
+	}
+	defer s.endpointLimiter.release(delivery.EndpointID)
+
+	if err := s.processDelivery(ctx, delivery); err != nil {
+		log.Printf("Failed to process delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// claimDeliveries leases up to limit due deliveries to this worker. A
+// half-open probe keeps its endpoint to itself for as long as its lease.
+func (s *Service) claimDeliveries(ctx context.Context, limit int32) ([]queries.WebhookDelivery, error) {
+	now := time.Now()
+	deliveries, err := s.db.Queries.ClaimWebhookDeliveries(ctx, queries.ClaimWebhookDeliveriesParams{
+		LockedUntil:       pgtype.Timestamptz{Time: now.Add(DeliveryLease), Valid: true},
+		LockedBy:          pgtype.Text{String: s.workerID, Valid: true},
+		ProbeStartedAfter: pgtype.Timestamptz{Time: now.Add(-DeliveryLease), Valid: true},
+		BatchSize:         limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// listenForDeliveries wakes the worker on every delivery notification,
+// reconnecting whenever the listening connection is lost
+func (s *Service) listenForDeliveries(ctx context.Context, wake chan<- struct{}) {
+	for ctx.Err() == nil {
+		err := s.listen(ctx, wake)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Webhook delivery listener stopped, retrying in %s: %v", listenRetryDelay, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+func (s *Service) listen(ctx context.Context, wake chan<- struct{}) error {
+	pooled, err := s.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	// a LISTENing connection must not go back to the pool, so it is closed
+	// instead of released
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+DeliveryChannel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", DeliveryChannel, err)
+	}
+
+	// pick up anything queued while nobody was listening
+	wakeUp(wake)
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
 		}
+		wakeUp(wake)
+	}
+}
+
+// wakeUp signals ch without blocking; pending wake-ups collapse into one
+func wakeUp(ch chan<- struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
 	}
 }
 
@@ -43,7 +210,7 @@ func (s *Service) ProcessAllPendingDeliveries(ctx context.Context) error {
 	batchSize := int32(WorkerBatchSize)
 
 	for {
-		deliveries, err := s.db.Queries.GetPendingWebhookDeliveries(ctx, batchSize)
+		deliveries, err := s.claimDeliveries(ctx, batchSize)
 		if err != nil {
 			return err
 		}
@@ -74,3 +241,40 @@ func (s *Service) ProcessAllPendingDeliveries(ctx context.Context) error {
 	log.Printf("Processed %d total webhook deliveries", totalProcessed)
 	return nil
 }
+
+// endpointLimiter caps how many deliveries this instance sends to one
+// endpoint at a time, so a slow endpoint cannot tie up every sender
+type endpointLimiter struct {
+	mu       sync.Mutex
+	limit    int
+	inFlight map[uuid.UUID]int
+}
+
+func newEndpointLimiter(limit int) *endpointLimiter {
+	return &endpointLimiter{
+		limit:    max(limit, 1),
+		inFlight: make(map[uuid.UUID]int),
+	}
+}
+
+func (l *endpointLimiter) acquire(endpointID uuid.UUID) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight[endpointID] >= l.limit {
+		return false
+	}
+	l.inFlight[endpointID]++
+	return true
+}
+
+func (l *endpointLimiter) release(endpointID uuid.UUID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight[endpointID] <= 1 {
+		delete(l.inFlight, endpointID)
+		return
+	}
+	l.inFlight[endpointID]--
+}
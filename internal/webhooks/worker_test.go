@@ -0,0 +1,35 @@
+// internal/webhooks/worker_test.go
+package webhooks
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointLimiter(t *testing.T) {
+	limiter := newEndpointLimiter(2)
+	slow, fast := uuid.New(), uuid.New()
+
+	assert.True(t, limiter.acquire(slow))
+	assert.True(t, limiter.acquire(slow))
+	assert.False(t, limiter.acquire(slow), "slow endpoint is at its cap")
+	assert.True(t, limiter.acquire(fast), "other endpoints are unaffected")
+
+	limiter.release(slow)
+	assert.True(t, limiter.acquire(slow))
+
+	limiter.release(slow)
+	limiter.release(slow)
+	limiter.release(fast)
+	assert.Empty(t, limiter.inFlight)
+}
+
+func TestWakeUpCollapses(t *testing.T) {
+	wake := make(chan struct{}, 1)
+	wakeUp(wake)
+	wakeUp(wake)
+
+	assert.Len(t, wake, 1)
+}
@@ -0,0 +1,14 @@
+-- migrations/20261018090000_add_rls_tenancy.down.sql
+
+-- Move rls-mode tenants back to schemas before dropping the shared tables
+-- (see move_tenant_to_schema); anything left in shared is dropped here
+DROP FUNCTION IF EXISTS move_tenant_to_schema(TEXT);
+DROP FUNCTION IF EXISTS move_tenant_to_shared(TEXT);
+
+DROP SCHEMA IF EXISTS shared CASCADE;
+
+DROP FUNCTION IF EXISTS current_tenant_id();
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS tenancy_mode;
+
+DROP TYPE IF EXISTS tenancy_mode_enum;
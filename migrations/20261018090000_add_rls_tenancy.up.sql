@@ -0,0 +1,232 @@
+-- migrations/20261018090000_add_rls_tenancy.up.sql
+
+-- Tenancy modes: a dedicated schema per tenant, or shared tables keyed by
+-- tenant_id and guarded by row-level security
+CREATE TYPE tenancy_mode_enum AS ENUM (
+    'schema',
+    'rls'
+);
+
+ALTER TABLE tenants ADD COLUMN tenancy_mode tenancy_mode_enum NOT NULL DEFAULT 'schema';
+
+-- =====================================================
+-- SHARED TENANT TABLES (row-level security mode)
+-- =====================================================
+
+CREATE SCHEMA IF NOT EXISTS shared;
+
+-- Tenant bound to the current transaction (set by the application with
+-- set_config('app.tenant_id', ..., true)); NULL when unset so policies match nothing
+CREATE OR REPLACE FUNCTION current_tenant_id()
+RETURNS UUID AS $$
+    SELECT NULLIF(current_setting('app.tenant_id', true), '')::uuid;
+$$ LANGUAGE sql STABLE;
+
+CREATE TABLE shared.accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL DEFAULT current_tenant_id() REFERENCES tenants(id) ON DELETE CASCADE,
+    code TEXT NOT NULL,
+    name TEXT NOT NULL,
+    account_type account_type_enum NOT NULL,
+    parent_id UUID,
+    currency CHAR(3) NOT NULL DEFAULT 'NGN',
+    metadata JSONB DEFAULT '{}',
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE(tenant_id, code),
+    UNIQUE(tenant_id, id),
+    FOREIGN KEY (tenant_id, parent_id) REFERENCES shared.accounts(tenant_id, id)
+);
+
+CREATE TABLE shared.transactions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL DEFAULT current_tenant_id() REFERENCES tenants(id) ON DELETE CASCADE,
+    idempotency_key TEXT NOT NULL,
+    description TEXT NOT NULL,
+    reference TEXT,
+    status transaction_status_enum DEFAULT 'pending',
+    posted_at TIMESTAMPTZ DEFAULT NOW(),
+    metadata JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE(tenant_id, idempotency_key),
+    UNIQUE(tenant_id, id)
+);
+
+-- Composite foreign keys keep every reference inside a single tenant, since
+-- foreign key checks are not subject to row-level security
+CREATE TABLE shared.transaction_lines (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL DEFAULT current_tenant_id() REFERENCES tenants(id) ON DELETE CASCADE,
+    transaction_id UUID NOT NULL,
+    account_id UUID NOT NULL,
+    amount NUMERIC(20,4) NOT NULL CHECK (amount > 0),
+    side transaction_side_enum NOT NULL,
+    currency CHAR(3) NOT NULL,
+    metadata JSONB DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (tenant_id, transaction_id) REFERENCES shared.transactions(tenant_id, id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id, account_id) REFERENCES shared.accounts(tenant_id, id)
+);
+
+CREATE TABLE shared.account_balances (
+    account_id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL DEFAULT current_tenant_id() REFERENCES tenants(id) ON DELETE CASCADE,
+    currency CHAR(3) NOT NULL,
+    balance NUMERIC(20,4) NOT NULL DEFAULT 0,
+    version BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    UNIQUE(account_id, currency),
+    FOREIGN KEY (tenant_id, account_id) REFERENCES shared.accounts(tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_shared_accounts_type ON shared.accounts(tenant_id, account_type);
+CREATE INDEX idx_shared_accounts_active ON shared.accounts(tenant_id, is_active);
+CREATE INDEX idx_shared_transactions_status ON shared.transactions(tenant_id, status);
+CREATE INDEX idx_shared_transactions_posted_at ON shared.transactions(tenant_id, posted_at);
+CREATE INDEX idx_shared_transaction_lines_account ON shared.transaction_lines(tenant_id, account_id);
+CREATE INDEX idx_shared_transaction_lines_transaction ON shared.transaction_lines(tenant_id, transaction_id);
+CREATE INDEX idx_shared_account_balances_tenant ON shared.account_balances(tenant_id);
+
+-- Row-level security. FORCE applies the policies to the table owner too;
+-- superusers and BYPASSRLS roles are still exempt, so the application must
+-- connect as a regular role. Cross-tenant admin queries use a BYPASSRLS role.
+ALTER TABLE shared.accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shared.accounts FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON shared.accounts
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+ALTER TABLE shared.transactions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shared.transactions FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON shared.transactions
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+ALTER TABLE shared.transaction_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shared.transaction_lines FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON shared.transaction_lines
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+ALTER TABLE shared.account_balances ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shared.account_balances FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON shared.account_balances
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+-- =====================================================
+-- MOVING TENANTS BETWEEN MODES
+-- =====================================================
+
+-- Copy a schema-mode tenant into the shared tables and drop its schema
+CREATE OR REPLACE FUNCTION move_tenant_to_shared(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+BEGIN
+    -- Lock the tenant so in-flight requests finish and new ones wait for the move
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'schema' THEN
+        RAISE EXCEPTION 'tenant % is not in schema mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+
+    -- Accounts first without parent links, then restore the hierarchy
+    EXECUTE format('
+        INSERT INTO shared.accounts (id, tenant_id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, $1, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM %I.accounts', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE shared.accounts sa SET parent_id = a.parent_id
+        FROM %I.accounts a
+        WHERE sa.id = a.id AND a.parent_id IS NOT NULL', schema_name);
+
+    EXECUTE format('
+        INSERT INTO shared.transactions (id, tenant_id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, $1, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM %I.transactions', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.transaction_lines (id, tenant_id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, $1, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM %I.transaction_lines', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balances (account_id, tenant_id, currency, balance, version, updated_at)
+        SELECT account_id, $1, currency, balance, version, updated_at
+        FROM %I.account_balances', schema_name) USING tenant_uuid;
+
+    EXECUTE format('DROP SCHEMA %I CASCADE', schema_name);
+
+    UPDATE tenants SET tenancy_mode = 'rls' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Recreate a dedicated schema for an rls-mode tenant and remove its shared rows
+CREATE OR REPLACE FUNCTION move_tenant_to_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+BEGIN
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'rls' THEN
+        RAISE EXCEPTION 'tenant % is not in rls mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+    PERFORM create_tenant_schema(tenant_slug);
+
+    EXECUTE format('
+        INSERT INTO %I.accounts (id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM shared.accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE %I.accounts a SET parent_id = sa.parent_id
+        FROM shared.accounts sa
+        WHERE a.id = sa.id AND sa.tenant_id = $1 AND sa.parent_id IS NOT NULL', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.transactions (id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM shared.transactions WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.transaction_lines (id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM shared.transaction_lines WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balances (account_id, currency, balance, version, updated_at)
+        SELECT account_id, currency, balance, version, updated_at
+        FROM shared.account_balances WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    DELETE FROM shared.transaction_lines WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.account_balances WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transactions WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.accounts WHERE tenant_id = tenant_uuid;
+
+    UPDATE tenants SET tenancy_mode = 'schema' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
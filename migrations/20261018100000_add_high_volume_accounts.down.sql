@@ -0,0 +1,237 @@
+-- migrations/20261018100000_add_high_volume_accounts.down.sql
+
+-- Fold outstanding slot amounts back into the base balances before dropping
+DO $$
+DECLARE
+    schema_name TEXT;
+BEGIN
+    FOR schema_name IN
+        SELECT nspname FROM pg_namespace
+        WHERE nspname LIKE 'tenant_%'
+    LOOP
+        EXECUTE format('
+            UPDATE %I.account_balances ab
+            SET balance = ab.balance + s.total, version = ab.version + 1, updated_at = NOW()
+            FROM (
+                SELECT account_id, currency, SUM(balance) AS total
+                FROM %I.account_balance_slots
+                GROUP BY account_id, currency
+            ) s
+            WHERE ab.account_id = s.account_id AND ab.currency = s.currency', schema_name, schema_name);
+
+        EXECUTE format('DROP VIEW IF EXISTS %I.account_balance_totals', schema_name);
+        EXECUTE format('DROP TABLE IF EXISTS %I.account_balance_slots', schema_name);
+        EXECUTE format('DROP TABLE IF EXISTS %I.high_volume_accounts', schema_name);
+    END LOOP;
+END
+$$;
+
+-- The table owner is subject to the forced policies, so lift them for the fold
+ALTER TABLE shared.account_balances NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE shared.account_balance_slots NO FORCE ROW LEVEL SECURITY;
+
+UPDATE shared.account_balances ab
+SET balance = ab.balance + s.total, version = ab.version + 1, updated_at = NOW()
+FROM (
+    SELECT account_id, currency, SUM(balance) AS total
+    FROM shared.account_balance_slots
+    GROUP BY account_id, currency
+) s
+WHERE ab.account_id = s.account_id AND ab.currency = s.currency;
+
+ALTER TABLE shared.account_balances FORCE ROW LEVEL SECURITY;
+
+DROP VIEW IF EXISTS shared.account_balance_totals;
+DROP TABLE IF EXISTS shared.account_balance_slots;
+DROP TABLE IF EXISTS shared.high_volume_accounts;
+
+DROP VIEW IF EXISTS account_balance_totals;
+DROP TABLE IF EXISTS account_balance_slots;
+DROP TABLE IF EXISTS high_volume_accounts;
+
+-- Restore the previous function definitions
+CREATE OR REPLACE FUNCTION create_tenant_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+BEGIN
+    -- Create schema
+    EXECUTE format('CREATE SCHEMA IF NOT EXISTS %I', schema_name);
+
+    -- Create accounts table (referencing enums from public schema)
+    EXECUTE format('
+        CREATE TABLE %I.accounts (
+            id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+            code TEXT NOT NULL UNIQUE,
+            name TEXT NOT NULL,
+            account_type public.account_type_enum NOT NULL,
+            parent_id UUID REFERENCES %I.accounts(id),
+            currency CHAR(3) NOT NULL DEFAULT ''NGN'',
+            metadata JSONB DEFAULT ''{}'',
+            is_active BOOLEAN DEFAULT true,
+            created_at TIMESTAMPTZ DEFAULT NOW(),
+            updated_at TIMESTAMPTZ DEFAULT NOW()
+        )', schema_name, schema_name);
+
+    -- Create transactions table
+    EXECUTE format('
+        CREATE TABLE %I.transactions (
+            id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+            idempotency_key TEXT NOT NULL UNIQUE,
+            description TEXT NOT NULL,
+            reference TEXT,
+            status public.transaction_status_enum DEFAULT ''pending'',
+            posted_at TIMESTAMPTZ DEFAULT NOW(),
+            metadata JSONB DEFAULT ''{}'',
+            created_at TIMESTAMPTZ DEFAULT NOW()
+        )', schema_name);
+
+    -- Create transaction_lines table
+    EXECUTE format('
+        CREATE TABLE %I.transaction_lines (
+            id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+            transaction_id UUID NOT NULL REFERENCES %I.transactions(id) ON DELETE CASCADE,
+            account_id UUID NOT NULL REFERENCES %I.accounts(id),
+            amount NUMERIC(20,4) NOT NULL CHECK (amount > 0),
+            side public.transaction_side_enum NOT NULL,
+            currency CHAR(3) NOT NULL,
+            metadata JSONB DEFAULT ''{}'',
+            created_at TIMESTAMPTZ DEFAULT NOW()
+        )', schema_name, schema_name, schema_name);
+
+    -- Create account_balances table
+    EXECUTE format('
+        CREATE TABLE %I.account_balances (
+            account_id UUID PRIMARY KEY REFERENCES %I.accounts(id) ON DELETE CASCADE,
+            currency CHAR(3) NOT NULL,
+            balance NUMERIC(20,4) NOT NULL DEFAULT 0,
+            version BIGINT NOT NULL DEFAULT 0,
+            updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+            UNIQUE(account_id, currency)
+        )', schema_name, schema_name);
+
+    -- Create indexes for performance
+    EXECUTE format('CREATE INDEX idx_%I_accounts_type ON %I.accounts(account_type)',
+                   replace(schema_name, '-', '_'), schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transactions_status ON %I.transactions(status)',
+                   replace(schema_name, '-', '_'), schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transactions_posted_at ON %I.transactions(posted_at)',
+                   replace(schema_name, '-', '_'), schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transaction_lines_account ON %I.transaction_lines(account_id)',
+                   replace(schema_name, '-', '_'), schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transaction_lines_transaction ON %I.transaction_lines(transaction_id)',
+                   replace(schema_name, '-', '_'), schema_name);
+
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS create_high_volume_tables(TEXT);
+
+-- Copy a schema-mode tenant into the shared tables and drop its schema
+CREATE OR REPLACE FUNCTION move_tenant_to_shared(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+BEGIN
+    -- Lock the tenant so in-flight requests finish and new ones wait for the move
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'schema' THEN
+        RAISE EXCEPTION 'tenant % is not in schema mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+
+    -- Accounts first without parent links, then restore the hierarchy
+    EXECUTE format('
+        INSERT INTO shared.accounts (id, tenant_id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, $1, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM %I.accounts', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE shared.accounts sa SET parent_id = a.parent_id
+        FROM %I.accounts a
+        WHERE sa.id = a.id AND a.parent_id IS NOT NULL', schema_name);
+
+    EXECUTE format('
+        INSERT INTO shared.transactions (id, tenant_id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, $1, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM %I.transactions', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.transaction_lines (id, tenant_id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, $1, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM %I.transaction_lines', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balances (account_id, tenant_id, currency, balance, version, updated_at)
+        SELECT account_id, $1, currency, balance, version, updated_at
+        FROM %I.account_balances', schema_name) USING tenant_uuid;
+
+    EXECUTE format('DROP SCHEMA %I CASCADE', schema_name);
+
+    UPDATE tenants SET tenancy_mode = 'rls' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Recreate a dedicated schema for an rls-mode tenant and remove its shared rows
+CREATE OR REPLACE FUNCTION move_tenant_to_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+BEGIN
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'rls' THEN
+        RAISE EXCEPTION 'tenant % is not in rls mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+    PERFORM create_tenant_schema(tenant_slug);
+
+    EXECUTE format('
+        INSERT INTO %I.accounts (id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM shared.accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE %I.accounts a SET parent_id = sa.parent_id
+        FROM shared.accounts sa
+        WHERE a.id = sa.id AND sa.tenant_id = $1 AND sa.parent_id IS NOT NULL', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.transactions (id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM shared.transactions WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.transaction_lines (id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM shared.transaction_lines WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balances (account_id, currency, balance, version, updated_at)
+        SELECT account_id, currency, balance, version, updated_at
+        FROM shared.account_balances WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    DELETE FROM shared.transaction_lines WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.account_balances WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transactions WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.accounts WHERE tenant_id = tenant_uuid;
+
+    UPDATE tenants SET tenancy_mode = 'schema' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
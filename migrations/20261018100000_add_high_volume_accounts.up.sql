@@ -0,0 +1,367 @@
+-- migrations/20261018100000_add_high_volume_accounts.up.sql
+
+-- High-volume accounts spread postings over N balance slots so concurrent
+-- transactions do not all queue on the single account_balances row. The exact
+-- balance is the base row plus the sum of its slots (account_balance_totals).
+
+-- =====================================================
+-- TEMPLATE TABLES (sqlc only, see create_template_tables)
+-- =====================================================
+
+CREATE TABLE IF NOT EXISTS high_volume_accounts (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    slot_count INTEGER NOT NULL DEFAULT 16 CHECK (slot_count BETWEEN 1 AND 256),
+    overdraft_protection BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS account_balance_slots (
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    currency CHAR(3) NOT NULL,
+    slot INTEGER NOT NULL,
+    balance NUMERIC(20,4) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (account_id, currency, slot)
+);
+
+CREATE OR REPLACE VIEW account_balance_totals AS
+SELECT
+    ab.account_id,
+    ab.currency,
+    ab.balance + COALESCE((
+        SELECT SUM(s.balance) FROM account_balance_slots s
+        WHERE s.account_id = ab.account_id AND s.currency = ab.currency
+    ), 0) AS balance,
+    ab.version,
+    ab.updated_at
+FROM account_balances ab;
+
+COMMENT ON TABLE high_volume_accounts IS 'Template table for sqlc generation - actual data is in tenant schemas';
+COMMENT ON TABLE account_balance_slots IS 'Template table for sqlc generation - actual data is in tenant schemas';
+
+-- =====================================================
+-- TENANT SCHEMAS
+-- =====================================================
+
+-- Create the high-volume tables and totals view inside a tenant schema
+CREATE OR REPLACE FUNCTION create_high_volume_tables(schema_name TEXT)
+RETURNS VOID AS $$
+BEGIN
+    EXECUTE format('
+        CREATE TABLE IF NOT EXISTS %I.high_volume_accounts (
+            account_id UUID PRIMARY KEY REFERENCES %I.accounts(id) ON DELETE CASCADE,
+            slot_count INTEGER NOT NULL DEFAULT 16 CHECK (slot_count BETWEEN 1 AND 256),
+            overdraft_protection BOOLEAN NOT NULL DEFAULT false,
+            created_at TIMESTAMPTZ DEFAULT NOW(),
+            updated_at TIMESTAMPTZ DEFAULT NOW()
+        )', schema_name, schema_name);
+
+    EXECUTE format('
+        CREATE TABLE IF NOT EXISTS %I.account_balance_slots (
+            account_id UUID NOT NULL REFERENCES %I.accounts(id) ON DELETE CASCADE,
+            currency CHAR(3) NOT NULL,
+            slot INTEGER NOT NULL,
+            balance NUMERIC(20,4) NOT NULL DEFAULT 0,
+            updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+            PRIMARY KEY (account_id, currency, slot)
+        )', schema_name, schema_name);
+
+    EXECUTE format('
+        CREATE OR REPLACE VIEW %I.account_balance_totals AS
+        SELECT
+            ab.account_id,
+            ab.currency,
+            ab.balance + COALESCE((
+                SELECT SUM(s.balance) FROM %I.account_balance_slots s
+                WHERE s.account_id = ab.account_id AND s.currency = ab.currency
+            ), 0) AS balance,
+            ab.version,
+            ab.updated_at
+        FROM %I.account_balances ab', schema_name, schema_name, schema_name);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Existing tenant schemas
+DO $$
+DECLARE
+    schema_name TEXT;
+BEGIN
+    FOR schema_name IN
+        SELECT nspname FROM pg_namespace
+        WHERE nspname LIKE 'tenant_%'
+    LOOP
+        PERFORM create_high_volume_tables(schema_name);
+    END LOOP;
+END
+$$;
+
+-- New tenant schemas
+CREATE OR REPLACE FUNCTION create_tenant_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+BEGIN
+    -- Create schema
+    EXECUTE format('CREATE SCHEMA IF NOT EXISTS %I', schema_name);
+
+    -- Create accounts table (referencing enums from public schema)
+    EXECUTE format('
+        CREATE TABLE %I.accounts (
+            id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+            code TEXT NOT NULL UNIQUE,
+            name TEXT NOT NULL,
+            account_type public.account_type_enum NOT NULL,
+            parent_id UUID REFERENCES %I.accounts(id),
+            currency CHAR(3) NOT NULL DEFAULT ''NGN'',
+            metadata JSONB DEFAULT ''{}'',
+            is_active BOOLEAN DEFAULT true,
+            created_at TIMESTAMPTZ DEFAULT NOW(),
+            updated_at TIMESTAMPTZ DEFAULT NOW()
+        )', schema_name, schema_name);
+
+    -- Create transactions table
+    EXECUTE format('
+        CREATE TABLE %I.transactions (
+            id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+            idempotency_key TEXT NOT NULL UNIQUE,
+            description TEXT NOT NULL,
+            reference TEXT,
+            status public.transaction_status_enum DEFAULT ''pending'',
+            posted_at TIMESTAMPTZ DEFAULT NOW(),
+            metadata JSONB DEFAULT ''{}'',
+            created_at TIMESTAMPTZ DEFAULT NOW()
+        )', schema_name);
+
+    -- Create transaction_lines table
+    EXECUTE format('
+        CREATE TABLE %I.transaction_lines (
+            id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+            transaction_id UUID NOT NULL REFERENCES %I.transactions(id) ON DELETE CASCADE,
+            account_id UUID NOT NULL REFERENCES %I.accounts(id),
+            amount NUMERIC(20,4) NOT NULL CHECK (amount > 0),
+            side public.transaction_side_enum NOT NULL,
+            currency CHAR(3) NOT NULL,
+            metadata JSONB DEFAULT ''{}'',
+            created_at TIMESTAMPTZ DEFAULT NOW()
+        )', schema_name, schema_name, schema_name);
+
+    -- Create account_balances table
+    EXECUTE format('
+        CREATE TABLE %I.account_balances (
+            account_id UUID PRIMARY KEY REFERENCES %I.accounts(id) ON DELETE CASCADE,
+            currency CHAR(3) NOT NULL,
+            balance NUMERIC(20,4) NOT NULL DEFAULT 0,
+            version BIGINT NOT NULL DEFAULT 0,
+            updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+            UNIQUE(account_id, currency)
+        )', schema_name, schema_name);
+
+    -- Create high-volume account tables
+    PERFORM create_high_volume_tables(schema_name);
+
+    -- Create indexes for performance
+    EXECUTE format('CREATE INDEX idx_%I_accounts_type ON %I.accounts(account_type)',
+                   replace(schema_name, '-', '_'), schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transactions_status ON %I.transactions(status)',
+                   replace(schema_name, '-', '_'), schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transactions_posted_at ON %I.transactions(posted_at)',
+                   replace(schema_name, '-', '_'), schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transaction_lines_account ON %I.transaction_lines(account_id)',
+                   replace(schema_name, '-', '_'), schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transaction_lines_transaction ON %I.transaction_lines(transaction_id)',
+                   replace(schema_name, '-', '_'), schema_name);
+
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- SHARED TABLES (row-level security mode)
+-- =====================================================
+
+CREATE TABLE shared.high_volume_accounts (
+    account_id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL DEFAULT current_tenant_id() REFERENCES tenants(id) ON DELETE CASCADE,
+    slot_count INTEGER NOT NULL DEFAULT 16 CHECK (slot_count BETWEEN 1 AND 256),
+    overdraft_protection BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    FOREIGN KEY (tenant_id, account_id) REFERENCES shared.accounts(tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE TABLE shared.account_balance_slots (
+    account_id UUID NOT NULL,
+    tenant_id UUID NOT NULL DEFAULT current_tenant_id() REFERENCES tenants(id) ON DELETE CASCADE,
+    currency CHAR(3) NOT NULL,
+    slot INTEGER NOT NULL,
+    balance NUMERIC(20,4) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (account_id, currency, slot),
+    FOREIGN KEY (tenant_id, account_id) REFERENCES shared.accounts(tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_shared_high_volume_accounts_tenant ON shared.high_volume_accounts(tenant_id);
+CREATE INDEX idx_shared_account_balance_slots_tenant ON shared.account_balance_slots(tenant_id);
+
+ALTER TABLE shared.high_volume_accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shared.high_volume_accounts FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON shared.high_volume_accounts
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+ALTER TABLE shared.account_balance_slots ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shared.account_balance_slots FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON shared.account_balance_slots
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+CREATE OR REPLACE VIEW shared.account_balance_totals AS
+SELECT
+    ab.account_id,
+    ab.currency,
+    ab.balance + COALESCE((
+        SELECT SUM(s.balance) FROM shared.account_balance_slots s
+        WHERE s.account_id = ab.account_id AND s.currency = ab.currency
+    ), 0) AS balance,
+    ab.version,
+    ab.updated_at
+FROM shared.account_balances ab;
+
+-- =====================================================
+-- MOVING TENANTS BETWEEN MODES
+-- =====================================================
+
+-- Copy a schema-mode tenant into the shared tables and drop its schema
+CREATE OR REPLACE FUNCTION move_tenant_to_shared(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+BEGIN
+    -- Lock the tenant so in-flight requests finish and new ones wait for the move
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'schema' THEN
+        RAISE EXCEPTION 'tenant % is not in schema mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+
+    -- Accounts first without parent links, then restore the hierarchy
+    EXECUTE format('
+        INSERT INTO shared.accounts (id, tenant_id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, $1, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM %I.accounts', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE shared.accounts sa SET parent_id = a.parent_id
+        FROM %I.accounts a
+        WHERE sa.id = a.id AND a.parent_id IS NOT NULL', schema_name);
+
+    EXECUTE format('
+        INSERT INTO shared.transactions (id, tenant_id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, $1, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM %I.transactions', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.transaction_lines (id, tenant_id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, $1, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM %I.transaction_lines', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balances (account_id, tenant_id, currency, balance, version, updated_at)
+        SELECT account_id, $1, currency, balance, version, updated_at
+        FROM %I.account_balances', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.high_volume_accounts (account_id, tenant_id, slot_count, overdraft_protection, created_at, updated_at)
+        SELECT account_id, $1, slot_count, overdraft_protection, created_at, updated_at
+        FROM %I.high_volume_accounts', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balance_slots (account_id, tenant_id, currency, slot, balance, updated_at)
+        SELECT account_id, $1, currency, slot, balance, updated_at
+        FROM %I.account_balance_slots', schema_name) USING tenant_uuid;
+
+    EXECUTE format('DROP SCHEMA %I CASCADE', schema_name);
+
+    UPDATE tenants SET tenancy_mode = 'rls' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Recreate a dedicated schema for an rls-mode tenant and remove its shared rows
+CREATE OR REPLACE FUNCTION move_tenant_to_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+BEGIN
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'rls' THEN
+        RAISE EXCEPTION 'tenant % is not in rls mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+    PERFORM create_tenant_schema(tenant_slug);
+
+    EXECUTE format('
+        INSERT INTO %I.accounts (id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM shared.accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE %I.accounts a SET parent_id = sa.parent_id
+        FROM shared.accounts sa
+        WHERE a.id = sa.id AND sa.tenant_id = $1 AND sa.parent_id IS NOT NULL', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.transactions (id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM shared.transactions WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.transaction_lines (id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM shared.transaction_lines WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balances (account_id, currency, balance, version, updated_at)
+        SELECT account_id, currency, balance, version, updated_at
+        FROM shared.account_balances WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.high_volume_accounts (account_id, slot_count, overdraft_protection, created_at, updated_at)
+        SELECT account_id, slot_count, overdraft_protection, created_at, updated_at
+        FROM shared.high_volume_accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balance_slots (account_id, currency, slot, balance, updated_at)
+        SELECT account_id, currency, slot, balance, updated_at
+        FROM shared.account_balance_slots WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    DELETE FROM shared.account_balance_slots WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.high_volume_accounts WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transaction_lines WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.account_balances WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transactions WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.accounts WHERE tenant_id = tenant_uuid;
+
+    UPDATE tenants SET tenancy_mode = 'schema' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,286 @@
+-- migrations/20261018110000_partition_ledger_tables.down.sql
+
+-- Converts the partitioned tables back to plain tables. Rows in archived
+-- partitions are not restored; load them from the archive files if needed.
+
+-- Replace table_schema.table_name with an unpartitioned copy
+CREATE OR REPLACE FUNCTION unpartition_table(table_schema TEXT, table_name TEXT, primary_key TEXT)
+RETURNS VOID AS $$
+DECLARE
+    partitioned_name TEXT := table_name || '_partitioned';
+    seq_name TEXT;
+    column_name TEXT;
+BEGIN
+    EXECUTE format('ALTER TABLE %I.%I RENAME TO %I', table_schema, table_name, partitioned_name);
+
+    EXECUTE format('
+        CREATE TABLE %I.%I (
+            LIKE %I.%I INCLUDING DEFAULTS INCLUDING CONSTRAINTS,
+            PRIMARY KEY (%s)
+        )', table_schema, table_name, table_schema, partitioned_name, primary_key);
+
+    EXECUTE format('INSERT INTO %I.%I SELECT * FROM %I.%I', table_schema, table_name, table_schema, partitioned_name);
+
+    FOR seq_name, column_name IN
+        SELECT s.oid::regclass::text, a.attname
+        FROM pg_depend d
+        JOIN pg_class s ON s.oid = d.objid AND s.relkind = 'S'
+        JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+        WHERE d.refobjid = format('%I.%I', table_schema, partitioned_name)::regclass
+        AND d.deptype = 'a'
+    LOOP
+        EXECUTE format('ALTER SEQUENCE %s OWNED BY %I.%I.%I', seq_name, table_schema, table_name, column_name);
+    END LOOP;
+
+    EXECUTE format('DROP TABLE %I.%I CASCADE', table_schema, partitioned_name);
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- GLOBAL EVENT STORE
+-- =====================================================
+
+SELECT unpartition_table('public', 'events', 'event_id');
+
+ALTER TABLE events ADD FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_events_tenant_created ON events(tenant_id, created_at);
+CREATE INDEX idx_events_aggregate ON events(aggregate_id, event_version);
+CREATE INDEX idx_events_sequence ON events(sequence_number);
+CREATE INDEX idx_events_type ON events(tenant_id, event_type);
+
+-- Deliveries of archived events can't be linked again
+DELETE FROM webhook_deliveries wd
+WHERE NOT EXISTS (SELECT 1 FROM events e WHERE e.event_id = wd.event_id);
+ALTER TABLE webhook_deliveries ADD FOREIGN KEY (event_id) REFERENCES events(event_id) ON DELETE CASCADE;
+
+-- =====================================================
+-- TENANT SCHEMAS
+-- =====================================================
+
+DROP FUNCTION IF EXISTS create_tenant_schema(TEXT);
+ALTER FUNCTION create_tenant_tables(TEXT) RENAME TO create_tenant_schema;
+
+DO $$
+DECLARE
+    schema_name TEXT;
+    index_prefix TEXT;
+BEGIN
+    FOR schema_name IN
+        SELECT nspname FROM pg_namespace
+        WHERE nspname LIKE 'tenant_%'
+    LOOP
+        index_prefix := replace(schema_name, '-', '_');
+
+        PERFORM unpartition_table(schema_name, 'transactions', 'id');
+        PERFORM unpartition_table(schema_name, 'transaction_lines', 'id');
+
+        -- Lines of archived transactions can't be linked again
+        EXECUTE format('
+            DELETE FROM %I.transaction_lines tl
+            WHERE NOT EXISTS (SELECT 1 FROM %I.transactions t WHERE t.id = tl.transaction_id)', schema_name, schema_name);
+
+        EXECUTE format('ALTER TABLE %I.transactions ADD UNIQUE (idempotency_key)', schema_name);
+        EXECUTE format('ALTER TABLE %I.transaction_lines ADD FOREIGN KEY (transaction_id) REFERENCES %I.transactions(id) ON DELETE CASCADE',
+                       schema_name, schema_name);
+        EXECUTE format('ALTER TABLE %I.transaction_lines ADD FOREIGN KEY (account_id) REFERENCES %I.accounts(id)',
+                       schema_name, schema_name);
+
+        EXECUTE format('CREATE INDEX idx_%I_transactions_status ON %I.transactions(status)',
+                       index_prefix, schema_name);
+        EXECUTE format('CREATE INDEX idx_%I_transactions_posted_at ON %I.transactions(posted_at)',
+                       index_prefix, schema_name);
+        EXECUTE format('CREATE INDEX idx_%I_transaction_lines_account ON %I.transaction_lines(account_id)',
+                       index_prefix, schema_name);
+        EXECUTE format('CREATE INDEX idx_%I_transaction_lines_transaction ON %I.transaction_lines(transaction_id)',
+                       index_prefix, schema_name);
+
+        EXECUTE format('DROP TABLE IF EXISTS %I.transaction_idempotency_keys', schema_name);
+    END LOOP;
+END
+$$;
+
+-- =====================================================
+-- SHARED TABLES (row-level security mode)
+-- =====================================================
+
+ALTER TABLE shared.transactions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE shared.transaction_lines NO FORCE ROW LEVEL SECURITY;
+
+SELECT unpartition_table('shared', 'transactions', 'id');
+SELECT unpartition_table('shared', 'transaction_lines', 'id');
+
+DELETE FROM shared.transaction_lines tl
+WHERE NOT EXISTS (SELECT 1 FROM shared.transactions t WHERE t.id = tl.transaction_id);
+
+ALTER TABLE shared.transactions ADD FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE shared.transactions ADD UNIQUE (tenant_id, idempotency_key);
+ALTER TABLE shared.transactions ADD UNIQUE (tenant_id, id);
+ALTER TABLE shared.transaction_lines ADD FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE shared.transaction_lines ADD FOREIGN KEY (tenant_id, transaction_id) REFERENCES shared.transactions(tenant_id, id) ON DELETE CASCADE;
+ALTER TABLE shared.transaction_lines ADD FOREIGN KEY (tenant_id, account_id) REFERENCES shared.accounts(tenant_id, id);
+
+CREATE INDEX idx_shared_transactions_status ON shared.transactions(tenant_id, status);
+CREATE INDEX idx_shared_transactions_posted_at ON shared.transactions(tenant_id, posted_at);
+CREATE INDEX idx_shared_transaction_lines_account ON shared.transaction_lines(tenant_id, account_id);
+CREATE INDEX idx_shared_transaction_lines_transaction ON shared.transaction_lines(tenant_id, transaction_id);
+
+ALTER TABLE shared.transactions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shared.transactions FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON shared.transactions
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+ALTER TABLE shared.transaction_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shared.transaction_lines FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON shared.transaction_lines
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+DROP TABLE IF EXISTS shared.transaction_idempotency_keys;
+
+-- =====================================================
+-- FUNCTIONS
+-- =====================================================
+
+DROP FUNCTION IF EXISTS unpartition_table(TEXT, TEXT, TEXT);
+DROP FUNCTION IF EXISTS partition_tenant_ledger(TEXT);
+DROP FUNCTION IF EXISTS record_idempotency_key();
+DROP FUNCTION IF EXISTS partition_table_by_month(TEXT, TEXT, TEXT);
+DROP FUNCTION IF EXISTS ensure_monthly_partitions(TEXT, TEXT, TIMESTAMPTZ, TIMESTAMPTZ);
+DROP FUNCTION IF EXISTS create_monthly_partition(TEXT, TEXT, TIMESTAMPTZ);
+DROP FUNCTION IF EXISTS monthly_partition_name(TEXT, TIMESTAMPTZ);
+
+DROP TABLE IF EXISTS archived_partitions;
+
+-- Restore the previous move functions
+
+-- Copy a schema-mode tenant into the shared tables and drop its schema
+CREATE OR REPLACE FUNCTION move_tenant_to_shared(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+BEGIN
+    -- Lock the tenant so in-flight requests finish and new ones wait for the move
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'schema' THEN
+        RAISE EXCEPTION 'tenant % is not in schema mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+
+    -- Accounts first without parent links, then restore the hierarchy
+    EXECUTE format('
+        INSERT INTO shared.accounts (id, tenant_id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, $1, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM %I.accounts', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE shared.accounts sa SET parent_id = a.parent_id
+        FROM %I.accounts a
+        WHERE sa.id = a.id AND a.parent_id IS NOT NULL', schema_name);
+
+    EXECUTE format('
+        INSERT INTO shared.transactions (id, tenant_id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, $1, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM %I.transactions', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.transaction_lines (id, tenant_id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, $1, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM %I.transaction_lines', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balances (account_id, tenant_id, currency, balance, version, updated_at)
+        SELECT account_id, $1, currency, balance, version, updated_at
+        FROM %I.account_balances', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.high_volume_accounts (account_id, tenant_id, slot_count, overdraft_protection, created_at, updated_at)
+        SELECT account_id, $1, slot_count, overdraft_protection, created_at, updated_at
+        FROM %I.high_volume_accounts', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balance_slots (account_id, tenant_id, currency, slot, balance, updated_at)
+        SELECT account_id, $1, currency, slot, balance, updated_at
+        FROM %I.account_balance_slots', schema_name) USING tenant_uuid;
+
+    EXECUTE format('DROP SCHEMA %I CASCADE', schema_name);
+
+    UPDATE tenants SET tenancy_mode = 'rls' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Recreate a dedicated schema for an rls-mode tenant and remove its shared rows
+CREATE OR REPLACE FUNCTION move_tenant_to_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+BEGIN
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'rls' THEN
+        RAISE EXCEPTION 'tenant % is not in rls mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+    PERFORM create_tenant_schema(tenant_slug);
+
+    EXECUTE format('
+        INSERT INTO %I.accounts (id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM shared.accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE %I.accounts a SET parent_id = sa.parent_id
+        FROM shared.accounts sa
+        WHERE a.id = sa.id AND sa.tenant_id = $1 AND sa.parent_id IS NOT NULL', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.transactions (id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM shared.transactions WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.transaction_lines (id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM shared.transaction_lines WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balances (account_id, currency, balance, version, updated_at)
+        SELECT account_id, currency, balance, version, updated_at
+        FROM shared.account_balances WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.high_volume_accounts (account_id, slot_count, overdraft_protection, created_at, updated_at)
+        SELECT account_id, slot_count, overdraft_protection, created_at, updated_at
+        FROM shared.high_volume_accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balance_slots (account_id, currency, slot, balance, updated_at)
+        SELECT account_id, currency, slot, balance, updated_at
+        FROM shared.account_balance_slots WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    DELETE FROM shared.account_balance_slots WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.high_volume_accounts WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transaction_lines WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.account_balances WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transactions WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.accounts WHERE tenant_id = tenant_uuid;
+
+    UPDATE tenants SET tenancy_mode = 'schema' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
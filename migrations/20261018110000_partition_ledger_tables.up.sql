@@ -0,0 +1,448 @@
+-- migrations/20261018110000_partition_ledger_tables.up.sql
+
+-- Monthly range partitioning on created_at for the global events table and
+-- every tenant's transactions/transaction_lines. Partitions are named
+-- <table>_pYYYYMM and cover whole UTC months; the service keeps the next few
+-- months created ahead of time and the archive command detaches old ones.
+
+-- =====================================================
+-- ARCHIVED PARTITIONS
+-- =====================================================
+
+-- Partitions that were detached and exported. Queries over these ranges are
+-- rejected instead of silently returning partial results.
+CREATE TABLE archived_partitions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    schema_name TEXT NOT NULL,
+    table_name TEXT NOT NULL,
+    partition_name TEXT NOT NULL,
+    range_start TIMESTAMPTZ NOT NULL,
+    range_end TIMESTAMPTZ NOT NULL,
+    row_count BIGINT NOT NULL,
+    location TEXT NOT NULL,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE(schema_name, table_name, range_start)
+);
+
+CREATE INDEX idx_archived_partitions_range ON archived_partitions(schema_name, table_name, range_end);
+
+-- =====================================================
+-- PARTITION MANAGEMENT
+-- =====================================================
+
+CREATE OR REPLACE FUNCTION monthly_partition_name(table_name TEXT, month_start TIMESTAMPTZ)
+RETURNS TEXT AS $$
+    SELECT table_name || '_p' || to_char(month_start AT TIME ZONE 'UTC', 'YYYYMM');
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Create the partition of parent_schema.parent_table covering the UTC month
+-- containing month_start; a no-op if it already exists
+CREATE OR REPLACE FUNCTION create_monthly_partition(parent_schema TEXT, parent_table TEXT, month_start TIMESTAMPTZ)
+RETURNS VOID AS $$
+DECLARE
+    lower_bound TIMESTAMPTZ := date_trunc('month', month_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    upper_bound TIMESTAMPTZ := (date_trunc('month', month_start AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC';
+    part_name TEXT := monthly_partition_name(parent_table, month_start);
+BEGIN
+    -- Never bring back an archived month: it would look complete but hold
+    -- only the rows written after archival
+    IF EXISTS (
+        SELECT 1 FROM archived_partitions ap
+        WHERE ap.schema_name = parent_schema
+        AND ap.table_name = parent_table
+        AND ap.range_start = lower_bound
+    ) THEN
+        RAISE EXCEPTION 'partition %.% has been archived and cannot be recreated', parent_schema, part_name;
+    END IF;
+
+    EXECUTE format('CREATE TABLE IF NOT EXISTS %I.%I PARTITION OF %I.%I FOR VALUES FROM (%L) TO (%L)',
+                   parent_schema, part_name, parent_schema, parent_table, lower_bound, upper_bound);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Create every monthly partition between from_ts and to_ts (inclusive)
+CREATE OR REPLACE FUNCTION ensure_monthly_partitions(parent_schema TEXT, parent_table TEXT, from_ts TIMESTAMPTZ, to_ts TIMESTAMPTZ)
+RETURNS VOID AS $$
+DECLARE
+    month_start TIMESTAMPTZ;
+BEGIN
+    IF from_ts IS NULL THEN
+        RETURN;
+    END IF;
+
+    month_start := date_trunc('month', from_ts AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    WHILE month_start <= to_ts LOOP
+        PERFORM create_monthly_partition(parent_schema, parent_table, month_start);
+        month_start := ((month_start AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC';
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replace table_schema.table_name with an equivalent table partitioned by
+-- month on created_at, keeping columns, defaults, check constraints, owned
+-- sequences and rows. Indexes, foreign keys, triggers and row-level security
+-- are not carried over and must be recreated by the caller.
+CREATE OR REPLACE FUNCTION partition_table_by_month(table_schema TEXT, table_name TEXT, primary_key TEXT)
+RETURNS VOID AS $$
+DECLARE
+    legacy_name TEXT := table_name || '_unpartitioned';
+    oldest TIMESTAMPTZ;
+    seq_name TEXT;
+    column_name TEXT;
+BEGIN
+    EXECUTE format('ALTER TABLE %I.%I RENAME TO %I', table_schema, table_name, legacy_name);
+    EXECUTE format('UPDATE %I.%I SET created_at = NOW() WHERE created_at IS NULL', table_schema, legacy_name);
+
+    EXECUTE format('
+        CREATE TABLE %I.%I (
+            LIKE %I.%I INCLUDING DEFAULTS INCLUDING CONSTRAINTS,
+            PRIMARY KEY (%s)
+        ) PARTITION BY RANGE (created_at)', table_schema, table_name, table_schema, legacy_name, primary_key);
+
+    EXECUTE format('SELECT min(created_at) FROM %I.%I', table_schema, legacy_name) INTO oldest;
+    PERFORM ensure_monthly_partitions(table_schema, table_name, COALESCE(oldest, NOW()), NOW() + INTERVAL '3 months');
+
+    EXECUTE format('INSERT INTO %I.%I SELECT * FROM %I.%I', table_schema, table_name, table_schema, legacy_name);
+
+    -- Serial columns keep their sequence, so hand ownership over before the
+    -- legacy table (and with it the sequence) is dropped
+    FOR seq_name, column_name IN
+        SELECT s.oid::regclass::text, a.attname
+        FROM pg_depend d
+        JOIN pg_class s ON s.oid = d.objid AND s.relkind = 'S'
+        JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+        WHERE d.refobjid = format('%I.%I', table_schema, legacy_name)::regclass
+        AND d.deptype = 'a'
+    LOOP
+        EXECUTE format('ALTER SEQUENCE %s OWNED BY %I.%I.%I', seq_name, table_schema, table_name, column_name);
+    END LOOP;
+
+    EXECUTE format('DROP TABLE %I.%I CASCADE', table_schema, legacy_name);
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- IDEMPOTENCY KEYS
+-- =====================================================
+
+-- Unique constraints on a partitioned table must include the partition key,
+-- so idempotency keys are kept unique in a side table filled by trigger. The
+-- side table is never archived, so keys stay reserved after their month is.
+CREATE OR REPLACE FUNCTION record_idempotency_key()
+RETURNS TRIGGER AS $$
+BEGIN
+    EXECUTE format('
+        INSERT INTO %I.transaction_idempotency_keys (idempotency_key, transaction_id, created_at)
+        VALUES ($1, $2, $3)', TG_TABLE_SCHEMA) USING NEW.idempotency_key, NEW.id, NEW.created_at;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- TENANT SCHEMAS
+-- =====================================================
+
+-- Partition a tenant schema's transactions and transaction_lines. Lines no
+-- longer reference transactions by foreign key (that would need the partition
+-- key on both sides); they are always written in the same database
+-- transaction as their transaction and archived in the same month.
+CREATE OR REPLACE FUNCTION partition_tenant_ledger(schema_name TEXT)
+RETURNS VOID AS $$
+DECLARE
+    index_prefix TEXT := replace(schema_name, '-', '_');
+BEGIN
+    EXECUTE format('
+        CREATE TABLE %I.transaction_idempotency_keys (
+            idempotency_key TEXT PRIMARY KEY,
+            transaction_id UUID NOT NULL,
+            created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )', schema_name);
+    EXECUTE format('
+        INSERT INTO %I.transaction_idempotency_keys (idempotency_key, transaction_id, created_at)
+        SELECT idempotency_key, id, COALESCE(created_at, NOW())
+        FROM %I.transactions', schema_name, schema_name);
+
+    PERFORM partition_table_by_month(schema_name, 'transaction_lines', 'id, created_at');
+    PERFORM partition_table_by_month(schema_name, 'transactions', 'id, created_at');
+
+    EXECUTE format('ALTER TABLE %I.transaction_lines ADD FOREIGN KEY (account_id) REFERENCES %I.accounts(id)',
+                   schema_name, schema_name);
+
+    EXECUTE format('CREATE TRIGGER record_idempotency_key AFTER INSERT ON %I.transactions
+                    FOR EACH ROW EXECUTE FUNCTION public.record_idempotency_key()', schema_name);
+
+    EXECUTE format('CREATE INDEX idx_%I_transactions_idempotency_key ON %I.transactions(idempotency_key)',
+                   index_prefix, schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transactions_status ON %I.transactions(status)',
+                   index_prefix, schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transactions_posted_at ON %I.transactions(posted_at)',
+                   index_prefix, schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transaction_lines_account ON %I.transaction_lines(account_id)',
+                   index_prefix, schema_name);
+    EXECUTE format('CREATE INDEX idx_%I_transaction_lines_transaction ON %I.transaction_lines(transaction_id)',
+                   index_prefix, schema_name);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Existing tenant schemas
+DO $$
+DECLARE
+    schema_name TEXT;
+BEGIN
+    FOR schema_name IN
+        SELECT nspname FROM pg_namespace
+        WHERE nspname LIKE 'tenant_%'
+    LOOP
+        PERFORM partition_tenant_ledger(schema_name);
+    END LOOP;
+END
+$$;
+
+-- New tenant schemas are created as before and then partitioned, so new and
+-- existing tenants go through the same conversion
+ALTER FUNCTION create_tenant_schema(TEXT) RENAME TO create_tenant_tables;
+
+CREATE OR REPLACE FUNCTION create_tenant_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+BEGIN
+    PERFORM create_tenant_tables(tenant_slug);
+    PERFORM partition_tenant_ledger('tenant_' || tenant_slug);
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- SHARED TABLES (row-level security mode)
+-- =====================================================
+
+-- The table owner is subject to the forced policies, so lift them while the
+-- rows are copied; the legacy tables are dropped afterwards
+ALTER TABLE shared.transactions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE shared.transaction_lines NO FORCE ROW LEVEL SECURITY;
+
+CREATE TABLE shared.transaction_idempotency_keys (
+    tenant_id UUID NOT NULL DEFAULT current_tenant_id() REFERENCES tenants(id) ON DELETE CASCADE,
+    idempotency_key TEXT NOT NULL,
+    transaction_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (tenant_id, idempotency_key)
+);
+
+INSERT INTO shared.transaction_idempotency_keys (tenant_id, idempotency_key, transaction_id, created_at)
+SELECT tenant_id, idempotency_key, id, COALESCE(created_at, NOW())
+FROM shared.transactions;
+
+SELECT partition_table_by_month('shared', 'transaction_lines', 'id, created_at');
+SELECT partition_table_by_month('shared', 'transactions', 'id, created_at');
+
+ALTER TABLE shared.transactions ADD FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE shared.transaction_lines ADD FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE shared.transaction_lines ADD FOREIGN KEY (tenant_id, account_id) REFERENCES shared.accounts(tenant_id, id);
+
+CREATE TRIGGER record_idempotency_key AFTER INSERT ON shared.transactions
+    FOR EACH ROW EXECUTE FUNCTION public.record_idempotency_key();
+
+CREATE INDEX idx_shared_transactions_idempotency_key ON shared.transactions(tenant_id, idempotency_key);
+CREATE INDEX idx_shared_transactions_status ON shared.transactions(tenant_id, status);
+CREATE INDEX idx_shared_transactions_posted_at ON shared.transactions(tenant_id, posted_at);
+CREATE INDEX idx_shared_transaction_lines_account ON shared.transaction_lines(tenant_id, account_id);
+CREATE INDEX idx_shared_transaction_lines_transaction ON shared.transaction_lines(tenant_id, transaction_id);
+
+-- Policies on the partitioned parents apply to every partition queried
+-- through them; the application never addresses partitions directly
+ALTER TABLE shared.transactions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shared.transactions FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON shared.transactions
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+ALTER TABLE shared.transaction_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shared.transaction_lines FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON shared.transaction_lines
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+ALTER TABLE shared.transaction_idempotency_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shared.transaction_idempotency_keys FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON shared.transaction_idempotency_keys
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+-- =====================================================
+-- GLOBAL EVENT STORE
+-- =====================================================
+
+-- webhook_deliveries loses its foreign key to events, which would otherwise
+-- need the partition key as well
+SELECT partition_table_by_month('public', 'events', 'event_id, created_at');
+
+ALTER TABLE events ADD FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_events_tenant_created ON events(tenant_id, created_at);
+CREATE INDEX idx_events_aggregate ON events(aggregate_id, event_version);
+CREATE INDEX idx_events_sequence ON events(sequence_number);
+CREATE INDEX idx_events_type ON events(tenant_id, event_type);
+
+-- =====================================================
+-- MOVING TENANTS BETWEEN MODES
+-- =====================================================
+
+-- Copy a schema-mode tenant into the shared tables and drop its schema
+CREATE OR REPLACE FUNCTION move_tenant_to_shared(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+    oldest TIMESTAMPTZ;
+BEGIN
+    -- Lock the tenant so in-flight requests finish and new ones wait for the move
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'schema' THEN
+        RAISE EXCEPTION 'tenant % is not in schema mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+
+    -- Accounts first without parent links, then restore the hierarchy
+    EXECUTE format('
+        INSERT INTO shared.accounts (id, tenant_id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, $1, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM %I.accounts', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE shared.accounts sa SET parent_id = a.parent_id
+        FROM %I.accounts a
+        WHERE sa.id = a.id AND a.parent_id IS NOT NULL', schema_name);
+
+    -- The tenant's history may predate the shared partitions
+    EXECUTE format('SELECT min(created_at) FROM %I.transactions', schema_name) INTO oldest;
+    PERFORM ensure_monthly_partitions('shared', 'transactions', oldest, NOW());
+    EXECUTE format('SELECT min(created_at) FROM %I.transaction_lines', schema_name) INTO oldest;
+    PERFORM ensure_monthly_partitions('shared', 'transaction_lines', oldest, NOW());
+
+    EXECUTE format('
+        INSERT INTO shared.transactions (id, tenant_id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, $1, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM %I.transactions', schema_name) USING tenant_uuid;
+
+    -- Keys of archived transactions have no row to fire the trigger
+    EXECUTE format('
+        INSERT INTO shared.transaction_idempotency_keys (tenant_id, idempotency_key, transaction_id, created_at)
+        SELECT $1, idempotency_key, transaction_id, created_at
+        FROM %I.transaction_idempotency_keys
+        ON CONFLICT DO NOTHING', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.transaction_lines (id, tenant_id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, $1, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM %I.transaction_lines', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balances (account_id, tenant_id, currency, balance, version, updated_at)
+        SELECT account_id, $1, currency, balance, version, updated_at
+        FROM %I.account_balances', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.high_volume_accounts (account_id, tenant_id, slot_count, overdraft_protection, created_at, updated_at)
+        SELECT account_id, $1, slot_count, overdraft_protection, created_at, updated_at
+        FROM %I.high_volume_accounts', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balance_slots (account_id, tenant_id, currency, slot, balance, updated_at)
+        SELECT account_id, $1, currency, slot, balance, updated_at
+        FROM %I.account_balance_slots', schema_name) USING tenant_uuid;
+
+    EXECUTE format('DROP SCHEMA %I CASCADE', schema_name);
+
+    UPDATE tenants SET tenancy_mode = 'rls' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Recreate a dedicated schema for an rls-mode tenant and remove its shared rows
+CREATE OR REPLACE FUNCTION move_tenant_to_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+    oldest TIMESTAMPTZ;
+BEGIN
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'rls' THEN
+        RAISE EXCEPTION 'tenant % is not in rls mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+    PERFORM create_tenant_schema(tenant_slug);
+
+    EXECUTE format('
+        INSERT INTO %I.accounts (id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM shared.accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE %I.accounts a SET parent_id = sa.parent_id
+        FROM shared.accounts sa
+        WHERE a.id = sa.id AND sa.tenant_id = $1 AND sa.parent_id IS NOT NULL', schema_name) USING tenant_uuid;
+
+    -- The new schema only has partitions from the current month on
+    SELECT min(created_at) INTO oldest FROM shared.transactions WHERE tenant_id = tenant_uuid;
+    PERFORM ensure_monthly_partitions(schema_name, 'transactions', oldest, NOW());
+    SELECT min(created_at) INTO oldest FROM shared.transaction_lines WHERE tenant_id = tenant_uuid;
+    PERFORM ensure_monthly_partitions(schema_name, 'transaction_lines', oldest, NOW());
+
+    EXECUTE format('
+        INSERT INTO %I.transactions (id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM shared.transactions WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    -- Keys of archived transactions have no row to fire the trigger
+    EXECUTE format('
+        INSERT INTO %I.transaction_idempotency_keys (idempotency_key, transaction_id, created_at)
+        SELECT idempotency_key, transaction_id, created_at
+        FROM shared.transaction_idempotency_keys WHERE tenant_id = $1
+        ON CONFLICT DO NOTHING', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.transaction_lines (id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM shared.transaction_lines WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balances (account_id, currency, balance, version, updated_at)
+        SELECT account_id, currency, balance, version, updated_at
+        FROM shared.account_balances WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.high_volume_accounts (account_id, slot_count, overdraft_protection, created_at, updated_at)
+        SELECT account_id, slot_count, overdraft_protection, created_at, updated_at
+        FROM shared.high_volume_accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balance_slots (account_id, currency, slot, balance, updated_at)
+        SELECT account_id, currency, slot, balance, updated_at
+        FROM shared.account_balance_slots WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    DELETE FROM shared.account_balance_slots WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.high_volume_accounts WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transaction_lines WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.account_balances WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transaction_idempotency_keys WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transactions WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.accounts WHERE tenant_id = tenant_uuid;
+
+    UPDATE tenants SET tenancy_mode = 'schema' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,8 @@
+-- migrations/20261018120000_add_event_outbox.down.sql
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_event_url;
+
+DROP TABLE IF EXISTS event_consumers;
+
+DROP INDEX IF EXISTS idx_events_outbox;
+ALTER TABLE events DROP COLUMN IF EXISTS tx_id;
@@ -0,0 +1,40 @@
+-- migrations/20261018120000_add_event_outbox.up.sql
+
+-- The events table doubles as a transactional outbox: consumers tail it with a
+-- persisted cursor and fan events out (e.g. to webhook deliveries) in the same
+-- transaction that advances the cursor.
+--
+-- sequence_number alone is not a safe cursor: values are handed out before
+-- commit, so a lower number can become visible after a higher one has been
+-- consumed. tx_id records the writing transaction; consumers only read events
+-- from transactions older than every running one and order by
+-- (tx_id, sequence_number), which never goes backwards.
+ALTER TABLE events ADD COLUMN tx_id XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX idx_events_outbox ON events(tx_id, sequence_number);
+
+CREATE TABLE event_consumers (
+    name TEXT PRIMARY KEY,
+    last_tx_id XID8 NOT NULL DEFAULT '0',
+    last_sequence BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_event_consumers_updated_at
+    BEFORE UPDATE ON event_consumers
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Webhooks start from the current head instead of replaying history; every
+-- existing event was stamped with this migration's transaction id above
+INSERT INTO event_consumers (name, last_tx_id, last_sequence)
+SELECT 'webhooks', pg_current_xact_id(), COALESCE(MAX(sequence_number), 0)
+FROM events;
+
+-- An event is queued at most once per webhook URL
+DELETE FROM webhook_deliveries a
+USING webhook_deliveries b
+WHERE a.event_id = b.event_id
+AND a.webhook_url = b.webhook_url
+AND a.ctid > b.ctid;
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_event_url ON webhook_deliveries(event_id, webhook_url);
@@ -0,0 +1,40 @@
+-- migrations/20261018130000_add_webhook_endpoints.down.sql
+
+ALTER TABLE webhook_deliveries ADD COLUMN webhook_url TEXT;
+
+UPDATE webhook_deliveries wd
+SET webhook_url = we.url
+FROM webhook_endpoints we
+WHERE we.id = wd.endpoint_id;
+
+ALTER TABLE webhook_deliveries ALTER COLUMN webhook_url SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_endpoint;
+DROP INDEX IF EXISTS idx_webhook_deliveries_event_endpoint;
+ALTER TABLE webhook_deliveries DROP COLUMN endpoint_id;
+
+-- Endpoints sharing a URL collapse into one delivery per event
+DELETE FROM webhook_deliveries a
+USING webhook_deliveries b
+WHERE a.event_id = b.event_id
+AND a.webhook_url = b.webhook_url
+AND a.ctid > b.ctid;
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_event_url ON webhook_deliveries(event_id, webhook_url);
+
+-- Metadata holds a single receiver per tenant; the oldest endpoint wins
+UPDATE tenants t
+SET metadata = COALESCE(t.metadata, '{}'::jsonb) || jsonb_build_object(
+    'webhook_url', we.url,
+    'webhook_secret', we.secret,
+    'webhook_events', to_jsonb(we.event_types),
+    'webhook_enabled', we.enabled
+)
+FROM (
+    SELECT DISTINCT ON (tenant_id) *
+    FROM webhook_endpoints
+    ORDER BY tenant_id, created_at
+) we
+WHERE we.tenant_id = t.id;
+
+DROP TABLE IF EXISTS webhook_endpoints;
@@ -0,0 +1,62 @@
+-- migrations/20261018130000_add_webhook_endpoints.up.sql
+
+-- Tenants can register several webhook receivers, each with its own secret
+-- and event subscriptions. event_types holds exact types ("transaction.posted"),
+-- prefix wildcards ("transaction.*") or "*" for everything.
+CREATE TABLE webhook_endpoints (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    disabled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_endpoints_tenant ON webhook_endpoints(tenant_id);
+
+CREATE TRIGGER update_webhook_endpoints_updated_at
+    BEFORE UPDATE ON webhook_endpoints
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Carry over the single endpoint previously kept in tenant metadata
+INSERT INTO webhook_endpoints (tenant_id, url, secret, event_types, enabled)
+SELECT
+    id,
+    metadata->>'webhook_url',
+    metadata->>'webhook_secret',
+    CASE
+        WHEN jsonb_typeof(metadata->'webhook_events') = 'array'
+            THEN ARRAY(SELECT jsonb_array_elements_text(metadata->'webhook_events'))
+        ELSE ARRAY['*']
+    END,
+    COALESCE((metadata->>'webhook_enabled')::BOOLEAN, TRUE)
+FROM tenants
+WHERE COALESCE(metadata->>'webhook_url', '') <> ''
+AND COALESCE(metadata->>'webhook_secret', '') <> '';
+
+UPDATE tenants
+SET metadata = metadata - 'webhook_url' - 'webhook_secret' - 'webhook_events' - 'webhook_enabled'
+WHERE metadata ?| ARRAY['webhook_url', 'webhook_secret', 'webhook_events', 'webhook_enabled'];
+
+-- Deliveries point at their endpoint instead of copying its URL
+ALTER TABLE webhook_deliveries ADD COLUMN endpoint_id UUID REFERENCES webhook_endpoints(id) ON DELETE CASCADE;
+
+UPDATE webhook_deliveries wd
+SET endpoint_id = we.id
+FROM webhook_endpoints we
+WHERE we.tenant_id = wd.tenant_id
+AND we.url = wd.webhook_url;
+
+DELETE FROM webhook_deliveries WHERE endpoint_id IS NULL;
+
+ALTER TABLE webhook_deliveries ALTER COLUMN endpoint_id SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_event_url;
+ALTER TABLE webhook_deliveries DROP COLUMN webhook_url;
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_event_endpoint ON webhook_deliveries(event_id, endpoint_id);
+CREATE INDEX idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id);
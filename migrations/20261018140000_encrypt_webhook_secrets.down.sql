@@ -0,0 +1,17 @@
+-- migrations/20261018140000_encrypt_webhook_secrets.down.sql
+
+-- Encrypted secrets cannot be decrypted here; only secrets the service has
+-- not yet encrypted are restored and every other endpoint gets an empty
+-- secret that must be replaced by rotating it
+ALTER TABLE webhook_endpoints ADD COLUMN secret TEXT NOT NULL DEFAULT '';
+
+UPDATE webhook_endpoints we
+SET secret = convert_from(s.encrypted_secret, 'UTF8')
+FROM webhook_endpoint_secrets s
+WHERE s.endpoint_id = we.id
+AND s.key_version = 0
+AND s.expires_at IS NULL;
+
+ALTER TABLE webhook_endpoints ALTER COLUMN secret DROP DEFAULT;
+
+DROP TABLE IF EXISTS webhook_endpoint_secrets;
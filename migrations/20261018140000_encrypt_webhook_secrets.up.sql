@@ -0,0 +1,27 @@
+-- migrations/20261018140000_encrypt_webhook_secrets.up.sql
+
+-- Webhook signing secrets, envelope-encrypted: encrypted_secret is sealed
+-- with a per-secret data key, and encrypted_key is that data key sealed with
+-- the server master key identified by key_version. Rotating an endpoint's
+-- secret gives the old row an expires_at, so both sign until it passes.
+CREATE TABLE webhook_endpoint_secrets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    encrypted_secret BYTEA NOT NULL,
+    encrypted_key BYTEA,
+    key_version INTEGER NOT NULL,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_endpoint_secrets_endpoint ON webhook_endpoint_secrets(endpoint_id, created_at);
+CREATE INDEX idx_webhook_endpoint_secrets_key_version ON webhook_endpoint_secrets(key_version);
+
+-- The database cannot encrypt with the application's master key, so existing
+-- plaintext secrets move over as key_version 0 and the service encrypts them
+-- on startup before any delivery is made
+INSERT INTO webhook_endpoint_secrets (endpoint_id, encrypted_secret, key_version)
+SELECT id, convert_to(secret, 'UTF8'), 0
+FROM webhook_endpoints;
+
+ALTER TABLE webhook_endpoints DROP COLUMN secret;
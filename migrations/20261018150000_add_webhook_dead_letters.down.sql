@@ -0,0 +1,6 @@
+-- migrations/20261018150000_add_webhook_dead_letters.down.sql
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_dead_letters;
+
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS dead_lettered_at;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS event_type;
@@ -0,0 +1,22 @@
+-- migrations/20261018150000_add_webhook_dead_letters.up.sql
+
+-- Deliveries that exhaust their retry schedule are dead-lettered instead of
+-- silently sitting with failed_at set, and can be redelivered in bulk.
+-- event_type is copied onto the delivery so dead letters can be filtered by
+-- it without reaching into the (partitioned, archivable) events table.
+ALTER TABLE webhook_deliveries ADD COLUMN event_type VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE webhook_deliveries ADD COLUMN dead_lettered_at TIMESTAMPTZ;
+
+UPDATE webhook_deliveries d
+SET event_type = e.event_type
+FROM events e
+WHERE e.event_id = d.event_id;
+
+UPDATE webhook_deliveries
+SET dead_lettered_at = failed_at
+WHERE failed_at IS NOT NULL
+AND delivered_at IS NULL
+AND next_retry_at IS NULL;
+
+CREATE INDEX idx_webhook_deliveries_dead_letters ON webhook_deliveries(tenant_id, dead_lettered_at)
+    WHERE dead_lettered_at IS NOT NULL;
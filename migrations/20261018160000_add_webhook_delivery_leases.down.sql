@@ -0,0 +1,11 @@
+-- migrations/20261018160000_add_webhook_delivery_leases.down.sql
+
+DROP TRIGGER IF EXISTS notify_webhook_deliveries ON webhook_deliveries;
+DROP FUNCTION IF EXISTS notify_webhook_deliveries();
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+CREATE INDEX idx_webhook_deliveries_retry ON webhook_deliveries(next_retry_at)
+    WHERE next_retry_at IS NOT NULL;
+
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS locked_by;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS locked_until;
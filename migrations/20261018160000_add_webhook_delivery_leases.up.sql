@@ -0,0 +1,27 @@
+-- migrations/20261018160000_add_webhook_delivery_leases.up.sql
+
+-- Workers claim due deliveries by leasing them until locked_until, so several
+-- replicas can send concurrently without sending the same delivery twice. A
+-- worker that dies mid-send loses its lease and the delivery is picked up
+-- again once it expires.
+ALTER TABLE webhook_deliveries ADD COLUMN locked_until TIMESTAMPTZ;
+ALTER TABLE webhook_deliveries ADD COLUMN locked_by TEXT;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_retry;
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_retry_at)
+    WHERE next_retry_at IS NOT NULL AND delivered_at IS NULL;
+
+-- Wake idle workers as soon as a delivery becomes due instead of waiting for
+-- their next poll. Notifications are sent on commit and collapsed per
+-- transaction, so a batch of deliveries raises a single one.
+CREATE OR REPLACE FUNCTION notify_webhook_deliveries()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('webhook_deliveries', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER notify_webhook_deliveries
+    AFTER INSERT OR UPDATE OF next_retry_at ON webhook_deliveries
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_webhook_deliveries();
@@ -0,0 +1,3 @@
+-- migrations/20261018170000_add_webhook_endpoint_health.down.sql
+
+DROP TABLE IF EXISTS webhook_endpoint_health;
@@ -0,0 +1,23 @@
+-- migrations/20261018170000_add_webhook_endpoint_health.up.sql
+
+-- Per-endpoint circuit breaker, shared by every worker replica. After enough
+-- consecutive failures the circuit opens and the endpoint's deliveries are
+-- held back until retry_at, when a single half-open probe is let through.
+-- An endpoint failing for long enough is disabled automatically.
+CREATE TABLE webhook_endpoint_health (
+    endpoint_id UUID PRIMARY KEY REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    circuit_state VARCHAR(20) NOT NULL DEFAULT 'closed'
+        CHECK (circuit_state IN ('closed', 'open', 'half_open')),
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    failing_since TIMESTAMPTZ,
+    last_success_at TIMESTAMPTZ,
+    last_failure_at TIMESTAMPTZ,
+    opened_at TIMESTAMPTZ,
+    retry_at TIMESTAMPTZ,
+    probe_started_at TIMESTAMPTZ,
+    auto_disabled_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_endpoint_health_unhealthy ON webhook_endpoint_health(endpoint_id)
+    WHERE circuit_state <> 'closed';
@@ -0,0 +1,3 @@
+-- migrations/20261018180000_add_webhook_delivery_attempts.down.sql
+
+DROP TABLE IF EXISTS webhook_delivery_attempts;
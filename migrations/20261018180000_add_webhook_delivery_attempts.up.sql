@@ -0,0 +1,23 @@
+-- migrations/20261018180000_add_webhook_delivery_attempts.up.sql
+
+-- One row per HTTP attempt of a webhook delivery. webhook_deliveries only
+-- keeps the latest outcome; this keeps every one, with enough of the request
+-- and response to see what happened. Rows are pruned after a retention period.
+CREATE TABLE webhook_delivery_attempts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempt_number INTEGER NOT NULL,
+    request_url TEXT NOT NULL,
+    request_headers JSONB NOT NULL DEFAULT '{}',
+    payload_sha256 VARCHAR(64) NOT NULL,
+    response_status INTEGER,
+    response_body TEXT,
+    response_truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    latency_ms INTEGER NOT NULL,
+    error_class VARCHAR(50),
+    error_message TEXT,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id, attempt_number);
+CREATE INDEX idx_webhook_delivery_attempts_attempted_at ON webhook_delivery_attempts(attempted_at);
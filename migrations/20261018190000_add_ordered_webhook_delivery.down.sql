@@ -0,0 +1,10 @@
+-- migrations/20261018190000_add_ordered_webhook_delivery.down.sql
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_aggregate_pending;
+
+ALTER TABLE webhook_deliveries
+    DROP COLUMN IF EXISTS ordered,
+    DROP COLUMN IF EXISTS sequence_number,
+    DROP COLUMN IF EXISTS aggregate_id;
+
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS ordered;
@@ -0,0 +1,22 @@
+-- migrations/20261018190000_add_ordered_webhook_delivery.up.sql
+
+-- Endpoints in ordered mode receive each aggregate's events strictly in
+-- sequence_number order: a delivery is not sent while an earlier delivery of
+-- the same aggregate to the same endpoint is still undelivered, including one
+-- that has been dead-lettered. Deliveries record the mode they were queued in.
+ALTER TABLE webhook_endpoints ADD COLUMN ordered BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE webhook_deliveries
+    ADD COLUMN aggregate_id UUID,
+    ADD COLUMN sequence_number BIGINT,
+    ADD COLUMN ordered BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE webhook_deliveries wd
+SET aggregate_id = e.aggregate_id,
+    sequence_number = e.sequence_number
+FROM events e
+WHERE e.event_id = wd.event_id;
+
+CREATE INDEX idx_webhook_deliveries_aggregate_pending
+    ON webhook_deliveries(endpoint_id, aggregate_id, sequence_number)
+    WHERE delivered_at IS NULL;
@@ -0,0 +1,5 @@
+-- migrations/20261018200000_add_event_notifications.down.sql
+
+DROP INDEX IF EXISTS idx_events_tenant_outbox;
+DROP TRIGGER IF EXISTS notify_events ON events;
+DROP FUNCTION IF EXISTS notify_events();
@@ -0,0 +1,20 @@
+-- migrations/20261018200000_add_event_notifications.up.sql
+
+-- Push new events to live tenant event streams. The payload is the tenant ID
+-- so each server only wakes the streams of that tenant; notifications are
+-- sent on commit and identical ones are collapsed per transaction, so a
+-- transaction posting many events for a tenant raises a single one.
+CREATE OR REPLACE FUNCTION notify_events()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('events', NEW.tenant_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER notify_events
+    AFTER INSERT ON events
+    FOR EACH ROW EXECUTE FUNCTION notify_events();
+
+-- Streams read a tenant's events in outbox order
+CREATE INDEX idx_events_tenant_outbox ON events(tenant_id, tx_id, sequence_number);
@@ -0,0 +1,3 @@
+-- migrations/20261018210000_add_event_history_index.down.sql
+
+DROP INDEX IF EXISTS idx_events_tenant_sequence;
@@ -0,0 +1,4 @@
+-- migrations/20261018210000_add_event_history_index.up.sql
+
+-- The event API pages through a tenant's history in sequence order
+CREATE INDEX idx_events_tenant_sequence ON events(tenant_id, sequence_number);
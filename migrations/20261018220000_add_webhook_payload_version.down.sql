@@ -0,0 +1,3 @@
+-- migrations/20261018220000_add_webhook_payload_version.down.sql
+
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS payload_version;
@@ -0,0 +1,6 @@
+-- migrations/20261018220000_add_webhook_payload_version.up.sql
+
+-- Each endpoint receives event payloads in the schemas of the payload version
+-- it is pinned to, so a new payload version never changes what existing
+-- endpoints are sent. Endpoints created before versioning get version 1.
+ALTER TABLE webhook_endpoints ADD COLUMN payload_version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+-- migrations/20261018230000_add_webhook_payload_format.down.sql
+
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS payload_format;
@@ -0,0 +1,7 @@
+-- migrations/20261018230000_add_webhook_payload_format.up.sql
+
+-- The format webhook requests are sent in: the service's own payload, or a
+-- CloudEvents 1.0 event in structured or binary content mode
+ALTER TABLE webhook_endpoints
+    ADD COLUMN payload_format VARCHAR(32) NOT NULL DEFAULT 'ledger'
+    CHECK (payload_format IN ('ledger', 'cloudevents_structured', 'cloudevents_binary'));
@@ -0,0 +1,7 @@
+-- migrations/20261019000000_add_event_aggregate_versions.down.sql
+
+DROP INDEX IF EXISTS idx_events_balance_account;
+DROP TRIGGER IF EXISTS record_event_aggregate_version ON events;
+DROP FUNCTION IF EXISTS record_event_aggregate_version();
+DROP TABLE IF EXISTS event_aggregate_versions;
+ALTER TABLE events DROP COLUMN IF EXISTS aggregate_version;
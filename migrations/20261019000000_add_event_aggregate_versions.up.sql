@@ -0,0 +1,46 @@
+-- migrations/20261019000000_add_event_aggregate_versions.up.sql
+
+-- aggregate_version is the version of the aggregate an event leaves behind,
+-- numbered from 1 without gaps per aggregate. event_version stays the schema
+-- version of the payload. Events of aggregates that are not versioned, and
+-- those written before this migration, have none. Balance events of postings
+-- to high-volume slots have none either: the slots are not ordered against
+-- each other, so those changes can't be detected from the versions.
+ALTER TABLE events ADD COLUMN aggregate_version BIGINT;
+
+-- Unique constraints on the partitioned events table must include the
+-- partition key, so versions are kept unique in a side table filled by
+-- trigger, like idempotency keys. It is never archived, so a version stays
+-- taken after its month is.
+CREATE TABLE event_aggregate_versions (
+    aggregate_id UUID NOT NULL,
+    version BIGINT NOT NULL,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (aggregate_id, version)
+);
+
+CREATE OR REPLACE FUNCTION record_event_aggregate_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO event_aggregate_versions (aggregate_id, version, tenant_id, event_id, created_at)
+    VALUES (NEW.aggregate_id, NEW.aggregate_version, NEW.tenant_id, NEW.event_id, NEW.created_at);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_event_aggregate_version
+    AFTER INSERT ON events
+    FOR EACH ROW
+    WHEN (NEW.aggregate_version IS NOT NULL)
+    EXECUTE FUNCTION record_event_aggregate_version();
+
+CREATE INDEX idx_event_aggregate_versions_tenant ON event_aggregate_versions(tenant_id);
+
+-- Balance events are their own aggregates, one per account and currency, so
+-- an account's history finds them by the account in their payload
+CREATE INDEX idx_events_balance_account
+    ON events(tenant_id, (event_data->>'account_id'), sequence_number)
+    WHERE aggregate_type = 'balance';
@@ -0,0 +1,11 @@
+-- migrations/20261019010000_add_webhook_replays.down.sql
+
+DELETE FROM webhook_deliveries WHERE replay_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_replay;
+DROP INDEX IF EXISTS idx_webhook_deliveries_event_endpoint;
+CREATE UNIQUE INDEX idx_webhook_deliveries_event_endpoint ON webhook_deliveries(event_id, endpoint_id);
+
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS replay_id;
+
+DROP TABLE IF EXISTS webhook_replays;
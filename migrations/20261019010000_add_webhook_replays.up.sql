@@ -0,0 +1,48 @@
+-- migrations/20261019010000_add_webhook_replays.up.sql
+
+-- A replay resends a tenant's past events to its webhook endpoints. It runs
+-- as a background job that walks the tenant's events in sequence order from
+-- a cursor (last_sequence), queuing at most rate_limit events per second as
+-- ordinary deliveries tagged with the replay. to_sequence is fixed when the
+-- replay is created, so events that arrive later are only sent live.
+CREATE TABLE webhook_replays (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    endpoint_id UUID REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    from_sequence BIGINT,
+    to_sequence BIGINT NOT NULL,
+    created_from TIMESTAMPTZ,
+    created_to TIMESTAMPTZ,
+    rate_limit INTEGER NOT NULL CHECK (rate_limit > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'running', 'completed', 'cancelled')),
+    total_events BIGINT NOT NULL DEFAULT 0,
+    events_processed BIGINT NOT NULL DEFAULT 0,
+    deliveries_queued BIGINT NOT NULL DEFAULT 0,
+    last_sequence BIGINT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    locked_by TEXT,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_replays_tenant ON webhook_replays(tenant_id, created_at);
+CREATE INDEX idx_webhook_replays_active ON webhook_replays(created_at)
+    WHERE status IN ('pending', 'running');
+
+CREATE TRIGGER update_webhook_replays_updated_at
+    BEFORE UPDATE ON webhook_replays
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Replayed deliveries are queued alongside the live one of the same event,
+-- so an event is queued at most once per endpoint live and once per replay
+ALTER TABLE webhook_deliveries ADD COLUMN replay_id UUID REFERENCES webhook_replays(id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_event_endpoint;
+CREATE UNIQUE INDEX idx_webhook_deliveries_event_endpoint
+    ON webhook_deliveries(event_id, endpoint_id) WHERE replay_id IS NULL;
+CREATE UNIQUE INDEX idx_webhook_deliveries_replay
+    ON webhook_deliveries(replay_id, event_id, endpoint_id) WHERE replay_id IS NOT NULL;
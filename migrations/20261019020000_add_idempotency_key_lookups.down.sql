@@ -0,0 +1,28 @@
+-- migrations/20261019020000_add_idempotency_key_lookups.down.sql
+
+DROP INDEX IF EXISTS shared.idx_shared_idempotency_keys_transaction;
+
+CREATE OR REPLACE FUNCTION create_tenant_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+BEGIN
+    PERFORM create_tenant_tables(tenant_slug);
+    PERFORM partition_tenant_ledger('tenant_' || tenant_slug);
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+DECLARE
+    schema_name TEXT;
+BEGIN
+    FOR schema_name IN
+        SELECT nspname FROM pg_namespace
+        WHERE nspname LIKE 'tenant_%'
+    LOOP
+        EXECUTE format('DROP INDEX IF EXISTS %I.%I',
+                       schema_name, 'idx_' || replace(schema_name, '-', '_') || '_idempotency_keys_transaction');
+    END LOOP;
+END
+$$;
+
+DROP FUNCTION IF EXISTS index_idempotency_key_transactions(TEXT);
+DROP TABLE IF EXISTS transaction_idempotency_keys;
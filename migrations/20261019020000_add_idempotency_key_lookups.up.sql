@@ -0,0 +1,59 @@
+-- migrations/20261019020000_add_idempotency_key_lookups.up.sql
+
+-- The idempotency key tables outlive the archived months of their
+-- transactions, so reads that miss a transaction look there to tell one that
+-- was archived from one that never existed: by key for retried postings, by
+-- transaction id for lookups.
+
+-- =====================================================
+-- TEMPLATE TABLES (sqlc only, see create_template_tables)
+-- =====================================================
+
+CREATE TABLE IF NOT EXISTS transaction_idempotency_keys (
+    idempotency_key TEXT PRIMARY KEY,
+    transaction_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE transaction_idempotency_keys IS 'Template table for sqlc generation - actual data is in tenant schemas';
+
+-- =====================================================
+-- TENANT SCHEMAS
+-- =====================================================
+
+CREATE OR REPLACE FUNCTION index_idempotency_key_transactions(schema_name TEXT)
+RETURNS VOID AS $$
+BEGIN
+    EXECUTE format('CREATE INDEX IF NOT EXISTS %I ON %I.transaction_idempotency_keys(transaction_id)',
+                   'idx_' || replace(schema_name, '-', '_') || '_idempotency_keys_transaction', schema_name);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Existing tenant schemas
+DO $$
+DECLARE
+    schema_name TEXT;
+BEGIN
+    FOR schema_name IN
+        SELECT nspname FROM pg_namespace
+        WHERE nspname LIKE 'tenant_%'
+    LOOP
+        PERFORM index_idempotency_key_transactions(schema_name);
+    END LOOP;
+END
+$$;
+
+CREATE OR REPLACE FUNCTION create_tenant_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+BEGIN
+    PERFORM create_tenant_tables(tenant_slug);
+    PERFORM partition_tenant_ledger('tenant_' || tenant_slug);
+    PERFORM index_idempotency_key_transactions('tenant_' || tenant_slug);
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- SHARED TABLES (row-level security mode)
+-- =====================================================
+
+CREATE INDEX idx_shared_idempotency_keys_transaction ON shared.transaction_idempotency_keys(tenant_id, transaction_id);
@@ -0,0 +1,13 @@
+-- migrations/20261019030000_scope_shared_balance_totals.down.sql
+
+CREATE OR REPLACE VIEW shared.account_balance_totals AS
+SELECT
+    ab.account_id,
+    ab.currency,
+    ab.balance + COALESCE((
+        SELECT SUM(s.balance) FROM shared.account_balance_slots s
+        WHERE s.account_id = ab.account_id AND s.currency = ab.currency
+    ), 0) AS balance,
+    ab.version,
+    ab.updated_at
+FROM shared.account_balances ab;
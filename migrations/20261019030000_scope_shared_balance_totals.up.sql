@@ -0,0 +1,18 @@
+-- migrations/20261019030000_scope_shared_balance_totals.up.sql
+
+-- A plain view reads its tables with the rights of its owner, and row-level
+-- security does not apply to an owner that is a superuser or has BYPASSRLS
+-- (usually the role that ran the migrations). Filter on the current tenant in
+-- the view itself so it never depends on who owns it.
+CREATE OR REPLACE VIEW shared.account_balance_totals AS
+SELECT
+    ab.account_id,
+    ab.currency,
+    ab.balance + COALESCE((
+        SELECT SUM(s.balance) FROM shared.account_balance_slots s
+        WHERE s.account_id = ab.account_id AND s.currency = ab.currency
+    ), 0) AS balance,
+    ab.version,
+    ab.updated_at
+FROM shared.account_balances ab
+WHERE ab.tenant_id = current_tenant_id();
@@ -0,0 +1,197 @@
+-- migrations/20261019040000_move_archived_partitions_with_tenants.down.sql
+
+-- Records carried along with moved tenants can't be told apart without
+-- tenant_id; the schema-wide ones are kept.
+-- Copy a schema-mode tenant into the shared tables and drop its schema
+CREATE OR REPLACE FUNCTION move_tenant_to_shared(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+    oldest TIMESTAMPTZ;
+BEGIN
+    -- Lock the tenant so in-flight requests finish and new ones wait for the move
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'schema' THEN
+        RAISE EXCEPTION 'tenant % is not in schema mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+
+    -- Accounts first without parent links, then restore the hierarchy
+    EXECUTE format('
+        INSERT INTO shared.accounts (id, tenant_id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, $1, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM %I.accounts', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE shared.accounts sa SET parent_id = a.parent_id
+        FROM %I.accounts a
+        WHERE sa.id = a.id AND a.parent_id IS NOT NULL', schema_name);
+
+    -- The tenant's history may predate the shared partitions
+    EXECUTE format('SELECT min(created_at) FROM %I.transactions', schema_name) INTO oldest;
+    PERFORM ensure_monthly_partitions('shared', 'transactions', oldest, NOW());
+    EXECUTE format('SELECT min(created_at) FROM %I.transaction_lines', schema_name) INTO oldest;
+    PERFORM ensure_monthly_partitions('shared', 'transaction_lines', oldest, NOW());
+
+    EXECUTE format('
+        INSERT INTO shared.transactions (id, tenant_id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, $1, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM %I.transactions', schema_name) USING tenant_uuid;
+
+    -- Keys of archived transactions have no row to fire the trigger
+    EXECUTE format('
+        INSERT INTO shared.transaction_idempotency_keys (tenant_id, idempotency_key, transaction_id, created_at)
+        SELECT $1, idempotency_key, transaction_id, created_at
+        FROM %I.transaction_idempotency_keys
+        ON CONFLICT DO NOTHING', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.transaction_lines (id, tenant_id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, $1, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM %I.transaction_lines', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balances (account_id, tenant_id, currency, balance, version, updated_at)
+        SELECT account_id, $1, currency, balance, version, updated_at
+        FROM %I.account_balances', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.high_volume_accounts (account_id, tenant_id, slot_count, overdraft_protection, created_at, updated_at)
+        SELECT account_id, $1, slot_count, overdraft_protection, created_at, updated_at
+        FROM %I.high_volume_accounts', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balance_slots (account_id, tenant_id, currency, slot, balance, updated_at)
+        SELECT account_id, $1, currency, slot, balance, updated_at
+        FROM %I.account_balance_slots', schema_name) USING tenant_uuid;
+
+    EXECUTE format('DROP SCHEMA %I CASCADE', schema_name);
+
+    UPDATE tenants SET tenancy_mode = 'rls' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Recreate a dedicated schema for an rls-mode tenant and remove its shared rows
+CREATE OR REPLACE FUNCTION move_tenant_to_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+    oldest TIMESTAMPTZ;
+BEGIN
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'rls' THEN
+        RAISE EXCEPTION 'tenant % is not in rls mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+    PERFORM create_tenant_schema(tenant_slug);
+
+    EXECUTE format('
+        INSERT INTO %I.accounts (id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM shared.accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE %I.accounts a SET parent_id = sa.parent_id
+        FROM shared.accounts sa
+        WHERE a.id = sa.id AND sa.tenant_id = $1 AND sa.parent_id IS NOT NULL', schema_name) USING tenant_uuid;
+
+    -- The new schema only has partitions from the current month on
+    SELECT min(created_at) INTO oldest FROM shared.transactions WHERE tenant_id = tenant_uuid;
+    PERFORM ensure_monthly_partitions(schema_name, 'transactions', oldest, NOW());
+    SELECT min(created_at) INTO oldest FROM shared.transaction_lines WHERE tenant_id = tenant_uuid;
+    PERFORM ensure_monthly_partitions(schema_name, 'transaction_lines', oldest, NOW());
+
+    EXECUTE format('
+        INSERT INTO %I.transactions (id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM shared.transactions WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    -- Keys of archived transactions have no row to fire the trigger
+    EXECUTE format('
+        INSERT INTO %I.transaction_idempotency_keys (idempotency_key, transaction_id, created_at)
+        SELECT idempotency_key, transaction_id, created_at
+        FROM shared.transaction_idempotency_keys WHERE tenant_id = $1
+        ON CONFLICT DO NOTHING', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.transaction_lines (id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM shared.transaction_lines WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balances (account_id, currency, balance, version, updated_at)
+        SELECT account_id, currency, balance, version, updated_at
+        FROM shared.account_balances WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.high_volume_accounts (account_id, slot_count, overdraft_protection, created_at, updated_at)
+        SELECT account_id, slot_count, overdraft_protection, created_at, updated_at
+        FROM shared.high_volume_accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balance_slots (account_id, currency, slot, balance, updated_at)
+        SELECT account_id, currency, slot, balance, updated_at
+        FROM shared.account_balance_slots WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    DELETE FROM shared.account_balance_slots WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.high_volume_accounts WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transaction_lines WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.account_balances WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transaction_idempotency_keys WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transactions WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.accounts WHERE tenant_id = tenant_uuid;
+
+    UPDATE tenants SET tenancy_mode = 'schema' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS ensure_unarchived_partitions(TEXT, TEXT, TIMESTAMPTZ, TIMESTAMPTZ, UUID);
+
+-- Create the partition of parent_schema.parent_table covering the UTC month
+-- containing month_start; a no-op if it already exists
+CREATE OR REPLACE FUNCTION create_monthly_partition(parent_schema TEXT, parent_table TEXT, month_start TIMESTAMPTZ)
+RETURNS VOID AS $$
+DECLARE
+    lower_bound TIMESTAMPTZ := date_trunc('month', month_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    upper_bound TIMESTAMPTZ := (date_trunc('month', month_start AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC';
+    part_name TEXT := monthly_partition_name(parent_table, month_start);
+BEGIN
+    -- Never bring back an archived month: it would look complete but hold
+    -- only the rows written after archival
+    IF EXISTS (
+        SELECT 1 FROM archived_partitions ap
+        WHERE ap.schema_name = parent_schema
+        AND ap.table_name = parent_table
+        AND ap.range_start = lower_bound
+    ) THEN
+        RAISE EXCEPTION 'partition %.% has been archived and cannot be recreated', parent_schema, part_name;
+    END IF;
+
+    EXECUTE format('CREATE TABLE IF NOT EXISTS %I.%I PARTITION OF %I.%I FOR VALUES FROM (%L) TO (%L)',
+                   parent_schema, part_name, parent_schema, parent_table, lower_bound, upper_bound);
+END;
+$$ LANGUAGE plpgsql;
+
+DELETE FROM archived_partitions WHERE tenant_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_archived_partitions_month;
+ALTER TABLE archived_partitions ADD CONSTRAINT archived_partitions_schema_name_table_name_range_start_key
+    UNIQUE (schema_name, table_name, range_start);
+
+ALTER TABLE archived_partitions DROP COLUMN tenant_id;
@@ -0,0 +1,257 @@
+-- migrations/20261019040000_move_archived_partitions_with_tenants.up.sql
+
+-- Archived months are recorded per schema, so a tenant moving between modes
+-- has to take its archived months along or its queries stop reporting them.
+-- In the shared schema one tenant's archived months must not apply to the
+-- others, so records carried there name the tenant. tenant_id is NULL for
+-- months archived from the schema's own partitions, which apply to everyone
+-- in it; only those stop a month from being recreated.
+ALTER TABLE archived_partitions ADD COLUMN tenant_id UUID REFERENCES tenants(id) ON DELETE CASCADE;
+
+ALTER TABLE archived_partitions DROP CONSTRAINT archived_partitions_schema_name_table_name_range_start_key;
+CREATE UNIQUE INDEX idx_archived_partitions_month ON archived_partitions(schema_name, table_name, range_start)
+    WHERE tenant_id IS NULL;
+
+-- Create the partition of parent_schema.parent_table covering the UTC month
+-- containing month_start; a no-op if it already exists
+CREATE OR REPLACE FUNCTION create_monthly_partition(parent_schema TEXT, parent_table TEXT, month_start TIMESTAMPTZ)
+RETURNS VOID AS $$
+DECLARE
+    lower_bound TIMESTAMPTZ := date_trunc('month', month_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    upper_bound TIMESTAMPTZ := (date_trunc('month', month_start AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC';
+    part_name TEXT := monthly_partition_name(parent_table, month_start);
+BEGIN
+    -- Never bring back an archived month: it would look complete but hold
+    -- only the rows written after archival
+    IF EXISTS (
+        SELECT 1 FROM archived_partitions ap
+        WHERE ap.schema_name = parent_schema
+        AND ap.table_name = parent_table
+        AND ap.range_start = lower_bound
+        AND ap.tenant_id IS NULL
+    ) THEN
+        RAISE EXCEPTION 'partition %.% has been archived and cannot be recreated', parent_schema, part_name;
+    END IF;
+
+    EXECUTE format('CREATE TABLE IF NOT EXISTS %I.%I PARTITION OF %I.%I FOR VALUES FROM (%L) TO (%L)',
+                   parent_schema, part_name, parent_schema, parent_table, lower_bound, upper_bound);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Like ensure_monthly_partitions, but skips the months archived for the
+-- tenant instead of failing on them. Rows of a skipped month have nowhere to
+-- go, so moving a tenant that still has rows in it fails on the copy.
+CREATE OR REPLACE FUNCTION ensure_unarchived_partitions(parent_schema TEXT, parent_table TEXT, from_ts TIMESTAMPTZ, to_ts TIMESTAMPTZ, for_tenant UUID)
+RETURNS VOID AS $$
+DECLARE
+    month_start TIMESTAMPTZ;
+BEGIN
+    IF from_ts IS NULL THEN
+        RETURN;
+    END IF;
+
+    month_start := date_trunc('month', from_ts AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+    WHILE month_start <= to_ts LOOP
+        IF NOT EXISTS (
+            SELECT 1 FROM archived_partitions ap
+            WHERE ap.schema_name = parent_schema
+            AND ap.table_name = parent_table
+            AND ap.range_start = month_start
+            AND (ap.tenant_id IS NULL OR ap.tenant_id = for_tenant)
+        ) THEN
+            PERFORM create_monthly_partition(parent_schema, parent_table, month_start);
+        END IF;
+        month_start := ((month_start AT TIME ZONE 'UTC') + INTERVAL '1 month') AT TIME ZONE 'UTC';
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =====================================================
+-- MOVING TENANTS BETWEEN MODES
+-- =====================================================
+
+-- Copy a schema-mode tenant into the shared tables and drop its schema
+CREATE OR REPLACE FUNCTION move_tenant_to_shared(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+    oldest TIMESTAMPTZ;
+BEGIN
+    -- Lock the tenant so in-flight requests finish and new ones wait for the move
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'schema' THEN
+        RAISE EXCEPTION 'tenant % is not in schema mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+
+    -- Accounts first without parent links, then restore the hierarchy
+    EXECUTE format('
+        INSERT INTO shared.accounts (id, tenant_id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, $1, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM %I.accounts', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE shared.accounts sa SET parent_id = a.parent_id
+        FROM %I.accounts a
+        WHERE sa.id = a.id AND a.parent_id IS NOT NULL', schema_name);
+
+    -- The tenant's history may predate the shared partitions
+    EXECUTE format('SELECT min(created_at) FROM %I.transactions', schema_name) INTO oldest;
+    PERFORM ensure_unarchived_partitions('shared', 'transactions', oldest, NOW(), tenant_uuid);
+    EXECUTE format('SELECT min(created_at) FROM %I.transaction_lines', schema_name) INTO oldest;
+    PERFORM ensure_unarchived_partitions('shared', 'transaction_lines', oldest, NOW(), tenant_uuid);
+
+    EXECUTE format('
+        INSERT INTO shared.transactions (id, tenant_id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, $1, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM %I.transactions', schema_name) USING tenant_uuid;
+
+    -- Keys of archived transactions have no row to fire the trigger
+    EXECUTE format('
+        INSERT INTO shared.transaction_idempotency_keys (tenant_id, idempotency_key, transaction_id, created_at)
+        SELECT $1, idempotency_key, transaction_id, created_at
+        FROM %I.transaction_idempotency_keys
+        ON CONFLICT DO NOTHING', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.transaction_lines (id, tenant_id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, $1, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM %I.transaction_lines', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balances (account_id, tenant_id, currency, balance, version, updated_at)
+        SELECT account_id, $1, currency, balance, version, updated_at
+        FROM %I.account_balances', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.high_volume_accounts (account_id, tenant_id, slot_count, overdraft_protection, created_at, updated_at)
+        SELECT account_id, $1, slot_count, overdraft_protection, created_at, updated_at
+        FROM %I.high_volume_accounts', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO shared.account_balance_slots (account_id, tenant_id, currency, slot, balance, updated_at)
+        SELECT account_id, $1, currency, slot, balance, updated_at
+        FROM %I.account_balance_slots', schema_name) USING tenant_uuid;
+
+    -- The schema's archived months now belong to the tenant's rows in the
+    -- shared tables; copies of shared archives it took along are dropped
+    DELETE FROM archived_partitions ap
+    WHERE ap.schema_name = 'tenant_' || tenant_slug
+    AND EXISTS (
+        SELECT 1 FROM archived_partitions s
+        WHERE s.schema_name = 'shared' AND s.tenant_id IS NULL
+        AND s.table_name = ap.table_name
+        AND s.range_start = ap.range_start
+        AND s.location = ap.location
+    );
+    UPDATE archived_partitions
+    SET schema_name = 'shared', tenant_id = tenant_uuid
+    WHERE archived_partitions.schema_name = 'tenant_' || tenant_slug;
+
+    EXECUTE format('DROP SCHEMA %I CASCADE', schema_name);
+
+    UPDATE tenants SET tenancy_mode = 'rls' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Recreate a dedicated schema for an rls-mode tenant and remove its shared rows
+CREATE OR REPLACE FUNCTION move_tenant_to_schema(tenant_slug TEXT)
+RETURNS VOID AS $$
+DECLARE
+    schema_name TEXT := 'tenant_' || tenant_slug;
+    tenant_uuid UUID;
+    current_mode tenancy_mode_enum;
+    oldest TIMESTAMPTZ;
+BEGIN
+    SELECT id, tenancy_mode INTO tenant_uuid, current_mode
+    FROM tenants WHERE slug = tenant_slug
+    FOR UPDATE;
+
+    IF tenant_uuid IS NULL THEN
+        RAISE EXCEPTION 'tenant % not found', tenant_slug;
+    END IF;
+    IF current_mode <> 'rls' THEN
+        RAISE EXCEPTION 'tenant % is not in rls mode', tenant_slug;
+    END IF;
+
+    PERFORM set_config('app.tenant_id', tenant_uuid::text, true);
+    PERFORM create_tenant_schema(tenant_slug);
+
+    EXECUTE format('
+        INSERT INTO %I.accounts (id, code, name, account_type, currency, metadata, is_active, created_at, updated_at)
+        SELECT id, code, name, account_type, currency, metadata, is_active, created_at, updated_at
+        FROM shared.accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+    EXECUTE format('
+        UPDATE %I.accounts a SET parent_id = sa.parent_id
+        FROM shared.accounts sa
+        WHERE a.id = sa.id AND sa.tenant_id = $1 AND sa.parent_id IS NOT NULL', schema_name) USING tenant_uuid;
+
+    -- The tenant's archived months go with it: those archived while it was
+    -- elsewhere, and the shared months archived while it was in rls mode,
+    -- whose files hold its rows as well
+    UPDATE archived_partitions
+    SET schema_name = 'tenant_' || tenant_slug
+    WHERE archived_partitions.schema_name = 'shared'
+    AND archived_partitions.tenant_id = tenant_uuid;
+    INSERT INTO archived_partitions (schema_name, table_name, partition_name, range_start, range_end, row_count, location, archived_at, tenant_id)
+    SELECT 'tenant_' || tenant_slug, ap.table_name, ap.partition_name, ap.range_start, ap.range_end, ap.row_count, ap.location, ap.archived_at, tenant_uuid
+    FROM archived_partitions ap
+    WHERE ap.schema_name = 'shared' AND ap.tenant_id IS NULL;
+
+    -- The new schema only has partitions from the current month on
+    SELECT min(created_at) INTO oldest FROM shared.transactions WHERE tenant_id = tenant_uuid;
+    PERFORM ensure_unarchived_partitions(schema_name, 'transactions', oldest, NOW(), tenant_uuid);
+    SELECT min(created_at) INTO oldest FROM shared.transaction_lines WHERE tenant_id = tenant_uuid;
+    PERFORM ensure_unarchived_partitions(schema_name, 'transaction_lines', oldest, NOW(), tenant_uuid);
+
+    EXECUTE format('
+        INSERT INTO %I.transactions (id, idempotency_key, description, reference, status, posted_at, metadata, created_at)
+        SELECT id, idempotency_key, description, reference, status, posted_at, metadata, created_at
+        FROM shared.transactions WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    -- Keys of archived transactions have no row to fire the trigger
+    EXECUTE format('
+        INSERT INTO %I.transaction_idempotency_keys (idempotency_key, transaction_id, created_at)
+        SELECT idempotency_key, transaction_id, created_at
+        FROM shared.transaction_idempotency_keys WHERE tenant_id = $1
+        ON CONFLICT DO NOTHING', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.transaction_lines (id, transaction_id, account_id, amount, side, currency, metadata, created_at)
+        SELECT id, transaction_id, account_id, amount, side, currency, metadata, created_at
+        FROM shared.transaction_lines WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balances (account_id, currency, balance, version, updated_at)
+        SELECT account_id, currency, balance, version, updated_at
+        FROM shared.account_balances WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.high_volume_accounts (account_id, slot_count, overdraft_protection, created_at, updated_at)
+        SELECT account_id, slot_count, overdraft_protection, created_at, updated_at
+        FROM shared.high_volume_accounts WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    EXECUTE format('
+        INSERT INTO %I.account_balance_slots (account_id, currency, slot, balance, updated_at)
+        SELECT account_id, currency, slot, balance, updated_at
+        FROM shared.account_balance_slots WHERE tenant_id = $1', schema_name) USING tenant_uuid;
+
+    DELETE FROM shared.account_balance_slots WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.high_volume_accounts WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transaction_lines WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.account_balances WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transaction_idempotency_keys WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.transactions WHERE tenant_id = tenant_uuid;
+    DELETE FROM shared.accounts WHERE tenant_id = tenant_uuid;
+
+    UPDATE tenants SET tenancy_mode = 'schema' WHERE id = tenant_uuid;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,164 @@
+// pkg/webhook/signature.go
+
+// Package webhook verifies the signatures on webhooks sent by the ledger
+// service.
+//
+// Every delivery carries an X-Ledger-Signature header of the form
+//
+//	t=<unix seconds>,v1=<hex hmac-sha256>[,v1=<hex hmac-sha256>...]
+//
+// where each v1 is the HMAC-SHA256 of "<t>.<raw body>" under one of the
+// endpoint's signing secrets. Several v1 values are sent while a secret is
+// being rotated; a delivery is valid if any of them matches.
+//
+// Sink is a receiver that captures and verifies deliveries, for developing
+// against the service locally; cmd/webhook-sink serves one.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on every delivery
+const (
+	SignatureHeader  = "X-Ledger-Signature"
+	DeliveryIDHeader = "X-Ledger-Delivery-ID"
+	EventIDHeader    = "X-Ledger-Event-ID"
+	EventTypeHeader  = "X-Ledger-Event-Type"
+
+	// TimestampHeader is the Unix time the delivery was signed at, the same
+	// as the signature's t= value
+	TimestampHeader = "X-Ledger-Timestamp"
+
+	// CorrelationIDHeader is set on deliveries of events caused by an API
+	// request, to the correlation ID of that request
+	CorrelationIDHeader = "X-Correlation-ID"
+)
+
+// DefaultTolerance is how old a signature may be before it is rejected as a
+// possible replay
+const DefaultTolerance = 5 * time.Minute
+
+// signatureScheme is the header key of HMAC-SHA256 signatures
+const signatureScheme = "v1"
+
+// Errors
+var (
+	ErrInvalidHeader      = errors.New("webhook signature header is malformed")
+	ErrNoValidSignature   = errors.New("no webhook signature matches the payload")
+	ErrTimestampTolerance = errors.New("webhook timestamp is outside the tolerance window")
+)
+
+// ComputeSignature returns the hex HMAC-SHA256 of "<timestamp>.<payload>"
+func ComputeSignature(payload []byte, timestamp time.Time, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	h.Write([]byte("."))
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GenerateHeader builds a signature header for payload signed at timestamp
+// with each of secrets
+func GenerateHeader(payload []byte, timestamp time.Time, secrets ...string) string {
+	parts := make([]string, 0, len(secrets)+1)
+	parts = append(parts, "t="+strconv.FormatInt(timestamp.Unix(), 10))
+	for _, secret := range secrets {
+		parts = append(parts, signatureScheme+"="+ComputeSignature(payload, timestamp, secret))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Verify checks that header carries a signature of payload made with secret
+// no longer than tolerance ago; a zero tolerance uses DefaultTolerance.
+// payload must be the raw request body, before any JSON decoding.
+func Verify(payload []byte, header, secret string, tolerance time.Duration) error {
+	return verify(payload, header, secret, tolerance, time.Now())
+}
+
+// VerifyRequest reads and verifies the body of a delivery request, returning
+// the body so it can be decoded afterwards
+func VerifyRequest(r *http.Request, secret string, tolerance time.Duration) ([]byte, error) {
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook body: %w", err)
+	}
+
+	if err := Verify(payload, r.Header.Get(SignatureHeader), secret, tolerance); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func verify(payload []byte, header, secret string, tolerance time.Duration, now time.Time) error {
+	timestamp, signatures, err := parseHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	if age := now.Sub(timestamp); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: signed at %s", ErrTimestampTolerance, timestamp.UTC().Format(time.RFC3339))
+	}
+
+	expected, _ := hex.DecodeString(ComputeSignature(payload, timestamp, secret))
+	for _, signature := range signatures {
+		if hmac.Equal(expected, signature) {
+			return nil
+		}
+	}
+
+	return ErrNoValidSignature
+}
+
+// parseHeader splits a signature header into its timestamp and v1 signatures.
+// Unknown keys are ignored so newer schemes can be added alongside v1.
+func parseHeader(header string) (time.Time, [][]byte, error) {
+	var (
+		timestamp  time.Time
+		signatures [][]byte
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return time.Time{}, nil, fmt.Errorf("%w: %q", ErrInvalidHeader, part)
+		}
+
+		switch key {
+		case "t":
+			unix, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return time.Time{}, nil, fmt.Errorf("%w: invalid timestamp %q", ErrInvalidHeader, value)
+			}
+			timestamp = time.Unix(unix, 0)
+		case signatureScheme:
+			signature, err := hex.DecodeString(value)
+			if err != nil {
+				continue
+			}
+			signatures = append(signatures, signature)
+		}
+	}
+
+	if timestamp.IsZero() {
+		return time.Time{}, nil, fmt.Errorf("%w: missing timestamp", ErrInvalidHeader)
+	}
+	if len(signatures) == 0 {
+		return time.Time{}, nil, ErrNoValidSignature
+	}
+
+	return timestamp, signatures, nil
+}
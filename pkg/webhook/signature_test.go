@@ -0,0 +1,82 @@
+// pkg/webhook/signature_test.go
+package webhook
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var payload = []byte(`{"id":"evt_1","type":"transaction.posted"}`)
+
+func TestGenerateHeader(t *testing.T) {
+	signedAt := time.Unix(1700000000, 0)
+
+	header := GenerateHeader(payload, signedAt, "new", "old")
+	parts := strings.Split(header, ",")
+	require.Len(t, parts, 3)
+	assert.Equal(t, "t=1700000000", parts[0])
+	assert.Equal(t, "v1="+ComputeSignature(payload, signedAt, "new"), parts[1])
+	assert.Equal(t, "v1="+ComputeSignature(payload, signedAt, "old"), parts[2])
+
+	// the timestamp is part of what is signed
+	assert.NotEqual(t, ComputeSignature(payload, signedAt, "new"), ComputeSignature(payload, signedAt.Add(time.Second), "new"))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	header := GenerateHeader(payload, now, "new", "old")
+
+	tests := []struct {
+		name    string
+		payload []byte
+		header  string
+		secret  string
+		now     time.Time
+		wantErr error
+	}{
+		{"current secret", payload, header, "new", now, nil},
+		{"rotated-out secret", payload, header, "old", now, nil},
+		{"within tolerance", payload, header, "new", now.Add(DefaultTolerance), nil},
+		{"wrong secret", payload, header, "other", now, ErrNoValidSignature},
+		{"tampered payload", []byte(`{"id":"evt_2"}`), header, "new", now, ErrNoValidSignature},
+		{"replayed too late", payload, header, "new", now.Add(DefaultTolerance + time.Second), ErrTimestampTolerance},
+		{"timestamp in the future", payload, header, "new", now.Add(-DefaultTolerance - time.Second), ErrTimestampTolerance},
+		{"timestamp swapped", payload, strings.Replace(header, "t=1700000000", "t=1700000001", 1), "new", now, ErrNoValidSignature},
+		{"missing timestamp", payload, "v1=abcd", "new", now, ErrInvalidHeader},
+		{"no signatures", payload, "t=1700000000", "new", now, ErrNoValidSignature},
+		{"malformed", payload, "sha256=abcd,garbage", "new", now, ErrInvalidHeader},
+		{"legacy header", payload, "sha256=" + ComputeSignature(payload, now, "new"), "new", now, ErrInvalidHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify(tt.payload, tt.header, tt.secret, 0, tt.now)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	req := httptest.NewRequest("POST", "/hooks", bytes.NewReader(payload))
+	req.Header.Set(SignatureHeader, GenerateHeader(payload, time.Now(), "secret"))
+
+	body, err := VerifyRequest(req, "secret", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, payload, body)
+
+	req = httptest.NewRequest("POST", "/hooks", bytes.NewReader(payload))
+	req.Header.Set(SignatureHeader, GenerateHeader(payload, time.Now().Add(-time.Hour), "secret"))
+
+	_, err = VerifyRequest(req, "secret", time.Minute)
+	assert.ErrorIs(t, err, ErrTimestampTolerance)
+}
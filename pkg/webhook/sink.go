@@ -0,0 +1,211 @@
+// pkg/webhook/sink.go
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MaxSinkBodyBytes is the largest delivery body a Sink reads
+const MaxSinkBodyBytes = 1 << 20
+
+// Delivery is a webhook request captured by a Sink. Body is the request body
+// when it is JSON and the body as a JSON string otherwise.
+type Delivery struct {
+	ReceivedAt time.Time       `json:"received_at"`
+	Path       string          `json:"path"`
+	DeliveryID string          `json:"delivery_id,omitempty"`
+	EventID    string          `json:"event_id,omitempty"`
+	EventType  string          `json:"event_type,omitempty"`
+	Headers    http.Header     `json:"headers"`
+	Body       json.RawMessage `json:"body"`
+	Verified   bool            `json:"verified"`
+	// Error is why the signature was rejected
+	Error string `json:"error,omitempty"`
+}
+
+// Sink is a webhook receiver for developing and testing against the ledger
+// without a real integration. It accepts deliveries POSTed to any path,
+// verifying their signatures, and keeps the most recent ones:
+//
+//	GET    /deliveries  the captured deliveries as JSON, newest first
+//	DELETE /deliveries  forgets them
+//	GET    /            a page listing them
+type Sink struct {
+	// Secrets verify signatures; a delivery signed with none of them is
+	// captured but answered 400. With no secrets nothing is verified.
+	Secrets   []string
+	Tolerance time.Duration
+	// Status answers verified deliveries instead of 200, e.g. 503 to
+	// exercise retries
+	Status int
+	// OnDelivery is called with each captured delivery, one at a time
+	OnDelivery func(Delivery)
+
+	mu         sync.Mutex
+	limit      int
+	deliveries []Delivery
+}
+
+// NewSink returns a Sink keeping the last limit deliveries and verifying
+// them against secrets
+func NewSink(limit int, secrets ...string) *Sink {
+	return &Sink{
+		Secrets:   secrets,
+		Tolerance: DefaultTolerance,
+		limit:     max(limit, 1),
+	}
+}
+
+func (s *Sink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost:
+		s.receive(w, r)
+	case r.URL.Path == "/deliveries" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.newestFirst())
+	case r.URL.Path == "/deliveries" && r.Method == http.MethodDelete:
+		s.Clear()
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == "/" && r.Method == http.MethodGet:
+		s.renderPage(w)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// Deliveries returns the captured deliveries, oldest first
+func (s *Sink) Deliveries() []Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.deliveries)
+}
+
+// Clear forgets every captured delivery
+func (s *Sink) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = nil
+}
+
+func (s *Sink) receive(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxSinkBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	delivery := Delivery{
+		ReceivedAt: time.Now().UTC(),
+		Path:       r.URL.Path,
+		DeliveryID: r.Header.Get(DeliveryIDHeader),
+		EventID:    r.Header.Get(EventIDHeader),
+		EventType:  r.Header.Get(EventTypeHeader),
+		Headers:    r.Header.Clone(),
+		Body:       body,
+	}
+	if !json.Valid(body) {
+		delivery.Body, _ = json.Marshal(string(body))
+	}
+	if err := s.verify(body, r.Header.Get(SignatureHeader)); err != nil {
+		delivery.Error = err.Error()
+	} else {
+		delivery.Verified = len(s.Secrets) > 0
+	}
+
+	s.record(delivery)
+
+	switch {
+	case delivery.Error != "":
+		http.Error(w, delivery.Error, http.StatusBadRequest)
+	case s.Status != 0:
+		w.WriteHeader(s.Status)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// verify accepts a payload signed with any of the sink's secrets
+func (s *Sink) verify(payload []byte, header string) error {
+	var err error
+	for _, secret := range s.Secrets {
+		if err = Verify(payload, header, secret, s.Tolerance); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (s *Sink) record(delivery Delivery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries = append(s.deliveries, delivery)
+	if over := len(s.deliveries) - s.limit; over > 0 {
+		s.deliveries = slices.Delete(s.deliveries, 0, over)
+	}
+	if s.OnDelivery != nil {
+		s.OnDelivery(delivery)
+	}
+}
+
+func (s *Sink) newestFirst() []Delivery {
+	deliveries := s.Deliveries()
+	slices.Reverse(deliveries)
+	return deliveries
+}
+
+func (s *Sink) renderPage(w http.ResponseWriter) {
+	type row struct {
+		Delivery
+		Pretty string
+	}
+	rows := []row{}
+	for _, delivery := range s.newestFirst() {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, delivery.Body, "", "  "); err != nil {
+			pretty.Write(delivery.Body)
+		}
+		rows = append(rows, row{Delivery: delivery, Pretty: pretty.String()})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	sinkPage.Execute(w, rows)
+}
+
+var sinkPage = template.Must(template.New("sink").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="5">
+<title>Webhook sink</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+td, th { border-bottom: 1px solid #ddd; padding: .4em; text-align: left; vertical-align: top; }
+pre { margin: 0; white-space: pre-wrap; }
+.ok { color: #070; }
+.bad { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Webhook sink</h1>
+<p>{{len .}} deliveries, newest first. <a href="/deliveries">JSON</a></p>
+<table>
+<tr><th>Received</th><th>Event</th><th>Signature</th><th>Body</th></tr>
+{{range .}}<tr>
+<td>{{.ReceivedAt.Format "15:04:05"}}<br><small>{{.Path}}</small></td>
+<td>{{.EventType}}<br><small>{{.EventID}}<br>{{.DeliveryID}}</small></td>
+<td>{{if .Error}}<span class="bad">{{.Error}}</span>{{else if .Verified}}<span class="ok">verified</span>{{else}}not checked{{end}}</td>
+<td><details><summary>{{len .Body}} bytes</summary><pre>{{.Pretty}}</pre></details></td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,98 @@
+// pkg/webhook/sink_test.go
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postDelivery(t *testing.T, handler http.Handler, body []byte, signature string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/hooks", bytes.NewReader(body))
+	req.Header.Set(SignatureHeader, signature)
+	req.Header.Set(DeliveryIDHeader, "del_1")
+	req.Header.Set(EventIDHeader, "evt_1")
+	req.Header.Set(EventTypeHeader, "transaction.posted")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSinkVerifiesAndCapturesDeliveries(t *testing.T) {
+	sink := NewSink(10, "new", "old")
+	var logged []Delivery
+	sink.OnDelivery = func(delivery Delivery) { logged = append(logged, delivery) }
+
+	// signed with a secret the sink knows, even one being rotated out
+	rec := postDelivery(t, sink, payload, GenerateHeader(payload, time.Now(), "old"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = postDelivery(t, sink, payload, GenerateHeader(payload, time.Now(), "unknown"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	deliveries := sink.Deliveries()
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, deliveries, logged)
+
+	assert.True(t, deliveries[0].Verified)
+	assert.Empty(t, deliveries[0].Error)
+	assert.Equal(t, "/hooks", deliveries[0].Path)
+	assert.Equal(t, "del_1", deliveries[0].DeliveryID)
+	assert.Equal(t, "evt_1", deliveries[0].EventID)
+	assert.Equal(t, "transaction.posted", deliveries[0].EventType)
+	assert.JSONEq(t, string(payload), string(deliveries[0].Body))
+
+	assert.False(t, deliveries[1].Verified)
+	assert.Equal(t, ErrNoValidSignature.Error(), deliveries[1].Error)
+}
+
+func TestSinkWithoutSecretsAcceptsEverything(t *testing.T) {
+	sink := NewSink(10)
+	sink.Status = http.StatusServiceUnavailable
+
+	rec := postDelivery(t, sink, []byte("not json"), "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	deliveries := sink.Deliveries()
+	require.Len(t, deliveries, 1)
+	assert.False(t, deliveries[0].Verified)
+	assert.Empty(t, deliveries[0].Error)
+	assert.Equal(t, `"not json"`, string(deliveries[0].Body))
+}
+
+func TestSinkKeepsRecentDeliveriesAndServesThem(t *testing.T) {
+	sink := NewSink(2)
+	for _, id := range []string{"evt_1", "evt_2", "evt_3"} {
+		body, err := json.Marshal(map[string]string{"id": id})
+		require.NoError(t, err)
+		postDelivery(t, sink, body, "")
+	}
+
+	rec := httptest.NewRecorder()
+	sink.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/deliveries", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var listed []Delivery
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&listed))
+	require.Len(t, listed, 2)
+	assert.JSONEq(t, `{"id":"evt_3"}`, string(listed[0].Body))
+	assert.JSONEq(t, `{"id":"evt_2"}`, string(listed[1].Body))
+
+	rec = httptest.NewRecorder()
+	sink.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "2 deliveries")
+	assert.Contains(t, rec.Body.String(), "transaction.posted")
+
+	rec = httptest.NewRecorder()
+	sink.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/deliveries", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, sink.Deliveries())
+}
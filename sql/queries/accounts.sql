@@ -17,6 +17,12 @@ INSERT INTO accounts (
 SELECT * FROM accounts 
 WHERE id = $1 AND is_active = true;
 
+-- name: GetAccountForUpdate :one
+-- locks an account, active or not, for a change that records its prior state
+SELECT * FROM accounts
+WHERE id = $1
+FOR UPDATE;
+
 -- name: GetAccountByCode :one
 SELECT * FROM accounts
 WHERE code = $1 AND is_active = true;
@@ -135,6 +141,15 @@ SELECT * FROM account_balances
 WHERE account_id = $1
 ORDER BY currency;
 
+-- name: GetAccountBalanceTotal :one
+SELECT * FROM account_balance_totals
+WHERE account_id = $1 AND currency = $2;
+
+-- name: GetAccountBalanceTotals :many
+SELECT * FROM account_balance_totals
+WHERE account_id = $1
+ORDER BY currency;
+
 -- name: UpdateAccountBalance :one
 UPDATE account_balances
 SET 
@@ -144,6 +159,18 @@ SET
 WHERE account_id = $1 AND currency = $2 AND version = $4
 RETURNING *;
 
+-- name: EnsureAccountBalance :exec
+-- Creates a zero balance unless the account already has one in the currency.
+-- Concurrent first postings wait for each other here instead of failing with
+-- a unique violation.
+INSERT INTO account_balances (
+    account_id,
+    currency,
+    balance
+) VALUES (
+    $1, $2, 0
+) ON CONFLICT (account_id, currency) DO NOTHING;
+
 -- name: GetAccountBalanceForUpdate :one
 SELECT * FROM account_balances
 WHERE account_id = $1 AND currency = $2
@@ -160,7 +187,7 @@ SELECT
     ab.version,
     ab.updated_at as balance_updated_at
 FROM accounts a
-LEFT JOIN account_balances ab ON a.id = ab.account_id
+LEFT JOIN account_balance_totals ab ON a.id = ab.account_id
 WHERE ab.currency = $1 AND a.is_active = true
 ORDER BY a.code;
 
@@ -171,7 +198,7 @@ SELECT
     SUM(ab.balance) as total_balance,
     COUNT(a.id) as account_count
 FROM accounts a
-JOIN account_balances ab ON a.id = ab.account_id
+JOIN account_balance_totals ab ON a.id = ab.account_id
 WHERE a.is_active = true
 GROUP BY a.account_type, ab.currency
 ORDER BY a.account_type, ab.currency;
@@ -186,7 +213,7 @@ SELECT
     ab.version as balance_version,
     ab.updated_at as balance_updated_at
 FROM accounts a
-LEFT JOIN account_balances ab ON a.id = ab.account_id AND ab.currency = $2
+LEFT JOIN account_balance_totals ab ON a.id = ab.account_id AND ab.currency = $2
 WHERE a.id = $1 AND a.is_active = true;
 
 -- name: ListAccountsWithBalances :many
@@ -211,7 +238,7 @@ SELECT
         '[]'::json
     ) as balances
 FROM accounts a
-LEFT JOIN account_balances ab ON a.id = ab.account_id
+LEFT JOIN account_balance_totals ab ON a.id = ab.account_id
 WHERE a.is_active = true
 GROUP BY a.id, a.code, a.name, a.account_type, a.parent_id, a.currency, a.metadata, a.created_at
 ORDER BY a.code;
@@ -258,36 +285,36 @@ SELECT
     COUNT(DISTINCT a.id)::bigint as total_accounts,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE ab2.currency = $1 AND a2.account_type = 'asset' AND a2.is_active = true
     ) as total_assets,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE ab2.currency = $1 AND a2.account_type = 'liability' AND a2.is_active = true
     ) as total_liabilities,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE ab2.currency = $1 AND a2.account_type = 'equity' AND a2.is_active = true
     ) as total_equity,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE ab2.currency = $1 AND a2.account_type = 'revenue' AND a2.is_active = true
     ) as total_revenue,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE ab2.currency = $1 AND a2.account_type = 'expense' AND a2.is_active = true
     ) as total_expenses,
     NOW() as generated_at
-FROM account_balances ab
+FROM account_balance_totals ab
 JOIN accounts a ON ab.account_id = a.id
 WHERE ab.currency = $1 AND a.is_active = true
 LIMIT 1;
@@ -298,36 +325,36 @@ SELECT
     COUNT(DISTINCT a.id)::bigint as total_accounts,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE a2.account_type = 'asset' AND a2.is_active = true
     ) as total_assets,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE a2.account_type = 'liability' AND a2.is_active = true
     ) as total_liabilities,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE a2.account_type = 'equity' AND a2.is_active = true
     ) as total_equity,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE a2.account_type = 'revenue' AND a2.is_active = true
     ) as total_revenue,
     (
         SELECT COALESCE(SUM(ab2.balance), 0::numeric(20,4))
-        FROM account_balances ab2
+        FROM account_balance_totals ab2
         JOIN accounts a2 ON ab2.account_id = a2.id
         WHERE a2.account_type = 'expense' AND a2.is_active = true
     ) as total_expenses,
     NOW() as generated_at
-FROM account_balances ab
+FROM account_balance_totals ab
 JOIN accounts a ON ab.account_id = a.id
 WHERE a.is_active = true
 LIMIT 1;
@@ -341,9 +368,70 @@ SELECT
     COALESCE(AVG(ab.balance), 0::numeric(20,4)) as average_balance,
     COALESCE(MIN(ab.balance), 0::numeric(20,4)) as minimum_balance,
     COALESCE(MAX(ab.balance), 0::numeric(20,4)) as maximum_balance
-FROM account_balances ab
+FROM account_balance_totals ab
 JOIN accounts a ON ab.account_id = a.id
 WHERE a.is_active = true
   AND ($1::text IS NULL OR ab.currency = $1)
 GROUP BY a.account_type, ab.currency
-ORDER BY a.account_type, ab.currency;
\ No newline at end of file
+ORDER BY a.account_type, ab.currency;
+
+-- High-Volume Account Operations
+
+-- name: GetHighVolumeAccount :one
+-- key-share lock so disabling waits for in-flight slot postings
+SELECT * FROM high_volume_accounts
+WHERE account_id = $1
+FOR KEY SHARE;
+
+-- name: UpsertHighVolumeAccount :one
+INSERT INTO high_volume_accounts (
+    account_id,
+    slot_count,
+    overdraft_protection
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (account_id) DO UPDATE
+SET
+    slot_count = EXCLUDED.slot_count,
+    overdraft_protection = EXCLUDED.overdraft_protection,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteHighVolumeAccount :exec
+DELETE FROM high_volume_accounts
+WHERE account_id = $1;
+
+-- name: AddToAccountBalanceSlot :exec
+INSERT INTO account_balance_slots (
+    account_id,
+    currency,
+    slot,
+    balance
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (account_id, currency, slot) DO UPDATE
+SET
+    balance = account_balance_slots.balance + EXCLUDED.balance,
+    updated_at = NOW();
+
+-- name: FoldAccountBalanceSlots :many
+-- moves the slots into the base rows; the totals don't change, so no
+-- balance.updated event is published and the versions are left alone
+WITH folded AS (
+    DELETE FROM account_balance_slots
+    WHERE account_balance_slots.account_id = $1
+    RETURNING currency, balance
+)
+UPDATE account_balances ab
+SET
+    balance = ab.balance + f.total,
+    updated_at = NOW()
+FROM (
+    SELECT currency, SUM(balance) AS total
+    FROM folded
+    GROUP BY currency
+) f
+WHERE ab.account_id = $1 AND ab.currency = f.currency
+RETURNING ab.*;
\ No newline at end of file
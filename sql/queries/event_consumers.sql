@@ -0,0 +1,16 @@
+-- sql/queries/event_consumers.sql
+
+-- name: CreateEventConsumer :exec
+INSERT INTO event_consumers (name) VALUES ($1)
+ON CONFLICT (name) DO NOTHING;
+
+-- name: LockEventConsumer :one
+-- returns no rows while another replica holds the consumer
+SELECT * FROM event_consumers
+WHERE name = $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: AdvanceEventConsumer :exec
+UPDATE event_consumers
+SET last_tx_id = $2, last_sequence = $3
+WHERE name = $1;
@@ -3,15 +3,15 @@
 -- name: CreateEvent :one
 INSERT INTO events (
     tenant_id, aggregate_id, aggregate_type, event_type, 
-    event_version, event_data, metadata
+    event_version, event_data, metadata, aggregate_version
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8
 ) RETURNING *;
 
 -- name: GetEventsByAggregate :many
 SELECT * FROM events 
 WHERE tenant_id = $1 AND aggregate_id = $2
-ORDER BY event_version ASC;
+ORDER BY aggregate_version ASC, sequence_number ASC;
 
 -- name: GetEventsByType :many
 SELECT * FROM events 
@@ -19,6 +19,10 @@ WHERE tenant_id = $1 AND event_type = $2
 ORDER BY created_at DESC
 LIMIT $3 OFFSET $4;
 
+-- name: GetEventStreamHead :one
+-- the outbox position behind which every event has been committed
+SELECT pg_snapshot_xmin(pg_current_snapshot())::xid8 AS tx_id;
+
 -- name: GetEventsAfterSequence :many
 SELECT * FROM events 
 WHERE sequence_number > $1
@@ -27,4 +31,44 @@ LIMIT $2;
 
 -- name: GetEventByID :one
 SELECT * FROM events 
-WHERE tenant_id = $1 AND event_id = $2 LIMIT 1;
\ No newline at end of file
+WHERE tenant_id = $1 AND event_id = $2 LIMIT 1;
+
+-- name: GetOutboxEvents :many
+-- events after a consumer's cursor, limited to transactions older than every
+-- running one so nothing can still appear behind the cursor
+SELECT * FROM events
+WHERE (tx_id, sequence_number) > (sqlc.arg(after_tx_id)::xid8, sqlc.arg(after_sequence)::bigint)
+AND tx_id < pg_snapshot_xmin(pg_current_snapshot())
+ORDER BY tx_id, sequence_number
+LIMIT sqlc.arg(batch_size);
+
+-- name: GetTenantEventsAfterCursor :many
+-- a tenant's events after a stream cursor, read in outbox order and limited
+-- like GetOutboxEvents to transactions older than every running one
+SELECT * FROM events
+WHERE tenant_id = sqlc.arg(tenant_id)
+AND (tx_id, sequence_number) > (sqlc.arg(after_tx_id)::xid8, sqlc.arg(after_sequence)::bigint)
+AND tx_id < pg_snapshot_xmin(pg_current_snapshot())
+AND (sqlc.narg(event_types)::TEXT[] IS NULL OR event_type = ANY(sqlc.narg(event_types)::TEXT[]))
+AND (sqlc.narg(aggregate_id)::UUID IS NULL OR aggregate_id = sqlc.narg(aggregate_id)::UUID)
+ORDER BY tx_id, sequence_number
+LIMIT sqlc.arg(batch_size);
+
+-- name: ListEvents :many
+-- a page of a tenant's event history in sequence order, continuing after the
+-- previous page's last sequence number
+SELECT * FROM events
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.narg(event_type)::VARCHAR IS NULL OR event_type = sqlc.narg(event_type)::VARCHAR)
+  AND (sqlc.narg(aggregate_type)::VARCHAR IS NULL OR aggregate_type = sqlc.narg(aggregate_type)::VARCHAR)
+  AND (sqlc.narg(aggregate_id)::UUID IS NULL OR aggregate_id = sqlc.narg(aggregate_id)::UUID)
+  AND (sqlc.narg(from_sequence)::BIGINT IS NULL OR sequence_number >= sqlc.narg(from_sequence)::BIGINT)
+  AND (sqlc.narg(to_sequence)::BIGINT IS NULL OR sequence_number <= sqlc.narg(to_sequence)::BIGINT)
+  AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(created_from)::TIMESTAMPTZ)
+  AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(created_to)::TIMESTAMPTZ)
+  AND (sqlc.narg(after_sequence)::BIGINT IS NULL OR sequence_number > sqlc.narg(after_sequence)::BIGINT)
+  AND (sqlc.narg(account_id)::UUID IS NULL
+       OR (aggregate_type = 'account' AND aggregate_id = sqlc.narg(account_id)::UUID)
+       OR (aggregate_type = 'balance' AND event_data->>'account_id' = sqlc.narg(account_id)::UUID::TEXT))
+ORDER BY sequence_number
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,33 @@
+-- sql/queries/partitions.sql
+
+-- name: CreateArchivedPartition :one
+INSERT INTO archived_partitions (
+    schema_name, table_name, partition_name, range_start, range_end, row_count, location
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetArchivedUntil :one
+-- the end of a table's latest archived partition in the current schema; the
+-- oldest months are archived first, so rows created before it are only in
+-- the archives. Months carried along by a moved tenant only count for it.
+SELECT MAX(range_end)::TIMESTAMPTZ AS archived_until FROM archived_partitions
+WHERE schema_name = current_schema()
+AND table_name = $1
+AND (tenant_id IS NULL OR tenant_id = current_tenant_id());
+
+-- name: ListArchivedPartitions :many
+SELECT * FROM archived_partitions
+ORDER BY schema_name, table_name, range_start;
+
+-- name: ListArchivedPartitionsInRange :many
+-- archived partitions of a table in the current schema (the tenant's own
+-- inside WithTenant) that overlap the requested range, including those the
+-- current tenant carried along from another schema
+SELECT * FROM archived_partitions
+WHERE schema_name = current_schema()
+AND (tenant_id IS NULL OR tenant_id = current_tenant_id())
+AND table_name = sqlc.arg(table_name)
+AND range_end > sqlc.arg(range_from)
+AND range_start <= sqlc.arg(range_to)
+ORDER BY range_start;
@@ -2,9 +2,9 @@
 
 -- name: CreateTenant :one
 INSERT INTO tenants (
-    name, slug, business_type, country_code, base_currency, timezone
+    name, slug, business_type, country_code, base_currency, timezone, tenancy_mode
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, $7
 ) RETURNING *;
 
 -- name: GetTenantByID :one
@@ -15,6 +15,11 @@ WHERE id = $1 LIMIT 1;
 SELECT * FROM tenants 
 WHERE slug = $1 LIMIT 1;
 
+-- name: GetTenantTenancyMode :one
+SELECT id, tenancy_mode FROM tenants
+WHERE slug = $1
+FOR KEY SHARE;
+
 -- name: ListTenantsByUser :many
 SELECT t.* FROM tenants t
 JOIN tenant_users tu ON t.id = tu.tenant_id
@@ -17,6 +17,15 @@ WHERE idempotency_key = $1 LIMIT 1;
 SELECT * FROM transactions 
 WHERE id = $1;
 
+-- name: GetIdempotencyKey :one
+-- idempotency keys stay reserved after their transaction's month is archived
+SELECT * FROM transaction_idempotency_keys
+WHERE idempotency_key = $1;
+
+-- name: GetIdempotencyKeyByTransactionID :one
+SELECT * FROM transaction_idempotency_keys
+WHERE transaction_id = $1;
+
 -- name: UpdateTransactionStatus :one
 UPDATE transactions
 SET 
@@ -40,17 +49,21 @@ ORDER BY t.created_at DESC
 LIMIT $2 OFFSET $3;
 
 -- name: ListTransactionsByDateRange :many
+-- filtered on created_at, the partition key, so only the months in range are
+-- read; postings are posted by the database transaction creating them, so it
+-- is also their posted_at
 SELECT * FROM transactions
-WHERE posted_at BETWEEN $1 AND $2
-ORDER BY posted_at DESC
+WHERE created_at BETWEEN $1 AND $2
+ORDER BY created_at DESC
 LIMIT $3 OFFSET $4;
 
 -- name: ListTransactionsByAccountAndDateRange :many
+-- filtered on created_at like ListTransactionsByDateRange
 SELECT DISTINCT t.* FROM transactions t
 JOIN transaction_lines tl ON t.id = tl.transaction_id
 JOIN accounts a ON tl.account_id = a.id
 WHERE a.code = $1 
-AND t.posted_at BETWEEN $2 AND $3
+AND t.created_at BETWEEN $2 AND $3
 ORDER BY t.created_at DESC
 LIMIT $4 OFFSET $5;
 
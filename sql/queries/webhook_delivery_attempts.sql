@@ -0,0 +1,25 @@
+-- sql/queries/webhook_delivery_attempts.sql
+
+-- name: CreateWebhookDeliveryAttempt :exec
+INSERT INTO webhook_delivery_attempts (
+    delivery_id, attempt_number, request_url, request_headers, payload_sha256,
+    response_status, response_body, response_truncated, latency_ms,
+    error_class, error_message
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+);
+
+-- name: ListWebhookDeliveryAttempts :many
+SELECT * FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY attempt_number ASC, attempted_at ASC;
+
+-- name: DeleteWebhookDeliveryAttemptsBefore :execrows
+-- Deletes at most batch_size attempts older than the cutoff, so pruning a
+-- large backlog never holds long locks
+DELETE FROM webhook_delivery_attempts
+WHERE id IN (
+    SELECT id FROM webhook_delivery_attempts
+    WHERE attempted_at < sqlc.arg(cutoff)
+    LIMIT sqlc.arg(batch_size)
+);
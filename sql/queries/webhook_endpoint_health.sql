@@ -0,0 +1,52 @@
+-- sql/queries/webhook_endpoint_health.sql
+
+-- name: GetWebhookEndpointHealth :one
+SELECT * FROM webhook_endpoint_health
+WHERE endpoint_id = $1;
+
+-- name: ListWebhookEndpointHealth :many
+SELECT h.* FROM webhook_endpoint_health h
+JOIN webhook_endpoints e ON e.id = h.endpoint_id
+WHERE e.tenant_id = $1;
+
+-- name: EnsureWebhookEndpointHealth :exec
+INSERT INTO webhook_endpoint_health (endpoint_id)
+VALUES ($1)
+ON CONFLICT (endpoint_id) DO NOTHING;
+
+-- name: LockWebhookEndpointHealth :one
+SELECT * FROM webhook_endpoint_health
+WHERE endpoint_id = $1
+FOR UPDATE;
+
+-- name: UpdateWebhookEndpointHealth :exec
+UPDATE webhook_endpoint_health
+SET circuit_state = $2,
+    consecutive_failures = $3,
+    failing_since = $4,
+    last_success_at = $5,
+    last_failure_at = $6,
+    opened_at = $7,
+    retry_at = $8,
+    probe_started_at = $9,
+    auto_disabled_at = $10,
+    updated_at = NOW()
+WHERE endpoint_id = $1;
+
+-- name: RecordWebhookEndpointSuccess :exec
+-- Any success closes the circuit and ends the failure streak
+INSERT INTO webhook_endpoint_health (endpoint_id, last_success_at)
+VALUES ($1, NOW())
+ON CONFLICT (endpoint_id) DO UPDATE
+SET circuit_state = 'closed',
+    consecutive_failures = 0,
+    failing_since = NULL,
+    last_success_at = NOW(),
+    opened_at = NULL,
+    retry_at = NULL,
+    probe_started_at = NULL,
+    updated_at = NOW();
+
+-- name: ResetWebhookEndpointHealth :exec
+DELETE FROM webhook_endpoint_health
+WHERE endpoint_id = $1;
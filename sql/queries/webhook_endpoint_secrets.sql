@@ -0,0 +1,39 @@
+-- sql/queries/webhook_endpoint_secrets.sql
+
+-- name: CreateWebhookEndpointSecret :one
+INSERT INTO webhook_endpoint_secrets (
+    endpoint_id, encrypted_secret, encrypted_key, key_version
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListActiveWebhookEndpointSecrets :many
+-- Newest first, so the current secret leads and rotated-out ones follow until they expire
+SELECT * FROM webhook_endpoint_secrets
+WHERE endpoint_id = $1
+AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY created_at DESC;
+
+-- name: ExpireWebhookEndpointSecrets :exec
+-- Secrets already due to expire sooner keep their earlier expiry
+UPDATE webhook_endpoint_secrets
+SET expires_at = sqlc.arg(expires_at)
+WHERE endpoint_id = sqlc.arg(endpoint_id)
+AND (expires_at IS NULL OR expires_at > sqlc.arg(expires_at));
+
+-- name: DeleteExpiredWebhookEndpointSecrets :exec
+DELETE FROM webhook_endpoint_secrets
+WHERE endpoint_id = $1 AND expires_at <= NOW();
+
+-- name: ListWebhookEndpointSecretsToReencrypt :many
+SELECT * FROM webhook_endpoint_secrets
+WHERE key_version <> sqlc.arg(key_version)
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
+
+-- name: UpdateWebhookEndpointSecretEncryption :exec
+UPDATE webhook_endpoint_secrets
+SET encrypted_secret = $2,
+    encrypted_key = $3,
+    key_version = $4
+WHERE id = $1;
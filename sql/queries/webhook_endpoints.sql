@@ -0,0 +1,51 @@
+-- sql/queries/webhook_endpoints.sql
+
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+    tenant_id, url, description, event_types, enabled, ordered, payload_version, payload_format
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1 AND tenant_id = $2
+LIMIT 1;
+
+-- name: ListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE tenant_id = $1
+ORDER BY created_at ASC;
+
+-- name: ListEnabledWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE tenant_id = $1 AND enabled = TRUE
+ORDER BY created_at ASC;
+
+-- name: UpdateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET url = COALESCE(sqlc.narg(url), url),
+    description = COALESCE(sqlc.narg(description), description),
+    event_types = COALESCE(sqlc.narg(event_types)::TEXT[], event_types),
+    enabled = COALESCE(sqlc.narg(enabled), enabled),
+    ordered = COALESCE(sqlc.narg(ordered), ordered),
+    payload_version = COALESCE(sqlc.narg(payload_version), payload_version),
+    payload_format = COALESCE(sqlc.narg(payload_format), payload_format),
+    disabled_at = CASE
+        WHEN sqlc.narg(enabled) IS NULL THEN disabled_at
+        WHEN sqlc.narg(enabled)::BOOLEAN THEN NULL
+        ELSE COALESCE(disabled_at, NOW())
+    END
+WHERE id = sqlc.arg(id) AND tenant_id = sqlc.arg(tenant_id)
+RETURNING *;
+
+-- name: DisableWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET enabled = FALSE,
+    disabled_at = COALESCE(disabled_at, NOW())
+WHERE id = $1 AND tenant_id = $2
+RETURNING *;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = $1 AND tenant_id = $2;
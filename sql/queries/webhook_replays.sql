@@ -0,0 +1,105 @@
+-- sql/queries/webhook_replays.sql
+
+-- name: CreateWebhookReplay :one
+INSERT INTO webhook_replays (
+    tenant_id, endpoint_id, event_types, from_sequence, to_sequence,
+    created_from, created_to, rate_limit, total_events, last_sequence
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING *;
+
+-- name: GetWebhookReplay :one
+SELECT * FROM webhook_replays
+WHERE id = $1 AND tenant_id = $2
+LIMIT 1;
+
+-- name: ListWebhookReplays :many
+SELECT * FROM webhook_replays
+WHERE tenant_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: ClaimWebhookReplay :one
+-- Leases the oldest unfinished replay that is due for its next batch
+UPDATE webhook_replays
+SET status = 'running',
+    started_at = COALESCE(started_at, NOW()),
+    locked_until = sqlc.arg(locked_until),
+    locked_by = sqlc.arg(locked_by)
+WHERE id = (
+    SELECT r.id FROM webhook_replays r
+    WHERE r.status IN ('pending', 'running')
+      AND (r.locked_until IS NULL OR r.locked_until <= NOW())
+    ORDER BY r.created_at ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: AdvanceWebhookReplay :execrows
+-- Moves a leased replay's cursor past a batch and hands the lease back until
+-- locked_until. Matches nothing if the replay was cancelled meanwhile.
+UPDATE webhook_replays
+SET last_sequence = sqlc.arg(last_sequence),
+    events_processed = events_processed + sqlc.arg(events_processed),
+    deliveries_queued = deliveries_queued + sqlc.arg(deliveries_queued),
+    status = CASE WHEN sqlc.arg(completed)::BOOLEAN THEN 'completed' ELSE status END,
+    completed_at = CASE WHEN sqlc.arg(completed)::BOOLEAN THEN NOW() ELSE completed_at END,
+    locked_until = sqlc.arg(locked_until),
+    locked_by = NULL
+WHERE id = sqlc.arg(id)
+  AND locked_by = sqlc.arg(locked_by)
+  AND status = 'running';
+
+-- name: CancelWebhookReplay :one
+UPDATE webhook_replays
+SET status = 'cancelled',
+    completed_at = NOW(),
+    locked_until = NULL,
+    locked_by = NULL
+WHERE id = $1 AND tenant_id = $2
+  AND status IN ('pending', 'running')
+RETURNING *;
+
+-- name: DeleteUnsentReplayDeliveries :execrows
+-- Drops a replay's queued deliveries that have not been attempted or claimed
+DELETE FROM webhook_deliveries
+WHERE replay_id = $1
+  AND delivered_at IS NULL
+  AND attempts = 0
+  AND (locked_until IS NULL OR locked_until <= NOW());
+
+-- name: GetWebhookReplayDeliveryStats :one
+SELECT
+    COUNT(*) FILTER (WHERE delivered_at IS NOT NULL) AS delivered,
+    COUNT(*) FILTER (WHERE dead_lettered_at IS NOT NULL) AS dead_lettered,
+    COUNT(*) FILTER (WHERE delivered_at IS NULL AND dead_lettered_at IS NULL) AS pending
+FROM webhook_deliveries
+WHERE replay_id = $1;
+
+-- name: GetTenantEventHead :one
+-- the sequence number of a tenant's latest event
+SELECT COALESCE(MAX(sequence_number), 0)::BIGINT AS sequence_number
+FROM events
+WHERE tenant_id = $1;
+
+-- name: CountReplayEvents :one
+SELECT COUNT(*) FROM events
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND sequence_number > sqlc.arg(after_sequence)::BIGINT
+  AND sequence_number <= sqlc.arg(to_sequence)::BIGINT
+  AND (sqlc.narg(event_type_patterns)::TEXT[] IS NULL OR event_type LIKE ANY(sqlc.narg(event_type_patterns)::TEXT[]))
+  AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(created_from)::TIMESTAMPTZ)
+  AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(created_to)::TIMESTAMPTZ);
+
+-- name: ListReplayEvents :many
+-- the next batch of a replay's events after its cursor, in sequence order
+SELECT * FROM events
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND sequence_number > sqlc.arg(after_sequence)::BIGINT
+  AND sequence_number <= sqlc.arg(to_sequence)::BIGINT
+  AND (sqlc.narg(event_type_patterns)::TEXT[] IS NULL OR event_type LIKE ANY(sqlc.narg(event_type_patterns)::TEXT[]))
+  AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(created_from)::TIMESTAMPTZ)
+  AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(created_to)::TIMESTAMPTZ)
+ORDER BY sequence_number ASC
+LIMIT sqlc.arg(batch_size);
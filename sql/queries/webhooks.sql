@@ -2,18 +2,57 @@
 
 -- name: CreateWebhookDelivery :one
 INSERT INTO webhook_deliveries (
-    tenant_id, event_id, webhook_url, max_attempts, next_retry_at
+    tenant_id, event_id, endpoint_id, event_type, max_attempts, next_retry_at,
+    aggregate_id, sequence_number, ordered
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 ) RETURNING *;
 
--- name: GetPendingWebhookDeliveries :many
-SELECT * FROM webhook_deliveries
-WHERE next_retry_at IS NOT NULL 
-  AND next_retry_at <= NOW()
-  AND attempts < max_attempts
-ORDER BY created_at ASC
-LIMIT $1;
+-- name: ClaimWebhookDeliveries :many
+-- Leases up to batch_size due deliveries to a worker until locked_until. Rows
+-- another worker is claiming at the same moment are skipped, not waited on.
+-- Endpoints whose circuit is open, or already have a half-open probe in
+-- flight (one started after probe_started_after, a lease ago), are skipped too.
+-- An ordered delivery waits until every earlier delivery of its aggregate to
+-- the same endpoint has been delivered. Live deliveries and each replay are
+-- ordered separately, so a replay is not held back by the dead letter it
+-- resends, nor live traffic by a replay.
+UPDATE webhook_deliveries
+SET locked_until = sqlc.arg(locked_until),
+    locked_by = sqlc.arg(locked_by)
+WHERE id IN (
+    SELECT d.id FROM webhook_deliveries d
+    WHERE d.next_retry_at IS NOT NULL
+      AND d.next_retry_at <= NOW()
+      AND d.attempts < d.max_attempts
+      AND d.delivered_at IS NULL
+      AND (d.locked_until IS NULL OR d.locked_until <= NOW())
+      AND NOT EXISTS (
+          SELECT 1 FROM webhook_endpoint_health h
+          WHERE h.endpoint_id = d.endpoint_id
+            AND ((h.circuit_state = 'open' AND h.retry_at > NOW())
+              OR (h.circuit_state = 'half_open' AND h.probe_started_at > sqlc.arg(probe_started_after)))
+      )
+      AND (NOT d.ordered OR NOT EXISTS (
+          SELECT 1 FROM webhook_deliveries prev
+          WHERE prev.endpoint_id = d.endpoint_id
+            AND prev.aggregate_id = d.aggregate_id
+            AND prev.sequence_number < d.sequence_number
+            AND prev.replay_id IS NOT DISTINCT FROM d.replay_id
+            AND prev.delivered_at IS NULL
+      ))
+    ORDER BY d.next_retry_at ASC
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ReleaseWebhookDelivery :exec
+-- Hands a claimed delivery back unsent; no worker picks it up before locked_until
+UPDATE webhook_deliveries
+SET locked_until = $2,
+    locked_by = NULL
+WHERE id = $1;
 
 -- name: UpdateWebhookDeliverySuccess :exec
 UPDATE webhook_deliveries 
@@ -21,22 +60,29 @@ SET http_status_code = $2,
     response_body = $3,
     attempts = attempts + 1,
     delivered_at = NOW(),
-    next_retry_at = NULL
+    next_retry_at = NULL,
+    locked_until = NULL,
+    locked_by = NULL
 WHERE id = $1;
 
 -- name: UpdateWebhookDeliveryFailure :exec
+-- A NULL next_retry_at means the retry schedule is exhausted and the delivery
+-- is dead-lettered
 UPDATE webhook_deliveries 
 SET http_status_code = $2,
     response_body = $3,
     attempts = attempts + 1,
-    next_retry_at = CASE 
-        WHEN attempts + 1 >= max_attempts THEN NULL
-        ELSE NOW() + (INTERVAL '1 minute' * POWER(2, attempts + 1))
-    END,
+    next_retry_at = $4,
     failed_at = CASE 
-        WHEN attempts + 1 >= max_attempts THEN NOW()
+        WHEN $4::TIMESTAMPTZ IS NULL THEN NOW()
         ELSE failed_at
-    END
+    END,
+    dead_lettered_at = CASE
+        WHEN $4::TIMESTAMPTZ IS NULL THEN NOW()
+        ELSE NULL
+    END,
+    locked_until = NULL,
+    locked_by = NULL
 WHERE id = $1;
 
 -- name: GetWebhookDeliveriesByTenant :many
@@ -53,5 +99,48 @@ LIMIT 1;
 -- name: ResetWebhookDeliveryForRetry :exec
 UPDATE webhook_deliveries
 SET next_retry_at = NOW(),
-    failed_at = NULL
-WHERE id = $1;
\ No newline at end of file
+    failed_at = NULL,
+    dead_lettered_at = NULL
+WHERE id = $1;
+
+-- name: CreateWebhookDeliveryIfNotExists :execrows
+INSERT INTO webhook_deliveries (
+    tenant_id, event_id, endpoint_id, event_type, max_attempts, next_retry_at,
+    aggregate_id, sequence_number, ordered
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) ON CONFLICT (event_id, endpoint_id) WHERE replay_id IS NULL DO NOTHING;
+
+-- name: ListDeadLetteredWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND dead_lettered_at IS NOT NULL
+  AND (sqlc.narg(endpoint_id)::UUID IS NULL OR endpoint_id = sqlc.narg(endpoint_id)::UUID)
+  AND (sqlc.narg(event_type)::VARCHAR IS NULL OR event_type = sqlc.narg(event_type)::VARCHAR)
+  AND (sqlc.narg(dead_lettered_from)::TIMESTAMPTZ IS NULL OR dead_lettered_at >= sqlc.narg(dead_lettered_from)::TIMESTAMPTZ)
+  AND (sqlc.narg(dead_lettered_to)::TIMESTAMPTZ IS NULL OR dead_lettered_at < sqlc.narg(dead_lettered_to)::TIMESTAMPTZ)
+ORDER BY dead_lettered_at DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: RedeliverDeadLetteredWebhookDeliveries :execrows
+-- Gives matching dead letters a fresh retry schedule starting now
+UPDATE webhook_deliveries
+SET attempts = 0,
+    max_attempts = sqlc.arg(max_attempts),
+    next_retry_at = NOW(),
+    failed_at = NULL,
+    dead_lettered_at = NULL
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND dead_lettered_at IS NOT NULL
+  AND (sqlc.narg(endpoint_id)::UUID IS NULL OR endpoint_id = sqlc.narg(endpoint_id)::UUID)
+  AND (sqlc.narg(event_type)::VARCHAR IS NULL OR event_type = sqlc.narg(event_type)::VARCHAR)
+  AND (sqlc.narg(dead_lettered_from)::TIMESTAMPTZ IS NULL OR dead_lettered_at >= sqlc.narg(dead_lettered_from)::TIMESTAMPTZ)
+  AND (sqlc.narg(dead_lettered_to)::TIMESTAMPTZ IS NULL OR dead_lettered_at < sqlc.narg(dead_lettered_to)::TIMESTAMPTZ);
+
+-- name: CreateReplayWebhookDelivery :execrows
+INSERT INTO webhook_deliveries (
+    tenant_id, event_id, endpoint_id, event_type, max_attempts, next_retry_at,
+    aggregate_id, sequence_number, ordered, replay_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) ON CONFLICT (replay_id, event_id, endpoint_id) WHERE replay_id IS NOT NULL DO NOTHING;